@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thediveo/enumflag/v2"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/controldiff"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// variable used to assign the output mode flag
+var diffOutputMode = localconstants.DiffOutputModePretty
+
+func benchmarkDiffCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "diff [flags] <previous-results> <current-results>",
+		Args:  cobra.ExactArgs(2),
+		Run:   runBenchmarkDiffCmd,
+		Short: "Compare the results of two benchmark runs",
+		Long: `Compare the results of two benchmark runs.
+
+The results must have been exported from 'powerpipe benchmark run' using the json format.
+Controls are matched by name, and are reported as newly failing, newly passing, changed,
+unchanged, or as only appearing in one of the runs.
+
+The exit code is 1 if any control is newly failing (including controls which are failing and
+only appear in the current run), and 0 otherwise.
+
+Examples:
+
+  # Compare the results of two runs
+  powerpipe benchmark diff previous.json current.json
+
+  # Show the diff as json
+  powerpipe benchmark diff previous.json current.json --output json
+
+  # Export the diff to a file
+  powerpipe benchmark diff previous.json current.json --export diff.json`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddVarFlag(enumflag.New(&diffOutputMode, constants.ArgOutput, localconstants.DiffOutputModeIds, enumflag.EnumCaseInsensitive),
+			constants.ArgOutput,
+			fmt.Sprintf("Output format; one of: %s", strings.Join(constants.FlagValues(localconstants.DiffOutputModeIds), ", "))).
+		AddStringSliceFlag(constants.ArgExport, nil, "Export the diff to a file, in json format; either 'json' or a file name ending in '.json'").
+		AddBoolFlag(constants.ArgHelp, false, "Help for diff", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func runBenchmarkDiffCmd(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	utils.LogTime("cmd.runBenchmarkDiffCmd start")
+	defer func() {
+		utils.LogTime("cmd.runBenchmarkDiffCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	exportPaths, err := getDiffExportPaths(viper.GetStringSlice(constants.ArgExport))
+	if err != nil {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	var results []*controldiff.ResultFile
+	for _, path := range args {
+		r, err := controldiff.LoadResultFile(path)
+		if err != nil {
+			exitCode = constants.ExitCodeInsufficientOrWrongInputs
+			error_helpers.ShowError(ctx, err)
+			return
+		}
+		results = append(results, r)
+	}
+
+	if err := displayBenchmarkDiff(cmd, controldiff.Compare(results[0], results[1]), exportPaths); err != nil {
+		exitCode = constants.ExitCodeFileSystemAccessFailure
+		error_helpers.ShowError(ctx, err)
+	}
+}
+
+func displayBenchmarkDiff(cmd *cobra.Command, diff *controldiff.Diff, exportPaths []string) error {
+	out := cmd.OutOrStdout()
+	var err error
+	switch diffOutputMode {
+	case localconstants.DiffOutputModeJson:
+		err = controldiff.RenderJSON(out, diff)
+	default:
+		useColor := diffOutputMode == localconstants.DiffOutputModePretty && viper.GetBool(constants.ConfigKeyIsTerminalTTY)
+		err = controldiff.RenderText(out, diff, useColor)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, path := range exportPaths {
+		var buf bytes.Buffer
+		if err := controldiff.RenderJSON(&buf, diff); err != nil {
+			return err
+		}
+		if err := export.Write(path, &buf); err != nil {
+			return sperr.WrapWithMessage(err, "failed to export diff to '%s'", path)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "File exported to %s\n", path)
+	}
+
+	if diff.HasNewFailures() {
+		exitCode = constants.ExitCodeControlsAlarm
+	}
+	return nil
+}
+
+// getDiffExportPaths returns the export file paths - an export of 'json' is written to a default file name
+func getDiffExportPaths(exportArgs []string) ([]string, error) {
+	var res []string
+	for _, exportArg := range exportArgs {
+		switch {
+		case exportArg == constants.OutputFormatJSON:
+			res = append(res, export.GenerateDefaultExportFileName("benchmark_diff", ".json"))
+		case strings.EqualFold(filepath.Ext(exportArg), ".json"):
+			res = append(res, exportArg)
+		default:
+			return nil, sperr.New("invalid diff export '%s' - the diff can only be exported as json", exportArg)
+		}
+	}
+	return res, nil
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,6 +19,7 @@ import (
 	"github.com/turbot/pipe-fittings/contexthelpers"
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
 	"github.com/turbot/pipe-fittings/statushooks"
 	"github.com/turbot/pipe-fittings/utils"
 	localcmdconfig "github.com/turbot/powerpipe/internal/cmdconfig"
@@ -26,6 +29,8 @@ import (
 	"github.com/turbot/powerpipe/internal/controlinit"
 	"github.com/turbot/powerpipe/internal/controlstatus"
 	"github.com/turbot/powerpipe/internal/display"
+	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/powerpipe/internal/notify"
 	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
@@ -58,10 +63,35 @@ func checkCmd[T controlinit.CheckTarget]() *cobra.Command {
 		AddCloudFlags().
 		AddModLocationFlag().
 		AddStringFlag(constants.ArgDatabase, "", "Turbot Pipes workspace database", localcmdconfig.Deprecated("see https://powerpipe.io/docs/run#selecting-a-database for the new syntax")).
+		AddStringSliceFlag(localconstants.ArgConnectionStrings, nil, "An ordered list of database connection strings to try - the first successful connection is used (comma-separated)").
+		AddStringFlag(localconstants.ArgConnectionStringFile, "", "Read the database connection string from this file - this takes precedence over --database but not --connection-strings").
+		AddStringFlag(localconstants.ArgConnection, "", "The name of a connection defined in the workspace config to use as the database, e.g. postgres.ci - this takes precedence over --database and --connection-string-file but not --connection-strings").
+		AddIntFlag(localconstants.ArgConnectionMaxRetries, 0, "The maximum number of times to retry connecting to the database if the connection fails with a transient error").
+		AddIntFlag(localconstants.ArgConnectionRetryInterval, localconstants.DefaultConnectionRetryInterval, "The base interval (in seconds) between database connection retries - this doubles after each retry").
+		AddIntFlag(localconstants.ArgDbPoolMaxConns, 0, "The maximum number of open database connections (defaults to the max parallelism)").
+		AddIntFlag(localconstants.ArgDbPoolMinConns, 0, "The number of database connections to open on startup and keep open while idle").
+		AddIntFlag(localconstants.ArgDbPoolWarmConns, 0, "The number of database connections to open once connected, so the first queries do not wait for a connection (failures are reported as warnings)").
+		AddIntFlag(localconstants.ArgDbKeepAliveInterval, localconstants.DefaultDbKeepAliveInterval, "The interval (in seconds) at which the database connection is checked, and re-established if it has dropped (0 to disable)").
+		AddIntFlag(localconstants.ArgInitTimeout, 0, "The maximum time (in seconds) allowed for initialization, including mod installation and connecting to the database (0 for no limit)").
+		AddBoolFlag(localconstants.ArgStrictRequirements, false, "Fail if the mod plugin requirements are not met by the database, or the Steampipe server version is not supported").
+		AddStringFlag(localconstants.ArgPluginVersionFile, "", "A JSON file of plugin versions to validate the mod plugin requirements against, rather than the plugin versions of the database").
+		AddBoolFlag(localconstants.ArgReadOnly, false, "Connect to the database in read-only mode, so any query which writes to the database fails").
+		AddStringFlag(localconstants.ArgDbSslRootCert, "", "A PEM file containing the certificate authority certificate(s) used to verify the database server certificate (postgres only)").
+		AddStringFlag(localconstants.ArgDbSslCert, "", "A PEM client certificate file used to authenticate with the database (postgres only, requires --db-ssl-key)").
+		AddStringFlag(localconstants.ArgDbSslKey, "", "The PEM private key file of the --db-ssl-cert client certificate").
+		AddStringFlag(localconstants.ArgDbAuthMode, localconstants.DbAuthModePassword, "The database authentication mode: 'password' uses the connection string credentials, 'rds-iam' generates an AWS IAM auth token when connecting (postgres only)").
+		AddStringFlag(localconstants.ArgCorrelationId, "", "An ID used to correlate the database queries and telemetry of this run (defaults to a generated UUID)").
+		AddStringFlag(localconstants.ArgTraceRootSpanName, "", "The name of the root telemetry span of this run (defaults to 'init')").
+		AddStringSliceFlag(localconstants.ArgTraceBaggage, nil, "Telemetry baggage, as key=value, added to all spans of this run").
 		AddBoolFlag(constants.ArgHeader, true, "Include column headers for csv and table output").
 		AddBoolFlag(constants.ArgHelp, false, "Help for run command", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
 		AddBoolFlag(constants.ArgModInstall, true, "Specify whether to install mod dependencies before running").
+		AddStringFlag(localconstants.ArgModInstallDir, "", "Install mod dependencies into this directory (e.g. a cache shared between CI jobs), rather than the workspace mods directory").
+		AddBoolFlag(localconstants.ArgModInstallDryRun, false, "Show the mod dependency changes which would be made, without installing them").
+		AddIntFlag(localconstants.ArgModInstallMaxRetries, 0, "The maximum number of times to retry installing mod dependencies if the install fails with a transient network error").
+		AddIntFlag(localconstants.ArgModInstallRetryInterval, localconstants.DefaultModInstallRetryInterval, "The base interval (in seconds) between mod install retries - this doubles after each retry").
+		AddStringFlag(localconstants.ArgModSource, "", "A local directory of pre-downloaded mods to install mod dependencies from, without network access").
 		AddVarFlag(enumflag.New(&updateStrategy, constants.ArgPull, constants.ModUpdateStrategyIds, enumflag.EnumCaseInsensitive),
 			constants.ArgPull,
 			fmt.Sprintf("Update strategy; one of: %s", strings.Join(constants.FlagValues(constants.ModUpdateStrategyIds), ", "))).
@@ -70,6 +100,8 @@ func checkCmd[T controlinit.CheckTarget]() *cobra.Command {
 		AddBoolFlag(constants.ArgSnapshot, false, "Create snapshot in Turbot Pipes with the default (workspace) visibility").
 		AddBoolFlag(constants.ArgTiming, false, "Turn on the query timer").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, localconstants.DatabaseDefaultQueryTimeout, "The query timeout").
+		AddIntFlag(localconstants.ArgControlQueryTimeout, 0, "The timeout (in seconds) for each control query - a control which exceeds this is marked as errored (0 for no limit, overridable using the control query_timeout tag)").
+		AddIntFlag(localconstants.ArgControlMaxRows, 0, "The maximum number of result rows kept for each control - further rows are discarded and the control is marked as truncated (0 for no limit, overridable using the control max_rows tag)").
 		// NOTE: use StringArrayFlag for ArgVariable, not StringSliceFlag
 		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
 		AddStringArrayFlag(constants.ArgSnapshotTag, nil, "Specify tags to set on the snapshot").
@@ -82,21 +114,44 @@ func checkCmd[T controlinit.CheckTarget]() *cobra.Command {
 		AddStringFlag(constants.ArgSeparator, ",", "Separator string for csv output").
 		AddStringFlag(constants.ArgSnapshotLocation, "", "The location to write snapshots - either a local file path or a Turbot Pipes workspace").
 		AddStringFlag(constants.ArgSnapshotTitle, "", "The title to give a snapshot").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, jsonl, md, nunit3, junit, pps (snapshot), asff, sarif, xlsx, pdf, summary, query-plan (the SQL and EXPLAIN plan of each control), null (discard, for benchmarking exports) - use <format>:- to write to stdout, and <format>:<key>=<value>,... to set exporter options, e.g. csv:delimiter=;,header=false - or export results to a postgres table using a connection string, e.g. postgres://user@host:5432/db?table=schema.results").
+		AddBoolFlag(localconstants.ArgAlarmExitCode, true, "Return a non-zero exit code (1) if any control is in alarm - if false, only control errors and run failures return a non-zero exit code").
+		AddStringSliceFlag(localconstants.ArgSeverityOrder, nil, "The control severities, from most to least severe, used to determine the worst severity of the failing controls (defaults to critical,high,medium,low,info)").
+		AddBoolFlag(localconstants.ArgExportOnlyFailed, false, "Only include failed (alarm or error) control results in exports").
+		AddStringFlag(localconstants.ArgExportGroupByTag, "", "Group the controls in exports by the value of this tag, rather than by benchmark (controls without the tag are grouped as untagged)").
+		AddBoolFlag(localconstants.ArgExportSorted, false, "Sort exported results deterministically, by benchmark path then control name, so repeated runs produce identical exports").
+		AddStringFlag(localconstants.ArgExportPathTemplate, "", "Template for the file name of exports specified by format, supporting the tokens {name}, {format}, {ext}, {timestamp} and {git_sha}").
+		AddBoolFlag(localconstants.ArgExportCompress, false, "Gzip compress exports (the .gz extension is appended to the export file names)").
+		AddBoolFlag(localconstants.ArgExportSign, false, "Write a sha256 checksum file (<file>.sha256) for each export file").
+		AddStringFlag(localconstants.ArgExportSigningKey, "", "Path to a PEM private key (ed25519, rsa or ecdsa) used to write a detached signature file (<file>.sig) for each export file, as well as a checksum file").
+		AddStringFlag(localconstants.ArgExportFileMode, "", "The octal file mode of export files, e.g. 0640 (missing parent directories are created with a corresponding directory mode)").
+		AddStringFlag(localconstants.ArgExportDir, "", "The directory to write exports to (created if missing) - relative export file names are resolved against this directory").
+		AddBoolFlag(localconstants.ArgQueryPlanAnalyze, false, "Use EXPLAIN ANALYZE to get the query plans for the query-plan export (this executes each control query a second time)").
+		AddStringSliceFlag(localconstants.ArgExportResourceType, nil, "Restrict an export format to a resource type, e.g. csv=benchmark - formats which are not restricted are exported for all resource types (benchmark, control, dashboard, query)").
+		AddBoolFlag(localconstants.ArgSarifIncludePassing, false, "Include passing control results in sarif exports").
 		AddStringSliceFlag(constants.ArgSearchPath, nil, "Set a custom search_path (comma-separated)").
 		AddStringSliceFlag(constants.ArgSearchPathPrefix, nil, "Set a prefix to the current search path (comma-separated)").
-		AddIntFlag(constants.ArgBenchmarkTimeout, 0, "Set the benchmark execution timeout")
+		AddIntFlag(constants.ArgBenchmarkTimeout, 0, "Set the benchmark execution timeout").
+		AddIntFlag(localconstants.ArgControlCacheTtl, 0, "Cache control results for this many seconds, so re-running a control with the same query and args within the TTL uses the cached results (0 disables the cache)").
+		AddBoolFlag(localconstants.ArgClearControlCache, false, "Clear the cached control results before running").
+		AddStringFlag(localconstants.ArgWebhookUrl, "", "Post a summary of the results to this webhook URL (Slack compatible) when the run completes").
+		AddStringFlag(localconstants.ArgWebhookTemplate, "", "A template file used to render the webhook payload, which is passed the run summary (the rendered payload must be JSON)")
 
 	// for control command, add --arg
 	switch typeName {
 	case "control":
-		builder.AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a control argument")
+		builder.AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a control argument, either as name=value or positionally - values are converted to the type of the parameter default")
 	case "benchmark":
 		builder.
-			AddStringFlag(constants.ArgWhere, "", "SQL 'where' clause, or named query, used to filter controls (cannot be used with '--tag')").
+			AddStringFlag(constants.ArgWhere, "", "SQL 'where' clause, or named query, used to filter controls (cannot be used with '--tag' or '--tag-filter')").
 			AddBoolFlag(constants.ArgDryRun, false, "Show which controls will be run without running them").
+			AddBoolFlag(localconstants.ArgList, false, "List the benchmarks and controls which would be run, without connecting to the database or executing any queries").
 			AddStringSliceFlag(constants.ArgTag, nil, "Filter controls based on their tag values ('--tag key=value')").
-			AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of concurrent database connections to open")
+			AddStringFlag(localconstants.ArgTagFilter, "", "Filter controls using a tag expression, e.g. 'service=s3 AND severity=high' - supports =, !=, AND, OR, NOT and parentheses").
+			AddBoolFlag(localconstants.ArgIncludeFiltered, false, "Include the controls excluded by '--where', '--tag' or '--tag-filter' in the results, as skipped, rather than omitting them").
+			AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of controls to execute concurrently (defaults to the database pool max connections)").
+			AddStringFlag(localconstants.ArgCheckpointFile, "", "Persist the results of each control as it completes to this file, so the run can be resumed with '--resume'").
+			AddBoolFlag(localconstants.ArgResume, false, "Resume the run recorded in the '--checkpoint-file', only executing the controls which did not complete")
 	}
 
 	return cmd
@@ -109,15 +164,24 @@ func checkCmdShort(typeName string) string {
 	return fmt.Sprintf("Execute one or more %ss", typeName)
 }
 func checkCmdLong(typeName string) string {
-	return fmt.Sprintf(`Execute one or more %ss.
+	return fmt.Sprintf(`Execute one or more %[1]ss.
 
-You may specify one or more benchmarks to run, separated by a space.`, typeName)
+You may specify one or more benchmarks to run, separated by a space.
+
+Exit codes:
+
+  0    all controls passed
+  1    1 or more controls are in alarm (unless --alarm-exit-code=false)
+  2    1 or more controls are in error
+  3    the run failed - a %[1]s could not be executed, or its snapshot or exports could not be published
+  250+ initialization or input errors`, typeName)
 }
 
 // exitCode=0 no runtime errors, no control alarms or errors
-// exitCode=1 no runtime errors, 1 or more control alarms, no control errors
+// exitCode=1 no runtime errors, 1 or more control alarms, no control errors (unless --alarm-exit-code=false)
 // exitCode=2 no runtime errors, 1 or more control errors
-// exitCode=3+ runtime errors
+// exitCode=3 the run failed - a benchmark could not be executed, or its snapshot or exports could not be published
+// exitCode=250+ initialization or input errors
 
 func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 	utils.LogTime("runCheckCmd start")
@@ -172,35 +236,80 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 	// if there is a usage warning we display it
 	initData.Result.DisplayMessages()
 
+	// in mod install dry run mode, init stops once the dependency changes have been reported - nothing is executed
+	if viper.GetBool(localconstants.ArgModInstallDryRun) {
+		return
+	}
+
 	// now filter the target
 	// get the execution trees
 	trees, err := getExecutionTrees[T](ctx, initData)
 	error_helpers.FailOnError(err)
 
+	// in list mode, just show the resolved trees - there is no database connection, so nothing is executed
+	if viper.GetBool(localconstants.ArgList) {
+		error_helpers.FailOnError(listExecutionTrees(trees))
+		return
+	}
+
+	// open the checkpoint file (if specified), loading the results of the run being resumed
+	checkpoint, err := openCheckpoint()
+	if err != nil {
+		exitCode = constants.ExitCodeInitializationFailed
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	if checkpoint != nil {
+		defer checkpoint.Close()
+	}
+
+	// open the control result cache (if enabled), clearing it first if requested
+	resultCache, err := openResultCache(initData.ControlCacheTtl)
+	if err != nil {
+		exitCode = constants.ExitCodeInitializationFailed
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
+	// create the webhook notifier (if a webhook URL was specified)
+	notifier, err := newWebhookNotifier()
+	if err != nil {
+		exitCode = constants.ExitCodeInitializationFailed
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+
 	// pull out useful properties
 	totalAlarms, totalErrors := 0, 0
+	// set if the run fails (as opposed to controls failing)
+	executionFailed := false
 	defer func() {
-		// set the defined exit code after successful execution
-		exitCode = getExitCode(totalAlarms, totalErrors)
+		// set the defined exit code after execution
+		exitCode = getExitCode(totalAlarms, totalErrors, executionFailed)
 	}()
 
 	for _, namedTree := range trees {
+		namedTree.tree.SetCheckpoint(checkpoint)
+		namedTree.tree.SetResultCache(resultCache)
+		namedTree.tree.SetMaxParallel(initData.MaxParallel)
+		namedTree.tree.SetSeverityOrder(viper.GetStringSlice(localconstants.ArgSeverityOrder))
+		namedTree.tree.SetQueryPlanMode(queryPlanMode(initData))
 		// execute controls synchronously (execute returns the number of alarms and errors)
 		err = executeTree(ctx, namedTree.tree, initData)
 		if err != nil {
-			totalErrors++
+			executionFailed = true
 			error_helpers.ShowError(ctx, err)
 			return
 		}
 
 		// append the total number of alarms and errors for multiple runs
-		totalAlarms = namedTree.tree.Root.Summary.Status.Alarm
-		totalErrors = namedTree.tree.Root.Summary.Status.Error
+		totalAlarms += namedTree.tree.Root.Summary.Status.Alarm
+		totalErrors += namedTree.tree.Root.Summary.Status.Error
 
 		err = publishSnapshot(ctx, namedTree.tree, viper.GetBool(constants.ArgShare), viper.GetBool(constants.ArgSnapshot))
 		if err != nil {
 			error_helpers.ShowError(ctx, err)
-			totalErrors++
+			executionFailed = true
 			return
 		}
 		if shouldPrintCheckTiming() {
@@ -209,26 +318,74 @@ func runCheckCmd[T controlinit.CheckTarget](cmd *cobra.Command, args []string) {
 			})
 		}
 
-		err = exportExecutionTree(ctx, namedTree, initData, viper.GetStringSlice(constants.ArgExport))
+		reports, err := exportExecutionTree(ctx, namedTree, initData, viper.GetStringSlice(constants.ArgExport))
 		if err != nil {
 			error_helpers.ShowError(ctx, err)
-			totalErrors++
+			executionFailed = true
 		}
+
+		notifyWebhook(ctx, notifier, namedTree, reports)
 	}
 }
 
+// newWebhookNotifier creates the webhook notifier, if a webhook URL was specified (dry runs are not notified)
+func newWebhookNotifier() (*notify.WebhookNotifier, error) {
+	webhookUrl := viper.GetString(localconstants.ArgWebhookUrl)
+	if webhookUrl == "" || viper.GetBool(constants.ArgDryRun) {
+		return nil, nil
+	}
+	return notify.NewWebhookNotifier(webhookUrl, viper.GetString(localconstants.ArgWebhookTemplate))
+}
+
+// notifyWebhook posts the summary of the executed tree to the webhook
+// a webhook failure does not fail the run - a warning is shown and the run continues
+func notifyWebhook(ctx context.Context, notifier *notify.WebhookNotifier, namedTree *namedExecutionTree, reports []string) {
+	if notifier == nil || error_helpers.IsContextCanceled(ctx) {
+		return
+	}
+	payload := notify.NewWebhookPayload(namedTree.name, namedTree.tree, reports)
+	if err := notifier.Notify(ctx, payload); err != nil {
+		slog.Warn("webhook notification failed", "name", namedTree.name, "error", err)
+		error_helpers.ShowWarning(fmt.Sprintf("failed to send webhook notification: %s", err.Error()))
+	}
+}
+
+// queryPlanMode returns the query plan mode used to execute the trees - query plans are only captured if they
+// are exported
+func queryPlanMode[T controlinit.CheckTarget](initData *controlinit.InitData[T]) controlexecute.QueryPlanMode {
+	if !initData.ExportManager.HasFormatExport(viper.GetStringSlice(constants.ArgExport), controldisplay.QueryPlanFormatName) {
+		return controlexecute.QueryPlanNone
+	}
+	if viper.GetBool(localconstants.ArgQueryPlanAnalyze) {
+		return controlexecute.QueryPlanExplainAnalyze
+	}
+	return controlexecute.QueryPlanExplain
+}
+
 // exportExecutionTree relies on the fact that the given tree is already executed
-func exportExecutionTree[T controlinit.CheckTarget](ctx context.Context, namedTree *namedExecutionTree, initData *controlinit.InitData[T], exportArgs []string) error {
+// the locations of the exported files are returned (these are returned even if some exports fail)
+func exportExecutionTree[T controlinit.CheckTarget](ctx context.Context, namedTree *namedExecutionTree, initData *controlinit.InitData[T], exportArgs []string) ([]string, error) {
 	statushooks.Show(ctx)
 	defer statushooks.Done(ctx)
 
 	if error_helpers.IsContextCanceled(ctx) {
-		return ctx.Err()
+		return nil, ctx.Err()
 	}
 
-	exportMsg, err := initData.ExportManager.DoExport(ctx, namedTree.name, namedTree.tree, exportArgs)
+	// add the run correlation ID to the context, so exporters can identify the run (e.g. the postgres exporter run_id)
+	ctx = initialisation.ContextWithCorrelationId(ctx, initData.CorrelationId)
+	results, err := initData.ExportManager.Export(ctx, namedTree.name, namedTree.tree, exportArgs)
+	var exportMsg, locations []string
+	for _, result := range results {
+		if result.Message != "" {
+			exportMsg = append(exportMsg, result.Message)
+		}
+		if result.Location != "" {
+			locations = append(locations, result.Location)
+		}
+	}
 	if err != nil {
-		return err
+		return locations, err
 	}
 
 	// print the location where the file is exported if progress=true
@@ -236,7 +393,7 @@ func exportExecutionTree[T controlinit.CheckTarget](ctx context.Context, namedTr
 		fmt.Printf("\n%s\n", strings.Join(exportMsg, "\n")) //nolint:forbidigo // we want to print
 	}
 
-	return nil
+	return locations, nil
 }
 
 // executeTree executes and displays the (table) results of an execution
@@ -311,14 +468,80 @@ func getExecutionTrees[T controlinit.CheckTarget](ctx context.Context, initData
 	return trees, ctx.Err()
 }
 
-// get the exit code for successful check run
-func getExitCode(alarms int, errors int) int {
+// listExecutionTrees writes the benchmarks and controls of the trees to stdout, without executing them
+// controls excluded by the control filter are only included if '--include-filtered' was set (and are marked as skipped)
+func listExecutionTrees(trees []*namedExecutionTree) error {
+	var roots []*display.ResourceTreeNode
+	for _, namedTree := range trees {
+		roots = append(roots, resultGroupResources(namedTree.tree.Root)...)
+	}
+	return display.WriteResourceTree(os.Stdout, roots...)
+}
+
+// resultGroupResources returns the resource tree nodes for the child groups and control runs of the group
+func resultGroupResources(group *controlexecute.ResultGroup) []*display.ResourceTreeNode {
+	var res []*display.ResourceTreeNode
+	for _, child := range group.Groups {
+		res = append(res, &display.ResourceTreeNode{
+			Name:      child.GroupId,
+			BlockType: child.NodeType,
+			Title:     child.Title,
+			Children:  resultGroupResources(child),
+		})
+	}
+	for _, controlRun := range group.ControlRuns {
+		node := &display.ResourceTreeNode{
+			Name:      controlRun.Control.Name(),
+			BlockType: schema.BlockTypeControl,
+			Title:     controlRun.Control.GetTitle(),
+		}
+		if controlRun.IsFiltered() {
+			node.Note = "skipped - excluded by filter"
+		}
+		res = append(res, node)
+	}
+	return res
+}
+
+// openCheckpoint opens the checkpoint file, if one was specified (dry runs do not use a checkpoint)
+func openCheckpoint() (*controlexecute.Checkpoint, error) {
+	path := viper.GetString(localconstants.ArgCheckpointFile)
+	if path == "" || viper.GetBool(constants.ArgDryRun) {
+		return nil, nil
+	}
+	return controlexecute.NewCheckpoint(path, viper.GetBool(localconstants.ArgResume))
+}
+
+// openResultCache opens the control result cache, if a TTL was set (dry runs do not use the cache)
+// if '--clear-control-cache' was set, the cached results are removed first
+func openResultCache(ttl time.Duration) (*controlexecute.ResultCache, error) {
+	if viper.GetBool(constants.ArgDryRun) {
+		return nil, nil
+	}
+	dir := controlexecute.DefaultResultCacheDir()
+	if viper.GetBool(localconstants.ArgClearControlCache) {
+		if err := controlexecute.ClearResultCache(dir); err != nil {
+			return nil, err
+		}
+	}
+	if ttl == 0 {
+		return nil, nil
+	}
+	return controlexecute.NewResultCache(dir, ttl)
+}
+
+// get the exit code for an executed check run
+func getExitCode(alarms int, errors int, executionFailed bool) int {
+	// the run failed, return exitCode=3
+	if executionFailed {
+		return localconstants.ExitCodeExecutionFailed
+	}
 	// 1 or more control errors, return exitCode=2
 	if errors > 0 {
 		return constants.ExitCodeControlsError
 	}
-	// 1 or more controls in alarm, return exitCode=1
-	if alarms > 0 {
+	// 1 or more controls in alarm, return exitCode=1 (unless alarms are not treated as a failure)
+	if alarms > 0 && viper.GetBool(localconstants.ArgAlarmExitCode) {
 		return constants.ExitCodeControlsAlarm
 	}
 	// no controls in alarm/error
@@ -349,8 +572,8 @@ func validateCheckArgs(ctx context.Context) error {
 		return fmt.Errorf("only one of --search-path or --search-path-prefix may be set")
 	}
 
-	// only 1 character is allowed for '--separator'
-	if len(viper.GetString(constants.ArgSeparator)) > 1 {
+	// only 1 character is allowed for '--separator' (this may be a multi-byte character such as '¦')
+	if utf8.RuneCountInString(viper.GetString(constants.ArgSeparator)) > 1 {
 		return fmt.Errorf("'--%s' can be 1 character long at most", constants.ArgSeparator)
 	}
 
@@ -359,9 +582,24 @@ func validateCheckArgs(ctx context.Context) error {
 		return fmt.Errorf("only 1 of '--%s' and '--%s' may be set", constants.ArgShare, constants.ArgSnapshot)
 	}
 
-	// if both '--where' and '--tag' have been used, then it's an error
-	if viper.IsSet(constants.ArgWhere) && viper.IsSet(constants.ArgTag) {
-		return fmt.Errorf("only 1 of '--%s' and '--%s' may be set", constants.ArgWhere, constants.ArgTag)
+	// only 1 of '--where', '--tag' and '--tag-filter' may be used
+	var filterArgs []string
+	for _, arg := range []string{constants.ArgWhere, constants.ArgTag, localconstants.ArgTagFilter} {
+		if viper.IsSet(arg) {
+			filterArgs = append(filterArgs, arg)
+		}
+	}
+	if len(filterArgs) > 1 {
+		return fmt.Errorf("only 1 of '--%s' and '--%s' may be set", filterArgs[0], filterArgs[1])
+	}
+
+	if viper.GetInt(localconstants.ArgControlMaxRows) < 0 {
+		return fmt.Errorf("'--%s' must not be negative", localconstants.ArgControlMaxRows)
+	}
+
+	// resuming requires the checkpoint file of the run being resumed
+	if viper.GetBool(localconstants.ArgResume) && viper.GetString(localconstants.ArgCheckpointFile) == "" {
+		return fmt.Errorf("'--%s' requires '--%s'", localconstants.ArgResume, localconstants.ArgCheckpointFile)
 	}
 
 	return localcmdconfig.ValidateDatabaseArg()
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+func TestGetExitCode(t *testing.T) {
+	tests := map[string]struct {
+		alarms          int
+		errors          int
+		executionFailed bool
+		alarmExitCode   bool
+		want            int
+	}{
+		"no alarms or errors": {
+			alarmExitCode: true,
+			want:          constants.ExitCodeSuccessful,
+		},
+		"alarms": {
+			alarms:        2,
+			alarmExitCode: true,
+			want:          constants.ExitCodeControlsAlarm,
+		},
+		"alarms with alarm exit code disabled": {
+			alarms: 2,
+			want:   constants.ExitCodeSuccessful,
+		},
+		"errors": {
+			errors:        1,
+			alarmExitCode: true,
+			want:          constants.ExitCodeControlsError,
+		},
+		"alarms and errors": {
+			alarms:        2,
+			errors:        1,
+			alarmExitCode: true,
+			want:          constants.ExitCodeControlsError,
+		},
+		"errors with alarm exit code disabled": {
+			alarms: 2,
+			errors: 1,
+			want:   constants.ExitCodeControlsError,
+		},
+		"execution failed": {
+			executionFailed: true,
+			alarmExitCode:   true,
+			want:            localconstants.ExitCodeExecutionFailed,
+		},
+		"execution failed with alarms and errors": {
+			alarms:          2,
+			errors:          1,
+			executionFailed: true,
+			alarmExitCode:   true,
+			want:            localconstants.ExitCodeExecutionFailed,
+		},
+		"execution failed with alarm exit code disabled": {
+			alarms:          2,
+			executionFailed: true,
+			want:            localconstants.ExitCodeExecutionFailed,
+		},
+	}
+	defer viper.Set(localconstants.ArgAlarmExitCode, viper.Get(localconstants.ArgAlarmExitCode))
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			viper.Set(localconstants.ArgAlarmExitCode, test.alarmExitCode)
+			if got := getExitCode(test.alarms, test.errors, test.executionFailed); got != test.want {
+				t.Errorf("getExitCode(%d, %d, %v) = %d, want %d", test.alarms, test.errors, test.executionFailed, got, test.want)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlstatus"
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
+	"github.com/turbot/powerpipe/internal/display"
 	"github.com/turbot/powerpipe/internal/initialisation"
 	"github.com/turbot/steampipe-plugin-sdk/v5/logging"
 )
@@ -53,13 +54,46 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddCloudFlags().
 		AddModLocationFlag().
 		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a dashboard argument").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported format: pps (snapshot)").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported format: pps (snapshot) - use <format>:- to write to stdout").
+		AddStringFlag(localconstants.ArgExportPathTemplate, "", "Template for the file name of exports specified by format, supporting the tokens {name}, {format}, {ext}, {timestamp} and {git_sha}").
+		AddBoolFlag(localconstants.ArgExportCompress, false, "Gzip compress exports (the .gz extension is appended to the export file names)").
+		AddBoolFlag(localconstants.ArgExportSign, false, "Write a sha256 checksum file (<file>.sha256) for each export file").
+		AddStringFlag(localconstants.ArgExportSigningKey, "", "Path to a PEM private key (ed25519, rsa or ecdsa) used to write a detached signature file (<file>.sig) for each export file, as well as a checksum file").
+		AddStringFlag(localconstants.ArgExportFileMode, "", "The octal file mode of export files, e.g. 0640 (missing parent directories are created with a corresponding directory mode)").
+		AddStringFlag(localconstants.ArgExportDir, "", "The directory to write exports to (created if missing) - relative export file names are resolved against this directory").
+		AddStringSliceFlag(localconstants.ArgExportResourceType, nil, "Restrict an export format to a resource type, e.g. csv=benchmark - formats which are not restricted are exported for all resource types (benchmark, control, dashboard, query)").
 		AddStringFlag(constants.ArgDatabase, "", "Turbot Pipes workspace database", localcmdconfig.Deprecated("see https://powerpipe.io/docs/run#selecting-a-database for the new syntax")).
+		AddStringSliceFlag(localconstants.ArgConnectionStrings, nil, "An ordered list of database connection strings to try - the first successful connection is used (comma-separated)").
+		AddStringFlag(localconstants.ArgConnectionStringFile, "", "Read the database connection string from this file - this takes precedence over --database but not --connection-strings").
+		AddStringFlag(localconstants.ArgConnection, "", "The name of a connection defined in the workspace config to use as the database, e.g. postgres.ci - this takes precedence over --database and --connection-string-file but not --connection-strings").
+		AddIntFlag(localconstants.ArgConnectionMaxRetries, 0, "The maximum number of times to retry connecting to the database if the connection fails with a transient error").
+		AddIntFlag(localconstants.ArgConnectionRetryInterval, localconstants.DefaultConnectionRetryInterval, "The base interval (in seconds) between database connection retries - this doubles after each retry").
+		AddIntFlag(localconstants.ArgDbPoolMaxConns, 0, "The maximum number of open database connections (defaults to the max parallelism)").
+		AddIntFlag(localconstants.ArgDbPoolMinConns, 0, "The number of database connections to open on startup and keep open while idle").
+		AddIntFlag(localconstants.ArgDbPoolWarmConns, 0, "The number of database connections to open once connected, so the first queries do not wait for a connection (failures are reported as warnings)").
+		AddIntFlag(localconstants.ArgDbKeepAliveInterval, localconstants.DefaultDbKeepAliveInterval, "The interval (in seconds) at which the database connection is checked, and re-established if it has dropped (0 to disable)").
+		AddIntFlag(localconstants.ArgInitTimeout, 0, "The maximum time (in seconds) allowed for initialization, including mod installation and connecting to the database (0 for no limit)").
+		AddBoolFlag(localconstants.ArgStrictRequirements, false, "Fail if the mod plugin requirements are not met by the database, or the Steampipe server version is not supported").
+		AddStringFlag(localconstants.ArgPluginVersionFile, "", "A JSON file of plugin versions to validate the mod plugin requirements against, rather than the plugin versions of the database").
+		AddBoolFlag(localconstants.ArgReadOnly, false, "Connect to the database in read-only mode, so any query which writes to the database fails").
+		AddStringFlag(localconstants.ArgDbSslRootCert, "", "A PEM file containing the certificate authority certificate(s) used to verify the database server certificate (postgres only)").
+		AddStringFlag(localconstants.ArgDbSslCert, "", "A PEM client certificate file used to authenticate with the database (postgres only, requires --db-ssl-key)").
+		AddStringFlag(localconstants.ArgDbSslKey, "", "The PEM private key file of the --db-ssl-cert client certificate").
+		AddStringFlag(localconstants.ArgDbAuthMode, localconstants.DbAuthModePassword, "The database authentication mode: 'password' uses the connection string credentials, 'rds-iam' generates an AWS IAM auth token when connecting (postgres only)").
+		AddStringFlag(localconstants.ArgCorrelationId, "", "An ID used to correlate the database queries and telemetry of this run (defaults to a generated UUID)").
+		AddStringFlag(localconstants.ArgTraceRootSpanName, "", "The name of the root telemetry span of this run (defaults to 'init')").
+		AddStringSliceFlag(localconstants.ArgTraceBaggage, nil, "Telemetry baggage, as key=value, added to all spans of this run").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, localconstants.DatabaseDefaultQueryTimeout, "The query timeout").
 		AddBoolFlag(constants.ArgHelp, false, "Help for dashboard", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
+		AddBoolFlag(localconstants.ArgList, false, "List the resources of the dashboard, without connecting to the database or executing any queries").
 		AddIntFlag(constants.ArgMaxParallel, constants.DefaultMaxConnections, "The maximum number of concurrent database connections to open").
 		AddBoolFlag(constants.ArgModInstall, true, "Specify whether to install mod dependencies before running the dashboard").
+		AddStringFlag(localconstants.ArgModInstallDir, "", "Install mod dependencies into this directory (e.g. a cache shared between CI jobs), rather than the workspace mods directory").
+		AddBoolFlag(localconstants.ArgModInstallDryRun, false, "Show the mod dependency changes which would be made, without installing them").
+		AddIntFlag(localconstants.ArgModInstallMaxRetries, 0, "The maximum number of times to retry installing mod dependencies if the install fails with a transient network error").
+		AddIntFlag(localconstants.ArgModInstallRetryInterval, localconstants.DefaultModInstallRetryInterval, "The base interval (in seconds) between mod install retries - this doubles after each retry").
+		AddStringFlag(localconstants.ArgModSource, "", "A local directory of pre-downloaded mods to install mod dependencies from, without network access").
 		AddVarFlag(enumflag.New(&updateStrategy, constants.ArgPull, constants.ModUpdateStrategyIds, enumflag.EnumCaseInsensitive),
 			constants.ArgPull,
 			fmt.Sprintf("Update strategy; one of: %s", strings.Join(constants.FlagValues(constants.ModUpdateStrategyIds), ", "))).
@@ -128,6 +162,7 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 		// validate required export formats
 		err = initData.ExportManager.ValidateExportFormat(viper.GetStringSlice(constants.ArgExport))
 		error_helpers.FailOnError(err)
+		initData.ReserveStdoutForExport(viper.GetStringSlice(constants.ArgExport))
 	}
 
 	statushooks.Done(ctx)
@@ -139,9 +174,21 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	// if there is a usage warning we display it
 	initData.Result.DisplayMessages()
 
+	// in mod install dry run mode, init stops once the dependency changes have been reported - nothing is executed
+	if viper.GetBool(localconstants.ArgModInstallDryRun) {
+		return
+	}
+
 	// so a dashboard name was specified - just call GenerateSnapshot
 	target, err := initData.GetSingleTarget()
 	error_helpers.FailOnError(err)
+
+	// in list mode, just show the resources of the dashboard - there is no database connection, so nothing is executed
+	if viper.GetBool(localconstants.ArgList) {
+		error_helpers.FailOnError(display.WriteResourceTree(os.Stdout, modTreeItemResources(target)))
+		return
+	}
+
 	snap, err := dashboardexecute.GenerateSnapshot(ctx, initData.WorkspaceEvents, target, inputs)
 	error_helpers.FailOnError(err)
 	// display the snapshot result (if needed)
@@ -166,6 +213,19 @@ func dashboardRun(cmd *cobra.Command, args []string) {
 	}
 }
 
+// modTreeItemResources returns the resource tree node for the item and its descendants
+func modTreeItemResources(item modconfig.ModTreeItem) *display.ResourceTreeNode {
+	node := &display.ResourceTreeNode{
+		Name:      item.Name(),
+		BlockType: item.BlockType(),
+		Title:     item.GetTitle(),
+	}
+	for _, child := range item.GetChildren() {
+		node.Children = append(node.Children, modTreeItemResources(child))
+	}
+	return node
+}
+
 // validate the args and extract a dashboard name, if provided
 func validateDashboardArgs(ctx context.Context) error {
 	err := localcmdconfig.ValidateSnapshotArgs(ctx)
@@ -50,10 +50,36 @@ The current mod is the working directory, or the directory specified by the --mo
 		AddModLocationFlag().
 		// NOTE: use StringArrayFlag for ArgQueryInput, not StringSliceFlag
 		// Cobra will interpret values passed to a StringSliceFlag as CSV, where args passed to StringArrayFlag are not parsed and used raw
-		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a query argument").
+		AddStringArrayFlag(constants.ArgArg, nil, "Specify the value of a query argument, either as name=value or positionally - values are converted to the type of the parameter default").
 		AddStringFlag(constants.ArgDatabase, "", "Turbot Pipes workspace database", localcmdconfig.Deprecated("see https://powerpipe.io/docs/run#selecting-a-database for the new syntax")).
+		AddStringSliceFlag(localconstants.ArgConnectionStrings, nil, "An ordered list of database connection strings to try - the first successful connection is used (comma-separated)").
+		AddStringFlag(localconstants.ArgConnectionStringFile, "", "Read the database connection string from this file - this takes precedence over --database but not --connection-strings").
+		AddStringFlag(localconstants.ArgConnection, "", "The name of a connection defined in the workspace config to use as the database, e.g. postgres.ci - this takes precedence over --database and --connection-string-file but not --connection-strings").
+		AddIntFlag(localconstants.ArgConnectionMaxRetries, 0, "The maximum number of times to retry connecting to the database if the connection fails with a transient error").
+		AddIntFlag(localconstants.ArgConnectionRetryInterval, localconstants.DefaultConnectionRetryInterval, "The base interval (in seconds) between database connection retries - this doubles after each retry").
+		AddIntFlag(localconstants.ArgDbPoolMaxConns, 0, "The maximum number of open database connections (defaults to the max parallelism)").
+		AddIntFlag(localconstants.ArgDbPoolMinConns, 0, "The number of database connections to open on startup and keep open while idle").
+		AddIntFlag(localconstants.ArgDbPoolWarmConns, 0, "The number of database connections to open once connected, so the first queries do not wait for a connection (failures are reported as warnings)").
+		AddIntFlag(localconstants.ArgDbKeepAliveInterval, localconstants.DefaultDbKeepAliveInterval, "The interval (in seconds) at which the database connection is checked, and re-established if it has dropped (0 to disable)").
+		AddIntFlag(localconstants.ArgInitTimeout, 0, "The maximum time (in seconds) allowed for initialization, including mod installation and connecting to the database (0 for no limit)").
+		AddBoolFlag(localconstants.ArgStrictRequirements, false, "Fail if the mod plugin requirements are not met by the database, or the Steampipe server version is not supported").
+		AddBoolFlag(localconstants.ArgReadOnly, false, "Connect to the database in read-only mode, so any query which writes to the database fails").
+		AddStringFlag(localconstants.ArgDbSslRootCert, "", "A PEM file containing the certificate authority certificate(s) used to verify the database server certificate (postgres only)").
+		AddStringFlag(localconstants.ArgDbSslCert, "", "A PEM client certificate file used to authenticate with the database (postgres only, requires --db-ssl-key)").
+		AddStringFlag(localconstants.ArgDbSslKey, "", "The PEM private key file of the --db-ssl-cert client certificate").
+		AddStringFlag(localconstants.ArgDbAuthMode, localconstants.DbAuthModePassword, "The database authentication mode: 'password' uses the connection string credentials, 'rds-iam' generates an AWS IAM auth token when connecting (postgres only)").
+		AddStringFlag(localconstants.ArgCorrelationId, "", "An ID used to correlate the database queries and telemetry of this run (defaults to a generated UUID)").
+		AddStringFlag(localconstants.ArgTraceRootSpanName, "", "The name of the root telemetry span of this run (defaults to 'init')").
+		AddStringSliceFlag(localconstants.ArgTraceBaggage, nil, "Telemetry baggage, as key=value, added to all spans of this run").
 		AddIntFlag(constants.ArgDatabaseQueryTimeout, localconstants.DatabaseDefaultQueryTimeout, "The query timeout").
-		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff").
+		AddStringSliceFlag(constants.ArgExport, nil, "Export output to file, supported formats: csv, html, json, md, nunit3, pps (snapshot), asff - use <format>:- to write to stdout").
+		AddStringFlag(localconstants.ArgExportPathTemplate, "", "Template for the file name of exports specified by format, supporting the tokens {name}, {format}, {ext}, {timestamp} and {git_sha}").
+		AddBoolFlag(localconstants.ArgExportCompress, false, "Gzip compress exports (the .gz extension is appended to the export file names)").
+		AddBoolFlag(localconstants.ArgExportSign, false, "Write a sha256 checksum file (<file>.sha256) for each export file").
+		AddStringFlag(localconstants.ArgExportSigningKey, "", "Path to a PEM private key (ed25519, rsa or ecdsa) used to write a detached signature file (<file>.sig) for each export file, as well as a checksum file").
+		AddStringFlag(localconstants.ArgExportFileMode, "", "The octal file mode of export files, e.g. 0640 (missing parent directories are created with a corresponding directory mode)").
+		AddStringFlag(localconstants.ArgExportDir, "", "The directory to write exports to (created if missing) - relative export file names are resolved against this directory").
+		AddStringSliceFlag(localconstants.ArgExportResourceType, nil, "Restrict an export format to a resource type, e.g. csv=benchmark - formats which are not restricted are exported for all resource types (benchmark, control, dashboard, query)").
 		AddBoolFlag(constants.ArgHeader, true, "Include column headers for csv and table output").
 		AddBoolFlag(constants.ArgHelp, false, "Help for query", cmdconfig.FlagOptions.WithShortHand("h")).
 		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
@@ -126,6 +152,7 @@ func queryRun(cmd *cobra.Command, args []string) {
 		// validate required export formats
 		err = initData.ExportManager.ValidateExportFormat(viper.GetStringSlice(constants.ArgExport))
 		error_helpers.FailOnError(err)
+		initData.ReserveStdoutForExport(viper.GetStringSlice(constants.ArgExport))
 	}
 
 	// execute query as a snapshot
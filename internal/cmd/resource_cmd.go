@@ -113,6 +113,11 @@ func getResourceCommands[T modconfig.ModTreeItem]() []*cobra.Command {
 		res = append(res, dashboardChildCommands()...)
 	}
 
+	// benchmark results may be compared
+	if typeName == schema.BlockTypeBenchmark {
+		res = append(res, benchmarkDiffCmd())
+	}
+
 	return res
 }
 
@@ -47,7 +47,10 @@ func rootCommand() *cobra.Command {
 		AddPersistentStringFlag(constants.ArgConfigPath, "", "Colon separated list of paths to search for workspace files, in order of decreasing precedence").
 		AddPersistentStringFlag(constants.ArgInstallDir, app_specific.DefaultInstallDir, "Path to the installation directory").
 		AddPersistentStringFlag(constants.ArgModLocation, wd, "Path to the workspace working directory").
-		AddPersistentStringFlag(constants.ArgWorkspaceProfile, "default", "Sets the Powerpipe workspace profile")
+		AddPersistentStringFlag(constants.ArgWorkspaceProfile, "default", "Sets the Powerpipe workspace profile").
+		AddPersistentStringFlag(constants.ArgTelemetry, constants.TelemetryInfo, "Set the telemetry level - 'none' disables all telemetry, including traces, metrics and any local metrics collection").
+		AddPersistentStringFlag(localconstants.ArgLocale, "", "The locale of status and warning messages, e.g. fr or pt-BR - messages with no translation for the locale are shown in English").
+		AddPersistentBoolFlag(localconstants.ArgQuiet, false, "Quiet mode - do not show status messages or progress (errors and warnings are still shown)")
 
 	rootCmd.AddCommand(
 		serverCmd(),
@@ -58,6 +61,7 @@ func rootCommand() *cobra.Command {
 		resourceCmd[*modconfig.Dashboard](),
 		resourceCmd[*modconfig.Query](),
 		resourceCmd[*modconfig.Variable](),
+		validateCmd(),
 	)
 
 	// disable auto completion generation, since we don't want to support
@@ -19,6 +19,7 @@ import (
 	"github.com/turbot/powerpipe/internal/dashboardserver"
 	"github.com/turbot/powerpipe/internal/initialisation"
 	"github.com/turbot/powerpipe/internal/service/api"
+	"github.com/turbot/powerpipe/internal/telemetry"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 	"gopkg.in/olahol/melody.v1"
 )
@@ -44,7 +45,28 @@ Powerpipe server runs in the foreground; Press Ctrl-C to exit.`,
 		AddStringArrayFlag(constants.ArgVariable, []string{}, "Specify the value of a variable. Multiple --var arguments may be passed.").
 		AddStringFlag(constants.ArgVarFile, "", "Specify a .ppvar file containing variable values.").
 		AddStringFlag(constants.ArgDatabase, "", "Turbot Pipes workspace database", localcmdconfig.Deprecated("see https://powerpipe.io/docs/run#selecting-a-database for the new syntax")).
-		AddIntFlag(constants.ArgDashboardTimeout, 0, "Set a the dashboard execution timeout")
+		AddStringSliceFlag(localconstants.ArgConnectionStrings, nil, "An ordered list of database connection strings to try - the first successful connection is used (comma-separated)").
+		AddStringFlag(localconstants.ArgConnectionStringFile, "", "Read the database connection string from this file - this takes precedence over --database but not --connection-strings").
+		AddStringFlag(localconstants.ArgConnection, "", "The name of a connection defined in the workspace config to use as the database, e.g. postgres.ci - this takes precedence over --database and --connection-string-file but not --connection-strings").
+		AddIntFlag(localconstants.ArgConnectionMaxRetries, 0, "The maximum number of times to retry connecting to the database if the connection fails with a transient error").
+		AddIntFlag(localconstants.ArgConnectionRetryInterval, localconstants.DefaultConnectionRetryInterval, "The base interval (in seconds) between database connection retries - this doubles after each retry").
+		AddIntFlag(localconstants.ArgDbPoolMaxConns, 0, "The maximum number of open database connections (defaults to the max parallelism)").
+		AddIntFlag(localconstants.ArgDbPoolMinConns, 0, "The number of database connections to open on startup and keep open while idle").
+		AddIntFlag(localconstants.ArgDbPoolWarmConns, 0, "The number of database connections to open once connected, so the first queries do not wait for a connection (failures are reported as warnings)").
+		AddIntFlag(localconstants.ArgDbKeepAliveInterval, localconstants.DefaultDbKeepAliveInterval, "The interval (in seconds) at which the database connection is checked, and re-established if it has dropped (0 to disable)").
+		AddIntFlag(localconstants.ArgInitTimeout, 0, "The maximum time (in seconds) allowed for initialization, including mod installation and connecting to the database (0 for no limit)").
+		AddBoolFlag(localconstants.ArgStrictRequirements, false, "Fail if the mod plugin requirements are not met by the database, or the Steampipe server version is not supported").
+		AddBoolFlag(localconstants.ArgReadOnly, false, "Connect to the database in read-only mode, so any query which writes to the database fails").
+		AddStringFlag(localconstants.ArgDbSslRootCert, "", "A PEM file containing the certificate authority certificate(s) used to verify the database server certificate (postgres only)").
+		AddStringFlag(localconstants.ArgDbSslCert, "", "A PEM client certificate file used to authenticate with the database (postgres only, requires --db-ssl-key)").
+		AddStringFlag(localconstants.ArgDbSslKey, "", "The PEM private key file of the --db-ssl-cert client certificate").
+		AddStringFlag(localconstants.ArgDbAuthMode, localconstants.DbAuthModePassword, "The database authentication mode: 'password' uses the connection string credentials, 'rds-iam' generates an AWS IAM auth token when connecting (postgres only)").
+		AddStringFlag(localconstants.ArgCorrelationId, "", "An ID used to correlate the database queries and telemetry of this run (defaults to a generated UUID)").
+		AddStringFlag(localconstants.ArgTraceRootSpanName, "", "The name of the root telemetry span of this run (defaults to 'init')").
+		AddStringSliceFlag(localconstants.ArgTraceBaggage, nil, "Telemetry baggage, as key=value, added to all spans of this run").
+		AddIntFlag(constants.ArgDashboardTimeout, 0, "Set a the dashboard execution timeout").
+		AddBoolFlag(localconstants.ArgMetrics, false, "Serve Prometheus metrics (database query and initialization metrics) on the /metrics path of the '--metrics-address'").
+		AddStringFlag(localconstants.ArgMetricsAddress, localconstants.DefaultMetricsAddress, "The address (host:port) the metrics server listens on")
 
 	return cmd
 }
@@ -74,6 +96,11 @@ func runServerCmd(cmd *cobra.Command, _ []string) {
 		error_helpers.FailOnError(sperr.New("Port %d is not available - is another instance of 'powerpipe server' running?\n       Set a different port using the --port argument", serverPort))
 	}
 
+	// start the metrics server before initialising, so the metrics are provided to it when telemetry is initialised
+	if viper.GetBool(localconstants.ArgMetrics) {
+		error_helpers.FailOnError(telemetry.StartMetricsServer(ctx, viper.GetString(localconstants.ArgMetricsAddress)))
+	}
+
 	// initialise the workspace
 	modInitData := initialisation.NewInitData[*modconfig.Dashboard](ctx, cmd)
 	error_helpers.FailOnError(modInitData.Result.Error)
@@ -109,5 +136,9 @@ func runServerCmd(cmd *cobra.Command, _ []string) {
 }
 
 func validateServerArgs() error {
+	// the metrics are collected by telemetry, so cannot be served if telemetry is disabled
+	if viper.GetBool(localconstants.ArgMetrics) && viper.GetString(constants.ArgTelemetry) == constants.TelemetryNone {
+		return fmt.Errorf("'--%s' cannot be used when '--%s' is '%s'", localconstants.ArgMetrics, constants.ArgTelemetry, constants.TelemetryNone)
+	}
 	return localcmdconfig.ValidateDatabaseArg()
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/cmdconfig"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+func validateCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "validate [flags]",
+		Args:  cobra.NoArgs,
+		Run:   runValidateCmd,
+		Short: "Validate the workspace without running anything",
+		Long: `Validate the workspace without running anything.
+
+Loads the workspace, checks that all referenced resources resolve and validates the
+requirements of the mod and its dependency mods. The database is not connected to, and
+no queries are run. Each problem is reported with its file and line, where available.
+
+Plugin requirements are only validated if a plugin version file is given. Requirement
+failures are warnings unless --strict-requirements is set.
+
+The exit code is 0 if the workspace is valid (it may have warnings), and 1 if there are
+any errors.
+
+Examples:
+
+  # Validate the workspace in the current directory
+  powerpipe validate
+
+  # Validate the plugin requirements against a plugin version file, as json
+  powerpipe validate --plugin-version-file plugins.json --output json`,
+	}
+
+	cmdconfig.OnCmd(cmd).
+		AddStringFlag(constants.ArgOutput, constants.OutputFormatText, "Output format: text or json").
+		AddBoolFlag(localconstants.ArgStrictRequirements, false, "Report unmet mod requirements as errors rather than warnings").
+		AddStringFlag(localconstants.ArgPluginVersionFile, "", "A JSON file of plugin versions to validate the mod plugin requirements against").
+		AddBoolFlag(constants.ArgInput, true, "Enable interactive prompts").
+		// NOTE: use StringArrayFlag for ArgVariable, not StringSliceFlag
+		// Cobra will interpret values passed to a StringSliceFlag as CSV,
+		// where args passed to StringArrayFlag are not parsed and used raw
+		AddStringArrayFlag(constants.ArgVariable, nil, "Specify the value of a variable").
+		AddStringArrayFlag(constants.ArgVarFile, nil, "Specify an .ppvar file containing variable values").
+		AddBoolFlag(constants.ArgHelp, false, "Help for validate", cmdconfig.FlagOptions.WithShortHand("h"))
+
+	return cmd
+}
+
+func runValidateCmd(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+	utils.LogTime("cmd.runValidateCmd start")
+	defer func() {
+		utils.LogTime("cmd.runValidateCmd end")
+		if r := recover(); r != nil {
+			error_helpers.ShowError(ctx, helpers.ToError(r))
+			exitCode = constants.ExitCodeUnknownErrorPanic
+		}
+	}()
+
+	output := viper.GetString(constants.ArgOutput)
+	if output != constants.OutputFormatText && output != constants.OutputFormatJSON {
+		exitCode = constants.ExitCodeInsufficientOrWrongInputs
+		error_helpers.ShowError(ctx, sperr.New("invalid output format '%s' - expected text or json", output))
+		return
+	}
+
+	problems := initialisation.ValidateWorkspace(ctx, viper.GetString(constants.ArgModLocation))
+	if err := displayValidationProblems(cmd.OutOrStdout(), output, problems); err != nil {
+		exitCode = constants.ExitCodeUnknownErrorPanic
+		error_helpers.ShowError(ctx, err)
+		return
+	}
+	if initialisation.HasValidationErrors(problems) {
+		exitCode = constants.ExitCodeControlsAlarm
+	}
+}
+
+func displayValidationProblems(out io.Writer, output string, problems []initialisation.ValidationProblem) error {
+	if output == constants.OutputFormatJSON {
+		if problems == nil {
+			problems = []initialisation.ValidationProblem{}
+		}
+		content, err := json.MarshalIndent(problems, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(content))
+		return err
+	}
+
+	var errorCount int
+	for _, problem := range problems {
+		if problem.Severity == initialisation.ValidationSeverityError {
+			errorCount++
+		}
+		if _, err := fmt.Fprintln(out, problem.String()); err != nil {
+			return err
+		}
+	}
+	if len(problems) == 0 {
+		_, err := fmt.Fprintln(out, "Workspace is valid")
+		return err
+	}
+	warningCount := len(problems) - errorCount
+	_, err := fmt.Fprintf(out, "\n%d %s, %d %s\n", errorCount, utils.Pluralize("error", errorCount), warningCount, utils.Pluralize("warning", warningCount))
+	return err
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/turbot/pipe-fittings/task"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/pipe-fittings/workspace_profile"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/logger"
 	"github.com/turbot/powerpipe/internal/powerpipeconfig"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -71,6 +72,9 @@ func preRunHook(cmd *cobra.Command, args []string) error {
 
 	logger.Initialize()
 
+	// in quiet mode, disable the status hooks for the command
+	cmd.SetContext(QuietContext(cmd.Context()))
+
 	// runScheduledTasks skips running tasks if this instance is the plugin manager
 	waitForTasksChannel = runScheduledTasks(cmd.Context(), cmd, args)
 
@@ -224,6 +228,11 @@ func validateConfig(activeWorkspace *workspace_profile.PowerpipeWorkspaceProfile
 		res.Error = sperr.New(`invalid value of 'telemetry' (%s), must be one of: %s`, telemetry, strings.Join(constants.TelemetryLevels, ", "))
 		return res
 	}
+	// the auth mode is only set for commands which connect to a database
+	if authMode := viper.GetString(localconstants.ArgDbAuthMode); authMode != "" && !helpers.StringSliceContains(localconstants.DbAuthModes, authMode) {
+		res.Error = sperr.New(`invalid value of 'db-auth-mode' (%s), must be one of: %s`, authMode, strings.Join(localconstants.DbAuthModes, ", "))
+		return res
+	}
 	if _, legacyDiagnosticsSet := os.LookupEnv(plugin.EnvLegacyDiagnosticsLevel); legacyDiagnosticsSet {
 		res.AddWarning(fmt.Sprintf("Environment variable %s is deprecated - use %s", plugin.EnvLegacyDiagnosticsLevel, plugin.EnvDiagnosticsLevel))
 	}
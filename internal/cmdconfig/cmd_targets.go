@@ -64,7 +64,7 @@ func resolveSingleTarget[T modconfig.ModTreeItem](cmdArg string, w *workspace.Wo
 
 	// now check if any args were specified on the command line using the --arg flag
 	// if so verify no args were passed in the resource invocation, e.g. query.my_query("val1","val1"
-	commandLineQueryArgs, err := getCommandLineQueryArgs()
+	commandLineQueryArgs, err := getCommandLineQueryArgs(target)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +167,8 @@ func handleAllArg[T modconfig.ModTreeItem](args []string, w *workspace.Workspace
 }
 
 // build a QueryArgs from any args passed using the --args flag
-func getCommandLineQueryArgs() (*modconfig.QueryArgs, error) {
+// if the target is a query provider, the args are bound to the params it declares (see bindQueryArgs)
+func getCommandLineQueryArgs(target modconfig.ModTreeItem) (*modconfig.QueryArgs, error) {
 	argTuples := viper.GetStringSlice(constants.ArgArg)
 	var res = modconfig.NewQueryArgs()
 
@@ -175,30 +176,43 @@ func getCommandLineQueryArgs() (*modconfig.QueryArgs, error) {
 		return res, nil
 	}
 
+	namedArgs := make(map[string]string)
+	var positionalArgs []string
 	for _, argTuple := range argTuples {
-		parts := strings.Split(argTuple, "=")
-		switch len(parts) {
-		case 1:
+		// split at the first '=' - the value may itself contain '=', e.g. a JSON value
+		argName, argValue, isNamed := strings.Cut(argTuple, "=")
+		if !isNamed {
 			// if there is no '=' this must be a positional arg
-			if err := res.AddPositionalArgVal(parts[0]); err != nil {
-				return nil, err
-			}
-
-		case 2:
-			argName := parts[0]
-			argValue := parts[1]
-
-			if err := res.SetNamedArgVal(argName, argValue); err != nil {
-				return nil, err
-			}
-		default:
+			positionalArgs = append(positionalArgs, argTuple)
+			continue
+		}
+		if argName == "" {
 			return nil, sperr.New("invalid arg format: %s", argTuple)
 		}
+		if _, ok := namedArgs[argName]; ok {
+			return nil, sperr.New("the arg '%s' is provided more than once", argName)
+		}
+		namedArgs[argName] = argValue
 	}
 	// we should not have both positional and named args
-	if len(res.ArgMap) > 0 && len(res.ArgList) > 0 {
+	if len(namedArgs) > 0 && len(positionalArgs) > 0 {
 		return nil, sperr.New("cannot mix positional and named args")
 	}
+
+	if qp, ok := target.(modconfig.QueryProvider); ok {
+		return bindQueryArgs(qp, namedArgs, positionalArgs)
+	}
+
+	for _, argValue := range positionalArgs {
+		if err := res.AddPositionalArgVal(argValue); err != nil {
+			return nil, err
+		}
+	}
+	for argName, argValue := range namedArgs {
+		if err := res.SetNamedArgVal(argName, argValue); err != nil {
+			return nil, err
+		}
+	}
 	return res, nil
 
 }
@@ -22,6 +22,9 @@ func configDefaults(cmd *cobra.Command) map[string]any {
 
 		// memory
 		constants.ArgMemoryMaxMb: 1024,
+
+		// shutdown
+		localconstants.ArgShutdownTimeout: localconstants.DefaultShutdownTimeout,
 	}
 	cmdSpecificDefaults, ok := cmdSpecificDefaults()[cmd.Name()]
 	if ok {
@@ -72,10 +75,14 @@ func envMappings() map[string]cmdconfig.EnvMapping {
 		constants.EnvPipesHost:       {ConfigVar: []string{constants.ArgPipesHost}, VarType: cmdconfig.EnvVarTypeString},
 		constants.EnvPipesToken:      {ConfigVar: []string{constants.ArgPipesToken}, VarType: cmdconfig.EnvVarTypeString},
 		// powerpipe specific constants
-		localconstants.EnvListen:           {ConfigVar: []string{constants.ArgListen}, VarType: cmdconfig.EnvVarTypeString},
-		localconstants.EnvPort:             {ConfigVar: []string{constants.ArgPort}, VarType: cmdconfig.EnvVarTypeInt},
-		localconstants.EnvBenchmarkTimeout: {ConfigVar: []string{constants.ArgBenchmarkTimeout}, VarType: cmdconfig.EnvVarTypeInt},
-		localconstants.EnvDashboardTimeout: {ConfigVar: []string{constants.ArgDashboardTimeout}, VarType: cmdconfig.EnvVarTypeInt},
-		localconstants.EnvDisplayWidth:     {ConfigVar: []string{constants.ArgDisplayWidth}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvListen:               {ConfigVar: []string{constants.ArgListen}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvPort:                 {ConfigVar: []string{constants.ArgPort}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvBenchmarkTimeout:     {ConfigVar: []string{constants.ArgBenchmarkTimeout}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvDashboardTimeout:     {ConfigVar: []string{constants.ArgDashboardTimeout}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvDisplayWidth:         {ConfigVar: []string{constants.ArgDisplayWidth}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvShutdownTimeout:      {ConfigVar: []string{localconstants.ArgShutdownTimeout}, VarType: cmdconfig.EnvVarTypeInt},
+		localconstants.EnvConnectionStringFile: {ConfigVar: []string{localconstants.ArgConnectionStringFile}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvLocale:               {ConfigVar: []string{localconstants.ArgLocale}, VarType: cmdconfig.EnvVarTypeString},
+		localconstants.EnvQuiet:                {ConfigVar: []string{localconstants.ArgQuiet}, VarType: cmdconfig.EnvVarTypeBool},
 	}
 }
@@ -0,0 +1,154 @@
+package cmdconfig
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the types of a query parameter, inferred from its default value
+const (
+	paramTypeAny    = "any"
+	paramTypeString = "string"
+	paramTypeNumber = "number"
+	paramTypeBool   = "bool"
+	paramTypeList   = "list"
+	paramTypeObject = "object"
+)
+
+// queryProviderParams returns the params declared by the query provider
+// - if the query provider declares no params but references a query, the params of the query are used
+func queryProviderParams(qp modconfig.QueryProvider) []*modconfig.ParamDef {
+	if params := qp.GetParams(); len(params) > 0 {
+		return params
+	}
+	if query := qp.GetQuery(); query != nil {
+		return query.GetParams()
+	}
+	return nil
+}
+
+// paramType returns the type of the param, inferred from its default value
+// - if the param has no default (or a null default), any value is accepted, and is passed as a string
+func paramType(param *modconfig.ParamDef) (string, error) {
+	if param.Default == nil {
+		return paramTypeAny, nil
+	}
+	if param.IsString {
+		return paramTypeString, nil
+	}
+	defaultValue, err := param.GetDefault()
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "failed to read the default value of parameter '%s'", param.ShortName)
+	}
+	switch defaultValue.(type) {
+	case string:
+		return paramTypeString, nil
+	case float64:
+		return paramTypeNumber, nil
+	case bool:
+		return paramTypeBool, nil
+	case []any:
+		return paramTypeList, nil
+	case map[string]any:
+		return paramTypeObject, nil
+	}
+	return paramTypeAny, nil
+}
+
+// coerceArgValue converts a command line arg value to the type of the param
+func coerceArgValue(param *modconfig.ParamDef, value string) (any, error) {
+	t, err := paramType(param)
+	if err != nil {
+		return nil, err
+	}
+	invalidValue := func(expected string) error {
+		return sperr.New("invalid value '%s' for parameter '%s': expected %s", value, param.ShortName, expected)
+	}
+
+	switch t {
+	case paramTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, invalidValue("a number")
+		}
+		// use a json number, so the value is passed as it was given
+		return json.Number(value), nil
+	case paramTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalidValue("true or false")
+		}
+		return b, nil
+	case paramTypeList:
+		var list []any
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return nil, invalidValue(`a JSON array, e.g. '["a","b"]'`)
+		}
+		return list, nil
+	case paramTypeObject:
+		var object map[string]any
+		if err := json.Unmarshal([]byte(value), &object); err != nil {
+			return nil, invalidValue(`a JSON object, e.g. '{"key":"value"}'`)
+		}
+		return object, nil
+	}
+	return value, nil
+}
+
+// bindQueryArgs binds the args passed using the --arg flag to the params declared by the query provider,
+// converting each value to the type of its param
+// an error is returned if an arg does not correspond to a param, or if its value does not match the param type
+func bindQueryArgs(qp modconfig.QueryProvider, namedArgs map[string]string, positionalArgs []string) (*modconfig.QueryArgs, error) {
+	res := modconfig.NewQueryArgs()
+	params := queryProviderParams(qp)
+
+	// if there are no params, this may be a sql query using positional args ($1, $2 etc.)
+	if len(params) == 0 {
+		if len(namedArgs) > 0 {
+			return nil, sperr.New("%s does not declare any parameters, so does not accept named args", qp.Name())
+		}
+		for _, value := range positionalArgs {
+			if err := res.AddPositionalArgVal(value); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+
+	if len(positionalArgs) > len(params) {
+		return nil, sperr.New("%s declares %d parameters, but %d args were provided", qp.Name(), len(params), len(positionalArgs))
+	}
+	for i, value := range positionalArgs {
+		typedValue, err := coerceArgValue(params[i], value)
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "invalid arg for %s", qp.Name())
+		}
+		if err := res.AddPositionalArgVal(typedValue); err != nil {
+			return nil, err
+		}
+	}
+
+	paramMap := make(map[string]*modconfig.ParamDef, len(params))
+	paramNames := make([]string, len(params))
+	for i, param := range params {
+		paramMap[param.ShortName] = param
+		paramNames[i] = param.ShortName
+	}
+	for name, value := range namedArgs {
+		param, ok := paramMap[name]
+		if !ok {
+			return nil, sperr.New("%s has no parameter '%s' - valid parameters: %s", qp.Name(), name, strings.Join(paramNames, ", "))
+		}
+		typedValue, err := coerceArgValue(param, value)
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "invalid arg for %s", qp.Name())
+		}
+		if err := res.SetNamedArgVal(name, typedValue); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
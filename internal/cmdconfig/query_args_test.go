@@ -0,0 +1,112 @@
+package cmdconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func newQueryArgsTestQuery(t *testing.T, defaults map[string]any, names ...string) *modconfig.Query {
+	query := &modconfig.Query{}
+	query.FullName = "mod.query.q1"
+	var params []*modconfig.ParamDef
+	for _, name := range names {
+		param := &modconfig.ParamDef{ShortName: name}
+		if value, ok := defaults[name]; ok {
+			if err := param.SetDefault(value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		params = append(params, param)
+	}
+	query.Params = params
+	return query
+}
+
+func TestBindQueryArgs(t *testing.T) {
+	query := newQueryArgsTestQuery(t, map[string]any{
+		"name":    "bucket",
+		"limit":   10,
+		"enabled": true,
+		"regions": []any{"us-east-1"},
+		"tags":    map[string]any{"env": "prod"},
+	}, "name", "limit", "enabled", "regions", "tags", "untyped")
+
+	args, err := bindQueryArgs(query, map[string]string{
+		"name":    "my-bucket",
+		"limit":   "25",
+		"enabled": "false",
+		"regions": `["eu-west-1","eu-west-2"]`,
+		"tags":    `{"env":"dev"}`,
+		"untyped": "42",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"name":    "my-bucket",
+		"limit":   float64(25),
+		"enabled": false,
+		"regions": []any{"eu-west-1", "eu-west-2"},
+		"tags":    map[string]any{"env": "dev"},
+		// a param with no default accepts any value, as a string
+		"untyped": "42",
+	}
+	for name, expectedValue := range expected {
+		value, ok, err := args.GetNamedArg(name)
+		if err != nil || !ok {
+			t.Errorf("%s: expected a value, got error %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, expectedValue) {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", name, expectedValue, expectedValue, value, value)
+		}
+	}
+
+	// positional args are bound to the params in order
+	args, err = bindQueryArgs(query, nil, []string{"my-bucket", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := args.GetPositionalArg(1); value != float64(5) {
+		t.Errorf("expected the second positional arg to be the number 5, got %v (%T)", value, value)
+	}
+
+	errorTests := []struct {
+		named      map[string]string
+		positional []string
+		expected   string
+	}{
+		{named: map[string]string{"bad": "x"}, expected: "has no parameter 'bad' - valid parameters: name, limit, enabled, regions, tags, untyped"},
+		{named: map[string]string{"limit": "ten"}, expected: "invalid value 'ten' for parameter 'limit': expected a number"},
+		{named: map[string]string{"enabled": "maybe"}, expected: "expected true or false"},
+		{named: map[string]string{"regions": "us-east-1"}, expected: "expected a JSON array"},
+		{named: map[string]string{"tags": "[]"}, expected: "expected a JSON object"},
+		{positional: []string{"a", "1", "true", "[]", "{}", "x", "extra"}, expected: "declares 6 parameters, but 7 args were provided"},
+	}
+	for _, test := range errorTests {
+		_, err := bindQueryArgs(query, test.named, test.positional)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%v%v: expected error containing %q, got %v", test.named, test.positional, test.expected, err)
+		}
+	}
+}
+
+func TestBindQueryArgsNoParams(t *testing.T) {
+	query := newQueryArgsTestQuery(t, nil)
+
+	// a query with no params may use positional args
+	args, err := bindQueryArgs(query, nil, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args.ArgList) != 2 {
+		t.Errorf("expected 2 positional args, got %d", len(args.ArgList))
+	}
+
+	if _, err := bindQueryArgs(query, map[string]string{"name": "x"}, nil); err == nil || !strings.Contains(err.Error(), "does not declare any parameters") {
+		t.Errorf("expected an error for named args, got %v", err)
+	}
+}
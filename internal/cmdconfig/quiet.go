@@ -0,0 +1,21 @@
+package cmdconfig
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/statushooks"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// QuietContext returns the context with status hooks disabled if quiet mode (ArgQuiet) is set, so status messages
+// (e.g. the spinner) are not rendered - execution progress is also disabled
+// errors and warnings are still shown in quiet mode
+func QuietContext(ctx context.Context) context.Context {
+	if !viper.GetBool(localconstants.ArgQuiet) {
+		return ctx
+	}
+	viper.Set(constants.ArgProgress, false)
+	return statushooks.DisableStatusHooks(ctx)
+}
@@ -0,0 +1,33 @@
+package cmdconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/statushooks"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+func TestQuietContext(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	spinner := statushooks.NewStatusSpinnerHook()
+	ctx := statushooks.AddStatusHooksToContext(context.Background(), spinner)
+
+	viper.Set(constants.ArgProgress, true)
+	if hooks := statushooks.StatusHooksFromContext(QuietContext(ctx)); hooks != spinner {
+		t.Error("expected the status hooks to be unchanged when quiet mode is not set")
+	}
+	if !viper.GetBool(constants.ArgProgress) {
+		t.Error("expected progress to be unchanged when quiet mode is not set")
+	}
+
+	viper.Set(localconstants.ArgQuiet, true)
+	if hooks := statushooks.StatusHooksFromContext(QuietContext(ctx)); hooks != statushooks.NullHooks {
+		t.Error("expected the status hooks to be disabled in quiet mode")
+	}
+	if viper.GetBool(constants.ArgProgress) {
+		t.Error("expected progress to be disabled in quiet mode")
+	}
+}
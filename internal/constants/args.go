@@ -0,0 +1,60 @@
+package constants
+
+// powerpipe specific argument name constants
+const (
+	ArgConnectionStrings       = "connection-strings"
+	ArgConnectionMaxRetries    = "connection-max-retries"
+	ArgConnectionRetryInterval = "connection-retry-interval"
+	ArgSarifIncludePassing     = "sarif-include-passing"
+	ArgShutdownTimeout         = "shutdown-timeout"
+	ArgStrictRequirements      = "strict-requirements"
+	ArgModInstallDryRun        = "mod-install-dry-run"
+	ArgExportOnlyFailed        = "export-only-failed"
+	ArgExportGroupByTag        = "export-group-by-tag"
+	ArgExportSorted            = "export-sorted"
+	ArgDbPoolMaxConns          = "db-pool-max-conns"
+	ArgDbPoolMinConns          = "db-pool-min-conns"
+	ArgInitTimeout             = "max-init-time"
+	ArgExportPathTemplate      = "export-path-template"
+	ArgConnectionStringFile    = "connection-string-file"
+	ArgExportCompress          = "export-compress"
+	ArgExportSign              = "export-sign"
+	ArgExportSigningKey        = "export-signing-key"
+	ArgCorrelationId           = "correlation-id"
+	ArgControlQueryTimeout     = "control-query-timeout"
+	ArgControlMaxRows          = "control-max-rows"
+	ArgReadOnly                = "read-only"
+	ArgExportFileMode          = "export-file-mode"
+	ArgExportDir               = "output-dir"
+	ArgModInstallMaxRetries    = "mod-install-max-retries"
+	ArgModInstallRetryInterval = "mod-install-retry-interval"
+	ArgModSource               = "mod-source"
+	ArgDbKeepAliveInterval     = "db-keep-alive-interval"
+	ArgCheckpointFile          = "checkpoint-file"
+	ArgResume                  = "resume"
+	ArgMetrics                 = "metrics"
+	ArgMetricsAddress          = "metrics-address"
+	ArgDbSslRootCert           = "db-ssl-root-cert"
+	ArgDbSslCert               = "db-ssl-cert"
+	ArgDbSslKey                = "db-ssl-key"
+	ArgDbAuthMode              = "db-auth-mode"
+	ArgTagFilter               = "tag-filter"
+	ArgIncludeFiltered         = "include-filtered"
+	ArgControlCacheTtl         = "control-cache-ttl"
+	ArgClearControlCache       = "clear-control-cache"
+	ArgWebhookUrl              = "webhook-url"
+	ArgWebhookTemplate         = "webhook-template"
+	ArgList                    = "list"
+	ArgPluginVersionFile       = "plugin-version-file"
+	ArgTraceRootSpanName       = "trace-root-span-name"
+	ArgTraceBaggage            = "trace-baggage"
+	ArgSeverityOrder           = "severity-order"
+	ArgModInstallDir           = "mod-install-dir"
+	ArgAlarmExitCode           = "alarm-exit-code"
+	ArgConnection              = "connection"
+	ArgExportResourceType      = "export-resource-type"
+	ArgDbPoolWarmConns         = "db-pool-warm-conns"
+	ArgQueryPlanAnalyze        = "query-plan-analyze"
+	ArgLocale                  = "locale"
+	ArgQuiet                   = "quiet"
+)
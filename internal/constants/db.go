@@ -3,4 +3,26 @@ package constants
 const (
 	DatabaseDefaultQueryTimeout = 300
 	DefaultConnection           = "steampipe.default"
+	// DefaultConnectionRetryInterval is the base interval (in seconds) used for connection retry backoff
+	DefaultConnectionRetryInterval = 1
+	// DefaultModInstallRetryInterval is the base interval (in seconds) used for mod install retry backoff
+	DefaultModInstallRetryInterval = 1
+	// DefaultDbKeepAliveInterval is the default interval (in seconds) at which the default client connection is checked
+	DefaultDbKeepAliveInterval = 60
+	// DefaultShutdownTimeout is the default timeout (in seconds) for each cleanup step when shutting down
+	DefaultShutdownTimeout = 30
+	// DefaultMetricsAddress is the default address the metrics server listens on
+	DefaultMetricsAddress = "localhost:9194"
+	// SupportedSteampipeVersions is the range of Steampipe server versions supported by Powerpipe (a semver constraint)
+	SupportedSteampipeVersions = ">=0.21.0, <2.0.0"
 )
+
+// the database authentication modes
+const (
+	// DbAuthModePassword uses the credentials in the connection string
+	DbAuthModePassword = "password"
+	// DbAuthModeRdsIam generates a short-lived AWS IAM auth token at connect time, which is used as the password
+	DbAuthModeRdsIam = "rds-iam"
+)
+
+var DbAuthModes = []string{DbAuthModePassword, DbAuthModeRdsIam}
@@ -1,11 +1,15 @@
 package constants
 
 const (
-	EnvListen           = "POWERPIPE_LISTEN"
-	EnvPort             = "POWERPIPE_PORT"
-	EnvBenchmarkTimeout = "POWERPIPE_BENCHMARK_TIMEOUT"
-	EnvDashboardTimeout = "POWERPIPE_DASHBOARD_TIMEOUT"
-	EnvDisplayWidth     = "POWERPIPE_DISPLAY_WIDTH"
+	EnvListen               = "POWERPIPE_LISTEN"
+	EnvPort                 = "POWERPIPE_PORT"
+	EnvBenchmarkTimeout     = "POWERPIPE_BENCHMARK_TIMEOUT"
+	EnvDashboardTimeout     = "POWERPIPE_DASHBOARD_TIMEOUT"
+	EnvDisplayWidth         = "POWERPIPE_DISPLAY_WIDTH"
+	EnvShutdownTimeout      = "POWERPIPE_SHUTDOWN_TIMEOUT"
+	EnvConnectionStringFile = "POWERPIPE_CONNECTION_STRING_FILE"
+	EnvLocale               = "POWERPIPE_LOCALE"
+	EnvQuiet                = "POWERPIPE_QUIET"
 	// EnvConfigDump is an undocumented variable is subject to change in the future
 	EnvConfigDump = "POWERPIPE_CONFIG_DUMP"
 )
@@ -0,0 +1,8 @@
+package constants
+
+// powerpipe specific exit codes (see also the pipe-fittings exit codes)
+const (
+	// ExitCodeExecutionFailed is returned by check if the run failed, i.e. a benchmark could not be executed,
+	// or its snapshot or exports could not be published - this is distinct from control alarms and errors
+	ExitCodeExecutionFailed = 3
+)
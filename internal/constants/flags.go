@@ -89,3 +89,17 @@ var CheckOutputModeIds = map[CheckOutputMode][]string{
 	CheckOutputModeSnapshotShort: {OutputFormatPpSnapshotShort},
 	CheckOutputModeNone:          {constants.OutputFormatNone},
 }
+
+type DiffOutputMode enumflag.Flag
+
+const (
+	DiffOutputModePretty DiffOutputMode = iota
+	DiffOutputModePlain
+	DiffOutputModeJson
+)
+
+var DiffOutputModeIds = map[DiffOutputMode][]string{
+	DiffOutputModePretty: {constants.OutputFormatPretty},
+	DiffOutputModePlain:  {constants.OutputFormatPlain},
+	DiffOutputModeJson:   {constants.OutputFormatJSON},
+}
@@ -0,0 +1,127 @@
+package controldiff
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+// ControlDiff is the change in the result of a single control between two benchmark runs
+// - for controls which only appear in one of the runs, the previous or current result is not set
+type ControlDiff struct {
+	ControlName     string                       `json:"control_name"`
+	Title           string                       `json:"title,omitempty"`
+	Path            []string                     `json:"path"`
+	PreviousStatus  string                       `json:"previous_status,omitempty"`
+	CurrentStatus   string                       `json:"current_status,omitempty"`
+	PreviousSummary *controlstatus.StatusSummary `json:"previous_summary,omitempty"`
+	CurrentSummary  *controlstatus.StatusSummary `json:"current_summary,omitempty"`
+}
+
+// Diff is the difference between the control results of two benchmark runs
+type Diff struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+	// controls which were not failing in the previous run, and are in alarm or error in the current run
+	NewlyFailing []*ControlDiff `json:"newly_failing"`
+	// controls which were in alarm or error in the previous run, and are ok or info in the current run
+	NewlyPassing []*ControlDiff `json:"newly_passing"`
+	// controls whose status changed in any other way, e.g. alarm to error, or ok to skip
+	Changed []*ControlDiff `json:"changed"`
+	// controls whose status did not change
+	Unchanged []*ControlDiff `json:"unchanged"`
+	// controls which only appear in the current run
+	Added []*ControlDiff `json:"added"`
+	// controls which only appear in the previous run
+	Removed []*ControlDiff `json:"removed"`
+}
+
+// Compare returns the diff between the previous and current control results,
+// matching controls by their control name
+func Compare(previous, current *ResultFile) *Diff {
+	res := &Diff{
+		Previous:     previous.Path,
+		Current:      current.Path,
+		NewlyFailing: []*ControlDiff{},
+		NewlyPassing: []*ControlDiff{},
+		Changed:      []*ControlDiff{},
+		Unchanged:    []*ControlDiff{},
+		Added:        []*ControlDiff{},
+		Removed:      []*ControlDiff{},
+	}
+
+	for name, p := range previous.Controls {
+		c, ok := current.Controls[name]
+		if !ok {
+			res.Removed = append(res.Removed, newControlDiff(p, nil))
+			continue
+		}
+		d := newControlDiff(p, c)
+		switch {
+		case !isFailing(p.Status) && isFailing(c.Status):
+			res.NewlyFailing = append(res.NewlyFailing, d)
+		case isFailing(p.Status) && isPassing(c.Status):
+			res.NewlyPassing = append(res.NewlyPassing, d)
+		case p.Status != c.Status:
+			res.Changed = append(res.Changed, d)
+		default:
+			res.Unchanged = append(res.Unchanged, d)
+		}
+	}
+	for name, c := range current.Controls {
+		if _, ok := previous.Controls[name]; !ok {
+			res.Added = append(res.Added, newControlDiff(nil, c))
+		}
+	}
+
+	for _, diffs := range [][]*ControlDiff{res.NewlyFailing, res.NewlyPassing, res.Changed, res.Unchanged, res.Added, res.Removed} {
+		slices.SortFunc(diffs, func(a, b *ControlDiff) int {
+			return strings.Compare(a.ControlName, b.ControlName)
+		})
+	}
+	return res
+}
+
+// HasNewFailures returns whether any control is newly failing, or is failing and only appears in the current run
+func (d *Diff) HasNewFailures() bool {
+	if len(d.NewlyFailing) > 0 {
+		return true
+	}
+	for _, a := range d.Added {
+		if isFailing(a.CurrentStatus) {
+			return true
+		}
+	}
+	return false
+}
+
+func newControlDiff(previous, current *ControlResult) *ControlDiff {
+	res := &ControlDiff{}
+	// use the current title and path, if the control is in the current run
+	for _, r := range []*ControlResult{previous, current} {
+		if r != nil {
+			res.ControlName = r.ControlName
+			res.Title = r.Title
+			res.Path = r.Path
+		}
+	}
+	if previous != nil {
+		res.PreviousStatus = previous.Status
+		res.PreviousSummary = &previous.Summary
+	}
+	if current != nil {
+		res.CurrentStatus = current.Status
+		res.CurrentSummary = &current.Summary
+	}
+	return res
+}
+
+func isFailing(status string) bool {
+	return status == constants.ControlAlarm || status == constants.ControlError
+}
+
+func isPassing(status string) bool {
+	return status == constants.ControlOk || status == constants.ControlInfo
+}
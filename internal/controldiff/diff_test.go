@@ -0,0 +1,147 @@
+package controldiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const previousResults = `{
+	"group_id": "root_result_group",
+	"title": "CIS",
+	"groups": [{
+		"group_id": "cis_v150",
+		"title": "CIS v1.5.0",
+		"groups": [{
+			"group_id": "cis_v150_1",
+			"groups": [],
+			"controls": [
+				{"control_id": "control.still_ok", "title": "Still ok", "summary": {"ok": 2}, "run_error": ""},
+				{"control_id": "control.starts_failing", "summary": {"ok": 2}, "run_error": ""},
+				{"control_id": "control.starts_passing", "summary": {"alarm": 1, "ok": 1}, "run_error": ""},
+				{"control_id": "control.starts_erroring", "summary": {"alarm": 1}, "run_error": ""},
+				{"control_id": "control.removed", "summary": {"alarm": 1}, "run_error": ""}
+			]
+		}],
+		"controls": null
+	}],
+	"controls": null
+}`
+
+const currentResults = `{
+	"group_id": "root_result_group",
+	"title": "CIS",
+	"groups": [{
+		"group_id": "cis_v150",
+		"title": "CIS v1.5.0",
+		"groups": [{
+			"group_id": "cis_v150_1",
+			"groups": [],
+			"controls": [
+				{"control_id": "control.still_ok", "title": "Still ok", "summary": {"ok": 3}, "run_error": ""},
+				{"control_id": "control.starts_failing", "summary": {"ok": 1, "alarm": 1}, "run_error": ""},
+				{"control_id": "control.starts_passing", "summary": {"ok": 2}, "run_error": ""},
+				{"control_id": "control.starts_erroring", "summary": {}, "run_error": "query timeout"},
+				{"control_id": "control.added", "summary": {"skip": 1}, "run_error": ""}
+			]
+		}],
+		"controls": null
+	}],
+	"controls": null
+}`
+
+func writeResultFile(t *testing.T, name, content string) *ResultFile {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	res, err := LoadResultFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func controlNames(diffs []*ControlDiff) []string {
+	res := []string{}
+	for _, d := range diffs {
+		res = append(res, d.ControlName)
+	}
+	return res
+}
+
+func TestCompare(t *testing.T) {
+	previous := writeResultFile(t, "previous.json", previousResults)
+	current := writeResultFile(t, "current.json", currentResults)
+
+	if path := previous.Controls["control.still_ok"].Path; !reflect.DeepEqual(path, []string{"cis_v150", "cis_v150_1"}) {
+		t.Errorf("expected the path to exclude the root group, got %v", path)
+	}
+
+	diff := Compare(previous, current)
+	expected := map[string][]string{
+		"newly failing": {"control.starts_failing"},
+		"newly passing": {"control.starts_passing"},
+		"changed":       {"control.starts_erroring"},
+		"unchanged":     {"control.still_ok"},
+		"added":         {"control.added"},
+		"removed":       {"control.removed"},
+	}
+	actual := map[string][]string{
+		"newly failing": controlNames(diff.NewlyFailing),
+		"newly passing": controlNames(diff.NewlyPassing),
+		"changed":       controlNames(diff.Changed),
+		"unchanged":     controlNames(diff.Unchanged),
+		"added":         controlNames(diff.Added),
+		"removed":       controlNames(diff.Removed),
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if !diff.HasNewFailures() {
+		t.Errorf("expected the diff to have new failures")
+	}
+	if d := diff.Changed[0]; d.PreviousStatus != "alarm" || d.CurrentStatus != "error" {
+		t.Errorf("expected alarm -> error, got %s -> %s", d.PreviousStatus, d.CurrentStatus)
+	}
+	if d := diff.Removed[0]; d.CurrentSummary != nil || d.PreviousSummary == nil {
+		t.Errorf("expected a removed control to only have a previous summary")
+	}
+
+	// the diff is exported as json
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, diff); err != nil {
+		t.Fatal(err)
+	}
+	var exported Diff
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&exported, diff) {
+		t.Errorf("expected the exported diff to round trip")
+	}
+
+	buf.Reset()
+	if err := RenderText(&buf, diff, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"Newly failing (1)", "ok    -> alarm  control.starts_failing", "-     -> skip   control.added", "1 unchanged"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected the text output to contain %q, got:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestLoadResultFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(path, []byte(`{"schema_version": "20221222"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadResultFile(path); err == nil || !strings.Contains(err.Error(), "not a benchmark result file") {
+		t.Errorf("expected an error for a file which is not a benchmark result, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package controldiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// RenderJSON writes the diff as json
+func RenderJSON(w io.Writer, diff *Diff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diff)
+}
+
+// RenderText writes a human-readable summary of the diff - the unchanged controls are only counted,
+// all other controls are listed with their status change
+func RenderText(w io.Writer, diff *Diff, useColor bool) error {
+	au := aurora.NewAurora(useColor)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Comparing %s with %s\n", diff.Current, diff.Previous)
+
+	sections := []struct {
+		title string
+		diffs []*ControlDiff
+	}{
+		{"Newly failing", diff.NewlyFailing},
+		{"Newly passing", diff.NewlyPassing},
+		{"Changed", diff.Changed},
+		{"Added", diff.Added},
+		{"Removed", diff.Removed},
+	}
+	for _, s := range sections {
+		if len(s.diffs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s\n", au.Bold(fmt.Sprintf("%s (%d)", s.title, len(s.diffs))))
+		for _, d := range s.diffs {
+			change := fmt.Sprintf("%s -> %s", statusString(au, d.PreviousStatus), statusString(au, d.CurrentStatus))
+			fmt.Fprintf(&b, "  %s  %s", change, d.ControlName)
+			if d.Title != "" {
+				fmt.Fprintf(&b, "  %s", au.Faint(d.Title))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%d newly failing, %d newly passing, %d changed, %d unchanged, %d added, %d removed\n",
+		len(diff.NewlyFailing), len(diff.NewlyPassing), len(diff.Changed), len(diff.Unchanged), len(diff.Added), len(diff.Removed))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// statusString returns the padded, colored status - a control missing from a run is shown as '-'
+func statusString(au aurora.Aurora, status string) aurora.Value {
+	padded := fmt.Sprintf("%-5s", status)
+	switch status {
+	case "":
+		return au.Faint(fmt.Sprintf("%-5s", "-"))
+	case constants.ControlAlarm, constants.ControlError:
+		return au.Red(padded)
+	case constants.ControlOk:
+		return au.Green(padded)
+	case constants.ControlInfo:
+		return au.Cyan(padded)
+	default:
+		return au.Faint(padded)
+	}
+}
@@ -0,0 +1,99 @@
+package controldiff
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// resultGroup is a result group, as written by the json export format
+type resultGroup struct {
+	GroupId  string          `json:"group_id"`
+	Title    string          `json:"title"`
+	Groups   []resultGroup   `json:"groups"`
+	Controls []controlResult `json:"controls"`
+}
+
+// controlResult is a control run, as written by the json export format
+type controlResult struct {
+	ControlId string                      `json:"control_id"`
+	Title     string                      `json:"title"`
+	Summary   controlstatus.StatusSummary `json:"summary"`
+	RunError  string                      `json:"run_error"`
+}
+
+// ControlResult is the result of a single control in a benchmark result file
+type ControlResult struct {
+	ControlName string `json:"control_name"`
+	Title       string `json:"title,omitempty"`
+	// the ids of the benchmarks containing the control, starting with the top level benchmark
+	Path    []string                    `json:"path"`
+	Status  string                      `json:"status"`
+	Summary controlstatus.StatusSummary `json:"summary"`
+}
+
+// ResultFile is a benchmark result file written by the json export format
+type ResultFile struct {
+	Path string
+	// map of control name to result
+	Controls map[string]*ControlResult
+}
+
+// LoadResultFile loads the control results from a benchmark result file written by the json export format
+func LoadResultFile(path string) (*ResultFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to read benchmark result file '%s'", path)
+	}
+	var root resultGroup
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to parse benchmark result file '%s'", path)
+	}
+	if root.GroupId == "" {
+		return nil, sperr.New("'%s' is not a benchmark result file - benchmark results must be exported using the json format", path)
+	}
+
+	res := &ResultFile{
+		Path:     path,
+		Controls: make(map[string]*ControlResult),
+	}
+	res.addGroup(root, nil)
+	return res, nil
+}
+
+func (r *ResultFile) addGroup(group resultGroup, parentPath []string) {
+	path := parentPath
+	// the root group is added by the execution tree, so is not part of the path
+	if group.GroupId != controlexecute.RootResultGroupName {
+		// copy the path, as it is shared by the sibling groups
+		path = append(append([]string{}, parentPath...), group.GroupId)
+	}
+	for _, c := range group.Controls {
+		// a control may be in more than one benchmark - the results are the same, so use the first
+		if _, ok := r.Controls[c.ControlId]; ok {
+			continue
+		}
+		r.Controls[c.ControlId] = &ControlResult{
+			ControlName: c.ControlId,
+			Title:       c.Title,
+			Path:        path,
+			Status:      controlStatus(c.Summary, c.RunError),
+			Summary:     c.Summary,
+		}
+	}
+	for _, child := range group.Groups {
+		r.addGroup(child, path)
+	}
+}
+
+// controlStatus returns the overall status of a control - this is the most severe status of its results
+func controlStatus(summary controlstatus.StatusSummary, runError string) string {
+	if runError != "" {
+		return constants.ControlError
+	}
+	return summary.Status()
+}
@@ -0,0 +1,118 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	asffFormatName = "asff"
+	// the maximum number of findings which Security Hub accepts in a single BatchImportFindings request
+	maxAsffFindingsPerFile = 100
+)
+
+// AsffExporter is a ControlExporter for the asff template which splits the exported findings into files
+// of at most maxAsffFindingsPerFile findings, and skips findings with no resource identifier
+// (these are rejected by Security Hub)
+type AsffExporter struct {
+	ControlExporter
+}
+
+func NewAsffExporter(formatter Formatter) *AsffExporter {
+	return &AsffExporter{ControlExporter{formatter}}
+}
+
+// asffFindingResources is used to read the resources of a rendered finding
+type asffFindingResources struct {
+	Resources []struct {
+		Id string `json:"Id"`
+	} `json:"Resources"`
+}
+
+func (e *AsffExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	_, err := e.ExportFiles(ctx, input, destPath)
+	return err
+}
+
+// ExportFiles implements export.MultiFileExporter - the first batch of findings is written to the destination path,
+// and each subsequent batch to a file alongside it (see asffBatchFilePath)
+func (e *AsffExporter) ExportFiles(ctx context.Context, input export.ExportSourceData, destPath string) ([]string, error) {
+	res, err := e.format(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []json.RawMessage
+	if err := json.NewDecoder(res).Decode(&findings); err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to parse rendered asff findings")
+	}
+	findings, skipped := filterAsffFindings(findings)
+	if skipped > 0 {
+		error_helpers.ShowWarning(fmt.Sprintf("%d asff %s skipped - no resource identifier", skipped, utils.Pluralize("finding", skipped)))
+	}
+
+	batches := batchAsffFindings(findings)
+	files := make([]string, len(batches))
+	for i, batch := range batches {
+		content, err := json.MarshalIndent(batch, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[i] = asffBatchFilePath(destPath, e.FileExtension(), i)
+		if err := export.Write(files[i], bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// filterAsffFindings removes findings which do not have a resource identifier,
+// returning the remaining findings and the number removed
+func filterAsffFindings(findings []json.RawMessage) ([]json.RawMessage, int) {
+	var res []json.RawMessage
+	for _, finding := range findings {
+		var f asffFindingResources
+		if err := json.Unmarshal(finding, &f); err != nil || len(f.Resources) == 0 || f.Resources[0].Id == "" {
+			continue
+		}
+		res = append(res, finding)
+	}
+	return res, len(findings) - len(res)
+}
+
+// batchAsffFindings splits the findings into batches of at most maxAsffFindingsPerFile
+// there is always at least one (possibly empty) batch
+func batchAsffFindings(findings []json.RawMessage) [][]json.RawMessage {
+	batches := [][]json.RawMessage{}
+	for len(findings) > maxAsffFindingsPerFile {
+		batches = append(batches, findings[:maxAsffFindingsPerFile])
+		findings = findings[maxAsffFindingsPerFile:]
+	}
+	// ensure an empty export still writes an (empty) array
+	if findings == nil {
+		findings = []json.RawMessage{}
+	}
+	return append(batches, findings)
+}
+
+// asffBatchFilePath returns the file path for the batch with the given index
+// the first batch is written to the destination path, subsequent batches have the batch number
+// inserted before the extension, e.g. check.asff.json, check.2.asff.json, check.3.asff.json
+func asffBatchFilePath(destPath, extension string, batchIdx int) string {
+	if batchIdx == 0 {
+		return destPath
+	}
+	if !strings.HasSuffix(destPath, extension) {
+		extension = filepath.Ext(destPath)
+	}
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(destPath, extension), batchIdx+1, extension)
+}
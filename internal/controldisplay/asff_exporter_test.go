@@ -0,0 +1,54 @@
+package controldisplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestFilterAsffFindings(t *testing.T) {
+	findings := []json.RawMessage{
+		json.RawMessage(`{"Id":"c1","Resources":[{"Type":"Other","Id":"arn:aws:s3:::b1"}]}`),
+		json.RawMessage(`{"Id":"c2","Resources":[{"Type":"Other","Id":""}]}`),
+		json.RawMessage(`{"Id":"c3","Resources":[]}`),
+	}
+	res, skipped := filterAsffFindings(findings)
+	if len(res) != 1 || skipped != 2 {
+		t.Errorf("expected 1 finding and 2 skipped - got %d findings and %d skipped", len(res), skipped)
+	}
+}
+
+func TestBatchAsffFindings(t *testing.T) {
+	for count, expected := range map[int][]int{0: {0}, 1: {1}, 100: {100}, 101: {100, 1}, 250: {100, 100, 50}} {
+		findings := make([]json.RawMessage, count)
+		batches := batchAsffFindings(findings)
+		if len(batches) != len(expected) {
+			t.Errorf("%d findings: expected %d batches - got %d", count, len(expected), len(batches))
+			continue
+		}
+		for i, batch := range batches {
+			if len(batch) != expected[i] {
+				t.Errorf("%d findings: expected batch %d to have %d findings - got %d", count, i, expected[i], len(batch))
+			}
+		}
+	}
+}
+
+func TestAsffBatchFilePath(t *testing.T) {
+	tests := []struct {
+		destPath string
+		batchIdx int
+		expected string
+	}{
+		{"check.20240102T030405.asff.json", 0, "check.20240102T030405.asff.json"},
+		{"check.20240102T030405.asff.json", 1, "check.20240102T030405.2.asff.json"},
+		{"out/findings.asff.json", 2, "out/findings.3.asff.json"},
+		{"findings.json", 1, "findings.2.json"},
+	}
+	for _, test := range tests {
+		name := fmt.Sprintf("%s[%d]", test.destPath, test.batchIdx)
+		if got := asffBatchFilePath(test.destPath, ".asff.json", test.batchIdx); got != test.expected {
+			t.Errorf("%s: expected %s - got %s", name, test.expected, got)
+		}
+	}
+}
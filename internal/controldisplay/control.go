@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/viper"
 	typehelpers "github.com/turbot/go-kit/types"
 	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/powerpipe/internal/controlexecute"
 )
 
@@ -120,6 +121,13 @@ func (r ControlRenderer) Render() string {
 		}
 	}
 
+	// if the results were truncated to the control row limit, say how many were discarded
+	if r.run.Truncated {
+		discarded := r.run.TotalRows - len(r.run.Rows)
+		note := fmt.Sprintf("%d more %s not shown (limited to %d)", discarded, utils.Pluralize("result", discarded), len(r.run.Rows))
+		resultStrings = append(resultStrings, fmt.Sprintf("%s%s", ControlColors.Indent(r.resultIndent()), ControlColors.ReasonSkip(note)))
+	}
+
 	// newline after results
 	if len(resultStrings) > 0 {
 		controlStrings = append(controlStrings, resultStrings...)
@@ -3,9 +3,12 @@ package controldisplay
 import (
 	"context"
 	"fmt"
+	"io"
+
 	"github.com/turbot/pipe-fittings/contexthelpers"
 	"github.com/turbot/pipe-fittings/export"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	localexport "github.com/turbot/powerpipe/internal/export"
 )
 
 var contextKeyFormatterPurpose = contexthelpers.ContextKey("formatter_purpose")
@@ -21,7 +24,20 @@ func NewControlExporter(formatter Formatter) *ControlExporter {
 }
 
 func (e *ControlExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	res, err := e.format(ctx, input)
+	if err != nil {
+		return err
+	}
+	// streaming formatters return a reader which must be closed (so the writer is released if the write fails)
+	if closer, ok := res.(io.Closer); ok {
+		defer closer.Close()
+	}
 
+	return export.Write(destPath, res)
+}
+
+// format renders the input using the exporter formatter
+func (e *ControlExporter) format(ctx context.Context, input export.ExportSourceData) (io.Reader, error) {
 	// tell the formatter it is being used for export
 	// this is a tactical mechanism used to ensure that exported snapshots are unindented
 	// whereas display snapshots are indented
@@ -30,14 +46,9 @@ func (e *ControlExporter) Export(ctx context.Context, input export.ExportSourceD
 	// input must be control execution tree
 	tree, ok := input.(*controlexecute.ExecutionTree)
 	if !ok {
-		return fmt.Errorf("ControlExporter input must be *controlexecute.ExecutionTree")
-	}
-	res, err := e.formatter.Format(exportCtx, tree)
-	if err != nil {
-		return err
+		return nil, fmt.Errorf("ControlExporter input must be *controlexecute.ExecutionTree")
 	}
-
-	return export.Write(destPath, res)
+	return e.formatter.Format(exportCtx, tree)
 }
 
 func (e *ControlExporter) FileExtension() string {
@@ -51,3 +62,24 @@ func (e *ControlExporter) Name() string {
 func (e *ControlExporter) Alias() string {
 	return e.formatter.Alias()
 }
+
+// ExportOptions implements export.ConfigurableExporter - the options are those accepted by the formatter (if any)
+func (e *ControlExporter) ExportOptions() []localexport.ExporterOption {
+	if f, ok := e.formatter.(ConfigurableFormatter); ok {
+		return f.ExportOptions()
+	}
+	return nil
+}
+
+// WithExportOptions implements export.ConfigurableExporter
+func (e *ControlExporter) WithExportOptions(options map[string]string) (export.Exporter, error) {
+	f, ok := e.formatter.(ConfigurableFormatter)
+	if !ok {
+		return nil, fmt.Errorf("the %s exporter does not accept any options", e.Name())
+	}
+	formatter, err := f.WithExportOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	return NewControlExporter(formatter), nil
+}
@@ -25,6 +25,7 @@ func NewFormatResolver() (*FormatResolver, error) {
 		&NullFormatter{},
 		&TextFormatter{},
 		&SnapshotFormatter{},
+		&JSONLFormatter{},
 	}
 
 	res := &FormatResolver{
@@ -82,9 +83,31 @@ func (r *FormatResolver) registerFormatter(f Formatter) error {
 func (r *FormatResolver) controlExporters() []export.Exporter {
 	res := make([]export.Exporter, len(r.exportFormatters))
 	for i, formatter := range r.exportFormatters {
+		// asff exports are post-processed to batch the findings
+		if formatter.Name() == asffFormatName {
+			res[i] = NewAsffExporter(formatter)
+			continue
+		}
+		// the json exporter serialises the result tree directly, in the same structure as the json template
+		if formatter.Name() == constants.OutputFormatJSON {
+			res[i] = NewJsonExporter()
+			continue
+		}
 		res[i] = NewControlExporter(formatter)
 
 	}
+	// the xlsx exporter writes the workbook directly rather than using a formatter
+	res = append(res, NewXlsxExporter())
+	// the pdf exporter renders the report directly rather than using a formatter
+	res = append(res, NewPdfExporter())
+	// the junit exporter writes the xml directly, so the control query and reasons are escaped by the xml encoder
+	res = append(res, NewJUnitExporter())
+	// the summary exporter writes the result counts only, rather than rendering every control
+	res = append(res, NewSummaryExporter())
+	// the postgres exporter upserts the results into a database table, rather than writing a file
+	res = append(res, NewPostgresExporter())
+	// the query plan exporter writes the executed SQL and query plan of each control, rather than the results
+	res = append(res, NewQueryPlanExporter())
 	return res
 }
 
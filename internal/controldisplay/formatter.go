@@ -3,6 +3,7 @@ package controldisplay
 import (
 	"context"
 	"github.com/turbot/powerpipe/internal/controlexecute"
+	localexport "github.com/turbot/powerpipe/internal/export"
 	"io"
 )
 
@@ -13,6 +14,15 @@ type Formatter interface {
 	Alias() string
 }
 
+// ConfigurableFormatter is a Formatter which accepts export options, e.g. --export csv:delimiter=;,header=false
+// (the ControlExporter for the formatter passes the options to it - see export.ConfigurableExporter)
+type ConfigurableFormatter interface {
+	Formatter
+	ExportOptions() []localexport.ExporterOption
+	// WithExportOptions returns a copy of the formatter configured with the options
+	WithExportOptions(options map[string]string) (Formatter, error)
+}
+
 type FormatterBase struct{}
 
 func (*FormatterBase) Alias() string {
@@ -0,0 +1,116 @@
+package controldisplay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+const (
+	jsonlFormatName    = "jsonl"
+	jsonlFileExtension = ".jsonl"
+)
+
+// JSONLFormatter formats the control results as JSON Lines - one JSON object per result row
+// (a control which failed to run is written as a single error record)
+//
+// the records are streamed, i.e. the output is written as it is read, rather than rendered into a buffer,
+// so large benchmarks do not require the whole output to be held in memory
+type JSONLFormatter struct {
+	FormatterBase
+}
+
+// jsonlRecord is a single line of the jsonl output
+type jsonlRecord struct {
+	GroupId    string                     `json:"group_id"`
+	ControlId  string                     `json:"control_id"`
+	Title      string                     `json:"title,omitempty"`
+	Severity   string                     `json:"severity,omitempty"`
+	Tags       map[string]string          `json:"tags,omitempty"`
+	Status     string                     `json:"status"`
+	Reason     string                     `json:"reason"`
+	Resource   string                     `json:"resource,omitempty"`
+	Dimensions []controlexecute.Dimension `json:"dimensions,omitempty"`
+}
+
+func (f *JSONLFormatter) Format(ctx context.Context, tree *controlexecute.ExecutionTree) (io.Reader, error) {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeJSONLRecords(ctx, tree, writer))
+	}()
+	return reader, nil
+}
+
+// writeJSONLRecords writes a record for each result row of each control run in the tree, in tree order
+// each record is flushed as soon as it is written
+func writeJSONLRecords(ctx context.Context, tree *controlexecute.ExecutionTree, w io.Writer) error {
+	bufferedWriter := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bufferedWriter)
+	return walkJSONLRecords(ctx, tree, func(record *jsonlRecord) error {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		return bufferedWriter.Flush()
+	})
+}
+
+// walkJSONLRecords calls the visit func with the record for each result row of each control run in the tree, in tree order
+func walkJSONLRecords(ctx context.Context, tree *controlexecute.ExecutionTree, visit func(*jsonlRecord) error) error {
+	if tree.Root == nil {
+		return nil
+	}
+
+	return walkResultGroups(ctx, tree.Root, func(group *controlexecute.ResultGroup, _ []*controlexecute.ResultGroup) error {
+		for _, run := range group.ControlRuns {
+			for _, record := range jsonlRecordsForControlRun(group.GroupId, run) {
+				if err := visit(record); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func jsonlRecordsForControlRun(groupId string, run *controlexecute.ControlRun) []*jsonlRecord {
+	newRecord := func() *jsonlRecord {
+		return &jsonlRecord{
+			GroupId:   groupId,
+			ControlId: run.ControlId,
+			Title:     run.Title,
+			Severity:  run.Severity,
+			Tags:      run.Tags,
+		}
+	}
+
+	// if the control failed to run, write an error record
+	if run.RunErrorString != "" {
+		record := newRecord()
+		record.Status = constants.ControlError
+		record.Reason = run.RunErrorString
+		return []*jsonlRecord{record}
+	}
+
+	records := make([]*jsonlRecord, len(run.Rows))
+	for i, row := range run.Rows {
+		record := newRecord()
+		record.Status = row.Status
+		record.Reason = row.Reason
+		record.Resource = row.Resource
+		record.Dimensions = row.Dimensions
+		records[i] = record
+	}
+	return records
+}
+
+func (f *JSONLFormatter) FileExtension() string {
+	return jsonlFileExtension
+}
+
+func (f *JSONLFormatter) Name() string {
+	return jsonlFormatName
+}
@@ -0,0 +1,64 @@
+package controldisplay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+func TestJSONLFormatter(t *testing.T) {
+	run1 := &controlexecute.ControlRun{ControlId: "control.c1", Title: "Control 1", Severity: "high"}
+	run1.Rows = controlexecute.ResultRows{
+		{Reason: "bucket is public", Resource: "arn:aws:s3:::b1", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+		{Reason: "bucket is private", Resource: "arn:aws:s3:::b2", Status: "ok"},
+	}
+	run2 := &controlexecute.ControlRun{ControlId: "control.c2", Title: "Control 2", RunErrorString: "relation does not exist"}
+
+	nested := &controlexecute.ResultGroup{GroupId: "benchmark.nested", ControlRuns: []*controlexecute.ControlRun{run2}}
+	root := &controlexecute.ResultGroup{
+		GroupId:     "benchmark.root",
+		ControlRuns: []*controlexecute.ControlRun{run1},
+		Groups:      []*controlexecute.ResultGroup{nested},
+	}
+
+	res, err := (&JSONLFormatter{}).Format(context.Background(), &controlexecute.ExecutionTree{Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var records []jsonlRecord
+	scanner := bufio.NewScanner(res)
+	for scanner.Scan() {
+		var record jsonlRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line is not valid json: %s", scanner.Text())
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		groupId, controlId, status, resource string
+	}{
+		{"benchmark.root", "control.c1", "alarm", "arn:aws:s3:::b1"},
+		{"benchmark.root", "control.c1", "ok", "arn:aws:s3:::b2"},
+		{"benchmark.nested", "control.c2", "error", ""},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records - got %d", len(expected), len(records))
+	}
+	for i, e := range expected {
+		r := records[i]
+		if r.GroupId != e.groupId || r.ControlId != e.controlId || r.Status != e.status || r.Resource != e.resource {
+			t.Errorf("record %d: expected %+v - got %+v", i, e, r)
+		}
+	}
+	if records[2].Reason != "relation does not exist" {
+		t.Errorf("expected the error record reason to be the control run error - got %q", records[2].Reason)
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/constants"
-	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlexecute"
 )
 
@@ -20,6 +20,8 @@ import (
 type TemplateFormatter struct {
 	template     *template.Template
 	exportFormat *OutputTemplate
+	// the render config set using export options - these override the config from the args
+	overrides templateRenderOverrides
 }
 
 func NewTemplateFormatter(input *OutputTemplate) (*TemplateFormatter, error) {
@@ -50,15 +52,21 @@ func (tf TemplateFormatter) Format(ctx context.Context, tree *controlexecute.Exe
 			writer.CloseWithError(err)
 			return
 		}
+		// the csv template writes the separator between cells - if none is set, use the csv default
+		separator := viper.GetString(constants.ArgSeparator)
+		if separator == "" {
+			separator = ","
+		}
 		renderContext := TemplateRenderContext{
 			Constants: TemplateRenderConstants{
 				PowerpipeVersion: app_specific.AppVersion.String(),
 				WorkingDir:       workingDirectory,
 			},
-			Config: TemplateRenderConfig{
-				RenderHeader: viper.GetBool(constants.ArgHeader),
-				Separator:    viper.GetString(constants.ArgSeparator),
-			},
+			Config: tf.overrides.apply(TemplateRenderConfig{
+				RenderHeader:        viper.GetBool(constants.ArgHeader),
+				Separator:           separator,
+				SarifIncludePassing: viper.GetBool(localconstants.ArgSarifIncludePassing),
+			}),
 			Data: tree,
 		}
 
@@ -81,8 +89,9 @@ func (tf TemplateFormatter) Format(ctx context.Context, tree *controlexecute.Exe
 	}()
 
 	// tactical - for json, prettify the output
+	// (this is streamed, so large execution trees are not buffered in memory)
 	if tf.shouldPrettify() {
-		return utils.PrettifyJsonFromReader(reader)
+		return newJsonIndentReader(reader), nil
 	}
 
 	return reader, nil
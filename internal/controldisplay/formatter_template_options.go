@@ -0,0 +1,83 @@
+package controldisplay
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/turbot/pipe-fittings/constants"
+	localexport "github.com/turbot/powerpipe/internal/export"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the export options accepted by the template formatters
+const (
+	templateOptionDelimiter      = "delimiter"
+	templateOptionHeader         = "header"
+	templateOptionIncludePassing = "include_passing"
+	sarifFormatName              = "sarif"
+)
+
+// templateRenderOverrides is the template render config set using export options
+// - a nil field means the option was not set, so the config from the args is used
+type templateRenderOverrides struct {
+	separator           *string
+	renderHeader        *bool
+	sarifIncludePassing *bool
+}
+
+func (o templateRenderOverrides) apply(config TemplateRenderConfig) TemplateRenderConfig {
+	if o.separator != nil {
+		config.Separator = *o.separator
+	}
+	if o.renderHeader != nil {
+		config.RenderHeader = *o.renderHeader
+	}
+	if o.sarifIncludePassing != nil {
+		config.SarifIncludePassing = *o.sarifIncludePassing
+	}
+	return config
+}
+
+// ExportOptions implements ConfigurableFormatter - the options depend on the template
+func (tf TemplateFormatter) ExportOptions() []localexport.ExporterOption {
+	switch tf.Name() {
+	case constants.OutputFormatCSV:
+		return []localexport.ExporterOption{
+			{Name: templateOptionDelimiter, Description: "the character used to separate cells"},
+			{Name: templateOptionHeader, Description: "whether to include the header row, true or false"},
+		}
+	case sarifFormatName:
+		return []localexport.ExporterOption{
+			{Name: templateOptionIncludePassing, Description: "whether to include passing control results, true or false"},
+		}
+	}
+	return nil
+}
+
+// WithExportOptions implements ConfigurableFormatter
+func (tf TemplateFormatter) WithExportOptions(options map[string]string) (Formatter, error) {
+	// NOTE: tf is a copy, so it is safe to set the overrides
+	for key, value := range options {
+		switch key {
+		case templateOptionDelimiter:
+			// (this may be a multi-byte character such as '¦')
+			if utf8.RuneCountInString(value) != 1 {
+				return nil, sperr.New("%s export option '%s' must be a single character", tf.Name(), key)
+			}
+			tf.overrides.separator = &value
+		case templateOptionHeader, templateOptionIncludePassing:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, sperr.New("%s export option '%s' must be true or false, got '%s'", tf.Name(), key, value)
+			}
+			if key == templateOptionHeader {
+				tf.overrides.renderHeader = &b
+			} else {
+				tf.overrides.sarifIncludePassing = &b
+			}
+		default:
+			return nil, sperr.New("unknown %s export option '%s'", tf.Name(), key)
+		}
+	}
+	return &tf, nil
+}
@@ -0,0 +1,45 @@
+package controldisplay
+
+import (
+	"testing"
+)
+
+func TestTemplateFormatterExportOptions(t *testing.T) {
+	csv := &TemplateFormatter{exportFormat: &OutputTemplate{FormatName: "csv"}}
+	base := TemplateRenderConfig{RenderHeader: true, Separator: ","}
+
+	f, err := csv.WithExportOptions(map[string]string{"delimiter": "¦", "header": "false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := f.(*TemplateFormatter).overrides.apply(base)
+	if config.Separator != "¦" || config.RenderHeader {
+		t.Errorf("expected the options to override the config, got %+v", config)
+	}
+	// the registered formatter is not modified
+	if config := csv.overrides.apply(base); config != base {
+		t.Errorf("expected the original formatter to be unchanged, got %+v", config)
+	}
+
+	for _, options := range []map[string]string{
+		{"delimiter": ";;"},
+		{"delimiter": ""},
+		{"header": "maybe"},
+	} {
+		if _, err := csv.WithExportOptions(options); err == nil {
+			t.Errorf("expected an error for options %v", options)
+		}
+	}
+
+	sarif := &TemplateFormatter{exportFormat: &OutputTemplate{FormatName: "sarif"}}
+	if len(sarif.ExportOptions()) != 1 || sarif.ExportOptions()[0].Name != templateOptionIncludePassing {
+		t.Errorf("expected sarif to accept the include_passing option, got %v", sarif.ExportOptions())
+	}
+	html := &TemplateFormatter{exportFormat: &OutputTemplate{FormatName: "html"}}
+	if len(html.ExportOptions()) != 0 {
+		t.Errorf("expected html to accept no options, got %v", html.ExportOptions())
+	}
+	if len(NewControlExporter(html).ExportOptions()) != 0 || len(NewControlExporter(csv).ExportOptions()) != 2 {
+		t.Errorf("expected the control exporter to accept the formatter options")
+	}
+}
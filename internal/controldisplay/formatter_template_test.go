@@ -1,183 +1,124 @@
 package controldisplay
 
-//
-//type resolveOutputTemplateTestCase struct {
-//	input    string // --export <val>
-//	expected interface{}
-//}
-//
-//// TODO change this test to GetFormatter and getFormatterByExtension
-//
-//var resolveOutputTemplateTestCases = map[string]resolveOutputTemplateTestCase{
-//	"html": {
-//		input: "html",
-//		expected: OutputTemplate{
-//			FormatFullName: "html.html",
-//			FileExtension:  ".html",
-//		},
-//	},
-//	"nunit3": {
-//		input: "nunit3",
-//		expected: OutputTemplate{
-//			FormatFullName: "nunit3.xml",
-//			FileExtension:  ".xml",
-//		},
-//	},
-//	"markdown": {
-//		input: "md",
-//		expected: OutputTemplate{
-//			FormatFullName: "md.md",
-//			FileExtension:  ".md",
-//		},
-//	},
-//
-//	"nunit3.xml": {
-//		input: "nunit3.xml",
-//		expected: OutputTemplate{
-//			FormatFullName: "nunit3.xml",
-//			FileExtension:  ".xml",
-//		},
-//	},
-//	"markdown.md": {
-//		input:    "markdown.md",
-//		expected: "ERROR",
-//		//OutputTemplate{
-//		//	FormatFullName:  "md.md",
-//		//	OutputExtension: ".md",
-//		//},
-//	},
-//	// "txt.dat": {
-//	// 	input: "txt.dat",
-//	// 	expected: OutputTemplate{
-//	// 		FormatFullName:  "txt.dat",
-//	// 		OutputExtension: ".dat",
-//	// 	},
-//	// },
-//	// "custom.txt": {
-//	// 	input: "custom.txt",
-//	// 	expected: OutputTemplate{
-//	// 		FormatFullName:  "custom.txt",
-//	// 		OutputExtension: ".txt",
-//	// 	},
-//	// },
-//	"foo.xml": {
-//		input: "foo.xml",
-//
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "nunit3.xml",
-//		//	OutputExtension: ".xml",
-//		//},
-//	},
-//	"brief.html": {
-//		input:    "brief.html",
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "html.html",
-//		//	OutputExtension: ".html",
-//		//},
-//	},
-//	"output.html": {
-//		input:    "output.html",
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "html.html",
-//		//	OutputExtension: ".html",
-//		//},
-//	},
-//	"output.md": {
-//		input:    "output.md",
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "md.md",
-//		//	OutputExtension: ".md",
-//		//},
-//	},
-//	// "output.txt": {
-//	// 	input: "output.txt",
-//	// 	expected: OutputTemplate{
-//	// 		FormatFullName:  "custom.txt",
-//	// 		OutputExtension: ".txt",
-//	// 	},
-//	// },
-//	// "output.dat": {
-//	// 	input: "output.dat",
-//	// 	expected: OutputTemplate{
-//	// 		FormatFullName:  "txt.dat",
-//	// 		OutputExtension: ".dat",
-//	// 	},
-//	// },
-//	"output.brief.html": {
-//		input:    "output.brief.html",
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "html.html",
-//		//	OutputExtension: ".html",
-//		//},
-//	},
-//	"output.nunit3.xml": {
-//		input:    "output.nunit3.xml",
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "nunit3.xml",
-//		//	OutputExtension: ".xml",
-//		//},
-//	},
-//	"output.foo.xml": {
-//		input:    "output.foo.xml",
-//		expected: "ERROR",
-//		//	OutputTemplate{
-//		//	FormatFullName:  "nunit3.xml",
-//		//	OutputExtension: ".xml",
-//		//},
-//	},
-//}
-//
-//func setup() {
-//	filepaths.SteampipeDir = "~/.steampipe"
-//	source, err := filepath.Abs("templates")
-//	if err != nil {
-//		log.Fatal(err)
-//	}
-//
-//	dest, err := filepath.Abs("~/.steampipe/check/templates")
-//	if err != nil {
-//		log.Fatal(err)
-//	}
-//
-//	err = copy.Copy(source, dest)
-//	if err != nil {
-//		log.Fatal(err)
-//	}
-//}
-//
-//func teardown() {
-//	os.RemoveAll("~/.steampipe/check/templates")
-//}
-//
-//func TestResolveOutputTemplate(t *testing.T) {
-//	setup()
-//	resolver, _ := NewFormatResolver()
-//	for name, test := range resolveOutputTemplateTestCases {
-//		outputTemplate, err := resolver.resolveOutputTemplate(test.input)
-//		if err != nil {
-//			if test.expected != "ERROR" {
-//				t.Errorf("Test: '%s'' FAILED with unexpected error: %v", name, err)
-//			}
-//			continue
-//		}
-//		if test.expected == "ERROR" {
-//			t.Errorf("Test: '%s'' FAILED - expected error", name)
-//			continue
-//		}
-//		expectedFormat := test.expected.(OutputTemplate)
-//		if !FormatEqual(outputTemplate, &expectedFormat) {
-//			t.Errorf("Test: '%s'' FAILED : expected:\n%s\n\ngot:\n%s", name, expectedFormat.FormatName, outputTemplate)
-//		}
-//	}
-//	teardown()
-//}
-//
-//func FormatEqual(l, r *OutputTemplate) bool {
-//	return (l.FormatFullName == r.FormatFullName)
-//}
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+// nunit3Suite is used to parse the test suites of the nunit3 output
+type nunit3Suite struct {
+	Type   string        `xml:"type,attr"`
+	Id     string        `xml:"id,attr"`
+	Result string        `xml:"result,attr"`
+	Suites []nunit3Suite `xml:"test-suite"`
+	Cases  []nunit3Case  `xml:"test-case"`
+}
+
+type nunit3Case struct {
+	Id     string `xml:"id,attr"`
+	Result string `xml:"result,attr"`
+}
+
+type nunit3Run struct {
+	XMLName xml.Name      `xml:"test-run"`
+	Total   int           `xml:"total,attr"`
+	Suites  []nunit3Suite `xml:"test-suite"`
+}
+
+func TestNunit3TemplateNestedSuites(t *testing.T) {
+	defer func(version *semver.Version) { app_specific.AppVersion = version }(app_specific.AppVersion)
+	app_specific.AppVersion = semver.MustParse("1.0.0")
+
+	newControl := func(name string) *modconfig.Control {
+		control := &modconfig.Control{}
+		control.ShortName = name
+		control.FullName = "test.control." + name
+		return control
+	}
+	newGroup := func(id string, summary controlstatus.StatusSummary, runs []*controlexecute.ControlRun, groups ...*controlexecute.ResultGroup) *controlexecute.ResultGroup {
+		group := &controlexecute.ResultGroup{GroupId: id, Title: id, ControlRuns: runs, Groups: groups, Summary: controlexecute.NewGroupSummary()}
+		group.Summary.Status = summary
+		return group
+	}
+
+	passing := &controlexecute.ControlRun{ControlId: "control.c1", Control: newControl("c1"), Summary: &controlstatus.StatusSummary{Ok: 1}}
+	passing.Rows = controlexecute.ResultRows{{Reason: "bucket is private", Status: "ok", Control: passing.Control}}
+	failing := &controlexecute.ControlRun{ControlId: "control.c2", Control: newControl("c2"), Summary: &controlstatus.StatusSummary{Alarm: 1}}
+	failing.Rows = controlexecute.ResultRows{{Reason: "bucket is <public>", Status: "alarm", Control: failing.Control}}
+	errored := &controlexecute.ControlRun{ControlId: "control.c3", Control: newControl("c3"), Summary: &controlstatus.StatusSummary{}, RunErrorString: "relation does not exist"}
+
+	// root benchmark -> child benchmark -> grandchild benchmark
+	grandchild := newGroup("benchmark.grandchild", controlstatus.StatusSummary{Alarm: 1}, []*controlexecute.ControlRun{failing})
+	child := newGroup("benchmark.child", controlstatus.StatusSummary{Alarm: 1}, []*controlexecute.ControlRun{errored}, grandchild)
+	benchmark := newGroup("benchmark.root", controlstatus.StatusSummary{Ok: 1, Alarm: 1}, []*controlexecute.ControlRun{passing}, child)
+	root := newGroup(controlexecute.RootResultGroupName, controlstatus.StatusSummary{Ok: 1, Alarm: 1}, nil, benchmark)
+	now := time.Now()
+	tree := &controlexecute.ExecutionTree{Root: root, StartTime: now, EndTime: now.Add(time.Second)}
+
+	formatter, err := NewTemplateFormatter(NewOutputTemplate(filepath.Join("templates", "nunit3.xml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := formatter.Format(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var run nunit3Run
+	if err := xml.Unmarshal(output, &run); err != nil {
+		t.Fatalf("output is not valid xml: %v\n%s", err, output)
+	}
+	if run.Total != 2 {
+		t.Errorf("expected a test run total of 2, got %d", run.Total)
+	}
+
+	// each benchmark is a test suite nested in the suite of its parent, and each control is a test fixture
+	if len(run.Suites) != 1 {
+		t.Fatalf("expected 1 top level test suite, got %d\n%s", len(run.Suites), output)
+	}
+	rootSuite := run.Suites[0]
+	assertNunit3Suite(t, rootSuite, "TestSuite", "benchmark.root", "Failed", 2)
+	assertNunit3Suite(t, rootSuite.Suites[0], "TestSuite", "benchmark.child", "Failed", 2)
+	assertNunit3Suite(t, rootSuite.Suites[1], "TestFixture", "control.c1", "Passed", 0)
+
+	childSuite := rootSuite.Suites[0]
+	assertNunit3Suite(t, childSuite.Suites[0], "TestSuite", "benchmark.grandchild", "Failed", 1)
+	assertNunit3Suite(t, childSuite.Suites[1], "TestFixture", "control.c3", "Failed", 0)
+	assertNunit3Suite(t, childSuite.Suites[0].Suites[0], "TestFixture", "control.c2", "Failed", 0)
+
+	// the test cases are the result rows of each control, or the control error
+	for suite, expected := range map[*nunit3Suite]nunit3Case{
+		&rootSuite.Suites[1]:            {Id: "c1::0", Result: "Passed"},
+		&childSuite.Suites[1]:           {Id: "c3::error", Result: "Failed"},
+		&childSuite.Suites[0].Suites[0]: {Id: "c2::0", Result: "Failed"},
+	} {
+		if len(suite.Cases) != 1 || suite.Cases[0] != expected {
+			t.Errorf("expected fixture %s to contain test case %+v, got %+v", suite.Id, expected, suite.Cases)
+		}
+	}
+}
+
+// assertNunit3Suite checks the type, id and result of the suite, and the number of nested suites
+func assertNunit3Suite(t *testing.T, suite nunit3Suite, suiteType, id, result string, suites int) {
+	t.Helper()
+	if suite.Type != suiteType || suite.Id != id || suite.Result != result {
+		t.Errorf("expected %s %s with result %s, got %s %s with result %s", suiteType, id, result, suite.Type, suite.Id, suite.Result)
+	}
+	if len(suite.Suites) != suites {
+		t.Fatalf("expected %s to contain %d nested suites, got %d", id, suites, len(suite.Suites))
+	}
+}
@@ -77,6 +77,14 @@ var formatterTestCase = []testCase{
 			name:      "nunit3",
 		},
 	},
+	{
+		input: "sarif",
+		expected: testFormatter{
+			alias:     "",
+			extension: ".sarif",
+			name:      "sarif",
+		},
+	},
 }
 
 func TestFormatResolver(t *testing.T) {
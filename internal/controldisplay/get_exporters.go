@@ -1,7 +1,12 @@
 package controldisplay
 
 import (
+	"context"
+
 	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	localexport "github.com/turbot/powerpipe/internal/export"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
 
 // GetExporters returns an array of ControlExporters corresponding to the available output formats
@@ -11,5 +16,19 @@ func GetExporters() ([]export.Exporter, error) {
 		return nil, err
 	}
 	exporters := formatResolver.controlExporters()
+	// the null exporter serialises the same records as the jsonl format, but writes nothing
+	exporters = append(exporters, localexport.NewNullExporter(controlExportRecords))
 	return exporters, nil
 }
+
+// controlExportRecords is the record source for the null exporter - there is a record for each result row
+// (or control run error) in the execution tree
+func controlExportRecords(ctx context.Context, source export.ExportSourceData, visit func(any) error) error {
+	tree, ok := source.(*controlexecute.ExecutionTree)
+	if !ok {
+		return sperr.New("null export source must be *controlexecute.ExecutionTree, got %T", source)
+	}
+	return walkJSONLRecords(ctx, tree, func(record *jsonlRecord) error {
+		return visit(record)
+	})
+}
@@ -0,0 +1,192 @@
+package controldisplay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+const jsonFileExtension = ".json"
+
+// the run_status values of the json output
+var jsonRunStatusValues = map[dashboardtypes.RunStatus]int{
+	"ready":                    1,
+	"started":                  2,
+	dashboardtypes.RunComplete: 4,
+	dashboardtypes.RunError:    8,
+}
+
+// JsonExporter exports the control result tree (the groups, and the title, status, reasons and dimensions of each
+// control) as json, in the same structure as the json output format
+//
+// the tree is serialised one group, control and result row at a time and streamed to the destination,
+// so large benchmarks do not require the whole document to be held in memory
+type JsonExporter struct{}
+
+func NewJsonExporter() *JsonExporter {
+	return &JsonExporter{}
+}
+
+func (e *JsonExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("JsonExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	reader, writer := io.Pipe()
+	// close the reader if the write fails, so the writer is released
+	defer reader.Close()
+	go func() {
+		writer.CloseWithError(writeJsonResultTree(ctx, tree, writer))
+	}()
+	return export.Write(destPath, newJsonIndentReader(reader))
+}
+
+func (e *JsonExporter) FileExtension() string {
+	return jsonFileExtension
+}
+
+func (e *JsonExporter) Name() string {
+	return constants.OutputFormatJSON
+}
+
+func (e *JsonExporter) Alias() string {
+	return ""
+}
+
+// writeJsonResultTree writes the (unindented) json of the root result group of the tree
+func writeJsonResultTree(ctx context.Context, tree *controlexecute.ExecutionTree, w io.Writer) error {
+	jw := &jsonResultWriter{w: bufio.NewWriter(w)}
+	if tree.Root == nil {
+		jw.writeString("null")
+	} else {
+		jw.writeGroup(ctx, tree.Root)
+	}
+	if jw.err != nil {
+		return jw.err
+	}
+	return jw.w.Flush()
+}
+
+// jsonResultWriter writes the json of the result groups - the first error is retained and subsequent writes are
+// skipped, so the error need only be checked once the tree has been written
+type jsonResultWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (jw *jsonResultWriter) writeGroup(ctx context.Context, group *controlexecute.ResultGroup) {
+	if jw.err == nil {
+		jw.err = ctx.Err()
+	}
+	jw.writeString(`{"group_id":`)
+	jw.writeValue(group.GroupId)
+	jw.writeString(`,"title":`)
+	jw.writeValue(group.Title)
+	jw.writeString(`,"description":`)
+	jw.writeValue(group.Description)
+	jw.writeString(`,"tags":`)
+	jw.writeValue(group.Tags)
+	jw.writeString(`,"summary":`)
+	jw.writeValue(group.Summary)
+
+	// groups with no child groups have an empty array, whereas groups with no controls have null controls
+	jw.writeString(`,"groups":[`)
+	for i, child := range group.Groups {
+		if i > 0 {
+			jw.writeString(",")
+		}
+		jw.writeGroup(ctx, child)
+	}
+	jw.writeString(`],"controls":`)
+	if len(group.ControlRuns) == 0 {
+		jw.writeString("null")
+	} else {
+		jw.writeString("[")
+		for i, run := range group.ControlRuns {
+			if i > 0 {
+				jw.writeString(",")
+			}
+			jw.writeControlRun(run)
+		}
+		jw.writeString("]")
+	}
+	jw.writeString("}")
+}
+
+func (jw *jsonResultWriter) writeControlRun(run *controlexecute.ControlRun) {
+	jw.writeString(`{"summary":`)
+	jw.writeValue(run.Summary)
+	jw.writeString(`,"results":`)
+	if len(run.Rows) == 0 {
+		jw.writeString("null")
+	} else {
+		jw.writeString("[")
+		for i, row := range run.Rows {
+			if i > 0 {
+				jw.writeString(",")
+			}
+			jw.writeString(`{"reason":`)
+			jw.writeValue(row.Reason)
+			jw.writeString(`,"resource":`)
+			jw.writeValue(row.Resource)
+			jw.writeString(`,"status":`)
+			jw.writeValue(row.Status)
+			jw.writeString(`,"dimensions":`)
+			jw.writeValue(row.Dimensions)
+			jw.writeString("}")
+		}
+		jw.writeString("]")
+	}
+	jw.writeString(`,"control_id":`)
+	jw.writeValue(run.ControlId)
+	jw.writeString(`,"description":`)
+	jw.writeValue(run.Description)
+	jw.writeString(`,"severity":`)
+	jw.writeValue(run.Severity)
+	jw.writeString(`,"tags":`)
+	jw.writeValue(run.Tags)
+	jw.writeString(`,"title":`)
+	jw.writeValue(run.Title)
+	// statuses with no run_status value (i.e. a control which has not completed) are written as null
+	jw.writeString(`,"run_status":`)
+	if status, ok := jsonRunStatusValues[run.RunStatus]; ok {
+		jw.writeValue(status)
+	} else {
+		jw.writeString("null")
+	}
+	if run.Truncated {
+		jw.writeString(`,"truncated":true,"total_rows":`)
+		jw.writeValue(run.TotalRows)
+	}
+	jw.writeString(`,"run_error":`)
+	jw.writeValue(run.RunErrorString)
+	jw.writeString("}")
+}
+
+func (jw *jsonResultWriter) writeValue(value any) {
+	if jw.err != nil {
+		return
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		jw.err = err
+		return
+	}
+	_, jw.err = jw.w.Write(b)
+}
+
+func (jw *jsonResultWriter) writeString(s string) {
+	if jw.err != nil {
+		return
+	}
+	_, jw.err = jw.w.WriteString(s)
+}
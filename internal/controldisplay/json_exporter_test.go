@@ -0,0 +1,93 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+func TestJsonExporter(t *testing.T) {
+	run1 := &controlexecute.ControlRun{
+		ControlId: "control.c1",
+		Title:     "Control <1>",
+		Severity:  "high",
+		Tags:      map[string]string{"service": "s3"},
+		RunStatus: dashboardtypes.RunComplete,
+		Summary:   &controlstatus.StatusSummary{Alarm: 1, Ok: 1},
+		Truncated: true,
+		TotalRows: 10,
+	}
+	run1.Rows = controlexecute.ResultRows{
+		{Reason: "bucket \"b1\" is public", Resource: "arn:aws:s3:::b1", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+		{Reason: "bucket b2 is private", Resource: "arn:aws:s3:::b2", Status: "ok"},
+	}
+	run2 := &controlexecute.ControlRun{ControlId: "control.c2", Title: "Control 2", RunStatus: dashboardtypes.RunError, RunErrorString: "relation does not exist"}
+
+	nested := &controlexecute.ResultGroup{GroupId: "benchmark.nested", Title: "Nested", ControlRuns: []*controlexecute.ControlRun{run2}, Summary: controlexecute.NewGroupSummary()}
+	benchmark := &controlexecute.ResultGroup{
+		GroupId:     "benchmark.root",
+		Title:       "Root",
+		Description: "The root benchmark",
+		ControlRuns: []*controlexecute.ControlRun{run1},
+		Groups:      []*controlexecute.ResultGroup{nested},
+		Summary:     controlexecute.NewGroupSummary(),
+	}
+	root := &controlexecute.ResultGroup{GroupId: controlexecute.RootResultGroupName, Groups: []*controlexecute.ResultGroup{benchmark}, Summary: controlexecute.NewGroupSummary()}
+	tree := &controlexecute.ExecutionTree{Root: root}
+
+	exporter := NewJsonExporter()
+	if exporter.FileExtension() != ".json" || exporter.Name() != "json" {
+		t.Errorf("unexpected exporter name %s and extension %s", exporter.Name(), exporter.FileExtension())
+	}
+	destPath := filepath.Join(t.TempDir(), "export.json")
+	if err := exporter.Export(context.Background(), tree, destPath); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the export must match the output of the json template (the template render context includes the app version)
+	defer func(version *semver.Version) { app_specific.AppVersion = version }(app_specific.AppVersion)
+	app_specific.AppVersion = semver.MustParse("1.0.0")
+	formatter, err := NewTemplateFormatter(NewOutputTemplate(filepath.Join("templates", "json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := formatter.Format(context.Background(), tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want any
+	if err := json.Unmarshal(exported, &got); err != nil {
+		t.Fatalf("export is not valid json: %v\n%s", err, exported)
+	}
+	if err := json.Unmarshal(rendered, &want); err != nil {
+		t.Fatalf("template output is not valid json: %v\n%s", err, rendered)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("export does not match the json template output\ngot:  %s\nwant: %s", exported, rendered)
+	}
+}
+
+func TestJsonExporterInvalidInput(t *testing.T) {
+	if err := NewJsonExporter().Export(context.Background(), nil, filepath.Join(t.TempDir(), "export.json")); err == nil {
+		t.Errorf("expected an error for an input which is not an execution tree")
+	}
+}
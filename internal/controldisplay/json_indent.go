@@ -0,0 +1,108 @@
+package controldisplay
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// newJsonIndentReader returns a reader which streams an indented copy of the json read from source
+// this produces the same output as json.Indent(dst, src, "", "  ") but does not buffer
+// the full document in memory, so can be used for very large control execution trees
+//
+// NOTE: the input is not validated - it is assumed to be valid json
+func newJsonIndentReader(source io.Reader) io.Reader {
+	reader, writer := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(writer)
+		err := streamIndentJson(bufio.NewReader(source), w)
+		if err == nil {
+			err = w.Flush()
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
+
+func streamIndentJson(r io.ByteReader, w *bufio.Writer) error {
+	const indent = "  "
+	var (
+		depth    int
+		inString bool
+		escaped  bool
+		// when we read an opening brace, we defer writing the newline until we know the object/array is not empty
+		pendingOpen bool
+		// has the top level value been written
+		started bool
+	)
+
+	newline := func() {
+		w.WriteByte('\n')
+		w.WriteString(strings.Repeat(indent, depth))
+	}
+
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if inString {
+			w.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		// skip insignificant whitespace
+		// (as with json.Indent, trailing whitespace after the top level value is preserved)
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			if started && depth == 0 {
+				w.WriteByte(c)
+			}
+			continue
+		}
+		started = true
+
+		if pendingOpen {
+			pendingOpen = false
+			// empty object or array - write the closing brace directly
+			if c == '}' || c == ']' {
+				depth--
+				w.WriteByte(c)
+				continue
+			}
+			newline()
+		}
+
+		switch c {
+		case '{', '[':
+			w.WriteByte(c)
+			depth++
+			pendingOpen = true
+		case '}', ']':
+			depth--
+			newline()
+			w.WriteByte(c)
+		case ',':
+			w.WriteByte(c)
+			newline()
+		case ':':
+			w.WriteString(": ")
+		case '"':
+			inString = true
+			w.WriteByte(c)
+		default:
+			w.WriteByte(c)
+		}
+	}
+}
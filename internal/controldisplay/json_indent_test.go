@@ -0,0 +1,37 @@
+package controldisplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+var jsonIndentTestCases = map[string]string{
+	"empty object":   `{}`,
+	"empty array":    `[ ]`,
+	"scalars":        `{"a": 1, "b": true, "c": null, "d": -1.5e10}`,
+	"nested":         `{"groups": [ {"group_id": "root", "tags": {}, "controls": null} ], "summary": {"ok": 1}}`,
+	"escaped string": `{"reason": "a \"quoted\" value, with: [brackets] and {braces}\\"}`,
+	"whitespace":     "{\n\t\"a\" :\n [1 ,2\r\n,3]\n}",
+	"trailing space": "  {\"a\": [1]} \n",
+}
+
+func TestStreamIndentJson(t *testing.T) {
+	for name, input := range jsonIndentTestCases {
+		var expected bytes.Buffer
+		if err := json.Indent(&expected, []byte(input), "", "  "); err != nil {
+			t.Fatalf("Test: '%s' FAILED : invalid test input: %v", name, err)
+		}
+
+		actual, err := io.ReadAll(newJsonIndentReader(strings.NewReader(input)))
+		if err != nil {
+			t.Errorf("Test: '%s' FAILED : unexpected error: %v", name, err)
+			continue
+		}
+		if string(actual) != expected.String() {
+			t.Errorf("Test: '%s' FAILED : \nexpected:\n %v \ngot:\n %v\n", name, expected.String(), string(actual))
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+const (
+	junitFormatName    = "junit"
+	junitFileExtension = ".junit.xml"
+)
+
+// JUnitExporter exports control results as JUnit XML. Each benchmark containing controls is a test suite, and each
+// control is a test case:
+//   - controls with alarm results have a failure element, listing the reason for each alarm
+//   - controls which failed to run (or have error results) have an error element
+//   - controls whose results are all skipped are skipped
+//
+// The control query is written to system-out, and the failure and error reasons to system-err, so they are shown
+// alongside the test case by CI systems
+//
+// NOTE: JUnit does not support nested suites, so nested benchmarks are written as separate suites, named using the
+// titles of their parent benchmarks
+type JUnitExporter struct{}
+
+func NewJUnitExporter() *JUnitExporter {
+	return &JUnitExporter{}
+}
+
+func (e *JUnitExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("JUnitExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	content, err := renderJUnit(ctx, tree)
+	if err != nil {
+		return err
+	}
+	return export.Write(destPath, bytes.NewReader(content))
+}
+
+func (e *JUnitExporter) FileExtension() string {
+	return junitFileExtension
+}
+
+func (e *JUnitExporter) Name() string {
+	return junitFormatName
+}
+
+func (e *JUnitExporter) Alias() string {
+	return ""
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Id        string          `xml:"id,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// renderJUnit renders the JUnit XML for the tree
+// NOTE: encoding/xml escapes XML special characters in both attributes and text, and replaces characters which
+// are not valid in XML
+func renderJUnit(ctx context.Context, tree *controlexecute.ExecutionTree) ([]byte, error) {
+	res := junitTestSuites{}
+	if tree.Root != nil {
+		res.Name = tree.Root.Title
+		// write a suite for each group which contains control runs
+		err := walkResultGroups(ctx, tree.Root, func(group *controlexecute.ResultGroup, parents []*controlexecute.ResultGroup) error {
+			if len(group.ControlRuns) == 0 {
+				return nil
+			}
+			suite := newJUnitTestSuite(group, junitGroupPath(group, parents), tree.StartTime)
+			res.Tests += suite.Tests
+			res.Failures += suite.Failures
+			res.Errors += suite.Errors
+			res.Skipped += suite.Skipped
+			res.Suites = append(res.Suites, suite)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	res.Time = junitDuration(tree.EndTime.Sub(tree.StartTime))
+
+	content, err := xml.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+func newJUnitTestSuite(group *controlexecute.ResultGroup, path []string, startTime time.Time) junitTestSuite {
+	name := strings.Join(path, " > ")
+	// controls which are not in a benchmark are in the root group
+	if name == "" {
+		name = junitGroupTitle(group)
+	}
+	suite := junitTestSuite{
+		Name: name,
+		Id:   group.GroupId,
+		Time: junitDuration(group.Duration),
+	}
+	if !startTime.IsZero() {
+		suite.Timestamp = startTime.UTC().Format("2006-01-02T15:04:05")
+	}
+	for _, run := range group.ControlRuns {
+		testCase := newJUnitTestCase(run, group.GroupId)
+		suite.Tests++
+		switch {
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	return suite
+}
+
+func newJUnitTestCase(run *controlexecute.ControlRun, className string) junitTestCase {
+	testCase := junitTestCase{
+		Name:      run.ControlId,
+		ClassName: className,
+		Time:      junitDuration(run.Duration),
+		SystemOut: junitControlQuery(run),
+	}
+	if run.Title != "" {
+		testCase.Name = fmt.Sprintf("%s (%s)", run.Title, run.ControlId)
+	}
+
+	var alarms, errors []string
+	skipped := 0
+	for _, row := range run.Rows {
+		switch row.Status {
+		case constants.ControlAlarm:
+			alarms = append(alarms, junitRowReason(row))
+		case constants.ControlError:
+			errors = append(errors, junitRowReason(row))
+		case constants.ControlSkip:
+			skipped++
+		}
+	}
+	if run.RunErrorString != "" {
+		errors = append([]string{run.RunErrorString}, errors...)
+	}
+
+	var systemErr []string
+	if len(errors) > 0 {
+		testCase.Error = &junitMessage{
+			Message: errors[0],
+			Type:    constants.ControlError,
+			Text:    strings.Join(errors, "\n"),
+		}
+		systemErr = append(systemErr, errors...)
+	}
+	if len(alarms) > 0 {
+		testCase.Failure = &junitMessage{
+			Message: fmt.Sprintf("%d of %d resources in alarm", len(alarms), len(run.Rows)),
+			Type:    constants.ControlAlarm,
+			Text:    strings.Join(alarms, "\n"),
+		}
+		systemErr = append(systemErr, alarms...)
+	}
+	if testCase.Error == nil && testCase.Failure == nil && len(run.Rows) > 0 && skipped == len(run.Rows) {
+		testCase.Skipped = &junitMessage{Message: junitRowReason(run.Rows[0])}
+	}
+	testCase.SystemErr = strings.Join(systemErr, "\n")
+	return testCase
+}
+
+// junitControlQuery returns the SQL of the control, or of the query it references
+func junitControlQuery(run *controlexecute.ControlRun) string {
+	if run.Control == nil {
+		return ""
+	}
+	if sql := run.Control.GetSQL(); sql != nil {
+		return *sql
+	}
+	if query := run.Control.GetQuery(); query != nil {
+		return typehelpers.SafeString(query.GetSQL())
+	}
+	return ""
+}
+
+func junitRowReason(row *controlexecute.ResultRow) string {
+	if row.Resource == "" {
+		return row.Reason
+	}
+	return fmt.Sprintf("%s: %s", row.Resource, row.Reason)
+}
+
+// junitGroupPath returns the titles of the group and its parent benchmarks (excluding the root of the tree)
+func junitGroupPath(group *controlexecute.ResultGroup, parents []*controlexecute.ResultGroup) []string {
+	if len(parents) == 0 {
+		return nil
+	}
+	var path []string
+	for _, parent := range parents[1:] {
+		path = append(path, junitGroupTitle(parent))
+	}
+	return append(path, junitGroupTitle(group))
+}
+
+func junitGroupTitle(group *controlexecute.ResultGroup) string {
+	if group.Title != "" {
+		return group.Title
+	}
+	return group.GroupId
+}
+
+func junitDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
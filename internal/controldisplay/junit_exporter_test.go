@@ -0,0 +1,77 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+func TestRenderJUnit(t *testing.T) {
+	sql := "select * from aws_s3_bucket where name <> '' and region = 'us-east-1'"
+	control := &modconfig.Control{}
+	control.SQL = &sql
+
+	failing := &controlexecute.ControlRun{ControlId: "control.c1", Title: "Buckets <private> & encrypted", Control: control}
+	failing.Rows = controlexecute.ResultRows{
+		{Reason: "bucket \"b1\" is <public>", Resource: "arn:aws:s3:::b1", Status: "alarm"},
+		{Reason: "bucket b2 is private", Resource: "arn:aws:s3:::b2", Status: "ok"},
+	}
+	errored := &controlexecute.ControlRun{ControlId: "control.c2", RunErrorString: "relation \"missing\" does not exist"}
+	skipped := &controlexecute.ControlRun{ControlId: "control.c3"}
+	skipped.Rows = controlexecute.ResultRows{{Reason: "not applicable", Status: "skip"}}
+
+	nested := &controlexecute.ResultGroup{GroupId: "benchmark.nested", Title: "Nested", ControlRuns: []*controlexecute.ControlRun{errored, skipped}}
+	benchmark := &controlexecute.ResultGroup{
+		GroupId:     "benchmark.root",
+		Title:       "Root",
+		ControlRuns: []*controlexecute.ControlRun{failing},
+		Groups:      []*controlexecute.ResultGroup{nested},
+	}
+	root := &controlexecute.ResultGroup{GroupId: controlexecute.RootResultGroupName, Title: "Root", Groups: []*controlexecute.ResultGroup{benchmark}}
+
+	content, err := renderJUnit(context.Background(), &controlexecute.ExecutionTree{Root: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the output must be well formed, and round trip the special characters
+	var res junitTestSuites
+	if err := xml.Unmarshal(content, &res); err != nil {
+		t.Fatalf("output is not valid xml: %s\n%s", err, content)
+	}
+	if strings.Contains(string(content), "<public>") {
+		t.Errorf("expected the reason to be escaped:\n%s", content)
+	}
+	if res.Tests != 3 || res.Failures != 1 || res.Errors != 1 || res.Skipped != 1 {
+		t.Errorf("unexpected totals: tests=%d failures=%d errors=%d skipped=%d", res.Tests, res.Failures, res.Errors, res.Skipped)
+	}
+	if len(res.Suites) != 2 || res.Suites[0].Name != "Root" || res.Suites[1].Name != "Root > Nested" {
+		t.Fatalf("expected suites 'Root' and 'Root > Nested', got %+v", res.Suites)
+	}
+
+	c1 := res.Suites[0].Cases[0]
+	if c1.Name != "Buckets <private> & encrypted (control.c1)" || c1.ClassName != "benchmark.root" {
+		t.Errorf("unexpected test case name %q, class name %q", c1.Name, c1.ClassName)
+	}
+	if c1.SystemOut != sql {
+		t.Errorf("expected the control query in system-out, got %q", c1.SystemOut)
+	}
+	if c1.Failure == nil || c1.Failure.Text != `arn:aws:s3:::b1: bucket "b1" is <public>` || c1.Failure.Message != "1 of 2 resources in alarm" {
+		t.Errorf("unexpected failure %+v", c1.Failure)
+	}
+	if c1.SystemErr != c1.Failure.Text {
+		t.Errorf("expected the failure reason in system-err, got %q", c1.SystemErr)
+	}
+
+	c2 := res.Suites[1].Cases[0]
+	if c2.Error == nil || c2.Error.Message != `relation "missing" does not exist` || c2.Failure != nil {
+		t.Errorf("unexpected error %+v", c2.Error)
+	}
+	if c3 := res.Suites[1].Cases[1]; c3.Skipped == nil || c3.Skipped.Message != "not applicable" {
+		t.Errorf("expected the control to be skipped, got %+v", c3)
+	}
+}
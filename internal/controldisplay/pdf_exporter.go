@@ -0,0 +1,535 @@
+package controldisplay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+const (
+	pdfFormatName    = "pdf"
+	pdfFileExtension = ".pdf"
+	// the report name used if the report is for more than one benchmark
+	pdfDefaultReportName = "Benchmark Report"
+	// the heading used for controls which are not in a benchmark
+	pdfControlsHeading = "Controls"
+	// A4 page size and the page margins, in points
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+	// the space reserved at the bottom of each page for the footer
+	pdfFooterHeight = 20.0
+	// the line height, as a multiple of the font size
+	pdfLineSpacing = 1.3
+	// the indent (in points) of each level of nested content
+	pdfIndent = 12.0
+)
+
+// the fonts defined in the resources of each page - these are the standard Helvetica fonts,
+// so no font data needs to be embedded
+type pdfFont string
+
+const (
+	pdfFontRegular pdfFont = "F1"
+	pdfFontBold    pdfFont = "F2"
+)
+
+type pdfColour struct{ r, g, b float64 }
+
+var (
+	pdfColourText  = pdfColour{}
+	pdfColourMuted = pdfColour{0.4, 0.4, 0.4}
+	pdfColourRule  = pdfColour{0.75, 0.75, 0.75}
+	// the colours used for each control status
+	pdfStatusColours = map[string]pdfColour{
+		constants.ControlAlarm: {0.8, 0.1, 0.1},
+		constants.ControlError: {0.6, 0.0, 0.0},
+		constants.ControlOk:    {0.1, 0.5, 0.1},
+		constants.ControlInfo:  {0.1, 0.3, 0.7},
+		constants.ControlSkip:  {0.4, 0.4, 0.4},
+	}
+)
+
+// PdfExporter exports control results as a paginated PDF report, containing a title block (the report name,
+// generation time and workspace), a summary of the results of each benchmark and the results of each control.
+// Long text (e.g. control reasons) is word wrapped, and content flows onto new pages as required.
+//
+// the report uses the standard PDF Helvetica fonts, so only characters in the Windows-1252 character set
+// can be displayed - other characters are replaced with '?'
+//
+// to sign the report, use --export-signing-key - as with other exports, this writes a detached signature file
+// (<file>.pdf.sig) and a checksum file (see export.Signer). Signatures embedded in the document are not supported.
+type PdfExporter struct{}
+
+func NewPdfExporter() *PdfExporter {
+	return &PdfExporter{}
+}
+
+func (e *PdfExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("PdfExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	// NOTE: the document is built in memory, as the page count (used in the page footers and the page tree)
+	// is not known until all pages have been rendered
+	var buf bytes.Buffer
+	if err := writePdfReport(ctx, tree, &buf); err != nil {
+		return err
+	}
+	return export.Write(destPath, &buf)
+}
+
+func (e *PdfExporter) FileExtension() string {
+	return pdfFileExtension
+}
+
+func (e *PdfExporter) Name() string {
+	return pdfFormatName
+}
+
+func (e *PdfExporter) Alias() string {
+	return ""
+}
+
+// pdfReportMetadata is the information displayed in the title block, and set in the document information dictionary
+type pdfReportMetadata struct {
+	name        string
+	generatedAt time.Time
+	workspace   string
+}
+
+func newPdfReportMetadata(tree *controlexecute.ExecutionTree) pdfReportMetadata {
+	metadata := pdfReportMetadata{
+		name:        pdfDefaultReportName,
+		generatedAt: tree.EndTime,
+	}
+	if metadata.generatedAt.IsZero() {
+		metadata.generatedAt = time.Now()
+	}
+	// if the report is for a single benchmark, use this as the report name
+	if tree.Root != nil && len(tree.Root.Groups) == 1 && len(tree.Root.ControlRuns) == 0 {
+		metadata.name = pdfGroupTitle(tree.Root.Groups[0])
+	}
+	if tree.Workspace != nil {
+		metadata.workspace = tree.Workspace.Path
+	}
+	return metadata
+}
+
+// writePdfReport renders the report for the tree and writes the PDF document
+func writePdfReport(ctx context.Context, tree *controlexecute.ExecutionTree, w io.Writer) error {
+	metadata := newPdfReportMetadata(tree)
+	doc := &pdfDocument{}
+
+	doc.writeWrapped(metadata.name, pdfFontBold, 18, 0, pdfColourText)
+	doc.writeLine("Generated at: "+metadata.generatedAt.UTC().Format(time.RFC1123), pdfFontRegular, 10, 0, pdfColourMuted)
+	if metadata.workspace != "" {
+		doc.writeWrapped("Workspace: "+metadata.workspace, pdfFontRegular, 10, 0, pdfColourMuted)
+	}
+	doc.writeRule()
+
+	if tree.Root != nil {
+		writePdfSummary(doc, tree.Root)
+		if err := writePdfResults(ctx, doc, tree.Root); err != nil {
+			return err
+		}
+	}
+	return doc.write(w, metadata)
+}
+
+// writePdfSummary writes the overall result counts, followed by the counts for each benchmark (in tree order)
+func writePdfSummary(doc *pdfDocument, root *controlexecute.ResultGroup) {
+	doc.writeHeading("Summary", 14)
+	doc.writeWrapped(pdfSummaryText(groupStatusSummary(root)), pdfFontRegular, 10, 0, pdfColourText)
+	doc.space(6)
+
+	walkPdfGroups(root.Groups, func(group *controlexecute.ResultGroup, depth int) {
+		indent := float64(depth) * pdfIndent
+		doc.writeWrapped(pdfGroupTitle(group), pdfFontBold, 10, indent, pdfColourText)
+		doc.writeWrapped(pdfSummaryText(groupStatusSummary(group)), pdfFontRegular, 9, indent, pdfColourMuted)
+	})
+	doc.writeRule()
+}
+
+// writePdfResults writes the results of each control, grouped by benchmark (in tree order)
+func writePdfResults(ctx context.Context, doc *pdfDocument, root *controlexecute.ResultGroup) error {
+	doc.writeHeading("Results", 14)
+
+	// controls which are not in a benchmark
+	if len(root.ControlRuns) > 0 {
+		doc.writeHeading(pdfControlsHeading, 12)
+		writePdfControlRuns(doc, root.ControlRuns, 0)
+	}
+
+	var err error
+	walkPdfGroups(root.Groups, func(group *controlexecute.ResultGroup, depth int) {
+		if err != nil {
+			return
+		}
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		// nested benchmarks use progressively smaller headings
+		doc.writeHeading(pdfGroupTitle(group), max(12-2*float64(depth), 10))
+		writePdfControlRuns(doc, group.ControlRuns, 0)
+	})
+	return err
+}
+
+func writePdfControlRuns(doc *pdfDocument, runs []*controlexecute.ControlRun, indent float64) {
+	for _, run := range runs {
+		title := run.Title
+		if title == "" {
+			title = run.ControlId
+		}
+		// keep the control heading with (at least) its first result
+		doc.ensureSpace(4 * 10 * pdfLineSpacing)
+		doc.writeWrapped(title, pdfFontBold, 10, indent, pdfColourText)
+
+		details := []string{run.ControlId}
+		if run.Severity != "" {
+			details = append(details, "Severity: "+run.Severity)
+		}
+		if run.Summary != nil {
+			details = append(details, pdfSummaryText(*run.Summary))
+		}
+		doc.writeWrapped(strings.Join(details, " | "), pdfFontRegular, 8, indent, pdfColourMuted)
+
+		if run.RunErrorString != "" {
+			doc.writeWrapped("ERROR: "+run.RunErrorString, pdfFontRegular, 9, indent+pdfIndent, pdfStatusColours[constants.ControlError])
+		}
+		for _, row := range run.Rows {
+			colour, ok := pdfStatusColours[row.Status]
+			if !ok {
+				colour = pdfColourText
+			}
+			doc.writeWrapped(fmt.Sprintf("%s: %s", strings.ToUpper(row.Status), row.Reason), pdfFontRegular, 9, indent+pdfIndent, colour)
+
+			var resourceDetails []string
+			if row.Resource != "" {
+				resourceDetails = append(resourceDetails, "Resource: "+row.Resource)
+			}
+			for _, d := range row.Dimensions {
+				resourceDetails = append(resourceDetails, fmt.Sprintf("%s: %s", d.Key, d.Value))
+			}
+			if len(resourceDetails) > 0 {
+				doc.writeWrapped(strings.Join(resourceDetails, " | "), pdfFontRegular, 8, indent+2*pdfIndent, pdfColourMuted)
+			}
+		}
+		doc.space(6)
+	}
+}
+
+// walkPdfGroups calls f for each group and all of its descendants, depth first
+func walkPdfGroups(groups []*controlexecute.ResultGroup, f func(group *controlexecute.ResultGroup, depth int)) {
+	var walk func(groups []*controlexecute.ResultGroup, depth int)
+	walk = func(groups []*controlexecute.ResultGroup, depth int) {
+		for _, group := range groups {
+			f(group, depth)
+			walk(group.Groups, depth+1)
+		}
+	}
+	walk(groups, 0)
+}
+
+func pdfGroupTitle(group *controlexecute.ResultGroup) string {
+	if group.Title != "" {
+		return group.Title
+	}
+	return group.GroupId
+}
+
+func groupStatusSummary(group *controlexecute.ResultGroup) controlstatus.StatusSummary {
+	if group.Summary == nil {
+		return controlstatus.StatusSummary{}
+	}
+	return group.Summary.Status
+}
+
+func pdfSummaryText(summary controlstatus.StatusSummary) string {
+	return fmt.Sprintf("Total: %d   OK: %d   Alarm: %d   Info: %d   Skip: %d   Error: %d",
+		summary.TotalCount(), summary.Ok, summary.Alarm, summary.Info, summary.Skip, summary.Error)
+}
+
+// pdfDocument lays out lines of text on a sequence of pages, starting a new page when the current page is full
+type pdfDocument struct {
+	// the content stream of each page
+	pages []*bytes.Buffer
+	// the vertical position of the top of the next line on the current page, measured from the bottom of the page
+	y float64
+}
+
+func (d *pdfDocument) currentPage() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// ensureSpace starts a new page if there is less than the given height remaining on the current page
+func (d *pdfDocument) ensureSpace(height float64) {
+	if len(d.pages) == 0 || d.y-height < pdfMargin+pdfFooterHeight {
+		d.newPage()
+	}
+}
+
+// space moves the position down by the given height (unless at the top of a page)
+func (d *pdfDocument) space(height float64) {
+	if len(d.pages) == 0 || d.y == pdfPageHeight-pdfMargin {
+		return
+	}
+	d.y -= height
+}
+
+func (d *pdfDocument) writeHeading(text string, size float64) {
+	// keep the heading with (at least) the following two lines
+	d.space(size / 2)
+	d.ensureSpace(size*pdfLineSpacing + 2*10*pdfLineSpacing)
+	d.writeWrapped(text, pdfFontBold, size, 0, pdfColourText)
+	d.space(size / 4)
+}
+
+// writeRule draws a horizontal line across the page
+func (d *pdfDocument) writeRule() {
+	d.space(6)
+	d.ensureSpace(12)
+	fmt.Fprintf(d.currentPage(), "%s RG 0.5 w %.2f %.2f m %.2f %.2f l S\n", d.colourOperands(pdfColourRule), pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+	d.y -= 12
+}
+
+// writeLine writes a single line of text at the current position, then moves the position to the next line
+func (d *pdfDocument) writeLine(text string, font pdfFont, size, indent float64, colour pdfColour) {
+	lineHeight := size * pdfLineSpacing
+	d.ensureSpace(lineHeight)
+	baseline := d.y - size
+	fmt.Fprintf(d.currentPage(), "BT /%s %.1f Tf %s rg %.2f %.2f Td (%s) Tj ET\n", font, size, d.colourOperands(colour), pdfMargin+indent, baseline, pdfEscapeString(text))
+	d.y -= lineHeight
+}
+
+// writeWrapped writes the text word wrapped to the width of the page (less the indent) - this may span multiple pages
+func (d *pdfDocument) writeWrapped(text string, font pdfFont, size, indent float64, colour pdfColour) {
+	for _, line := range wrapPdfText(text, font, size, pdfPageWidth-2*pdfMargin-indent) {
+		d.writeLine(line, font, size, indent, colour)
+	}
+}
+
+func (d *pdfDocument) colourOperands(c pdfColour) string {
+	return fmt.Sprintf("%.2f %.2f %.2f", c.r, c.g, c.b)
+}
+
+// write writes the PDF document, adding a footer to each page
+// the objects are: 1 catalog, 2 page tree, 3 and 4 fonts, 5 document information, followed by each page and its content
+func (d *pdfDocument) write(w io.Writer, metadata pdfReportMetadata) error {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+	pageCount := len(d.pages)
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, pageCount)
+		footerSize := 8.0
+		footerWidth := pdfTextWidth(footer, pdfFontRegular, footerSize)
+		fmt.Fprintf(page, "BT /%s %.1f Tf %s rg %.2f %.2f Td (%s) Tj ET\n", pdfFontRegular, footerSize, d.colourOperands(pdfColourMuted), pdfMargin, pdfMargin, pdfEscapeString(truncatePdfText(metadata.name, pdfFontRegular, footerSize, pdfPageWidth-2*pdfMargin-footerWidth-pdfIndent)))
+		fmt.Fprintf(page, "BT /%s %.1f Tf %s rg %.2f %.2f Td (%s) Tj ET\n", pdfFontRegular, footerSize, d.colourOperands(pdfColourMuted), pdfPageWidth-pdfMargin-footerWidth, pdfMargin, footer)
+	}
+
+	const firstPageObject = 6
+	var kids strings.Builder
+	for i := range d.pages {
+		if i > 0 {
+			kids.WriteString(" ")
+		}
+		fmt.Fprintf(&kids, "%d 0 R", firstPageObject+2*i)
+	}
+
+	info := fmt.Sprintf("<< /Title (%s) /Creator (%s) /Producer (%s) /CreationDate (%s)",
+		pdfEscapeString(metadata.name), "Powerpipe", "Powerpipe", metadata.generatedAt.UTC().Format("D:20060102150405Z"))
+	if metadata.workspace != "" {
+		info += fmt.Sprintf(" /Subject (%s)", pdfEscapeString("Workspace: "+metadata.workspace))
+	}
+	info += " >>"
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), pageCount),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		info,
+	}
+	for i, page := range d.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pdfFontRegular, pdfFontBold, firstPageObject+2*i+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()),
+		)
+	}
+
+	out := &pdfWriter{w: bufio.NewWriter(w)}
+	// the binary comment marks the file as containing binary data
+	out.writeString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.offset
+		out.writeString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object))
+	}
+	xrefOffset := out.offset
+	out.writeString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objects)+1))
+	for _, offset := range offsets {
+		out.writeString(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	out.writeString(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset))
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// pdfWriter writes to the underlying writer, recording the byte offset (for the cross-reference table)
+// and the first error, so the document can be written without checking the error of every write
+type pdfWriter struct {
+	w      *bufio.Writer
+	offset int
+	err    error
+}
+
+func (p *pdfWriter) writeString(s string) {
+	if p.err != nil {
+		return
+	}
+	var n int
+	n, p.err = p.w.WriteString(s)
+	p.offset += n
+}
+
+// wrapPdfText splits the text into lines which fit within the given width
+// lines are broken at whitespace where possible - words which are wider than the width are broken mid-word
+func wrapPdfText(text string, font pdfFont, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		var line string
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if pdfTextWidth(candidate, font, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// break words which do not fit on a line by themselves
+			for pdfTextWidth(word, font, size) > width {
+				head := truncatePdfText(word, font, size, width)
+				if head == "" {
+					// the width is too small for even a single character - write it anyway to ensure progress
+					_, n := utf8.DecodeRuneInString(word)
+					head = word[:n]
+				}
+				lines = append(lines, head)
+				word = word[len(head):]
+			}
+			line = word
+		}
+		// NOTE: empty paragraphs are retained as blank lines
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncatePdfText returns the longest prefix of the text which fits within the given width
+func truncatePdfText(text string, font pdfFont, size, width float64) string {
+	var w float64
+	for i, r := range text {
+		w += pdfRuneWidth(r, font) * size / 1000
+		if w > width {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// pdfTextWidth returns the width (in points) of the text in the given font and size
+func pdfTextWidth(text string, font pdfFont, size float64) float64 {
+	var w float64
+	for _, r := range text {
+		w += pdfRuneWidth(r, font)
+	}
+	return w * size / 1000
+}
+
+// pdfRuneWidth returns the width of the character in the font, in thousandths of the font size
+// NOTE: the bold widths are approximated by scaling the regular widths - this slightly overestimates the width
+// of most text, so wrapped lines never overflow
+func pdfRuneWidth(r rune, font pdfFont) float64 {
+	w := 556.0
+	if r >= 32 && int(r-32) < len(helveticaWidths) {
+		w = float64(helveticaWidths[r-32])
+	}
+	if font == pdfFontBold {
+		w *= 1.1
+	}
+	return w
+}
+
+// the widths of the Helvetica characters 32-126, in thousandths of the font size (from the Helvetica AFM)
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0 - 9
+	278, 278, 584, 584, 584, 556, 1015, // : - @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A - M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N - Z
+	278, 278, 278, 469, 556, 333, // [ - `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a - m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n - z
+	334, 260, 334, 584, // { - ~
+}
+
+// the Windows-1252 codes of the characters in the range 0x80-0x9f which commonly appear in text
+var pdfWinAnsiCodes = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfEscapeString encodes the text as the contents of a PDF literal string using the WinAnsi (Windows-1252) encoding
+// the delimiters and backslash are escaped, control characters are replaced with spaces, characters which cannot be
+// encoded are replaced with '?', and non ASCII characters are written as octal escapes so the output is ASCII
+func pdfEscapeString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			b.WriteByte(' ')
+		case r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			if code, ok := pdfWinAnsiCodes[r]; ok {
+				fmt.Fprintf(&b, "\\%03o", code)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
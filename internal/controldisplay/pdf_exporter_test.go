@@ -0,0 +1,167 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/export"
+)
+
+func TestPdfReport(t *testing.T) {
+	// a reason long enough to be wrapped over several lines
+	longReason := strings.Repeat("the bucket policy allows public access ", 20)
+	run1 := &controlexecute.ControlRun{ControlId: "control.c1", Title: "Control (1)", Severity: "high"}
+	run1.Rows = controlexecute.ResultRows{
+		{Reason: longReason, Resource: "arn:aws:s3:::b1", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+		{Reason: "bucket b2 is private", Resource: "arn:aws:s3:::b2", Status: "ok"},
+	}
+	// enough rows to span several pages
+	for i := 0; i < 100; i++ {
+		run1.Rows = append(run1.Rows, &controlexecute.ResultRow{Reason: "bucket b" + strconv.Itoa(i) + " is private", Status: "ok"})
+	}
+	run2 := &controlexecute.ControlRun{ControlId: "control.c2", Title: "Control 2", RunErrorString: "relation does not exist"}
+
+	nested := &controlexecute.ResultGroup{GroupId: "benchmark.nested", Title: "Nested", ControlRuns: []*controlexecute.ControlRun{run2}}
+	benchmark := &controlexecute.ResultGroup{
+		GroupId:     "benchmark.root",
+		Title:       "Root",
+		ControlRuns: []*controlexecute.ControlRun{run1},
+		Groups:      []*controlexecute.ResultGroup{nested},
+	}
+	root := &controlexecute.ResultGroup{GroupId: controlexecute.RootResultGroupName, Groups: []*controlexecute.ResultGroup{benchmark}}
+	tree := &controlexecute.ExecutionTree{Root: root, Workspace: &workspace.Workspace{Path: "/work/space"}}
+
+	var buf bytes.Buffer
+	if err := writePdfReport(context.Background(), tree, &buf); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.String()
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("output is not a PDF document")
+	}
+
+	// the report for a single benchmark is named after the benchmark
+	for _, expected := range []string{"/Title (Root)", "(Workspace: /work/space)", "(Control \\(1\\)) Tj", "(ERROR: relation does not exist) Tj"} {
+		if !strings.Contains(pdf, expected) {
+			t.Errorf("document does not contain %s", expected)
+		}
+	}
+
+	pageCount := regexp.MustCompile(`/Count (\d+)`).FindStringSubmatch(pdf)
+	if pageCount == nil {
+		t.Fatalf("document does not contain a page tree")
+	}
+	pages, _ := strconv.Atoi(pageCount[1])
+	if pages < 2 {
+		t.Errorf("expected the report to span multiple pages - got %d", pages)
+	}
+	if got := strings.Count(pdf, "/Type /Page "); got != pages {
+		t.Errorf("expected %d page objects - got %d", pages, got)
+	}
+	if !strings.Contains(pdf, "(Page "+pageCount[1]+" of "+pageCount[1]+") Tj") {
+		t.Errorf("last page does not have a page footer")
+	}
+
+	// each cross-reference entry must point at the start of its object
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf, -1)
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(entry[1])
+		if !strings.HasPrefix(pdf[offset:], strconv.Itoa(i+1)+" 0 obj\n") {
+			t.Errorf("xref entry %d does not point at object %d", i, i+1)
+		}
+	}
+}
+
+func TestPdfExportSigned(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := export.NewSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := &controlexecute.ControlRun{ControlId: "control.c1", Title: "Control 1"}
+	run.Rows = controlexecute.ResultRows{{Reason: "bucket is public", Resource: "arn:aws:s3:::b1", Status: "alarm"}}
+	benchmark := &controlexecute.ResultGroup{GroupId: "benchmark.root", Title: "Root", ControlRuns: []*controlexecute.ControlRun{run}}
+	root := &controlexecute.ResultGroup{GroupId: controlexecute.RootResultGroupName, Groups: []*controlexecute.ResultGroup{benchmark}}
+	tree := &controlexecute.ExecutionTree{Root: root, Workspace: &workspace.Workspace{Path: "/work/space"}}
+
+	// the report is signed by the export manager, which writes a checksum and a detached signature file
+	m := export.NewManager()
+	if err := m.Register(NewPdfExporter()); err != nil {
+		t.Fatal(err)
+	}
+	m.SetSigner(signer)
+	pdfPath := filepath.Join(t.TempDir(), "report.pdf")
+	if _, err := m.DoExport(context.Background(), "benchmark.root", tree, []string{pdfPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.VerifyExport(pdfPath, publicKey); err != nil {
+		t.Errorf("expected the pdf report signature to verify: %v", err)
+	}
+}
+
+func TestWrapPdfText(t *testing.T) {
+	width := 100.0
+	tests := []struct {
+		name  string
+		text  string
+		lines int
+	}{
+		{"short", "ok", 1},
+		{"empty", "", 1},
+		{"paragraphs", "first\n\nthird", 3},
+		{"wrapped", strings.Repeat("word ", 20), 5},
+		{"long word", strings.Repeat("w", 40), 4},
+	}
+	for _, test := range tests {
+		lines := wrapPdfText(test.text, pdfFontRegular, 10, width)
+		if len(lines) != test.lines {
+			t.Errorf("%s: expected %d lines - got %d: %q", test.name, test.lines, len(lines), lines)
+		}
+		for _, line := range lines {
+			if w := pdfTextWidth(line, pdfFontRegular, 10); w > width {
+				t.Errorf("%s: line %q is wider than %.0f (%.2f)", test.name, line, width, w)
+			}
+		}
+	}
+}
+
+func TestPdfEscapeString(t *testing.T) {
+	tests := map[string]string{
+		"plain":     "plain",
+		`a(b)c\d`:   `a\(b\)c\\d`,
+		"tab\there": "tab here",
+		"café":      `caf\351`,
+		"“quoted”":  `\223quoted\224`,
+		"日本":        "??",
+	}
+	for input, expected := range tests {
+		if got := pdfEscapeString(input); got != expected {
+			t.Errorf("pdfEscapeString(%q): expected %q - got %q", input, expected, got)
+		}
+	}
+}
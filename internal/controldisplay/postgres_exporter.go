@@ -0,0 +1,234 @@
+package controldisplay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	postgresFormatName = "postgres"
+	// the connection string parameter used to specify the results table - this is removed before connecting
+	postgresTableParam = "table"
+	// the results table used if the connection string has no table parameter
+	defaultPostgresResultsTable = "powerpipe_control_results"
+	// the number of result rows upserted by each insert statement (each row has 8 parameters, and postgres
+	// allows at most 65535 parameters per statement)
+	postgresExportBatchSize = 500
+)
+
+// postgresResultColumns are the columns of the results table, in insert order
+var postgresResultColumns = []string{"run_id", "control", "result_index", "resource", "status", "reason", "dimensions", "timestamp"}
+
+// the number of postgresResultColumns which are key columns
+const postgresResultKeyColumns = 3
+
+// PostgresExporter upserts the control results into a postgres table, which is created if it does not exist,
+// so the results of each run can be queried historically
+//
+// the export destination is the connection string of the database, with an optional table parameter giving the
+// (optionally schema qualified) results table, e.g. postgres://user@host:5432/db?table=reports.results
+// results are keyed by run, control and result index (the position of the result in the control results, so a control
+// may have any number of results for the same resource) - the run ID is the correlation ID of the run
+type PostgresExporter struct{}
+
+func NewPostgresExporter() *PostgresExporter {
+	return &PostgresExporter{}
+}
+
+func (e *PostgresExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("PostgresExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	connectionString, table, err := parsePostgresExportDestination(destPath)
+	if err != nil {
+		return err
+	}
+	runId := initialisation.CorrelationIdFromContext(ctx)
+	if runId == "" {
+		runId = uuid.NewString()
+	}
+	rows, err := postgresResultRows(ctx, tree, runId)
+	if err != nil {
+		return err
+	}
+
+	conn, err := pgx.Connect(ctx, connectionString)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to connect to the postgres export database")
+	}
+	defer conn.Close(context.Background())
+
+	// upsert all rows in a single transaction, so a failed export does not leave a partial run in the table
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, postgresCreateTableStatement(table)); err != nil {
+			return sperr.WrapWithMessage(err, "failed to create the results table %s", table.Sanitize())
+		}
+		for start := 0; start < len(rows); start += postgresExportBatchSize {
+			batch := rows[start:min(start+postgresExportBatchSize, len(rows))]
+			var args []any
+			for _, row := range batch {
+				args = append(args, row.values()...)
+			}
+			if _, err := tx.Exec(ctx, postgresUpsertStatement(table, len(batch)), args...); err != nil {
+				return sperr.WrapWithMessage(err, "failed to insert results into %s", table.Sanitize())
+			}
+		}
+		return nil
+	})
+}
+
+func (e *PostgresExporter) FileExtension() string {
+	return ""
+}
+
+func (e *PostgresExporter) Name() string {
+	return postgresFormatName
+}
+
+func (e *PostgresExporter) Alias() string {
+	return ""
+}
+
+// ConnectionStringSchemes implements export.DatabaseExporter
+func (e *PostgresExporter) ConnectionStringSchemes() []string {
+	return []string{"postgres", "postgresql"}
+}
+
+// postgresResultRow is a row of the results table
+type postgresResultRow struct {
+	runId       string
+	control     string
+	resultIndex int
+	resource    string
+	status      string
+	reason      string
+	dimensions  string
+	timestamp   time.Time
+}
+
+func (r *postgresResultRow) values() []any {
+	return []any{r.runId, r.control, r.resultIndex, r.resource, r.status, r.reason, r.dimensions, r.timestamp}
+}
+
+// postgresResultRows returns the rows of the results table for the tree, in tree order
+// (a control which failed to run has a single error row)
+// each result of a control has its own row - a control which is a child of multiple groups has the same results for
+// each, so only the results of its first group are used
+func postgresResultRows(ctx context.Context, tree *controlexecute.ExecutionTree, runId string) ([]*postgresResultRow, error) {
+	var rows []*postgresResultRow
+	// map of control to the group its results are used from
+	controlGroups := make(map[string]string)
+	// map of control to the number of results added
+	resultCounts := make(map[string]int)
+
+	err := walkJSONLRecords(ctx, tree, func(record *jsonlRecord) error {
+		if groupId, ok := controlGroups[record.ControlId]; ok && groupId != record.GroupId {
+			return nil
+		}
+		controlGroups[record.ControlId] = record.GroupId
+
+		dimensions := make(map[string]string, len(record.Dimensions))
+		for _, dimension := range record.Dimensions {
+			dimensions[dimension.Key] = dimension.Value
+		}
+		dimensionsJSON, err := json.Marshal(dimensions)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, &postgresResultRow{
+			runId:       runId,
+			control:     record.ControlId,
+			resultIndex: resultCounts[record.ControlId],
+			resource:    record.Resource,
+			status:      record.Status,
+			reason:      record.Reason,
+			dimensions:  string(dimensionsJSON),
+			timestamp:   tree.StartTime,
+		})
+		resultCounts[record.ControlId]++
+		return nil
+	})
+	return rows, err
+}
+
+// parsePostgresExportDestination returns the connection string (with the table parameter removed) and the results
+// table identifier for the export destination
+func parsePostgresExportDestination(destination string) (string, pgx.Identifier, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		// do not include the connection string in the error, as it may contain a password
+		return "", nil, sperr.New("invalid postgres export connection string")
+	}
+	query := u.Query()
+	tableName := query.Get(postgresTableParam)
+	query.Del(postgresTableParam)
+	u.RawQuery = query.Encode()
+
+	if tableName == "" {
+		tableName = defaultPostgresResultsTable
+	}
+	table := pgx.Identifier(strings.Split(tableName, "."))
+	if len(table) > 2 {
+		return "", nil, sperr.New("invalid postgres export table '%s' - expected table or schema.table", tableName)
+	}
+	for _, part := range table {
+		if part == "" {
+			return "", nil, sperr.New("invalid postgres export table '%s' - expected table or schema.table", tableName)
+		}
+	}
+	return u.String(), table, nil
+}
+
+// postgresCreateTableStatement returns the statement which creates the results table, if it does not exist
+func postgresCreateTableStatement(table pgx.Identifier) string {
+	return fmt.Sprintf(`create table if not exists %s (
+  run_id text not null,
+  control text not null,
+  result_index integer not null,
+  resource text not null,
+  status text not null,
+  reason text,
+  dimensions jsonb,
+  "timestamp" timestamptz not null,
+  primary key (run_id, control, result_index)
+)`, table.Sanitize())
+}
+
+// postgresUpsertStatement returns the statement which upserts the given number of result rows
+func postgresUpsertStatement(table pgx.Identifier, rowCount int) string {
+	columns := make([]string, len(postgresResultColumns))
+	for i, column := range postgresResultColumns {
+		columns[i] = pgx.Identifier{column}.Sanitize()
+	}
+
+	values := make([]string, rowCount)
+	for row := 0; row < rowCount; row++ {
+		params := make([]string, len(columns))
+		for col := range columns {
+			params[col] = fmt.Sprintf("$%d", row*len(columns)+col+1)
+		}
+		values[row] = fmt.Sprintf("(%s)", strings.Join(params, ", "))
+	}
+
+	// on conflict, update the result columns (i.e. all columns other than the key columns)
+	var updates []string
+	for _, column := range columns[postgresResultKeyColumns:] {
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+	return fmt.Sprintf("insert into %s (%s) values %s on conflict (run_id, control, result_index) do update set %s",
+		table.Sanitize(), strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(updates, ", "))
+}
@@ -0,0 +1,112 @@
+package controldisplay
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+func TestParsePostgresExportDestination(t *testing.T) {
+	testCases := []struct {
+		destination      string
+		connectionString string
+		table            string
+		err              bool
+	}{
+		{"postgres://u@host:5432/db", "postgres://u@host:5432/db", `"powerpipe_control_results"`, false},
+		{"postgres://u@host:5432/db?sslmode=disable&table=results", "postgres://u@host:5432/db?sslmode=disable", `"results"`, false},
+		{"postgresql://u@host/db?table=reports.results", "postgresql://u@host/db", `"reports"."results"`, false},
+		{"postgres://u@host/db?table=a.b.c", "", "", true},
+		{"postgres://u@host/db?table=reports.", "", "", true},
+	}
+	for _, tc := range testCases {
+		connectionString, table, err := parsePostgresExportDestination(tc.destination)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.destination)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.destination, err)
+			continue
+		}
+		if connectionString != tc.connectionString {
+			t.Errorf("%s: expected connection string %s, got %s", tc.destination, tc.connectionString, connectionString)
+		}
+		if table.Sanitize() != tc.table {
+			t.Errorf("%s: expected table %s, got %s", tc.destination, tc.table, table.Sanitize())
+		}
+	}
+}
+
+func TestPostgresUpsertStatement(t *testing.T) {
+	statement := postgresUpsertStatement([]string{"results"}, 2)
+	// each row has a parameter per column, numbered consecutively
+	if !strings.Contains(statement, "values ($1, $2, $3, $4, $5, $6, $7, $8), ($9, $10, $11, $12, $13, $14, $15, $16) on conflict") {
+		t.Errorf("unexpected values in statement: %s", statement)
+	}
+	// the key columns are not updated on conflict
+	if strings.Contains(statement, `"control" = excluded`) || strings.Contains(statement, `"result_index" = excluded`) || !strings.Contains(statement, `"status" = excluded."status"`) {
+		t.Errorf("unexpected update columns in statement: %s", statement)
+	}
+}
+
+func TestPostgresResultRows(t *testing.T) {
+	run1 := &controlexecute.ControlRun{ControlId: "control.c1"}
+	run1.Rows = controlexecute.ResultRows{
+		{Reason: "public", Resource: "b1", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+		{Reason: "public", Resource: "b1", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-west-2"}}},
+		{Reason: "private", Resource: "b2", Status: "ok"},
+		{Reason: "no buckets", Status: "info"},
+		{Reason: "no keys", Status: "info"},
+	}
+	run2 := &controlexecute.ControlRun{ControlId: "control.c2", RunErrorString: "relation does not exist"}
+	startTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// control c1 is a child of both groups
+	tree := &controlexecute.ExecutionTree{
+		StartTime: startTime,
+		Root: &controlexecute.ResultGroup{
+			GroupId:     "benchmark.root",
+			ControlRuns: []*controlexecute.ControlRun{run1, run2},
+			Groups:      []*controlexecute.ResultGroup{{GroupId: "benchmark.child", ControlRuns: []*controlexecute.ControlRun{run1}}},
+		},
+	}
+
+	rows, err := postgresResultRows(context.Background(), tree, "run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every result has a row, keyed by its index in the control results - results for the same resource (or with
+	// no resource) are not combined, and the results of c1 are only included once
+	expected := []struct {
+		control     string
+		resultIndex int
+		resource    string
+		status      string
+		reason      string
+		dimensions  string
+	}{
+		{"control.c1", 0, "b1", "alarm", "public", `{"region":"us-east-1"}`},
+		{"control.c1", 1, "b1", "alarm", "public", `{"region":"us-west-2"}`},
+		{"control.c1", 2, "b2", "ok", "private", "{}"},
+		{"control.c1", 3, "", "info", "no buckets", "{}"},
+		{"control.c1", 4, "", "info", "no keys", "{}"},
+		{"control.c2", 0, "", "error", "relation does not exist", "{}"},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(rows))
+	}
+	for i, e := range expected {
+		r := rows[i]
+		if r.control != e.control || r.resultIndex != e.resultIndex || r.resource != e.resource || r.status != e.status || r.reason != e.reason || r.dimensions != e.dimensions {
+			t.Errorf("row %d: expected %+v, got %+v", i, e, r)
+		}
+		if r.runId != "run1" || !r.timestamp.Equal(startTime) {
+			t.Errorf("row %d: expected run id run1 and timestamp %s, got %s and %s", i, startTime, r.runId, r.timestamp)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	QueryPlanFormatName    = "query-plan"
+	queryPlanFileExtension = ".query-plan.json"
+)
+
+// queryPlanRecord is the query plan export record for a control
+type queryPlanRecord struct {
+	Control   string                    `json:"control"`
+	Status    string                    `json:"status"`
+	SQL       string                    `json:"sql,omitempty"`
+	Args      []any                     `json:"args,omitempty"`
+	QueryPlan *controlexecute.QueryPlan `json:"query_plan,omitempty"`
+	Duration  float64                   `json:"duration_seconds"`
+}
+
+// QueryPlanExporter exports the SQL executed for each control, and its query plan, as JSON
+// the query plans are only captured if the tree was executed with a query plan mode (see ExecutionTree.SetQueryPlanMode)
+type QueryPlanExporter struct{}
+
+func NewQueryPlanExporter() *QueryPlanExporter {
+	return &QueryPlanExporter{}
+}
+
+func (e *QueryPlanExporter) Export(_ context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("QueryPlanExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	content, err := json.MarshalIndent(queryPlanRecords(tree), "", "  ")
+	if err != nil {
+		return err
+	}
+	return export.Write(destPath, bytes.NewReader(content))
+}
+
+func (e *QueryPlanExporter) FileExtension() string {
+	return queryPlanFileExtension
+}
+
+func (e *QueryPlanExporter) Name() string {
+	return QueryPlanFormatName
+}
+
+func (e *QueryPlanExporter) Alias() string {
+	return ""
+}
+
+// queryPlanRecords returns the query plan record of each control run in the tree, ordered by control name
+// (controls which were not executed, e.g. skipped controls or those restored from a checkpoint, are not included)
+func queryPlanRecords(tree *controlexecute.ExecutionTree) []*queryPlanRecord {
+	names := maps.Keys(tree.ControlRuns)
+	slices.Sort(names)
+
+	records := make([]*queryPlanRecord, 0, len(names))
+	for _, name := range names {
+		run := tree.ControlRuns[name]
+		if run.ExecutedSQL == "" {
+			continue
+		}
+		records = append(records, &queryPlanRecord{
+			Control:   name,
+			Status:    string(run.GetRunStatus()),
+			SQL:       run.ExecutedSQL,
+			Args:      run.QueryArgs,
+			QueryPlan: run.QueryPlan,
+			Duration:  run.Duration.Seconds(),
+		})
+	}
+	return records
+}
@@ -0,0 +1,34 @@
+package controldisplay
+
+import (
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+func TestQueryPlanRecords(t *testing.T) {
+	plan := &controlexecute.QueryPlan{Statement: "EXPLAIN", Plan: []string{"Seq Scan on t"}}
+	tree := &controlexecute.ExecutionTree{
+		ControlRuns: map[string]*controlexecute.ControlRun{
+			"m.control.b": {ExecutedSQL: "select * from t where id = $1", QueryArgs: []any{1}, QueryPlan: plan},
+			"m.control.a": {ExecutedSQL: "select 1"},
+			// not executed, e.g. restored from a checkpoint
+			"m.control.c": {},
+		},
+	}
+
+	records := queryPlanRecords(tree)
+	if len(records) != 2 {
+		t.Fatalf("expected records for the 2 executed controls, got %d", len(records))
+	}
+	// records are ordered by control name
+	if records[0].Control != "m.control.a" || records[1].Control != "m.control.b" {
+		t.Errorf("expected records for m.control.a and m.control.b, got %s and %s", records[0].Control, records[1].Control)
+	}
+	if records[0].QueryPlan != nil {
+		t.Errorf("expected no query plan for a control executed without a query plan mode")
+	}
+	if records[1].QueryPlan != plan || len(records[1].Args) != 1 {
+		t.Errorf("expected the query plan and args of m.control.b, got %v", records[1])
+	}
+}
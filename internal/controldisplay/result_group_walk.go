@@ -0,0 +1,37 @@
+package controldisplay
+
+import (
+	"context"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+// walkResultGroups calls fn for the root group and each of its descendant groups, depth first in tree order, passing
+// the ancestors of the group (starting with the root)
+// the tree is walked using a stack rather than recursion, so deeply nested benchmarks are not a problem
+// the walk stops if fn returns an error, or the context is cancelled
+func walkResultGroups(ctx context.Context, root *controlexecute.ResultGroup, fn func(group *controlexecute.ResultGroup, parents []*controlexecute.ResultGroup) error) error {
+	type stackItem struct {
+		group   *controlexecute.ResultGroup
+		parents []*controlexecute.ResultGroup
+	}
+	stack := []stackItem{{group: root}}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if err := fn(item.group, item.parents); err != nil {
+			return err
+		}
+		// copy the parents, as they are shared by the sibling groups
+		childParents := append(append([]*controlexecute.ResultGroup{}, item.parents...), item.group)
+		// push child groups in reverse order so they are visited in order
+		for i := len(item.group.Groups) - 1; i >= 0; i-- {
+			stack = append(stack, stackItem{group: item.group.Groups[i], parents: childParents})
+		}
+	}
+	return nil
+}
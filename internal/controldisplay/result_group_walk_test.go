@@ -0,0 +1,55 @@
+package controldisplay
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+func TestWalkResultGroups(t *testing.T) {
+	a1 := &controlexecute.ResultGroup{GroupId: "a1"}
+	a := &controlexecute.ResultGroup{GroupId: "a", Groups: []*controlexecute.ResultGroup{a1}}
+	b := &controlexecute.ResultGroup{GroupId: "b"}
+	root := &controlexecute.ResultGroup{GroupId: "root", Groups: []*controlexecute.ResultGroup{a, b}}
+
+	// groups are visited depth first in tree order, with their ancestors
+	var visited []string
+	err := walkResultGroups(context.Background(), root, func(group *controlexecute.ResultGroup, parents []*controlexecute.ResultGroup) error {
+		var path []string
+		for _, parent := range parents {
+			path = append(path, parent.GroupId)
+		}
+		visited = append(visited, strings.Join(append(path, group.GroupId), "/"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(visited, ","), "root,root/a,root/a/a1,root/b"; got != want {
+		t.Errorf("visited %s, want %s", got, want)
+	}
+
+	// the walk stops at the first error
+	stopErr := errors.New("stop")
+	visited = nil
+	err = walkResultGroups(context.Background(), root, func(group *controlexecute.ResultGroup, _ []*controlexecute.ResultGroup) error {
+		visited = append(visited, group.GroupId)
+		if group == a {
+			return stopErr
+		}
+		return nil
+	})
+	if !errors.Is(err, stopErr) || len(visited) != 2 {
+		t.Errorf("expected the walk to stop after group a, got %v (visited %v)", err, visited)
+	}
+
+	// the walk stops if the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := walkResultGroups(ctx, root, func(*controlexecute.ResultGroup, []*controlexecute.ResultGroup) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancellation error, got %v", err)
+	}
+}
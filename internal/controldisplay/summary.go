@@ -66,6 +66,10 @@ func (r SummaryRenderer) Render() string {
 		summaryLines = append(summaryLines, "") // blank line
 		summaryLines = append(summaryLines, severityRows...)
 	}
+	// if any control with a ranked severity failed, add the worst severity
+	if rollup := r.resultTree.SeverityRollup(); rollup != nil {
+		summaryLines = append(summaryLines, "", fmt.Sprintf("Worst failing severity: %s", ControlColors.Severity(rollup.Severity)))
+	}
 	// now add the summary
 	summaryLines = append(summaryLines,
 		"", // blank line
@@ -0,0 +1,136 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+const (
+	summaryFormatName    = "summary"
+	summaryFileExtension = ".summary.txt"
+	// the maximum number of failing benchmarks listed in the summary
+	maxSummaryFailingBenchmarks = 5
+)
+
+// SummaryExporter exports a compact plain text summary of the control results - the result counts by status,
+// the benchmarks with the most failures and the run duration - without listing the individual controls
+// (to write the summary to the terminal, export to stdout, i.e. --export summary:-)
+type SummaryExporter struct{}
+
+func NewSummaryExporter() *SummaryExporter {
+	return &SummaryExporter{}
+}
+
+func (e *SummaryExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("SummaryExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	var buf bytes.Buffer
+	if err := writeSummary(ctx, tree, &buf); err != nil {
+		return err
+	}
+	return export.Write(destPath, &buf)
+}
+
+func (e *SummaryExporter) FileExtension() string {
+	return summaryFileExtension
+}
+
+func (e *SummaryExporter) Name() string {
+	return summaryFormatName
+}
+
+func (e *SummaryExporter) Alias() string {
+	return ""
+}
+
+// writeSummary writes the text summary of the tree
+func writeSummary(ctx context.Context, tree *controlexecute.ExecutionTree, w io.Writer) error {
+	if tree.Root == nil {
+		_, err := fmt.Fprintln(w, "No controls were run")
+		return err
+	}
+	status := groupStatusSummary(tree.Root)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\n", summaryGroupTitle(tree.Root))
+	fmt.Fprintf(&buf, "Controls:  %d\n", len(tree.ControlRuns))
+	fmt.Fprintf(&buf, "Results:   %d total, %d ok, %d alarm, %d error, %d skip, %d info\n",
+		status.TotalCount(), status.Ok, status.Alarm, status.Error, status.Skip, status.Info)
+	fmt.Fprintf(&buf, "Duration:  %s\n", summaryDuration(tree.EndTime.Sub(tree.StartTime)))
+	if rollup := tree.SeverityRollup(); rollup != nil {
+		fmt.Fprintf(&buf, "Severity:  %s (%d failing %s)\n", rollup.Severity, len(rollup.Controls), utils.Pluralize("control", len(rollup.Controls)))
+	}
+
+	failing, err := topFailingBenchmarks(ctx, tree.Root, maxSummaryFailingBenchmarks)
+	if err != nil {
+		return err
+	}
+	if len(failing) > 0 {
+		fmt.Fprintf(&buf, "\nTop failing %s:\n", utils.Pluralize("benchmark", len(failing)))
+		for i, group := range failing {
+			groupStatus := groupStatusSummary(group)
+			name := group.GroupId
+			if group.Title != "" {
+				name = fmt.Sprintf("%s (%s)", group.Title, group.GroupId)
+			}
+			fmt.Fprintf(&buf, "  %d. %s - %d alarm, %d error\n", i+1, name, groupStatus.Alarm, groupStatus.Error)
+		}
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// topFailingBenchmarks returns the benchmarks with the most failed (alarm or error) results, in descending order
+// only benchmarks which directly contain controls are included - the counts of a parent benchmark include those of
+// its children, so would otherwise always be listed ahead of the benchmarks where the failures are
+func topFailingBenchmarks(ctx context.Context, root *controlexecute.ResultGroup, limit int) ([]*controlexecute.ResultGroup, error) {
+	var failing []*controlexecute.ResultGroup
+	err := walkResultGroups(ctx, root, func(group *controlexecute.ResultGroup, _ []*controlexecute.ResultGroup) error {
+		if status := groupStatusSummary(group); len(group.ControlRuns) > 0 && status.FailedCount() > 0 {
+			failing = append(failing, group)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(failing, func(i, j int) bool {
+		iStatus, jStatus := groupStatusSummary(failing[i]), groupStatusSummary(failing[j])
+		if iFailed, jFailed := iStatus.FailedCount(), jStatus.FailedCount(); iFailed != jFailed {
+			return iFailed > jFailed
+		}
+		return failing[i].GroupId < failing[j].GroupId
+	})
+	if len(failing) > limit {
+		failing = failing[:limit]
+	}
+	return failing, nil
+}
+
+func summaryGroupTitle(group *controlexecute.ResultGroup) string {
+	if group.Title != "" {
+		return group.Title
+	}
+	return group.GroupId
+}
+
+// summaryDuration returns the duration rounded for display, e.g. 1.25s or 2m3s
+func summaryDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,89 @@
+package controldisplay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+func newSummaryTestGroup(id string, status controlstatus.StatusSummary, runs int) *controlexecute.ResultGroup {
+	group := &controlexecute.ResultGroup{GroupId: id, Summary: &controlexecute.GroupSummary{Status: status}}
+	for i := 0; i < runs; i++ {
+		group.ControlRuns = append(group.ControlRuns, &controlexecute.ControlRun{})
+	}
+	return group
+}
+
+func TestWriteSummary(t *testing.T) {
+	root := newSummaryTestGroup(controlexecute.RootResultGroupName, controlstatus.StatusSummary{Ok: 5, Alarm: 9, Error: 2, Skip: 1}, 0)
+	root.Title = "CIS v1.0"
+	parent := newSummaryTestGroup("benchmark.parent", controlstatus.StatusSummary{Ok: 5, Alarm: 9, Error: 2, Skip: 1}, 0)
+	root.Groups = []*controlexecute.ResultGroup{parent}
+	// seven benchmarks with controls, six of which have failures
+	for i := 1; i <= 7; i++ {
+		group := newSummaryTestGroup(fmt.Sprintf("benchmark.b%d", i), controlstatus.StatusSummary{Alarm: i - 1, Error: i % 2}, 1)
+		switch i {
+		case 1:
+			// no failures
+			group.Summary.Status = controlstatus.StatusSummary{Ok: 5}
+		case 7:
+			group.Title = "S3"
+		}
+		parent.Groups = append(parent.Groups, group)
+	}
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tree := &controlexecute.ExecutionTree{
+		Root:        root,
+		ControlRuns: map[string]*controlexecute.ControlRun{"c1": {}, "c2": {}, "c3": {}},
+		StartTime:   start,
+		EndTime:     start.Add(1234567 * time.Microsecond),
+	}
+
+	var buf bytes.Buffer
+	if err := writeSummary(context.Background(), tree, &buf); err != nil {
+		t.Fatal(err)
+	}
+	summary := buf.String()
+	for _, expected := range []string{
+		"CIS v1.0\n",
+		"Controls:  3\n",
+		"Results:   17 total, 5 ok, 9 alarm, 2 error, 1 skip, 0 info\n",
+		"Duration:  1.23s\n",
+		// the parent benchmark is not listed, as it does not directly contain controls
+		// benchmarks with the same number of failures are ordered by name
+		"Top failing benchmarks:\n  1. S3 (benchmark.b7) - 6 alarm, 1 error\n  2. benchmark.b5 - 4 alarm, 1 error\n  3. benchmark.b6 - 5 alarm, 0 error\n  4. benchmark.b3 - 2 alarm, 1 error\n  5. benchmark.b4 - 3 alarm, 0 error\n",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected summary to contain %q, got:\n%s", expected, summary)
+		}
+	}
+	if strings.Contains(summary, "benchmark.parent") || strings.Contains(summary, "benchmark.b1 ") {
+		t.Errorf("unexpected benchmark in top failing benchmarks:\n%s", summary)
+	}
+
+	// a run with no failures has no failing benchmarks
+	buf.Reset()
+	if err := writeSummary(context.Background(), &controlexecute.ExecutionTree{Root: newSummaryTestGroup("root", controlstatus.StatusSummary{Ok: 1}, 1)}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Top failing") || strings.Contains(buf.String(), "Severity:") {
+		t.Errorf("expected no failing benchmarks or severity, got:\n%s", buf.String())
+	}
+
+	// the severity rollup is included if set
+	buf.Reset()
+	root.Summary.SeverityRollup = &controlexecute.SeverityRollup{Severity: "high", Controls: []string{"control.c1", "control.c2"}}
+	if err := writeSummary(context.Background(), tree, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Severity:  high (2 failing controls)\n"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected summary to contain %q, got:\n%s", expected, buf.String())
+	}
+}
@@ -3,6 +3,7 @@ package controldisplay
 import (
 	"bytes"
 	"encoding/csv"
+	"encoding/xml"
 	"fmt"
 	"strings"
 	"sync"
@@ -34,6 +35,8 @@ func templateFuncs(renderContext TemplateRenderContext) template.FuncMap {
 	formatterTemplateFuncMap := template.FuncMap{
 		"durationInSeconds": durationInSeconds,
 		"toCsvCell":         toCSVCellFnFactory(renderContext.Config.Separator),
+		"xmlEscape":         xmlEscape,
+		"mdEscape":          mdEscape,
 	}
 	for k, v := range formatterTemplateFuncMap {
 		funcs[k] = v
@@ -65,5 +68,35 @@ func toCSVCellFnFactory(comma string) func(interface{}) string {
 	}
 }
 
+// xmlEscape escapes a value for use in xml text or attribute values
+func xmlEscape(v interface{}) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(fmt.Sprintf("%v", v))) //nolint:errcheck // writing to a strings.Builder cannot fail
+	return b.String()
+}
+
+// markdownEscaper escapes the characters which have inline meaning in markdown (including '|', which
+// would otherwise break table cells) and collapses line breaks, which would end a table row or heading
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+	"*", "\\*",
+	"_", "\\_",
+	"[", "\\[",
+	"]", "\\]",
+	"<", "\\<",
+	">", "\\>",
+	"|", "\\|",
+	"~", "\\~",
+	"\r\n", " ",
+	"\n", " ",
+	"\r", " ",
+)
+
+// mdEscape escapes a value for use in markdown text or table cells
+func mdEscape(v interface{}) string {
+	return markdownEscaper.Replace(fmt.Sprintf("%v", v))
+}
+
 // durationInSeconds returns the passed in duration as seconds
 func durationInSeconds(t time.Duration) float64 { return t.Seconds() }
@@ -11,3 +11,25 @@ func BenchmarkToCsvCell(b *testing.B) {
 		toCsvCell(i)
 	}
 }
+
+func TestMdEscape(t *testing.T) {
+	tests := map[string]struct {
+		input    interface{}
+		expected string
+	}{
+		"plain":      {input: "bucket is encrypted", expected: "bucket is encrypted"},
+		"emphasis":   {input: "*not* _encrypted_", expected: `\*not\* \_encrypted\_`},
+		"pipe":       {input: "a | b", expected: `a \| b`},
+		"code":       {input: "`arn`", expected: "\\`arn\\`"},
+		"link":       {input: "[x](y)", expected: `\[x\](y)`},
+		"html":       {input: "<b>", expected: `\<b\>`},
+		"backslash":  {input: `a\b`, expected: `a\\b`},
+		"newlines":   {input: "line 1\nline 2\r\nline 3", expected: "line 1 line 2 line 3"},
+		"non string": {input: 42, expected: "42"},
+	}
+	for name, test := range tests {
+		if got := mdEscape(test.input); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", name, test.expected, got)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import "github.com/turbot/powerpipe/internal/controlexecute"
 type TemplateRenderConfig struct {
 	RenderHeader bool
 	Separator    string
+	// should the sarif export include passing (non-failed) results
+	SarifIncludePassing bool
 }
 
 type TemplateRenderConstants struct {
@@ -0,0 +1,433 @@
+package controldisplay
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	xlsxFormatName    = "xlsx"
+	xlsxFileExtension = ".xlsx"
+	// excel limits sheet names to 31 characters, and cell text to 32767 characters
+	maxXlsxSheetNameLength = 31
+	maxXlsxCellLength      = 32767
+	xlsxSummarySheetName   = "Summary"
+	// the sheet name used for controls which are not in a benchmark
+	xlsxControlsSheetName = "Controls"
+)
+
+// excel limits sheets to 1,048,576 rows - results which exceed this are continued on another sheet
+// NOTE: this is a var so tests may lower it
+var maxXlsxSheetRows = 1048576
+
+// the style indexes defined in xlsxStyles
+const (
+	xlsxStyleDefault = 0
+	xlsxStyleHeader  = 1
+)
+
+// XlsxExporter exports control results as an Excel workbook, with a summary sheet and a sheet for each benchmark
+// containing controls. Alarm result rows are coloured red and ok rows green, using conditional formatting.
+//
+// the workbook is streamed, i.e. each sheet is written as it is generated, rather than rendering the whole workbook
+// into memory, so large benchmarks do not require the whole output to be held in memory
+type XlsxExporter struct{}
+
+func NewXlsxExporter() *XlsxExporter {
+	return &XlsxExporter{}
+}
+
+func (e *XlsxExporter) Export(ctx context.Context, input export.ExportSourceData, destPath string) error {
+	// input must be control execution tree
+	tree, ok := input.(*controlexecute.ExecutionTree)
+	if !ok {
+		return fmt.Errorf("XlsxExporter input must be *controlexecute.ExecutionTree")
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeXlsxWorkbook(ctx, tree, writer))
+	}()
+	// close the reader so the writer is released if the write fails
+	defer reader.Close()
+
+	return export.Write(destPath, reader)
+}
+
+func (e *XlsxExporter) FileExtension() string {
+	return xlsxFileExtension
+}
+
+func (e *XlsxExporter) Name() string {
+	return xlsxFormatName
+}
+
+func (e *XlsxExporter) Alias() string {
+	return ""
+}
+
+// xlsxCell is a single cell of a sheet row
+type xlsxCell struct {
+	value  string
+	number bool
+	style  int
+}
+
+func xlsxText(value string) xlsxCell   { return xlsxCell{value: value} }
+func xlsxNumber(value int) xlsxCell    { return xlsxCell{value: strconv.Itoa(value), number: true} }
+func xlsxHeader(value string) xlsxCell { return xlsxCell{value: value, style: xlsxStyleHeader} }
+
+var (
+	xlsxSummaryHeaders = []string{"Benchmark", "Title", "Total", "OK", "Alarm", "Info", "Skip", "Error"}
+	xlsxResultHeaders  = []string{"Control", "Title", "Severity", "Status", "Reason", "Resource", "Dimensions"}
+	// the (1 based) column of the result status, used by the conditional formatting rules
+	xlsxResultStatusColumn = 4
+)
+
+// writeXlsxWorkbook writes the workbook for the tree as a zip archive
+// a summary sheet is written first, followed by a sheet for each group which contains control runs, in tree order
+func writeXlsxWorkbook(ctx context.Context, tree *controlexecute.ExecutionTree, w io.Writer) error {
+	zipWriter := zip.NewWriter(w)
+	workbook := newXlsxWorkbook(zipWriter)
+
+	if tree.Root != nil {
+		if err := workbook.writeSummarySheet(ctx, tree.Root); err != nil {
+			return err
+		}
+
+		// write a sheet for each group which contains control runs
+		err := walkResultGroups(ctx, tree.Root, func(group *controlexecute.ResultGroup, _ []*controlexecute.ResultGroup) error {
+			if len(group.ControlRuns) == 0 {
+				return nil
+			}
+			return workbook.writeResultSheet(group)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := workbook.writeParts(); err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+// xlsxWorkbook writes the sheets of a workbook to a zip archive, recording the sheet names
+// so the workbook parts can be written once all sheets have been written
+type xlsxWorkbook struct {
+	zipWriter  *zip.Writer
+	sheetNames []string
+	// the lower case names of the sheets - excel sheet names are case insensitive
+	usedNames map[string]struct{}
+}
+
+func newXlsxWorkbook(zipWriter *zip.Writer) *xlsxWorkbook {
+	return &xlsxWorkbook{zipWriter: zipWriter, usedNames: make(map[string]struct{})}
+}
+
+func (b *xlsxWorkbook) writeSummarySheet(ctx context.Context, root *controlexecute.ResultGroup) error {
+	sheet, err := b.newSheet(xlsxSummarySheetName)
+	if err != nil {
+		return err
+	}
+	if err := sheet.writeHeader(xlsxSummaryHeaders); err != nil {
+		return err
+	}
+
+	writeSummaryRow := func(name, title string, summary *controlexecute.GroupSummary) error {
+		if summary == nil {
+			summary = controlexecute.NewGroupSummary()
+		}
+		status := summary.Status
+		return sheet.writeRow(
+			xlsxText(name),
+			xlsxText(title),
+			xlsxNumber(status.TotalCount()),
+			xlsxNumber(status.Ok),
+			xlsxNumber(status.Alarm),
+			xlsxNumber(status.Info),
+			xlsxNumber(status.Skip),
+			xlsxNumber(status.Error),
+		)
+	}
+
+	// write a row for each benchmark (in tree order), followed by the overall total
+	err = walkResultGroups(ctx, root, func(group *controlexecute.ResultGroup, _ []*controlexecute.ResultGroup) error {
+		if group == root {
+			return nil
+		}
+		return writeSummaryRow(group.GroupId, group.Title, group.Summary)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeSummaryRow("Total", "", root.Summary); err != nil {
+		return err
+	}
+	return sheet.close(false)
+}
+
+func (b *xlsxWorkbook) writeResultSheet(group *controlexecute.ResultGroup) error {
+	name := group.Title
+	if name == "" {
+		name = group.GroupId
+	}
+	if group.GroupId == controlexecute.RootResultGroupName {
+		name = xlsxControlsSheetName
+	}
+	newResultSheet := func() (*xlsxSheetWriter, error) {
+		// if the sheet name is already used (i.e. for continuation sheets), a numeric suffix is added
+		sheet, err := b.newSheet(name)
+		if err != nil {
+			return nil, err
+		}
+		return sheet, sheet.writeHeader(xlsxResultHeaders)
+	}
+	sheet, err := newResultSheet()
+	if err != nil {
+		return err
+	}
+
+	for _, run := range group.ControlRuns {
+		for _, record := range jsonlRecordsForControlRun(group.GroupId, run) {
+			// if the sheet is full, continue the results on a new sheet
+			if sheet.full() {
+				if err := sheet.close(true); err != nil {
+					return err
+				}
+				if sheet, err = newResultSheet(); err != nil {
+					return err
+				}
+			}
+			dimensions := make([]string, len(record.Dimensions))
+			for i, d := range record.Dimensions {
+				dimensions[i] = fmt.Sprintf("%s=%s", d.Key, d.Value)
+			}
+			err := sheet.writeRow(
+				xlsxText(record.ControlId),
+				xlsxText(record.Title),
+				xlsxText(record.Severity),
+				xlsxText(record.Status),
+				xlsxText(record.Reason),
+				xlsxText(record.Resource),
+				xlsxText(strings.Join(dimensions, ", ")),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return sheet.close(true)
+}
+
+// newSheet starts writing a new sheet - the sheet must be closed before another sheet is started
+func (b *xlsxWorkbook) newSheet(name string) (*xlsxSheetWriter, error) {
+	name = b.uniqueSheetName(name)
+	b.sheetNames = append(b.sheetNames, name)
+
+	entry, err := b.zipWriter.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(b.sheetNames)))
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to create sheet '%s'", name)
+	}
+	sheet := &xlsxSheetWriter{w: bufio.NewWriter(entry)}
+	// freeze the header row
+	sheet.writeString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`)
+	return sheet, sheet.err
+}
+
+// uniqueSheetName returns a valid sheet name, which is not already used in the workbook
+func (b *xlsxWorkbook) uniqueSheetName(name string) string {
+	name = sanitiseXlsxSheetName(name)
+	candidate := name
+	for i := 2; ; i++ {
+		if _, used := b.usedNames[strings.ToLower(candidate)]; !used {
+			break
+		}
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, maxXlsxSheetNameLength-len(suffix)) + suffix
+	}
+	b.usedNames[strings.ToLower(candidate)] = struct{}{}
+	return candidate
+}
+
+// sanitiseXlsxSheetName removes the characters which are invalid in sheet names, and truncates the name to the maximum length
+func sanitiseXlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	// sheet names may not start or end with an apostrophe
+	name = strings.Trim(truncateRunes(strings.TrimSpace(name), maxXlsxSheetNameLength), "'")
+	if name == "" {
+		return xlsxControlsSheetName
+	}
+	return name
+}
+
+func truncateRunes(s string, length int) string {
+	if runes := []rune(s); len(runes) > length {
+		return string(runes[:length])
+	}
+	return s
+}
+
+// writeParts writes the workbook, relationship, content type and style parts for the sheets which have been written
+func (b *xlsxWorkbook) writeParts() error {
+	var sheets, sheetRels, sheetContentTypes strings.Builder
+	for i, name := range b.sheetNames {
+		id := i + 1
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), id, id)
+		fmt.Fprintf(&sheetRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, id, id)
+		fmt.Fprintf(&sheetContentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, id)
+	}
+	stylesId := len(b.sheetNames) + 1
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			sheetContentTypes.String() +
+			`</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets>` +
+			`</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			sheetRels.String() +
+			fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, stylesId) +
+			`</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		entry, err := b.zipWriter.Create(part.name)
+		if err != nil {
+			return sperr.WrapWithMessage(err, "failed to create workbook part '%s'", part.name)
+		}
+		if _, err := io.WriteString(entry, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xlsxStyles defines the header cell style, and the red (alarm) and green (ok) conditional formats
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`<dxfs count="2">` +
+	`<dxf><font><color rgb="FF9C0006"/></font><fill><patternFill><bgColor rgb="FFFFC7CE"/></patternFill></fill></dxf>` +
+	`<dxf><font><color rgb="FF006100"/></font><fill><patternFill><bgColor rgb="FFC6EFCE"/></patternFill></fill></dxf>` +
+	`</dxfs>` +
+	`</styleSheet>`
+
+// xlsxSheetWriter writes the rows of a sheet
+// write errors are stored, and returned by subsequent calls
+type xlsxSheetWriter struct {
+	w     *bufio.Writer
+	rows  int
+	width int
+	err   error
+}
+
+func (s *xlsxSheetWriter) writeString(str string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(str)
+	}
+}
+
+func (s *xlsxSheetWriter) writeHeader(headers []string) error {
+	cells := make([]xlsxCell, len(headers))
+	for i, h := range headers {
+		cells[i] = xlsxHeader(h)
+	}
+	return s.writeRow(cells...)
+}
+
+// full returns true if the sheet contains the maximum number of rows
+func (s *xlsxSheetWriter) full() bool {
+	return s.rows >= maxXlsxSheetRows
+}
+
+func (s *xlsxSheetWriter) writeRow(cells ...xlsxCell) error {
+	if s.full() {
+		return sperr.New("an xlsx sheet cannot contain more than %d rows", maxXlsxSheetRows)
+	}
+	s.rows++
+	s.width = max(s.width, len(cells))
+
+	s.writeString(fmt.Sprintf(`<row r="%d">`, s.rows))
+	for i, cell := range cells {
+		ref := xlsxCellRef(i+1, s.rows)
+		style := ""
+		if cell.style != xlsxStyleDefault {
+			style = fmt.Sprintf(` s="%d"`, cell.style)
+		}
+		if cell.number {
+			s.writeString(fmt.Sprintf(`<c r="%s"%s><v>%s</v></c>`, ref, style, cell.value))
+			continue
+		}
+		s.writeString(fmt.Sprintf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(truncateRunes(cell.value, maxXlsxCellLength))))
+	}
+	s.writeString(`</row>`)
+	return s.err
+}
+
+// close completes the sheet - if statusFormatting is set, the conditional formatting rules which colour
+// the result rows by status are added
+func (s *xlsxSheetWriter) close(statusFormatting bool) error {
+	s.writeString(`</sheetData>`)
+	if statusFormatting && s.rows > 1 {
+		statusCell := "$" + xlsxColumnName(xlsxResultStatusColumn) + "2"
+		s.writeString(fmt.Sprintf(`<conditionalFormatting sqref="A2:%s">`, xlsxCellRef(s.width, s.rows)) +
+			fmt.Sprintf(`<cfRule type="expression" dxfId="0" priority="1"><formula>%s="alarm"</formula></cfRule>`, statusCell) +
+			fmt.Sprintf(`<cfRule type="expression" dxfId="1" priority="2"><formula>%s="ok"</formula></cfRule>`, statusCell) +
+			`</conditionalFormatting>`)
+	}
+	s.writeString(`</worksheet>`)
+	if s.err != nil {
+		return s.err
+	}
+	return s.w.Flush()
+}
+
+// xlsxCellRef returns the A1 style reference of the (1 based) column and row
+func xlsxCellRef(column, row int) string {
+	return xlsxColumnName(column) + strconv.Itoa(row)
+}
+
+// xlsxColumnName returns the letters of the (1 based) column, e.g. 1 -> A, 27 -> AA
+func xlsxColumnName(column int) string {
+	var name []byte
+	for column > 0 {
+		column--
+		name = append([]byte{byte('A' + column%26)}, name...)
+		column /= 26
+	}
+	return string(name)
+}
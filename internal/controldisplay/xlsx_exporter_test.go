@@ -0,0 +1,200 @@
+package controldisplay
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+)
+
+func TestXlsxWorkbook(t *testing.T) {
+	run1 := &controlexecute.ControlRun{ControlId: "control.c1", Title: "Control 1", Severity: "high"}
+	run1.Rows = controlexecute.ResultRows{
+		{Reason: "bucket is public", Resource: "arn:aws:s3:::b1", Status: "alarm", Dimensions: []controlexecute.Dimension{{Key: "region", Value: "us-east-1"}}},
+		{Reason: "bucket <b2> is private", Resource: "arn:aws:s3:::b2", Status: "ok"},
+	}
+	run2 := &controlexecute.ControlRun{ControlId: "control.c2", Title: "Control 2", RunErrorString: "relation does not exist"}
+
+	nested := &controlexecute.ResultGroup{GroupId: "benchmark.nested", Title: "S3: Nested/Checks", ControlRuns: []*controlexecute.ControlRun{run2}}
+	benchmark := &controlexecute.ResultGroup{
+		GroupId:     "benchmark.root",
+		Title:       "Root",
+		ControlRuns: []*controlexecute.ControlRun{run1},
+		Groups:      []*controlexecute.ResultGroup{nested},
+	}
+	root := &controlexecute.ResultGroup{GroupId: controlexecute.RootResultGroupName, Groups: []*controlexecute.ResultGroup{benchmark}}
+
+	var buf bytes.Buffer
+	if err := writeXlsxWorkbook(context.Background(), &controlexecute.ExecutionTree{Root: root}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	parts := readXlsxParts(t, buf.Bytes())
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook is missing part %s", name)
+		}
+	}
+
+	// the summary sheet is first, followed by a sheet for each group with controls
+	for _, expected := range []string{`<sheet name="Summary" sheetId="1"`, `<sheet name="Root" sheetId="2"`, `<sheet name="S3- Nested-Checks" sheetId="3"`} {
+		if !strings.Contains(parts["xl/workbook.xml"], expected) {
+			t.Errorf("workbook.xml does not contain %s", expected)
+		}
+	}
+
+	summary := parts["xl/worksheets/sheet1.xml"]
+	for _, expected := range []string{"benchmark.root", "benchmark.nested", "Total"} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("summary sheet does not contain %s", expected)
+		}
+	}
+
+	results := parts["xl/worksheets/sheet2.xml"]
+	for _, expected := range []string{
+		"bucket &lt;b2&gt; is private",
+		"region=us-east-1",
+		`<conditionalFormatting sqref="A2:G3">`,
+		`<formula>$D2="alarm"</formula>`,
+		`<formula>$D2="ok"</formula>`,
+	} {
+		if !strings.Contains(results, expected) {
+			t.Errorf("results sheet does not contain %s", expected)
+		}
+	}
+	if rows := strings.Count(results, "<row "); rows != 3 {
+		t.Errorf("expected 3 rows in results sheet - got %d", rows)
+	}
+
+	// a control which failed to run is written as a single error row
+	errors := parts["xl/worksheets/sheet3.xml"]
+	if !strings.Contains(errors, "relation does not exist") || !strings.Contains(errors, ">error<") {
+		t.Errorf("error sheet does not contain the control error")
+	}
+}
+
+func TestXlsxResultSheetRowLimit(t *testing.T) {
+	// lower the row limit so a sheet holds the header row and 2 result rows
+	defer func(limit int) { maxXlsxSheetRows = limit }(maxXlsxSheetRows)
+	maxXlsxSheetRows = 3
+
+	run := &controlexecute.ControlRun{ControlId: "control.c1", Title: "Control 1"}
+	for _, resource := range []string{"r1", "r2", "r3", "r4", "r5"} {
+		run.Rows = append(run.Rows, &controlexecute.ResultRow{Reason: "reason", Resource: resource, Status: "ok"})
+	}
+	benchmark := &controlexecute.ResultGroup{GroupId: "benchmark.root", Title: "Root", ControlRuns: []*controlexecute.ControlRun{run}}
+	root := &controlexecute.ResultGroup{GroupId: controlexecute.RootResultGroupName, Groups: []*controlexecute.ResultGroup{benchmark}}
+
+	var buf bytes.Buffer
+	if err := writeXlsxWorkbook(context.Background(), &controlexecute.ExecutionTree{Root: root}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	parts := readXlsxParts(t, buf.Bytes())
+
+	// the results are continued on additional sheets, each with a header row
+	for _, expected := range []string{`<sheet name="Root" sheetId="2"`, `<sheet name="Root (2)" sheetId="3"`, `<sheet name="Root (3)" sheetId="4"`} {
+		if !strings.Contains(parts["xl/workbook.xml"], expected) {
+			t.Errorf("workbook.xml does not contain %s", expected)
+		}
+	}
+	for sheet, expected := range map[string][]string{
+		"xl/worksheets/sheet2.xml": {"r1", "r2"},
+		"xl/worksheets/sheet3.xml": {"r3", "r4"},
+		"xl/worksheets/sheet4.xml": {"r5"},
+	} {
+		content := parts[sheet]
+		if rows := strings.Count(content, "<row "); rows != len(expected)+1 {
+			t.Errorf("expected %d rows in %s - got %d", len(expected)+1, sheet, rows)
+		}
+		if !strings.Contains(content, xlsxResultHeaders[0]) {
+			t.Errorf("%s does not contain the header row", sheet)
+		}
+		for _, resource := range expected {
+			if !strings.Contains(content, ">"+resource+"<") {
+				t.Errorf("%s does not contain resource %s", sheet, resource)
+			}
+		}
+	}
+}
+
+func TestXlsxSheetRowLimit(t *testing.T) {
+	defer func(limit int) { maxXlsxSheetRows = limit }(maxXlsxSheetRows)
+	maxXlsxSheetRows = 1
+
+	sheet := &xlsxSheetWriter{w: bufio.NewWriter(io.Discard)}
+	if err := sheet.writeRow(xlsxText("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sheet.writeRow(xlsxText("b")); err == nil {
+		t.Errorf("expected an error writing more than %d rows", maxXlsxSheetRows)
+	}
+}
+
+func TestXlsxSheetNames(t *testing.T) {
+	workbook := newXlsxWorkbook(nil)
+	long := strings.Repeat("a", 40)
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Summary", "Summary"},
+		{"summary", "summary (2)"},
+		{"a[b]:c*d?e/f\\g", "a-b--c-d-e-f-g"},
+		{long, strings.Repeat("a", maxXlsxSheetNameLength)},
+		{long, strings.Repeat("a", maxXlsxSheetNameLength-4) + " (2)"},
+		{"", xlsxControlsSheetName},
+	}
+	for _, test := range tests {
+		if got := workbook.uniqueSheetName(test.name); got != test.expected {
+			t.Errorf("uniqueSheetName(%q): expected %q - got %q", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestXlsxColumnName(t *testing.T) {
+	for column, expected := range map[int]string{1: "A", 7: "G", 26: "Z", 27: "AA", 52: "AZ", 703: "AAA"} {
+		if got := xlsxColumnName(column); got != expected {
+			t.Errorf("xlsxColumnName(%d): expected %s - got %s", column, expected, got)
+		}
+	}
+}
+
+// readXlsxParts returns the content of each part of the workbook, verifying each part is well formed xml
+func readXlsxParts(t *testing.T, workbook []byte) map[string]string {
+	t.Helper()
+	zipReader, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("workbook is not a valid zip archive: %s", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range zipReader.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every part must be well formed xml
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("part %s is not valid xml: %s", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(content)
+	}
+
+	return parts
+}
@@ -0,0 +1,256 @@
+package controlexecute
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// CheckpointVersion is the version of the checkpoint file format - this must be incremented if the format changes,
+// as a checkpoint file with a different version cannot be resumed
+const CheckpointVersion = 1
+
+// checkpointHeader is the first line of a checkpoint file
+type checkpointHeader struct {
+	Version int `json:"version"`
+}
+
+// checkpointControl is the persisted result of a completed control run
+type checkpointControl struct {
+	Name string          `json:"name"`
+	Rows []checkpointRow `json:"rows"`
+	// if the rows were truncated to the control row limit, the total row count and the summary of all rows
+	Truncated bool                         `json:"truncated,omitempty"`
+	TotalRows int                          `json:"total_rows,omitempty"`
+	Summary   *controlstatus.StatusSummary `json:"summary,omitempty"`
+}
+
+type checkpointRow struct {
+	Reason     string                `json:"reason"`
+	Resource   string                `json:"resource"`
+	Status     string                `json:"status"`
+	Dimensions []checkpointDimension `json:"dimensions,omitempty"`
+}
+
+// checkpointDimension is a Dimension, including the sql type (which is not serialised by Dimension)
+type checkpointDimension struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	SqlType string `json:"sql_type"`
+}
+
+func newCheckpointControl(run *ControlRun) *checkpointControl {
+	res := &checkpointControl{
+		Name: run.FullName,
+		Rows: make([]checkpointRow, len(run.Rows)),
+	}
+	if run.Truncated {
+		summary := *run.Summary
+		res.Truncated, res.TotalRows, res.Summary = true, run.TotalRows, &summary
+	}
+	for i, row := range run.Rows {
+		res.Rows[i] = checkpointRow{
+			Reason:   row.Reason,
+			Resource: row.Resource,
+			Status:   row.Status,
+		}
+		for _, d := range row.Dimensions {
+			res.Rows[i].Dimensions = append(res.Rows[i].Dimensions, checkpointDimension{Key: d.Key, Value: d.Value, SqlType: d.SqlType})
+		}
+	}
+	return res
+}
+
+func (c checkpointRow) toResultRow(run *ControlRun) *ResultRow {
+	res := &ResultRow{
+		Reason:   c.Reason,
+		Resource: c.Resource,
+		Status:   c.Status,
+		Run:      run,
+		Control:  run.Control,
+	}
+	for _, d := range c.Dimensions {
+		res.Dimensions = append(res.Dimensions, Dimension{Key: d.Key, Value: d.Value, SqlType: d.SqlType})
+	}
+	return res
+}
+
+// Checkpoint persists the results of control runs to a file as each control completes, so that a run which fails
+// part way through can be resumed, only executing the controls which had not completed.
+//
+// The file is JSON lines - a header containing the format version, followed by a line for each completed control.
+// As lines are only ever appended, a partially written final line (e.g. if the process was killed) is ignored on resume
+type Checkpoint struct {
+	path string
+	file *os.File
+	// the results of the controls completed by a previous run, keyed by control full name
+	completed map[string]*checkpointControl
+	lock      sync.Mutex
+}
+
+// NewCheckpoint opens the checkpoint file at the given path.
+// If resume is set, the results of the controls completed by the previous run are loaded and retained in the file,
+// otherwise any existing checkpoint file is replaced
+func NewCheckpoint(path string, resume bool) (*Checkpoint, error) {
+	c := &Checkpoint{
+		path:      path,
+		completed: make(map[string]*checkpointControl),
+	}
+	if resume {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	// (re)write the header and the loaded results to a temporary file, then replace the checkpoint file with it
+	// - this drops any partially written line, so new results can be appended
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to create checkpoint file")
+	}
+	if err := c.writeInitialContent(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, sperr.WrapWithMessage(err, "failed to write checkpoint file")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, sperr.WrapWithMessage(err, "failed to write checkpoint file")
+	}
+	c.file = file
+	return c, nil
+}
+
+// load reads the results of completed controls from an existing checkpoint file - if there is no file, there are no
+// completed controls
+func (c *Checkpoint) load() error {
+	file, err := os.Open(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to open checkpoint file")
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		// NOTE: use ReadBytes rather than a Scanner, as the results of a control may exceed the Scanner token size
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a final line with no newline was not completely written
+			if lineNumber == 1 {
+				return sperr.New("checkpoint file %s has no header", c.path)
+			}
+			return nil
+		}
+		if err != nil {
+			return sperr.WrapWithMessage(err, "failed to read checkpoint file")
+		}
+
+		if lineNumber == 1 {
+			var header checkpointHeader
+			if err := json.Unmarshal(line, &header); err != nil {
+				return sperr.New("checkpoint file %s has an invalid header", c.path)
+			}
+			if header.Version != CheckpointVersion {
+				return sperr.New("checkpoint file %s has version %d - only version %d can be resumed", c.path, header.Version, CheckpointVersion)
+			}
+			continue
+		}
+
+		var control checkpointControl
+		if err := json.Unmarshal(line, &control); err != nil {
+			return sperr.New("checkpoint file %s is invalid at line %d: %s", c.path, lineNumber, err.Error())
+		}
+		c.completed[control.Name] = &control
+	}
+}
+
+func (c *Checkpoint) writeInitialContent(file *os.File) error {
+	writer := bufio.NewWriter(file)
+	if err := writeCheckpointLine(writer, checkpointHeader{Version: CheckpointVersion}); err != nil {
+		return err
+	}
+	for _, control := range c.completed {
+		if err := writeCheckpointLine(writer, control); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// completedControl returns the persisted result of the control, if it was completed by the previous run
+// (a nil Checkpoint has no completed controls)
+func (c *Checkpoint) completedControl(name string) *checkpointControl {
+	if c == nil {
+		return nil
+	}
+	return c.completed[name]
+}
+
+// recordControl appends the results of the completed control run to the checkpoint file
+func (c *Checkpoint) recordControl(run *ControlRun) error {
+	if c == nil {
+		return nil
+	}
+	// serialise the whole line first, so it is written with a single write
+	var buf bytes.Buffer
+	if err := writeCheckpointLine(&buf, newCheckpointControl(run)); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, err := c.file.Write(buf.Bytes()); err != nil {
+		return sperr.WrapWithMessage(err, "failed to write checkpoint file")
+	}
+	return nil
+}
+
+// Close closes the checkpoint file
+func (c *Checkpoint) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.file.Close()
+}
+
+func writeCheckpointLine(w io.Writer, value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// restoreFromCheckpoint populates the run with the results persisted by a previous run, rather than executing the
+// control query
+func (r *ControlRun) restoreFromCheckpoint(ctx context.Context, control *checkpointControl) {
+	for _, row := range control.Rows {
+		r.addResultRow(row.toResultRow(r))
+	}
+	if control.Truncated {
+		r.Truncated, r.TotalRows = true, control.TotalRows
+		if control.Summary != nil {
+			*r.Summary = *control.Summary
+		}
+	}
+	r.setRunStatus(ctx, dashboardtypes.RunComplete)
+	r.createdOrderedResultRows()
+	r.Data = r.Rows.ToLeafData(r.getDimensionSchema())
+}
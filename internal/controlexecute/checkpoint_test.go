@@ -0,0 +1,124 @@
+package controlexecute
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+func newCheckpointTestRun(name string) *ControlRun {
+	return &ControlRun{
+		FullName:  name,
+		Summary:   &controlstatus.StatusSummary{},
+		RunStatus: dashboardtypes.RunRunning,
+		rowMap:    make(map[string]ResultRows),
+		doneChan:  make(chan bool, 1),
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	checkpoint, err := NewCheckpoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := newCheckpointTestRun("mod.control.c1")
+	run.Rows = ResultRows{
+		{Reason: "bucket is public", Resource: "b1", Status: "alarm", Dimensions: []Dimension{{Key: "region", Value: "us-east-1", SqlType: "TEXT"}}},
+		{Reason: "bucket is private", Resource: "b2", Status: "ok"},
+	}
+	if err := checkpoint.recordControl(run); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a line which was partially written when the run was killed
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"name":"mod.control.c2","rows":[{"rea`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	checkpoint, err = NewCheckpoint(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.completedControl("mod.control.c2") != nil {
+		t.Errorf("partially written control should not be completed")
+	}
+	completed := checkpoint.completedControl("mod.control.c1")
+	if completed == nil {
+		t.Fatalf("control recorded by the previous run is not completed")
+	}
+
+	restored := newCheckpointTestRun("mod.control.c1")
+	restored.restoreFromCheckpoint(context.Background(), completed)
+	if restored.RunStatus != dashboardtypes.RunComplete {
+		t.Errorf("expected restored run to be complete, got %s", restored.RunStatus)
+	}
+	if restored.Summary.Alarm != 1 || restored.Summary.Ok != 1 {
+		t.Errorf("expected restored summary to have 1 alarm and 1 ok, got %+v", restored.Summary)
+	}
+	if len(restored.Rows) != 2 || restored.Rows[0].Dimensions[0].SqlType != "TEXT" || restored.Rows[0].Run != restored {
+		t.Errorf("restored rows do not match the recorded rows")
+	}
+	if len(restored.Data.Rows) != 2 {
+		t.Errorf("expected restored snapshot data to have 2 rows, got %d", len(restored.Data.Rows))
+	}
+
+	// results recorded after resuming are appended to those of the previous run
+	if err := checkpoint.recordControl(newCheckpointTestRun("mod.control.c3")); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.Close()
+	checkpoint, err = NewCheckpoint(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpoint.Close()
+	for _, name := range []string{"mod.control.c1", "mod.control.c3"} {
+		if checkpoint.completedControl(name) == nil {
+			t.Errorf("%s is not completed after resuming twice", name)
+		}
+	}
+}
+
+func TestCheckpointVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	if err := os.WriteFile(path, []byte("{\"version\":99}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCheckpoint(path, true); err == nil {
+		t.Errorf("expected an error resuming a checkpoint with an unsupported version")
+	}
+
+	// a checkpoint which is not resumed is replaced
+	checkpoint, err := NewCheckpoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.Close()
+	if _, err := NewCheckpoint(path, true); err != nil {
+		t.Errorf("unexpected error resuming a replaced checkpoint: %v", err)
+	}
+}
+
+func TestNilCheckpoint(t *testing.T) {
+	var checkpoint *Checkpoint
+	if checkpoint.completedControl("mod.control.c1") != nil {
+		t.Errorf("nil checkpoint should have no completed controls")
+	}
+	if err := checkpoint.recordControl(newCheckpointTestRun("mod.control.c1")); err != nil {
+		t.Errorf("unexpected error recording to a nil checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package controlexecute
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the control tag used to override the maximum number of result rows kept for the control, e.g. tags = { max_rows = "500" }
+const maxRowsTag = "max_rows"
+
+// resolveMaxRows returns the maximum number of result rows kept for the control - this is the control max_rows tag
+// if set, otherwise ArgControlMaxRows. Zero means there is no limit
+func (r *ControlRun) resolveMaxRows() (int, error) {
+	if value, ok := r.Control.Tags[maxRowsTag]; ok {
+		maxRows, err := parseMaxRows(value)
+		if err != nil {
+			return 0, sperr.New("invalid %s tag for %s: %s", maxRowsTag, r.Control.Name(), err.Error())
+		}
+		return maxRows, nil
+	}
+	return viper.GetInt(localconstants.ArgControlMaxRows), nil
+}
+
+// parseMaxRows parses a maximum row count, which must be a non-negative integer
+func parseMaxRows(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	maxRows, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, sperr.New("'%s' is not a number of rows", value)
+	}
+	if maxRows < 0 {
+		return 0, sperr.New("'%s' must not be negative", value)
+	}
+	return maxRows, nil
+}
+
+// keepResultRow counts the result row and returns whether it should be kept - once the row limit is reached, further
+// rows are discarded and the run is marked as truncated (the summary still includes the discarded rows)
+func (r *ControlRun) keepResultRow() bool {
+	r.rowCount++
+	if r.maxRows == 0 || r.rowCount <= r.maxRows {
+		return true
+	}
+	r.Truncated = true
+	r.TotalRows = r.rowCount
+	return false
+}
+
+// matchesRowLimit returns whether the persisted results have the rows which executing the control with the current row
+// limit would - if not (i.e. the limit has changed since the results were cached), the control must be executed
+func (r *ControlRun) matchesRowLimit(control *checkpointControl) bool {
+	if control.Truncated {
+		return len(control.Rows) == r.maxRows
+	}
+	return r.maxRows == 0 || len(control.Rows) <= r.maxRows
+}
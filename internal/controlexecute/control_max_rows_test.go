@@ -0,0 +1,78 @@
+package controlexecute
+
+import (
+	"testing"
+)
+
+func TestParseMaxRows(t *testing.T) {
+	tests := map[string]struct {
+		value string
+		want  int
+		err   bool
+	}{
+		"rows":         {value: "500", want: 500},
+		"whitespace":   {value: " 10 ", want: 10},
+		"zero":         {value: "0", want: 0},
+		"negative":     {value: "-5", err: true},
+		"not a number": {value: "lots", err: true},
+	}
+	for name, test := range tests {
+		got, err := parseMaxRows(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %d, got %d", name, test.want, got)
+		}
+	}
+}
+
+func TestKeepResultRow(t *testing.T) {
+	r := &ControlRun{maxRows: 2}
+	var kept int
+	for i := 0; i < 5; i++ {
+		if r.keepResultRow() {
+			kept++
+		}
+	}
+	if kept != 2 || !r.Truncated || r.TotalRows != 5 {
+		t.Errorf("expected 2 rows kept of 5, truncated - got %d kept of %d, truncated %v", kept, r.TotalRows, r.Truncated)
+	}
+
+	r = &ControlRun{}
+	for i := 0; i < 5; i++ {
+		r.keepResultRow()
+	}
+	if r.Truncated || r.TotalRows != 0 {
+		t.Errorf("expected no truncation with no limit - got truncated %v, total %d", r.Truncated, r.TotalRows)
+	}
+}
+
+func TestMatchesRowLimit(t *testing.T) {
+	rows := func(count int) []checkpointRow { return make([]checkpointRow, count) }
+	tests := map[string]struct {
+		maxRows int
+		control *checkpointControl
+		want    bool
+	}{
+		"no limit":                     {maxRows: 0, control: &checkpointControl{Rows: rows(5)}, want: true},
+		"within limit":                 {maxRows: 10, control: &checkpointControl{Rows: rows(5)}, want: true},
+		"exceeds lower limit":          {maxRows: 2, control: &checkpointControl{Rows: rows(5)}},
+		"truncated to same limit":      {maxRows: 2, control: &checkpointControl{Rows: rows(2), Truncated: true, TotalRows: 5}, want: true},
+		"truncated to different limit": {maxRows: 3, control: &checkpointControl{Rows: rows(2), Truncated: true, TotalRows: 5}},
+		"truncated, limit removed":     {maxRows: 0, control: &checkpointControl{Rows: rows(2), Truncated: true, TotalRows: 5}},
+	}
+	for name, test := range tests {
+		r := &ControlRun{maxRows: test.maxRows}
+		if got := r.matchesRowLimit(test.control); got != test.want {
+			t.Errorf("%s: expected %v, got %v", name, test.want, got)
+		}
+	}
+}
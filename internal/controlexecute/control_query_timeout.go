@@ -0,0 +1,59 @@
+package controlexecute
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the control tag used to override the control query timeout, e.g. tags = { query_timeout = "90s" }
+const queryTimeoutTag = "query_timeout"
+
+// resolveQueryTimeout returns the timeout for the control query - this is the control query_timeout tag if set,
+// otherwise ArgControlQueryTimeout. Zero means there is no control specific timeout
+func (r *ControlRun) resolveQueryTimeout() (time.Duration, error) {
+	if value, ok := r.Control.Tags[queryTimeoutTag]; ok {
+		timeout, err := parseQueryTimeout(value)
+		if err != nil {
+			return 0, sperr.New("invalid %s tag for %s: %s", queryTimeoutTag, r.Control.Name(), err.Error())
+		}
+		return timeout, nil
+	}
+	return time.Duration(viper.GetInt(localconstants.ArgControlQueryTimeout)) * time.Second, nil
+}
+
+// parseQueryTimeout parses a timeout given either as a number of seconds or as a duration, e.g. "90" or "1m30s"
+func parseQueryTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var timeout time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		timeout = time.Duration(seconds) * time.Second
+	} else {
+		timeout, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, sperr.New("'%s' is not a number of seconds or a duration", value)
+		}
+	}
+	if timeout < 0 {
+		return 0, sperr.New("'%s' must not be negative", value)
+	}
+	return timeout, nil
+}
+
+// toQueryTimeoutError returns a QueryTimeoutError if the error was caused by the control query timeout
+// (rather than the parent context, e.g. the benchmark timeout), and whether it was
+func (r *ControlRun) toQueryTimeoutError(ctx context.Context, err error) (error, bool) {
+	if r.queryTimeout == 0 || ctx.Err() != nil {
+		return err, false
+	}
+	err = db_client.NewQueryTimeoutError(err, r.queryTimeout)
+	var timeoutErr *db_client.QueryTimeoutError
+	return err, errors.As(err, &timeoutErr)
+}
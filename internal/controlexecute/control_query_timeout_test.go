@@ -0,0 +1,37 @@
+package controlexecute
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQueryTimeout(t *testing.T) {
+	tests := map[string]struct {
+		value string
+		want  time.Duration
+		err   bool
+	}{
+		"seconds":      {value: "90", want: 90 * time.Second},
+		"duration":     {value: "1m30s", want: 90 * time.Second},
+		"whitespace":   {value: " 30s ", want: 30 * time.Second},
+		"zero":         {value: "0", want: 0},
+		"negative":     {value: "-5", err: true},
+		"not a number": {value: "soon", err: true},
+	}
+	for name, test := range tests {
+		got, err := parseQueryTimeout(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %v, got %v", name, test.want, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package controlexecute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -65,6 +66,16 @@ type ControlRun struct {
 	Parents []*ResultGroup `json:"-"`
 	// execution tree
 	Tree *ExecutionTree `json:"-"`
+	// the final SQL and args executed for the control query (these are not set if the results were restored from a
+	// checkpoint or the result cache)
+	ExecutedSQL string `json:"-"`
+	QueryArgs   []any  `json:"-"`
+	// the query plan of the control query - this is only set if the tree query plan mode is set
+	QueryPlan *QueryPlan `json:"-"`
+	// set if the result rows were truncated to the control row limit (see ArgControlMaxRows) - TotalRows is the number
+	// of rows returned by the control query
+	Truncated bool `json:"truncated,omitempty"`
+	TotalRows int  `json:"total_rows,omitempty"`
 	// save run error as string for JSON export
 	RunErrorString string `json:"error,omitempty"`
 	runError       error
@@ -75,6 +86,14 @@ type ControlRun struct {
 	doneChan    chan bool
 	attempts    int
 	startTime   time.Time
+	// the control query timeout - zero if there is no control specific timeout
+	queryTimeout time.Duration
+	// the maximum number of result rows kept - zero if there is no limit
+	maxRows int
+	// the number of result rows received
+	rowCount int
+	// is the control excluded by the control filter - if so it is skipped rather than executed
+	filtered bool
 }
 
 // ResultRowInstance is used in ControlRunInstance, to store the single ResultRow and
@@ -162,6 +181,19 @@ func (r *ControlRun) GetStatusSummary() *controlstatus.StatusSummary {
 	return r.Summary
 }
 
+// GetPath implements ControlRunStatusProvider
+// it returns the names of the groups containing the control (outermost first, excluding the root group), followed
+// by the control name - if the control is in more than one group, the path of the first group is used
+func (r *ControlRun) GetPath() []string {
+	path := []string{r.ControlId}
+	if len(r.Parents) > 0 {
+		for group := r.Parents[0]; group != nil && group.GroupId != RootResultGroupName; group = group.Parent {
+			path = append([]string{group.GroupId}, path...)
+		}
+	}
+	return path
+}
+
 func (r *ControlRun) Finished() bool {
 	return r.GetRunStatus().IsFinished()
 }
@@ -176,6 +208,11 @@ func (r *ControlRun) GetError() error {
 	return r.runError
 }
 
+// IsFiltered returns whether the control was excluded by the control filter (and so is skipped rather than executed)
+func (r *ControlRun) IsFiltered() bool {
+	return r.filtered
+}
+
 // IsSnapshotPanel implements SnapshotPanel
 func (*ControlRun) IsSnapshotPanel() {}
 
@@ -207,7 +244,10 @@ func (r *ControlRun) setError(ctx context.Context, err error) {
 		return
 	}
 
-	if err.Error() == context.DeadlineExceeded.Error() {
+	var timeoutErr *db_client.QueryTimeoutError
+	if errors.As(err, &timeoutErr) {
+		r.runError = fmt.Errorf("control query timed out after %s", timeoutErr.Timeout)
+	} else if err.Error() == context.DeadlineExceeded.Error() {
 		// had the control started?
 		if r.RunStatus == dashboardtypes.RunRunning {
 			r.runError = fmt.Errorf("control execution timed out after running for %0.2fs", time.Since(r.startTime).Seconds())
@@ -236,6 +276,18 @@ func (r *ControlRun) skip(ctx context.Context) {
 	r.setRunStatus(ctx, dashboardtypes.RunComplete)
 }
 
+// skipFiltered completes a control excluded by the control filter, with a single skip result
+func (r *ControlRun) skipFiltered(ctx context.Context) {
+	r.addResultRow(&ResultRow{
+		Reason:  "Control excluded by the control filter",
+		Status:  constants.ControlSkip,
+		Run:     r,
+		Control: r.Control,
+	})
+	r.createdOrderedResultRows()
+	r.setRunStatus(ctx, dashboardtypes.RunComplete)
+}
+
 func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 	utils.LogTime("ControlRun.execute start")
 	defer utils.LogTime("ControlRun.execute end")
@@ -278,6 +330,19 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 		}
 	}()
 
+	if r.filtered {
+		slog.Debug("skipping control excluded by the control filter", "name", r.Control.Name())
+		r.skipFiltered(ctx)
+		return
+	}
+
+	// if the control was completed by the run being resumed, use the persisted results
+	if checkpointed := r.Tree.checkpoint.completedControl(r.FullName); checkpointed != nil {
+		slog.Debug("restoring control results from checkpoint", "name", r.Control.Name())
+		r.restoreFromCheckpoint(ctx, checkpointed)
+		return
+	}
+
 	// resolve the control query
 	resolvedQuery, err := r.resolveControlQuery(control)
 	if err != nil {
@@ -285,8 +350,37 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 		return
 	}
 
+	// if there is a row limit, result rows beyond it are discarded
+	r.maxRows, err = r.resolveMaxRows()
+	if err != nil {
+		r.setError(ctx, err)
+		return
+	}
+
+	// if the results of the control query are cached, use the cached results rather than executing the query
+	cacheKey := r.resultCacheKey(client, resolvedQuery)
+	if cached, ok := r.Tree.resultCache.get(cacheKey); ok && r.matchesRowLimit(cached) {
+		slog.Debug("restoring control results from the result cache", "name", r.Control.Name())
+		r.restoreFromCheckpoint(ctx, cached)
+		r.recordResults("")
+		return
+	}
+
 	controlExecutionCtx := r.getControlQueryContext(ctx)
 
+	// if there is a control query timeout, the db client applies this to the query
+	r.queryTimeout, err = r.resolveQueryTimeout()
+	if err != nil {
+		r.setError(ctx, err)
+		return
+	}
+	if r.queryTimeout > 0 {
+		controlExecutionCtx = db_client.ContextWithQueryTimeout(controlExecutionCtx, r.queryTimeout)
+	}
+
+	r.ExecutedSQL = resolvedQuery.ExecuteSQL
+	r.QueryArgs = resolvedQuery.Args
+
 	// execute the control query
 	// NOTE no need to pass an OnComplete callback - we are already closing our session after waiting for results
 	slog.Debug("execute start", "name", r.Control.Name())
@@ -294,6 +388,10 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 	slog.Debug("execute finish", "name", r.Control.Name())
 
 	if err != nil {
+		if timeoutErr, isTimeout := r.toQueryTimeoutError(ctx, err); isTimeout {
+			r.setError(ctx, timeoutErr)
+			return
+		}
 		r.attempts++
 
 		// is this an rpc EOF error - meaning that the plugin somehow crashed
@@ -317,6 +415,31 @@ func (r *ControlRun) execute(ctx context.Context, client *db_client.DbClient) {
 	slog.Debug("wait result", "name", r.Control.Name())
 	r.waitForResults(ctx)
 	slog.Debug("finish result", "name", r.Control.Name())
+
+	if r.GetRunStatus() == dashboardtypes.RunComplete {
+		r.recordResults(cacheKey)
+		r.captureQueryPlan(controlExecutionCtx, client)
+	}
+}
+
+// recordResults persists the results of the completed control run to the checkpoint, so the control is not re-run
+// if this run is resumed, and (if a cache key is given) to the result cache
+func (r *ControlRun) recordResults(cacheKey string) {
+	if err := r.Tree.checkpoint.recordControl(r); err != nil {
+		slog.Warn("failed to checkpoint control results", "name", r.Control.Name(), "error", err)
+	}
+	if err := r.Tree.resultCache.put(cacheKey, r); err != nil {
+		slog.Warn("failed to cache control results", "name", r.Control.Name(), "error", err)
+	}
+}
+
+// resultCacheKey returns the key used to cache the results of the resolved control query
+// (an empty key is returned if there is no result cache)
+func (r *ControlRun) resultCacheKey(client *db_client.DbClient, resolvedQuery *modconfig.ResolvedQuery) string {
+	if r.Tree.resultCache == nil {
+		return ""
+	}
+	return resultCacheKey(client.GetConnectionString(), r.Tree.SearchPath, resolvedQuery.ExecuteSQL, resolvedQuery.Args)
 }
 
 // create a context with status updates disabled (we do not want to show 'loading' results)
@@ -355,6 +478,13 @@ func (r *ControlRun) waitForResults(ctx context.Context) {
 				r.createdOrderedResultRows()
 				return
 			}
+			// if the query was cancelled by the control query timeout, the control is in error
+			if row.Error != nil {
+				if timeoutErr, isTimeout := r.toQueryTimeoutError(ctx, row.Error); isTimeout {
+					r.setError(ctx, timeoutErr)
+					return
+				}
+			}
 			// create a result row
 			result, err := NewResultRow(r, row, r.queryResult.Cols)
 			if err != nil {
@@ -391,10 +521,12 @@ func (r *ControlRun) getDimensionSchema() map[string]*queryresult.ColumnDef {
 	return dimensionsSchema
 }
 
-// add the result row to our results and update the summary with the row status
+// add the result row to our results (unless the row limit has been reached) and update the summary with the row status
 func (r *ControlRun) addResultRow(row *ResultRow) {
 	// update results
-	r.rowMap[row.Status] = append(r.rowMap[row.Status], row)
+	if r.keepResultRow() {
+		r.rowMap[row.Status] = append(r.rowMap[row.Status], row)
+	}
 
 	// update summary
 	switch row.Status {
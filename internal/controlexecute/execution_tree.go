@@ -11,6 +11,7 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/workspace"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controlstatus"
 	"github.com/turbot/powerpipe/internal/db_client"
 	"golang.org/x/sync/semaphore"
@@ -34,6 +35,16 @@ type ExecutionTree struct {
 	client              *db_client.DbClient
 	// an optional map of control names used to filter the controls which are run
 	controlNameFilterMap map[string]struct{}
+	// an optional checkpoint, used to persist the results of completed controls and restore those of a previous run
+	checkpoint *Checkpoint
+	// an optional cache of control results, used rather than executing a control query whose results are cached
+	resultCache *ResultCache
+	// the maximum number of control queries to execute concurrently - if this is not set, ArgMaxParallel is used
+	maxParallel int
+	// the order used to rank control severities (most severe first) - if this is not set, DefaultSeverityOrder is used
+	severityOrder []string
+	// whether the query plan of each control query is captured
+	queryPlanMode QueryPlanMode
 }
 
 func NewExecutionTree(ctx context.Context, workspace *workspace.Workspace, client *db_client.DbClient, controlFilter workspace.ResourceFilter, targets ...modconfig.ModTreeItem) (*ExecutionTree, error) {
@@ -45,8 +56,11 @@ func NewExecutionTree(ctx context.Context, workspace *workspace.Workspace, clien
 	}
 
 	// if backend supports search path, get it
-	if sp, ok := client.Backend.(backend.SearchPathProvider); ok {
-		executionTree.SearchPath = sp.RequiredSearchPath()
+	// (the client may be nil if no resources require a database)
+	if client != nil {
+		if sp, ok := client.Backend.(backend.SearchPathProvider); ok {
+			executionTree.SearchPath = sp.RequiredSearchPath()
+		}
 	}
 
 	// if a "--where" or "--tag" parameter was passed, build a map of control names used to filter the controls to run
@@ -90,6 +104,23 @@ func (tree *ExecutionTree) PopulateControlRunInstances() {
 	tree.ControlRunInstances = controlRunInstances
 }
 
+// SetCheckpoint sets the checkpoint used to persist the results of each control as it completes - if the checkpoint
+// was resumed, controls completed by the previous run use the persisted results rather than being executed
+func (e *ExecutionTree) SetCheckpoint(checkpoint *Checkpoint) {
+	e.checkpoint = checkpoint
+}
+
+// SetResultCache sets the cache used to store control results - a control whose query results are cached
+// uses these rather than executing the query
+func (e *ExecutionTree) SetResultCache(cache *ResultCache) {
+	e.resultCache = cache
+}
+
+// SetMaxParallel sets the maximum number of control queries to execute concurrently
+func (e *ExecutionTree) SetMaxParallel(maxParallel int) {
+	e.maxParallel = maxParallel
+}
+
 // IsExportSourceData implements ExportSourceData
 func (*ExecutionTree) IsExportSourceData() {}
 
@@ -97,7 +128,9 @@ func (*ExecutionTree) IsExportSourceData() {}
 // if so, creates a ControlRun, which is added to the parent group
 func (e *ExecutionTree) AddControl(ctx context.Context, control *modconfig.Control, group *ResultGroup) error {
 	// note we use short name to determine whether to include a control
-	if e.ShouldIncludeControl(control.Name()) {
+	// (if requested, controls excluded by the control filter are included, but are skipped rather than executed)
+	include := e.ShouldIncludeControl(control.Name())
+	if include || viper.GetBool(localconstants.ArgIncludeFiltered) {
 		// check if we have a run already
 		var controlRun *ControlRun
 		controlRun, ok := e.ControlRuns[control.FullName]
@@ -115,6 +148,7 @@ func (e *ExecutionTree) AddControl(ctx context.Context, control *modconfig.Contr
 			if err != nil {
 				return err
 			}
+			controlRun.filtered = !include
 			// add it to the map
 			e.ControlRuns[control.FullName] = controlRun
 		}
@@ -138,7 +172,9 @@ func (e *ExecutionTree) Execute(ctx context.Context) error {
 
 	// the number of goroutines parallel to start
 	var maxParallelGoRoutines int64 = constants.DefaultMaxConnections
-	if viper.IsSet(constants.ArgMaxParallel) {
+	if e.maxParallel > 0 {
+		maxParallelGoRoutines = int64(e.maxParallel)
+	} else if viper.IsSet(constants.ArgMaxParallel) {
 		maxParallelGoRoutines = viper.GetInt64(constants.ArgMaxParallel)
 	}
 
@@ -156,6 +192,9 @@ func (e *ExecutionTree) Execute(ctx context.Context) error {
 	e.DimensionColorGenerator, _ = NewDimensionColorGenerator(4, 27)
 	e.DimensionColorGenerator.populate(e)
 
+	// determine the worst severity of the failing controls
+	e.Root.Summary.SeverityRollup = e.buildSeverityRollup()
+
 	return nil
 }
 
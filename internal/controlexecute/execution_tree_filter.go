@@ -0,0 +1,130 @@
+package controlexecute
+
+import (
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// FilterFailed returns a copy of the tree containing only failed control results (i.e. with status alarm or error).
+// Controls with no failed results (and which did not fail to run), and groups with no failed controls, are excluded.
+// NOTE: the tree must have been executed. Summaries are not modified, so still describe the full execution
+func (e *ExecutionTree) FilterFailed() *ExecutionTree {
+	res := *e
+	res.ControlRuns = make(map[string]*ControlRun)
+
+	// a control run may have multiple parents - track the filtered copies so each control run is only copied once
+	filteredRuns := make(map[*ControlRun]*ControlRun)
+	res.Root = e.Root.filterFailed(nil, &res, filteredRuns)
+	if res.Root == nil {
+		// nothing failed - retain an empty root group
+		root := *e.Root
+		root.Groups = nil
+		root.ControlRuns = nil
+		root.Children = nil
+		res.Root = &root
+	}
+
+	// if the control run instances have been populated for the source tree, populate them for the filtered tree
+	res.ControlRunInstances = nil
+	if len(e.ControlRunInstances) > 0 {
+		res.PopulateControlRunInstances()
+	}
+	return &res
+}
+
+// filterFailed returns a copy of the group containing only failed control runs, or nil if there are none
+func (r *ResultGroup) filterFailed(parent *ResultGroup, tree *ExecutionTree, filteredRuns map[*ControlRun]*ControlRun) *ResultGroup {
+	res := *r
+	res.Parent = parent
+	res.Groups = nil
+	res.ControlRuns = nil
+	res.Children = nil
+
+	for _, child := range r.Children {
+		switch c := child.(type) {
+		case *ResultGroup:
+			if filteredGroup := c.filterFailed(&res, tree, filteredRuns); filteredGroup != nil {
+				res.Groups = append(res.Groups, filteredGroup)
+				res.Children = append(res.Children, filteredGroup)
+			}
+		case *ControlRun:
+			filteredRun, ok := filteredRuns[c]
+			if !ok {
+				filteredRun = c.filterFailed(tree)
+				filteredRuns[c] = filteredRun
+				if filteredRun != nil {
+					tree.ControlRuns[filteredRun.FullName] = filteredRun
+				}
+			}
+			if filteredRun != nil {
+				filteredRun.Parents = append(filteredRun.Parents, &res)
+				res.ControlRuns = append(res.ControlRuns, filteredRun)
+				res.Children = append(res.Children, filteredRun)
+			}
+		}
+	}
+
+	if len(res.Children) == 0 {
+		return nil
+	}
+	return &res
+}
+
+// filterFailed returns a copy of the control run containing only failed rows
+// if the control run failed to run, it is returned with no rows
+// if the control run has no failed rows, nil is returned
+func (r *ControlRun) filterFailed(tree *ExecutionTree) *ControlRun {
+	res := r.copyForTree(tree)
+
+	for _, row := range r.Rows {
+		if isFailedStatus(row.Status) {
+			filteredRow := *row
+			filteredRow.Run = res
+			res.Rows = append(res.Rows, &filteredRow)
+		}
+	}
+	if len(res.Rows) == 0 && r.runError == nil && r.RunErrorString == "" {
+		return nil
+	}
+
+	if r.Data != nil {
+		res.Data = &dashboardtypes.LeafData{Columns: r.Data.Columns}
+		for _, row := range r.Data.Rows {
+			if status, ok := row["status"].(string); ok && isFailedStatus(status) {
+				res.Data.Rows = append(res.Data.Rows, row)
+			}
+		}
+	}
+	return res
+}
+
+func isFailedStatus(status string) bool {
+	return status == constants.ControlAlarm || status == constants.ControlError
+}
+
+// copyForTree returns a copy of the control run for the tree, without the result rows, data or parents
+func (r *ControlRun) copyForTree(tree *ExecutionTree) *ControlRun {
+	return &ControlRun{
+		ControlId:      r.ControlId,
+		FullName:       r.FullName,
+		Title:          r.Title,
+		Description:    r.Description,
+		Documentation:  r.Documentation,
+		Tags:           r.Tags,
+		Display:        r.Display,
+		Type:           r.Type,
+		Severity:       r.Severity,
+		NodeType:       r.NodeType,
+		Control:        r.Control,
+		Properties:     r.Properties,
+		Summary:        r.Summary,
+		RunStatus:      r.RunStatus,
+		DimensionKeys:  r.DimensionKeys,
+		Duration:       r.Duration,
+		Tree:           tree,
+		Truncated:      r.Truncated,
+		TotalRows:      r.TotalRows,
+		RunErrorString: r.RunErrorString,
+		runError:       r.runError,
+	}
+}
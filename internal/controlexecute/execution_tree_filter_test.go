@@ -0,0 +1,95 @@
+package controlexecute
+
+import (
+	"testing"
+)
+
+func newTestControlRun(name string, statuses ...string) *ControlRun {
+	r := &ControlRun{FullName: name}
+	for _, s := range statuses {
+		r.Rows = append(r.Rows, &ResultRow{Status: s, Run: r})
+	}
+	return r
+}
+
+func newTestResultGroup(name string, parent *ResultGroup, children ...ExecutionTreeNode) *ResultGroup {
+	g := &ResultGroup{GroupId: name, Parent: parent}
+	for _, child := range children {
+		switch c := child.(type) {
+		case *ResultGroup:
+			c.Parent = g
+			g.Groups = append(g.Groups, c)
+		case *ControlRun:
+			c.Parents = append(c.Parents, g)
+			g.ControlRuns = append(g.ControlRuns, c)
+		}
+		g.Children = append(g.Children, child)
+	}
+	return g
+}
+
+func TestExecutionTreeFilterFailed(t *testing.T) {
+	passing := newTestControlRun("control.passing", "ok", "info", "skip")
+	failing := newTestControlRun("control.failing", "ok", "alarm", "error")
+	errored := newTestControlRun("control.errored")
+	errored.RunErrorString = "query failed"
+
+	passingGroup := newTestResultGroup("benchmark.passing", nil, passing)
+	failingGroup := newTestResultGroup("benchmark.failing", nil, failing, errored)
+	root := newTestResultGroup(RootResultGroupName, nil, passingGroup, failingGroup)
+
+	tree := &ExecutionTree{
+		Root: root,
+		ControlRuns: map[string]*ControlRun{
+			passing.FullName: passing,
+			failing.FullName: failing,
+			errored.FullName: errored,
+		},
+	}
+
+	filtered := tree.FilterFailed()
+
+	if len(filtered.Root.Groups) != 1 || filtered.Root.Groups[0].GroupId != "benchmark.failing" {
+		t.Fatalf("expected only the failing group to be retained, got %d groups", len(filtered.Root.Groups))
+	}
+	if _, ok := filtered.ControlRuns[passing.FullName]; ok {
+		t.Errorf("passing control should have been excluded")
+	}
+	filteredFailing, ok := filtered.ControlRuns[failing.FullName]
+	if !ok {
+		t.Fatalf("failing control should have been retained")
+	}
+	if len(filteredFailing.Rows) != 2 {
+		t.Errorf("expected 2 failed rows, got %d", len(filteredFailing.Rows))
+	}
+	for _, row := range filteredFailing.Rows {
+		if row.Run != filteredFailing {
+			t.Errorf("filtered row should reference the filtered control run")
+		}
+	}
+	if filteredFailing.Parents[0] != filtered.Root.Groups[0] {
+		t.Errorf("filtered control run should reference the filtered group as parent")
+	}
+	if _, ok := filtered.ControlRuns[errored.FullName]; !ok {
+		t.Errorf("errored control should have been retained")
+	}
+
+	// the source tree should be unchanged
+	if len(root.Groups) != 2 || len(failing.Rows) != 3 || len(tree.ControlRuns) != 3 {
+		t.Errorf("source tree should not be modified")
+	}
+}
+
+func TestExecutionTreeFilterFailedNoFailures(t *testing.T) {
+	passing := newTestControlRun("control.passing", "ok")
+	root := newTestResultGroup(RootResultGroupName, nil, newTestResultGroup("benchmark.passing", nil, passing))
+	tree := &ExecutionTree{
+		Root:        root,
+		ControlRuns: map[string]*ControlRun{passing.FullName: passing},
+	}
+
+	filtered := tree.FilterFailed()
+	if filtered.Root == nil || len(filtered.Root.Children) != 0 || len(filtered.ControlRuns) != 0 {
+		t.Errorf("expected an empty root group when there are no failures")
+	}
+}
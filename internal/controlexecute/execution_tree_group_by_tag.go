@@ -0,0 +1,139 @@
+package controlexecute
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+// UntaggedGroupId is the id of the group containing the controls which do not have the tag, when a tree is grouped
+// by tag (see GroupByTag)
+const UntaggedGroupId = "untagged"
+
+// GroupByTag returns a copy of the tree with the control runs regrouped by the value of a tag, rather than by the
+// benchmark hierarchy - the root group has a child group for each value of the tag (sorted by value), containing the
+// controls with that value, followed by an "untagged" group containing the controls which do not have the tag.
+// Each control appears once, even if it is a child of more than one benchmark, and the group summaries are
+// recalculated accordingly.
+// NOTE: the tree must have been executed
+func (e *ExecutionTree) GroupByTag(key string) *ExecutionTree {
+	res := *e
+	res.ControlRuns = make(map[string]*ControlRun)
+
+	root := &ResultGroup{
+		GroupId:     e.Root.GroupId,
+		Title:       e.Root.Title,
+		Description: e.Root.Description,
+		Tags:        e.Root.Tags,
+		Summary:     NewGroupSummary(),
+		Severity:    make(map[string]controlstatus.StatusSummary),
+		NodeType:    e.Root.NodeType,
+		GroupItem:   e.Root.GroupItem,
+		Duration:    e.Root.Duration,
+		updateLock:  new(sync.Mutex),
+	}
+	// the severity rollup is of the distinct failing controls, so is unchanged by regrouping
+	root.Summary.SeverityRollup = e.Root.Summary.SeverityRollup
+	res.Root = root
+
+	tagGroups := make(map[string]*ResultGroup)
+	var untaggedGroup *ResultGroup
+	for _, run := range e.Root.distinctControlRuns() {
+		var group *ResultGroup
+		if value := run.Tags[key]; value == "" {
+			if untaggedGroup == nil {
+				untaggedGroup = newTagResultGroup(root, UntaggedGroupId, "Untagged", nil)
+			}
+			group = untaggedGroup
+		} else {
+			group = tagGroups[value]
+			if group == nil {
+				group = newTagResultGroup(root, fmt.Sprintf("%s:%s", key, value), value, map[string]string{key: value})
+				tagGroups[value] = group
+			}
+		}
+		groupedRun := run.copyForGroup(&res, group)
+		res.ControlRuns[groupedRun.FullName] = groupedRun
+	}
+
+	values := make([]string, 0, len(tagGroups))
+	for value := range tagGroups {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		root.addResultGroup(tagGroups[value])
+	}
+	if untaggedGroup != nil {
+		root.addResultGroup(untaggedGroup)
+	}
+
+	// if the control run instances have been populated for the source tree, populate them for the grouped tree
+	res.ControlRunInstances = nil
+	if len(e.ControlRunInstances) > 0 {
+		res.PopulateControlRunInstances()
+	}
+	return &res
+}
+
+func newTagResultGroup(parent *ResultGroup, groupId, title string, tags map[string]string) *ResultGroup {
+	return &ResultGroup{
+		GroupId:    groupId,
+		Title:      title,
+		Tags:       tags,
+		Parent:     parent,
+		Summary:    NewGroupSummary(),
+		Severity:   make(map[string]controlstatus.StatusSummary),
+		NodeType:   schema.BlockTypeBenchmark,
+		updateLock: new(sync.Mutex),
+	}
+}
+
+// distinctControlRuns returns the control runs of the group and its descendants, in tree order - a control run which
+// is a child of more than one group is only returned once
+func (r *ResultGroup) distinctControlRuns() []*ControlRun {
+	var res []*ControlRun
+	seen := make(map[*ControlRun]struct{})
+	var walk func(group *ResultGroup)
+	walk = func(group *ResultGroup) {
+		for _, child := range group.Children {
+			switch c := child.(type) {
+			case *ResultGroup:
+				walk(c)
+			case *ControlRun:
+				if _, ok := seen[c]; !ok {
+					seen[c] = struct{}{}
+					res = append(res, c)
+				}
+			}
+		}
+	}
+	walk(r)
+	return res
+}
+
+// copyForGroup returns a copy of the control run (including its results) for the tree, added to the group - the group
+// (and its ancestors) summaries and dimension keys are updated with those of the control run
+func (r *ControlRun) copyForGroup(tree *ExecutionTree, group *ResultGroup) *ControlRun {
+	res := r.copyForTree(tree)
+	res.Data = r.Data
+	for _, row := range r.Rows {
+		groupedRow := *row
+		groupedRow.Run = res
+		res.Rows = append(res.Rows, &groupedRow)
+	}
+	res.Parents = []*ResultGroup{group}
+	group.addControl(res)
+
+	if res.Summary != nil {
+		group.updateSummary(res.Summary)
+		if len(res.Severity) != 0 {
+			group.updateSeverityCounts(res.Severity, res.Summary)
+		}
+	}
+	group.addDimensionKeys(res.DimensionKeys...)
+	return res
+}
@@ -0,0 +1,72 @@
+package controlexecute
+
+import (
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+func TestExecutionTreeGroupByTag(t *testing.T) {
+	newRun := func(name string, tags map[string]string, summary controlstatus.StatusSummary) *ControlRun {
+		r := newTestControlRun(name, "ok")
+		r.Tags = tags
+		r.Summary = &summary
+		return r
+	}
+	a := newRun("control.a", map[string]string{"cis_item_id": "1.2"}, controlstatus.StatusSummary{Ok: 1})
+	b := newRun("control.b", map[string]string{"cis_item_id": "1.1"}, controlstatus.StatusSummary{Alarm: 2})
+	c := newRun("control.c", map[string]string{"cis_item_id": "1.2"}, controlstatus.StatusSummary{Ok: 3})
+	d := newRun("control.d", map[string]string{"service": "s3"}, controlstatus.StatusSummary{Error: 1})
+
+	// control a is a child of both benchmarks
+	root := newTestResultGroup(RootResultGroupName, nil,
+		newTestResultGroup("benchmark.one", nil, a, b),
+		newTestResultGroup("benchmark.two", nil, a, c, d))
+	root.Summary = NewGroupSummary()
+	tree := &ExecutionTree{
+		Root:        root,
+		ControlRuns: map[string]*ControlRun{a.FullName: a, b.FullName: b, c.FullName: c, d.FullName: d},
+	}
+
+	grouped := tree.GroupByTag("cis_item_id")
+
+	var groupIds []string
+	for _, g := range grouped.Root.Groups {
+		groupIds = append(groupIds, g.GroupId)
+	}
+	expectedGroupIds := []string{"cis_item_id:1.1", "cis_item_id:1.2", UntaggedGroupId}
+	if len(groupIds) != len(expectedGroupIds) {
+		t.Fatalf("expected groups %v - got %v", expectedGroupIds, groupIds)
+	}
+	for i := range expectedGroupIds {
+		if groupIds[i] != expectedGroupIds[i] {
+			t.Fatalf("expected groups %v - got %v", expectedGroupIds, groupIds)
+		}
+	}
+
+	// each control appears once
+	group := grouped.Root.Groups[1]
+	if len(group.ControlRuns) != 2 || group.ControlRuns[0].FullName != "control.a" || group.ControlRuns[1].FullName != "control.c" {
+		t.Errorf("expected controls a and c in group %s", group.GroupId)
+	}
+	if group.Title != "1.2" || group.Summary.Status.Ok != 4 {
+		t.Errorf("unexpected title %q or summary %+v for group %s", group.Title, group.Summary.Status, group.GroupId)
+	}
+	if untagged := grouped.Root.Groups[2]; len(untagged.ControlRuns) != 1 || untagged.ControlRuns[0].FullName != "control.d" {
+		t.Errorf("expected control d in the untagged group")
+	}
+	if s := grouped.Root.Summary.Status; s.Ok != 4 || s.Alarm != 2 || s.Error != 1 {
+		t.Errorf("unexpected root summary %+v", s)
+	}
+	if len(grouped.ControlRuns) != 4 {
+		t.Errorf("expected 4 control runs - got %d", len(grouped.ControlRuns))
+	}
+	if run := grouped.ControlRuns["control.a"]; len(run.Parents) != 1 || run.Parents[0] != group || run.Rows[0].Run != run {
+		t.Errorf("expected the grouped control run to be parented by its tag group")
+	}
+
+	// the source tree is unchanged
+	if len(tree.Root.Groups) != 2 || len(a.Parents) != 2 {
+		t.Errorf("expected the source tree to be unchanged")
+	}
+}
@@ -0,0 +1,147 @@
+package controlexecute
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+// the number of fixed (non dimension) columns of the control run data - see ResultRows.ToLeafData
+const fixedLeafDataColumns = 3
+
+// Sorted returns a copy of the tree in a deterministic order, so repeated runs with unchanged results produce identical
+// exports, regardless of the order the controls were executed in:
+//   - the children of each group are sorted by name (so groups are ordered by benchmark path, then control name) -
+//     the untagged group of a tree grouped by tag (see GroupByTag) is kept last
+//   - the result rows of each control run are sorted by status, then resource, reason and dimension values
+//   - the dimension columns of the control run data are sorted by name
+//   - the control run instances (if populated for the source tree) are in the order of the sorted tree
+//
+// NOTE: the tree must have been executed
+func (e *ExecutionTree) Sorted() *ExecutionTree {
+	res := *e
+	res.ControlRuns = make(map[string]*ControlRun)
+
+	// a control run may have multiple parents - track the sorted copies so each control run is only copied once
+	sortedRuns := make(map[*ControlRun]*ControlRun)
+	res.Root = e.Root.sorted(nil, &res, sortedRuns)
+
+	res.ControlRunInstances = nil
+	if len(e.ControlRunInstances) > 0 {
+		// PopulateControlRunInstances iterates the control run map, so instead walk the sorted tree
+		res.Root.appendControlRunInstances(&res.ControlRunInstances)
+	}
+	return &res
+}
+
+// sorted returns a copy of the group with its children (recursively) sorted by name
+func (r *ResultGroup) sorted(parent *ResultGroup, tree *ExecutionTree, sortedRuns map[*ControlRun]*ControlRun) *ResultGroup {
+	res := *r
+	res.Parent = parent
+	res.Groups = nil
+	res.ControlRuns = nil
+	res.Children = nil
+
+	children := make([]ExecutionTreeNode, len(r.Children))
+	copy(children, r.Children)
+	sort.SliceStable(children, func(i, j int) bool {
+		a, b := sortName(children[i]), sortName(children[j])
+		if a == UntaggedGroupId || b == UntaggedGroupId {
+			return b == UntaggedGroupId && a != UntaggedGroupId
+		}
+		return a < b
+	})
+
+	for _, child := range children {
+		switch c := child.(type) {
+		case *ResultGroup:
+			res.addResultGroup(c.sorted(&res, tree, sortedRuns))
+		case *ControlRun:
+			sortedRun, ok := sortedRuns[c]
+			if !ok {
+				sortedRun = c.sorted(tree)
+				sortedRuns[c] = sortedRun
+				tree.ControlRuns[sortedRun.FullName] = sortedRun
+			}
+			sortedRun.Parents = append(sortedRun.Parents, &res)
+			res.addControl(sortedRun)
+		}
+	}
+	return &res
+}
+
+// sortName returns the name used to sort the node - the group id of a group, or the full name of a control run
+func sortName(node ExecutionTreeNode) string {
+	if r, ok := node.(*ControlRun); ok {
+		return r.FullName
+	}
+	return node.GetName()
+}
+
+// appendControlRunInstances appends an instance of each control run of the group and its descendants, in tree order
+func (r *ResultGroup) appendControlRunInstances(instances *[]*ControlRunInstance) {
+	for _, child := range r.Children {
+		switch c := child.(type) {
+		case *ResultGroup:
+			c.appendControlRunInstances(instances)
+		case *ControlRun:
+			instance := NewControlRunInstance(c, r)
+			*instances = append(*instances, &instance)
+		}
+	}
+}
+
+// sorted returns a copy of the control run with its result rows and data sorted
+func (r *ControlRun) sorted(tree *ExecutionTree) *ControlRun {
+	res := r.copyForTree(tree)
+	for _, row := range r.Rows {
+		sortedRow := *row
+		sortedRow.Run = res
+		res.Rows = append(res.Rows, &sortedRow)
+	}
+	sort.SliceStable(res.Rows, func(i, j int) bool { return res.Rows[i].sortKey() < res.Rows[j].sortKey() })
+
+	if r.Data != nil {
+		// rebuild the data rows from the sorted rows, retaining the columns (with the dimension columns sorted)
+		res.Data = res.Rows.ToLeafData(nil)
+		res.Data.Columns = sortedLeafDataColumns(r.Data)
+	}
+	return res
+}
+
+// the status order of result rows - this is the order used by createdOrderedResultRows
+var resultRowStatusOrder = map[string]string{
+	constants.ControlError: "0",
+	constants.ControlAlarm: "1",
+	constants.ControlInfo:  "2",
+	constants.ControlOk:    "3",
+	constants.ControlSkip:  "4",
+}
+
+// sortKey returns the key used to sort result rows - the status (in status order), resource, reason and dimension values
+func (r *ResultRow) sortKey() string {
+	status, ok := resultRowStatusOrder[r.Status]
+	if !ok {
+		status = "5" + r.Status
+	}
+	fields := []string{status, r.Resource, r.Reason}
+	for _, d := range r.Dimensions {
+		fields = append(fields, d.Key, d.Value)
+	}
+	// separate the fields with a character which sorts before any printable character
+	return strings.Join(fields, "\x00")
+}
+
+// sortedLeafDataColumns returns the columns of the data, with the dimension columns sorted by name
+func sortedLeafDataColumns(data *dashboardtypes.LeafData) []*queryresult.ColumnDef {
+	columns := make([]*queryresult.ColumnDef, len(data.Columns))
+	copy(columns, data.Columns)
+	if len(columns) > fixedLeafDataColumns {
+		dimensions := columns[fixedLeafDataColumns:]
+		sort.SliceStable(dimensions, func(i, j int) bool { return dimensions[i].Name < dimensions[j].Name })
+	}
+	return columns
+}
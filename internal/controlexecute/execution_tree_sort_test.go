@@ -0,0 +1,99 @@
+package controlexecute
+
+import (
+	"testing"
+
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+func TestExecutionTreeSorted(t *testing.T) {
+	z := newTestControlRun("control.z", "ok", "alarm")
+	a := newTestControlRun("control.a", "ok")
+	a.Rows = append(a.Rows, &ResultRow{Status: "ok", Resource: "r1", Run: a})
+	a.Rows[0].Resource = "r2"
+	a.Data = &dashboardtypes.LeafData{Columns: []*queryresult.ColumnDef{
+		{Name: "reason"}, {Name: "resource"}, {Name: "status"}, {Name: "region"}, {Name: "account"},
+	}}
+
+	// control a is a child of both benchmarks
+	root := newTestResultGroup(RootResultGroupName, nil,
+		newTestResultGroup("benchmark.two", nil, z, a),
+		newTestResultGroup("benchmark.one", nil, a))
+	tree := &ExecutionTree{
+		Root:        root,
+		ControlRuns: map[string]*ControlRun{z.FullName: z, a.FullName: a},
+	}
+	tree.PopulateControlRunInstances()
+
+	sorted := tree.Sorted()
+
+	if g := sorted.Root.Groups; len(g) != 2 || g[0].GroupId != "benchmark.one" || g[1].GroupId != "benchmark.two" {
+		t.Fatalf("expected the groups to be sorted by name")
+	}
+	two := sorted.Root.Groups[1]
+	if c := two.ControlRuns; len(c) != 2 || c[0].FullName != "control.a" || c[1].FullName != "control.z" {
+		t.Errorf("expected the control runs to be sorted by name")
+	}
+	if c := two.Children; sortName(c[0]) != "control.a" || sortName(c[1]) != "control.z" {
+		t.Errorf("expected the children to be sorted by name")
+	}
+
+	// rows are sorted by status, then resource
+	if rows := sorted.ControlRuns["control.z"].Rows; rows[0].Status != "alarm" || rows[1].Status != "ok" {
+		t.Errorf("expected the rows to be sorted by status")
+	}
+	sortedA := sorted.ControlRuns["control.a"]
+	if rows := sortedA.Rows; rows[0].Resource != "r1" || rows[1].Resource != "r2" || rows[0].Run != sortedA {
+		t.Errorf("expected the rows to be sorted by resource")
+	}
+	if rows := sortedA.Data.Rows; len(rows) != 2 || rows[0]["resource"] != "r1" {
+		t.Errorf("expected the data rows to be sorted")
+	}
+	var columns []string
+	for _, c := range sortedA.Data.Columns {
+		columns = append(columns, c.Name)
+	}
+	if len(columns) != 5 || columns[3] != "account" || columns[4] != "region" {
+		t.Errorf("expected the dimension columns to be sorted - got %v", columns)
+	}
+	if len(sortedA.Parents) != 2 || sortedA.Parents[0].GroupId != "benchmark.one" {
+		t.Errorf("expected the sorted control run to be parented by the sorted groups")
+	}
+
+	// instances are in the order of the sorted tree
+	var instances []string
+	for _, i := range sorted.ControlRunInstances {
+		instances = append(instances, i.Group.GroupId+"/"+i.FullName)
+	}
+	expected := []string{"benchmark.one/control.a", "benchmark.two/control.a", "benchmark.two/control.z"}
+	if len(instances) != len(expected) {
+		t.Fatalf("expected instances %v - got %v", expected, instances)
+	}
+	for i := range expected {
+		if instances[i] != expected[i] {
+			t.Fatalf("expected instances %v - got %v", expected, instances)
+		}
+	}
+
+	// the source tree is unchanged
+	if root.Groups[0].GroupId != "benchmark.two" || a.Rows[0].Resource != "r2" {
+		t.Errorf("expected the source tree to be unchanged")
+	}
+}
+
+func TestExecutionTreeSortedKeepsUntaggedGroupLast(t *testing.T) {
+	root := newTestResultGroup(RootResultGroupName, nil,
+		newTestResultGroup("zone:b", nil, newTestControlRun("control.b", "ok")),
+		newTestResultGroup(UntaggedGroupId, nil, newTestControlRun("control.c", "ok")),
+		newTestResultGroup("zone:a", nil, newTestControlRun("control.a", "ok")))
+	tree := &ExecutionTree{Root: root, ControlRuns: map[string]*ControlRun{}}
+
+	var groups []string
+	for _, g := range tree.Sorted().Root.Groups {
+		groups = append(groups, g.GroupId)
+	}
+	if len(groups) != 3 || groups[0] != "zone:a" || groups[1] != "zone:b" || groups[2] != UntaggedGroupId {
+		t.Errorf("expected the untagged group to be last - got %v", groups)
+	}
+}
@@ -0,0 +1,99 @@
+package controlexecute
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// QueryPlanMode determines whether the query plan of each control query is captured when the tree is executed
+type QueryPlanMode int
+
+const (
+	// QueryPlanNone does not capture query plans
+	QueryPlanNone QueryPlanMode = iota
+	// QueryPlanExplain captures the planned query plan (EXPLAIN)
+	QueryPlanExplain
+	// QueryPlanExplainAnalyze captures the actual query plan (EXPLAIN ANALYZE) - this executes each control query again
+	QueryPlanExplainAnalyze
+)
+
+// QueryPlan is the query plan of a control query
+type QueryPlan struct {
+	// the statement used to get the plan, e.g. EXPLAIN ANALYZE
+	Statement string `json:"statement,omitempty"`
+	// the lines of the plan
+	Plan []string `json:"plan,omitempty"`
+	// set if the plan could not be retrieved - this does not affect the control results
+	Error string `json:"error,omitempty"`
+}
+
+// SetQueryPlanMode sets whether the query plan of each executed control query is captured (see ControlRun.QueryPlan)
+// controls whose results are restored from a checkpoint or the result cache do not have a query plan
+func (e *ExecutionTree) SetQueryPlanMode(mode QueryPlanMode) {
+	e.queryPlanMode = mode
+}
+
+// explainPrefix returns the statement prefix used to get the query plan for the backend
+func explainPrefix(backendName string, mode QueryPlanMode) (string, error) {
+	switch backendName {
+	case constants.SQLiteBackendName:
+		if mode == QueryPlanExplainAnalyze {
+			return "", sperr.New("the %s backend does not support EXPLAIN ANALYZE", backendName)
+		}
+		return "EXPLAIN QUERY PLAN", nil
+	default:
+		// postgres, steampipe, duckdb and mysql
+		if mode == QueryPlanExplainAnalyze {
+			return "EXPLAIN ANALYZE", nil
+		}
+		return "EXPLAIN", nil
+	}
+}
+
+// captureQueryPlan gets the query plan of the executed control query, if the tree query plan mode is set
+// a failure is stored on the plan, rather than failing the control
+func (r *ControlRun) captureQueryPlan(ctx context.Context, client *db_client.DbClient) {
+	mode := r.Tree.queryPlanMode
+	if mode == QueryPlanNone {
+		return
+	}
+
+	prefix, err := explainPrefix(client.Backend.Name(), mode)
+	if err != nil {
+		r.QueryPlan = &QueryPlan{Error: err.Error()}
+		return
+	}
+	r.QueryPlan = &QueryPlan{Statement: prefix}
+	result, err := client.ExecuteSync(ctx, fmt.Sprintf("%s %s", prefix, r.ExecutedSQL), r.QueryArgs...)
+	if err != nil {
+		slog.Debug("failed to get the control query plan", "name", r.Control.Name(), "error", err)
+		r.QueryPlan.Error = err.Error()
+		return
+	}
+	r.QueryPlan.Plan = queryPlanLines(result)
+}
+
+// queryPlanLines returns a line of the plan for each row of the EXPLAIN result
+// (if the result has more than one column, e.g. for sqlite, the column values are separated by tabs)
+func queryPlanLines(result *queryresult.SyncQueryResult) []string {
+	lines := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		rowResult, ok := row.(*queryresult.RowResult)
+		if !ok {
+			continue
+		}
+		values := make([]string, len(rowResult.Data))
+		for idx, value := range rowResult.Data {
+			values[idx] = fmt.Sprintf("%v", value)
+		}
+		lines = append(lines, strings.Join(values, "\t"))
+	}
+	return lines
+}
@@ -0,0 +1,45 @@
+package controlexecute
+
+import (
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/queryresult"
+)
+
+func TestExplainPrefix(t *testing.T) {
+	tests := []struct {
+		backend  string
+		mode     QueryPlanMode
+		expected string
+		wantErr  bool
+	}{
+		{backend: constants.PostgresBackendName, mode: QueryPlanExplain, expected: "EXPLAIN"},
+		{backend: constants.SteampipeBackendName, mode: QueryPlanExplainAnalyze, expected: "EXPLAIN ANALYZE"},
+		{backend: constants.SQLiteBackendName, mode: QueryPlanExplain, expected: "EXPLAIN QUERY PLAN"},
+		{backend: constants.SQLiteBackendName, mode: QueryPlanExplainAnalyze, wantErr: true},
+	}
+	for _, test := range tests {
+		prefix, err := explainPrefix(test.backend, test.mode)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.backend, prefix)
+			}
+			continue
+		}
+		if err != nil || prefix != test.expected {
+			t.Errorf("%s: expected %s, got %s (%v)", test.backend, test.expected, prefix, err)
+		}
+	}
+}
+
+func TestQueryPlanLines(t *testing.T) {
+	result := &queryresult.SyncQueryResult{Rows: []any{
+		&queryresult.RowResult{Data: []any{"Seq Scan on t"}},
+		&queryresult.RowResult{Data: []any{int64(2), int64(0), "SCAN t"}},
+	}}
+	lines := queryPlanLines(result)
+	if len(lines) != 2 || lines[0] != "Seq Scan on t" || lines[1] != "2\t0\tSCAN t" {
+		t.Errorf("unexpected plan lines %q", lines)
+	}
+}
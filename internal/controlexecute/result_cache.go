@@ -0,0 +1,149 @@
+package controlexecute
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const resultCacheDirName = "control_cache"
+
+// resultCacheEntry is a cached control result, with the time it was cached
+type resultCacheEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	checkpointControl
+}
+
+// ResultCache caches the results of control queries on disk, so that a control which is run again within the TTL
+// uses the cached results rather than executing its query.
+//
+// Results are keyed by the control query text and args, and the database and search path the query is executed with,
+// so a change to any of these results in a cache miss. Each entry is stored in its own file, named by the key
+type ResultCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// DefaultResultCacheDir returns the directory used to store the control result cache
+func DefaultResultCacheDir() string {
+	return filepath.Join(filepaths.EnsureInternalDir(), resultCacheDirName)
+}
+
+// NewResultCache creates a cache of control results stored in the given directory, which expire after the given TTL
+func NewResultCache(dir string, ttl time.Duration) (*ResultCache, error) {
+	if ttl <= 0 {
+		return nil, sperr.New("the control result cache TTL must be greater than zero")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to create control result cache directory")
+	}
+	return &ResultCache{dir: dir, ttl: ttl}, nil
+}
+
+// ClearResultCache removes all cached control results from the given directory
+func ClearResultCache(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to read control result cache directory")
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return sperr.WrapWithMessage(err, "failed to clear control result cache")
+		}
+	}
+	return nil
+}
+
+// resultCacheKey returns the cache key for a control query - if the args cannot be serialised, the query cannot be
+// cached and an empty key is returned
+func resultCacheKey(connectionString string, searchPath []string, query string, args []any) string {
+	keyData, err := json.Marshal(struct {
+		ConnectionString string   `json:"connection_string"`
+		SearchPath       []string `json:"search_path"`
+		Query            string   `json:"query"`
+		Args             []any    `json:"args"`
+	}{connectionString, searchPath, query, args})
+	if err != nil {
+		slog.Debug("control query args cannot be serialised - not using the result cache", "error", err)
+		return ""
+	}
+	hash := sha256.Sum256(keyData)
+	return hex.EncodeToString(hash[:])
+}
+
+func (c *ResultCache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get returns the cached result for the key, if there is one which has not expired
+// (a nil ResultCache has no cached results)
+func (c *ResultCache) get(key string) (*checkpointControl, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("failed to read control result cache entry", "key", key, "error", err)
+		}
+		return nil, false
+	}
+	var entry resultCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		slog.Debug("invalid control result cache entry", "key", key, "error", err)
+		return nil, false
+	}
+	if time.Since(entry.CreatedAt) > c.ttl {
+		// remove the expired entry
+		os.Remove(c.entryPath(key))
+		return nil, false
+	}
+	return &entry.checkpointControl, true
+}
+
+// put caches the results of the completed control run
+func (c *ResultCache) put(key string, run *ControlRun) error {
+	if c == nil || key == "" {
+		return nil
+	}
+	data, err := json.Marshal(resultCacheEntry{
+		CreatedAt:         time.Now(),
+		checkpointControl: *newCheckpointControl(run),
+	})
+	if err != nil {
+		return err
+	}
+	// write to a temporary file and rename it, so a concurrent reader never sees a partially written entry
+	// (the temporary file name is unique, as controls with the same query may complete concurrently)
+	file, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to write control result cache entry")
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.entryPath(key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return sperr.WrapWithMessage(err, "failed to write control result cache entry")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package controlexecute
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResultCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewResultCache(dir, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := resultCacheKey("postgres://localhost/db", []string{"aws"}, "select $1", []any{"a"})
+	if _, ok := cache.get(key); ok {
+		t.Fatalf("expected a cache miss for an empty cache")
+	}
+
+	run := newCheckpointTestRun("mod.control.c1")
+	run.Rows = ResultRows{
+		{Reason: "bucket is public", Resource: "b1", Status: "alarm", Dimensions: []Dimension{{Key: "region", Value: "us-east-1", SqlType: "TEXT"}}},
+	}
+	if err := cache.put(key, run); err != nil {
+		t.Fatal(err)
+	}
+	cached, ok := cache.get(key)
+	if !ok {
+		t.Fatalf("expected a cache hit")
+	}
+	if cached.Name != "mod.control.c1" || len(cached.Rows) != 1 || cached.Rows[0].Dimensions[0].SqlType != "TEXT" {
+		t.Errorf("cached result does not match the control run: %+v", cached)
+	}
+
+	// a change to the query, args, database or search path is a different key
+	for _, otherKey := range []string{
+		resultCacheKey("postgres://localhost/db", []string{"aws"}, "select $1", []any{"b"}),
+		resultCacheKey("postgres://localhost/db", []string{"aws"}, "select $1 ", []any{"a"}),
+		resultCacheKey("postgres://localhost/other", []string{"aws"}, "select $1", []any{"a"}),
+		resultCacheKey("postgres://localhost/db", []string{"gcp"}, "select $1", []any{"a"}),
+	} {
+		if otherKey == key {
+			t.Errorf("expected a different cache key")
+		}
+		if _, ok := cache.get(otherKey); ok {
+			t.Errorf("expected a cache miss for a different key")
+		}
+	}
+
+	// expired results are not used, and are removed
+	expired := &ResultCache{dir: dir, ttl: time.Nanosecond}
+	time.Sleep(time.Millisecond)
+	if _, ok := expired.get(key); ok {
+		t.Errorf("expected a cache miss for an expired result")
+	}
+	if _, err := os.Stat(filepath.Join(dir, key+".json")); !os.IsNotExist(err) {
+		t.Errorf("expected the expired result to be removed")
+	}
+
+	// clearing the cache removes all results
+	if err := cache.put(key, run); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearResultCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.get(key); ok {
+		t.Errorf("expected a cache miss after clearing the cache")
+	}
+	if err := ClearResultCache(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("unexpected error clearing a cache which does not exist: %v", err)
+	}
+}
+
+func TestNilResultCache(t *testing.T) {
+	var cache *ResultCache
+	if _, ok := cache.get("key"); ok {
+		t.Errorf("nil cache should have no cached results")
+	}
+	if err := cache.put("key", newCheckpointTestRun("mod.control.c1")); err != nil {
+		t.Errorf("unexpected error caching to a nil cache: %v", err)
+	}
+	if _, err := NewResultCache(t.TempDir(), 0); err == nil {
+		t.Errorf("expected an error creating a cache with no TTL")
+	}
+}
@@ -61,6 +61,8 @@ type ResultGroup struct {
 type GroupSummary struct {
 	Status   controlstatus.StatusSummary            `json:"status"`
 	Severity map[string]controlstatus.StatusSummary `json:"-"`
+	// the worst severity of the failing controls - this is only set for the root group, once the tree has executed
+	SeverityRollup *SeverityRollup `json:"severity_rollup,omitempty"`
 }
 
 func NewGroupSummary() *GroupSummary {
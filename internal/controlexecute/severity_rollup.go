@@ -0,0 +1,80 @@
+package controlexecute
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// the control tag which gives the severity of a control - if a control has no severity tag, the severity property is used
+const severityTag = "severity"
+
+// DefaultSeverityOrder is the order used to rank control severities if no order is set, from most to least severe
+var DefaultSeverityOrder = []string{"critical", "high", "medium", "low", "info"}
+
+// SeverityRollup is the worst severity among the failing (alarm or error) controls of a run
+type SeverityRollup struct {
+	// the worst severity of the failing controls
+	Severity string `json:"severity"`
+	// the failing controls with this severity, sorted by name
+	Controls []string `json:"controls"`
+}
+
+// TagSeverity returns the severity of the control - this is the severity tag, if the control has one,
+// otherwise the severity property
+func (r *ControlRun) TagSeverity() string {
+	if severity := r.Tags[severityTag]; severity != "" {
+		return severity
+	}
+	return r.Severity
+}
+
+// SetSeverityOrder sets the order used to rank control severities, from most to least severe
+// if this is not set, DefaultSeverityOrder is used
+func (e *ExecutionTree) SetSeverityOrder(severityOrder []string) {
+	e.severityOrder = severityOrder
+}
+
+// SeverityRollup returns the severity rollup of the run, or nil if no control with a ranked severity failed
+// (this is populated once the tree has executed)
+func (e *ExecutionTree) SeverityRollup() *SeverityRollup {
+	if e.Root == nil || e.Root.Summary == nil {
+		return nil
+	}
+	return e.Root.Summary.SeverityRollup
+}
+
+// buildSeverityRollup returns the worst severity among the failing controls of the tree, using the severity order
+// severities are compared case insensitively - the severities of failing controls which are not in the order are ignored
+func (e *ExecutionTree) buildSeverityRollup() *SeverityRollup {
+	severityOrder := e.severityOrder
+	if len(severityOrder) == 0 {
+		severityOrder = DefaultSeverityOrder
+	}
+	rank := func(severity string) int {
+		return slices.IndexFunc(severityOrder, func(s string) bool { return strings.EqualFold(s, severity) })
+	}
+
+	var rollup *SeverityRollup
+	worstRank := -1
+	for _, run := range e.ControlRuns {
+		if run.Summary == nil || run.Summary.FailedCount() == 0 {
+			continue
+		}
+		severity := run.TagSeverity()
+		runRank := rank(severity)
+		switch {
+		case runRank == -1:
+			continue
+		case rollup == nil || runRank < worstRank:
+			rollup = &SeverityRollup{Severity: severityOrder[runRank], Controls: []string{run.ControlId}}
+			worstRank = runRank
+		case runRank == worstRank:
+			rollup.Controls = append(rollup.Controls, run.ControlId)
+		}
+	}
+	if rollup != nil {
+		sort.Strings(rollup.Controls)
+	}
+	return rollup
+}
@@ -0,0 +1,42 @@
+package controlexecute
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+func TestBuildSeverityRollup(t *testing.T) {
+	newRun := func(controlId, severity string, tags map[string]string, summary controlstatus.StatusSummary) *ControlRun {
+		return &ControlRun{ControlId: controlId, Severity: severity, Tags: tags, Summary: &summary}
+	}
+	tree := &ExecutionTree{ControlRuns: map[string]*ControlRun{
+		// the severity tag takes precedence over the severity property
+		"c1": newRun("control.c1", "low", map[string]string{"severity": "High"}, controlstatus.StatusSummary{Alarm: 1}),
+		"c2": newRun("control.c2", "high", nil, controlstatus.StatusSummary{Error: 1}),
+		// passing controls are ignored
+		"c3": newRun("control.c3", "critical", nil, controlstatus.StatusSummary{Ok: 3}),
+		"c4": newRun("control.c4", "medium", nil, controlstatus.StatusSummary{Alarm: 2}),
+		// severities which are not in the order are ignored
+		"c5": newRun("control.c5", "urgent", nil, controlstatus.StatusSummary{Alarm: 1}),
+	}}
+
+	rollup := tree.buildSeverityRollup()
+	if rollup == nil || rollup.Severity != "high" || !slices.Equal(rollup.Controls, []string{"control.c1", "control.c2"}) {
+		t.Errorf("expected high severity rollup for c1 and c2, got %+v", rollup)
+	}
+
+	// the order is configurable
+	tree.SetSeverityOrder([]string{"urgent", "medium", "high"})
+	rollup = tree.buildSeverityRollup()
+	if rollup == nil || rollup.Severity != "urgent" || !slices.Equal(rollup.Controls, []string{"control.c5"}) {
+		t.Errorf("expected urgent severity rollup for c5, got %+v", rollup)
+	}
+
+	// no failing controls with a ranked severity
+	tree.SetSeverityOrder([]string{"critical"})
+	if rollup = tree.buildSeverityRollup(); rollup != nil {
+		t.Errorf("expected no severity rollup, got %+v", rollup)
+	}
+}
@@ -0,0 +1,243 @@
+package controlexecute
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// ParseTagFilter parses a tag filter expression, returning a predicate which determines whether a resource matches it
+//
+// The expression is made up of tag comparisons of the form key=value or key!=value, which may be combined
+// using AND, OR, NOT and parentheses, e.g. service=s3 AND (severity=high OR severity=critical)
+// - keywords are case-insensitive, and AND takes precedence over OR
+// - values containing spaces or parentheses must be quoted, e.g. title='Public buckets'
+// - a comparison against a tag the resource does not have is false for '=' and true for '!='
+// - if a control has no 'severity' tag, its severity property is compared instead
+func ParseTagFilter(expression string) (func(modconfig.HclResource) bool, error) {
+	tokens, err := tokenizeTagFilter(expression)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "invalid tag filter '%s'", expression)
+	}
+	if len(tokens) == 0 {
+		return nil, sperr.New("invalid tag filter '%s': the filter is empty", expression)
+	}
+
+	p := &tagFilterParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = sperr.New("unexpected '%s'", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "invalid tag filter '%s'", expression)
+	}
+
+	return func(resource modconfig.HclResource) bool {
+		return node.evaluate(resource)
+	}, nil
+}
+
+type tagFilterNode interface {
+	evaluate(resource modconfig.HclResource) bool
+}
+
+type tagFilterAnd struct{ left, right tagFilterNode }
+
+func (n tagFilterAnd) evaluate(resource modconfig.HclResource) bool {
+	return n.left.evaluate(resource) && n.right.evaluate(resource)
+}
+
+type tagFilterOr struct{ left, right tagFilterNode }
+
+func (n tagFilterOr) evaluate(resource modconfig.HclResource) bool {
+	return n.left.evaluate(resource) || n.right.evaluate(resource)
+}
+
+type tagFilterNot struct{ node tagFilterNode }
+
+func (n tagFilterNot) evaluate(resource modconfig.HclResource) bool {
+	return !n.node.evaluate(resource)
+}
+
+type tagFilterComparison struct {
+	key      string
+	value    string
+	notEqual bool
+}
+
+func (n tagFilterComparison) evaluate(resource modconfig.HclResource) bool {
+	value, ok := resource.GetTags()[n.key]
+	if !ok && n.key == "severity" {
+		if control, isControl := resource.(*modconfig.Control); isControl && control.Severity != nil {
+			value, ok = *control.Severity, true
+		}
+	}
+	equal := ok && value == n.value
+	return equal != n.notEqual
+}
+
+type tagFilterTokenType int
+
+const (
+	tagFilterTokenWord tagFilterTokenType = iota
+	tagFilterTokenString
+	tagFilterTokenEqual
+	tagFilterTokenNotEqual
+	tagFilterTokenOpen
+	tagFilterTokenClose
+)
+
+type tagFilterToken struct {
+	tokenType tagFilterTokenType
+	text      string
+}
+
+// isKeyword returns whether the token is the given (case-insensitive) keyword
+func (t tagFilterToken) isKeyword(keyword string) bool {
+	return t.tokenType == tagFilterTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func tokenizeTagFilter(expression string) ([]tagFilterToken, error) {
+	var tokens []tagFilterToken
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, tagFilterToken{tagFilterTokenOpen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, tagFilterToken{tagFilterTokenClose, ")"})
+			i++
+		case r == '=':
+			tokens = append(tokens, tagFilterToken{tagFilterTokenEqual, "="})
+			i++
+		case r == '!':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, sperr.New("expected '=' after '!'")
+			}
+			tokens = append(tokens, tagFilterToken{tagFilterTokenNotEqual, "!="})
+			i += 2
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, sperr.New("unterminated string %s", string(runes[i:]))
+			}
+			tokens = append(tokens, tagFilterToken{tagFilterTokenString, string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()=!'\"", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, tagFilterToken{tagFilterTokenWord, string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// tagFilterParser is a recursive descent parser for tag filter expressions
+type tagFilterParser struct {
+	tokens []tagFilterToken
+	pos    int
+}
+
+func (p *tagFilterParser) peek() *tagFilterToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *tagFilterParser) parseOr() (tagFilterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t != nil && t.isKeyword("or"); t = p.peek() {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = tagFilterOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *tagFilterParser) parseAnd() (tagFilterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t != nil && t.isKeyword("and"); t = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = tagFilterAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *tagFilterParser) parseUnary() (tagFilterNode, error) {
+	t := p.peek()
+	switch {
+	case t == nil:
+		return nil, sperr.New("unexpected end of filter")
+	case t.isKeyword("not"):
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return tagFilterNot{node}, nil
+	case t.tokenType == tagFilterTokenOpen:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.peek(); t == nil || t.tokenType != tagFilterTokenClose {
+			return nil, sperr.New("missing ')'")
+		}
+		p.pos++
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *tagFilterParser) parseComparison() (tagFilterNode, error) {
+	key := p.peek()
+	if key.tokenType != tagFilterTokenWord || key.isKeyword("and") || key.isKeyword("or") {
+		return nil, sperr.New("expected a tag name, got '%s'", key.text)
+	}
+	p.pos++
+
+	operator := p.peek()
+	if operator == nil || (operator.tokenType != tagFilterTokenEqual && operator.tokenType != tagFilterTokenNotEqual) {
+		return nil, sperr.New("expected '=' or '!=' after '%s'", key.text)
+	}
+	p.pos++
+
+	value := p.peek()
+	if value == nil || (value.tokenType != tagFilterTokenWord && value.tokenType != tagFilterTokenString) {
+		return nil, sperr.New("expected a value for '%s'", key.text)
+	}
+	p.pos++
+
+	return tagFilterComparison{
+		key:      key.text,
+		value:    value.text,
+		notEqual: operator.tokenType == tagFilterTokenNotEqual,
+	}, nil
+}
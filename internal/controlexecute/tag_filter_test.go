@@ -0,0 +1,73 @@
+package controlexecute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+func newTagFilterTestControl(tags map[string]string, severity string) *modconfig.Control {
+	control := &modconfig.Control{}
+	control.Tags = tags
+	if severity != "" {
+		control.Severity = &severity
+	}
+	return control
+}
+
+func TestParseTagFilter(t *testing.T) {
+	s3High := newTagFilterTestControl(map[string]string{"service": "s3", "severity": "high"}, "")
+	s3Low := newTagFilterTestControl(map[string]string{"service": "s3"}, "low")
+	ec2High := newTagFilterTestControl(map[string]string{"service": "ec2", "cis": "true"}, "high")
+	untagged := newTagFilterTestControl(nil, "")
+
+	tests := []struct {
+		expression string
+		// the expected result for s3High, s3Low, ec2High and untagged
+		expected []bool
+	}{
+		{"service=s3", []bool{true, true, false, false}},
+		{"service=s3 AND severity=high", []bool{true, false, false, false}},
+		{"service=s3 and severity=high or cis=true", []bool{true, false, true, false}},
+		{"service=s3 AND (severity=high OR severity=low)", []bool{true, true, false, false}},
+		{"NOT service=s3", []bool{false, false, true, true}},
+		{"service!=s3", []bool{false, false, true, true}},
+		{"severity=high", []bool{true, false, true, false}},
+		{`service="s3"`, []bool{true, true, false, false}},
+	}
+	for _, test := range tests {
+		predicate, err := ParseTagFilter(test.expression)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expression, err)
+			continue
+		}
+		for i, control := range []*modconfig.Control{s3High, s3Low, ec2High, untagged} {
+			if actual := predicate(control); actual != test.expected[i] {
+				t.Errorf("%s: control %d expected %v, got %v", test.expression, i, test.expected[i], actual)
+			}
+		}
+	}
+
+	for _, expression := range []string{"", "service", "service=", "service=s3 AND", "(service=s3", "service=s3)", "service=s3 severity=high", "service!s3", "service='s3"} {
+		if _, err := ParseTagFilter(expression); err == nil {
+			t.Errorf("%s: expected an error", expression)
+		}
+	}
+}
+
+func TestSkipFiltered(t *testing.T) {
+	run := newCheckpointTestRun("mod.control.c1")
+	run.skipFiltered(context.Background())
+
+	if run.RunStatus != dashboardtypes.RunComplete {
+		t.Errorf("expected the run to be complete, got %s", run.RunStatus)
+	}
+	if run.Summary.Skip != 1 || run.Summary.TotalCount() != 1 {
+		t.Errorf("expected the summary to have a single skip, got %+v", run.Summary)
+	}
+	if len(run.Rows) != 1 || run.Rows[0].Status != "skip" {
+		t.Errorf("expected a single skip row, got %v", run.Rows)
+	}
+}
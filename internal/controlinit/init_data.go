@@ -10,9 +10,15 @@ import (
 	"github.com/turbot/pipe-fittings/error_helpers"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/statushooks"
+	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/pipe-fittings/workspace"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/controldisplay"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/export"
+	"github.com/turbot/powerpipe/internal/i18n"
 	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
 
 type CheckTarget interface {
@@ -31,7 +37,7 @@ type InitData[T CheckTarget] struct {
 // InitData.Done closes after asynchronous initialization completes
 func NewInitData[T CheckTarget](ctx context.Context, cmd *cobra.Command, args []string) *InitData[T] {
 
-	statushooks.SetStatus(ctx, "Loading workspace")
+	statushooks.SetStatus(ctx, i18n.Message(i18n.StatusLoadingWorkspace))
 
 	initData := initialisation.NewInitData[T](ctx, cmd, args...)
 
@@ -45,7 +51,7 @@ func NewInitData[T CheckTarget](ctx context.Context, cmd *cobra.Command, args []
 
 	w := i.Workspace
 	if !w.ModfileExists() {
-		i.Result.Error = workspace.ErrorNoModDefinition
+		i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeNoModFile, workspace.ErrorNoModDefinition)
 	}
 
 	if viper.GetString(constants.ArgOutput) == constants.OutputFormatNone {
@@ -55,47 +61,82 @@ func NewInitData[T CheckTarget](ctx context.Context, cmd *cobra.Command, args []
 	// set color schema
 	err := initialiseCheckColorScheme()
 	if err != nil {
-		i.Result.Error = err
+		i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeInvalidConfig, err)
 		return i
 	}
 
 	if len(w.GetResourceMaps().Controls)+len(w.GetResourceMaps().Benchmarks) == 0 {
-		i.Result.AddWarnings("no controls or benchmarks found in current workspace")
+		i.Result.AddWarnings(i18n.Message(i18n.WarningNoControls))
 	}
 
 	if err := controldisplay.EnsureTemplates(); err != nil {
-		i.Result.Error = err
+		i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeGeneral, err)
 		return i
 	}
 
 	if len(viper.GetStringSlice(constants.ArgExport)) > 0 {
 		if err := i.registerCheckExporters(); err != nil {
-			i.Result.Error = err
+			i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeGeneral, err)
 			return i
 		}
 
 		// validate required export formats
 		if err := i.ExportManager.ValidateExportFormat(viper.GetStringSlice(constants.ArgExport)); err != nil {
-			i.Result.Error = err
+			i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeInvalidConfig, err)
 			return i
 		}
+		i.ReserveStdoutForExport(viper.GetStringSlice(constants.ArgExport))
+
+		// if only failed controls should be exported, or the controls should be grouped by tag, filter the execution
+		// tree for all exports
+		var sourceFilters []export.SourceFilter
+		if viper.GetBool(localconstants.ArgExportOnlyFailed) {
+			sourceFilters = append(sourceFilters, filterFailedControls)
+		}
+		if tagKey := viper.GetString(localconstants.ArgExportGroupByTag); tagKey != "" {
+			sourceFilters = append(sourceFilters, groupControlsByTag(tagKey))
+		}
+		// sort last, so the sorted tree is not reordered by the other filters
+		if viper.GetBool(localconstants.ArgExportSorted) {
+			sourceFilters = append(sourceFilters, sortControls)
+		}
+		if len(sourceFilters) > 0 {
+			i.ExportManager.SetSourceFilter(export.ChainSourceFilters(sourceFilters...))
+		}
 	}
 
 	output := viper.GetString(constants.ArgOutput)
 	formatter, err := parseOutputArg(output)
 	if err != nil {
-		i.Result.Error = err
+		i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeInvalidConfig, err)
 		return i
 	}
 	i.OutputFormatter = formatter
 
-	i.setControlFilter()
+	if err := i.setControlFilter(); err != nil {
+		i.Result.Error = initialisation.NewInitError(initialisation.InitErrorCodeInvalidConfig, err)
+		return i
+	}
+
+	// report the effective control concurrency, as this may have been derived from the database pool size
+	if i.MaxParallel > 0 {
+		i.Result.AddMessage(fmt.Sprintf("Executing up to %d %s concurrently", i.MaxParallel, utils.Pluralize("control", i.MaxParallel)))
+	}
 
 	return i
 }
 
-func (i *InitData[T]) setControlFilter() {
-	if viper.IsSet(constants.ArgTag) {
+func (i *InitData[T]) setControlFilter() error {
+	if viper.IsSet(localconstants.ArgTagFilter) {
+		// if a '--tag-filter' expression was used, filter controls using a predicate built from the expression
+		predicate, err := controlexecute.ParseTagFilter(viper.GetString(localconstants.ArgTagFilter))
+		if err != nil {
+			return err
+		}
+		i.ControlFilter = workspace.ResourceFilter{
+			WherePredicate: predicate,
+		}
+	} else if viper.IsSet(constants.ArgTag) {
 		// if '--tag' args were used, derive the whereClause from them
 		tags := viper.GetStringSlice(constants.ArgTag)
 		i.ControlFilter = workspace.ResourceFilterFromTags(tags)
@@ -106,6 +147,37 @@ func (i *InitData[T]) setControlFilter() {
 			Where: viper.GetString(constants.ArgWhere),
 		}
 	}
+	return nil
+}
+
+// filterFailedControls is an export source filter which restricts the exported execution tree to failed controls
+func filterFailedControls(source export.ExportSourceData) (export.ExportSourceData, error) {
+	tree, ok := source.(*controlexecute.ExecutionTree)
+	if !ok {
+		return nil, sperr.New("cannot filter failed controls for export source of type %T", source)
+	}
+	return tree.FilterFailed(), nil
+}
+
+// groupControlsByTag returns an export source filter which regroups the controls of the exported execution tree by
+// the value of the tag
+func groupControlsByTag(key string) export.SourceFilter {
+	return func(source export.ExportSourceData) (export.ExportSourceData, error) {
+		tree, ok := source.(*controlexecute.ExecutionTree)
+		if !ok {
+			return nil, sperr.New("cannot group controls by tag for export source of type %T", source)
+		}
+		return tree.GroupByTag(key), nil
+	}
+}
+
+// sortControls is an export source filter which sorts the exported execution tree, so exports are deterministic
+func sortControls(source export.ExportSourceData) (export.ExportSourceData, error) {
+	tree, ok := source.(*controlexecute.ExecutionTree)
+	if !ok {
+		return nil, sperr.New("cannot sort controls for export source of type %T", source)
+	}
+	return tree.Sorted(), nil
 }
 
 // register exporters for each of the supported check formats
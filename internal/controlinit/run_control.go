@@ -0,0 +1,125 @@
+package controlinit
+
+import (
+	"context"
+	"time"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/initialisation"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// ControlResult is the result of running a single control
+type ControlResult struct {
+	// the full name of the control, e.g. mod.control.my_control
+	ControlName string
+	Title       string
+	Severity    string
+	// the overall status of the control - this is the most severe status of its result rows,
+	// or error if the control failed to run
+	Status  string
+	Summary controlstatus.StatusSummary
+	Rows    []ControlResultRow
+	// the error if the control failed to run
+	Error    error
+	Duration time.Duration
+}
+
+// ControlResultRow is the result of a control for a single resource
+type ControlResultRow struct {
+	Status     string
+	Reason     string
+	Resource   string
+	Dimensions []controlexecute.Dimension
+}
+
+// RunControl resolves the named control from the workspace of the initialised InitData and executes it
+// using the default client, returning the result
+//
+// The name may be qualified with the mod and resource type (mod.control.my_control), or just the resource type
+// (control.my_control), or may be the short name (my_control)
+// An error is only returned if the control cannot be resolved or executed - if the control query fails,
+// this is reported in ControlResult.Error
+func RunControl[T modconfig.ModTreeItem](ctx context.Context, i *initialisation.InitData[T], controlName string) (*ControlResult, error) {
+	if i.Result != nil && i.Result.Error != nil {
+		return nil, sperr.WrapWithMessage(i.Result.Error, "cannot run control - initialisation failed")
+	}
+	if i.Workspace == nil || i.DefaultClient == nil {
+		return nil, sperr.New("cannot run control - the workspace and database client must be initialised")
+	}
+
+	control, err := resolveControl(i.Workspace, controlName)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := controlexecute.NewExecutionTree(ctx, i.Workspace, i.DefaultClient, workspace.ResourceFilter{}, control)
+	if err != nil {
+		return nil, err
+	}
+	tree.SetMaxParallel(i.MaxParallel)
+	if err := tree.Execute(ctx); err != nil {
+		return nil, err
+	}
+
+	run, ok := tree.ControlRuns[control.Name()]
+	if !ok {
+		return nil, sperr.New("no result was returned for control '%s'", control.Name())
+	}
+	return newControlResult(run), nil
+}
+
+// resolveControl returns the control with the given name - if the name does not include the resource type,
+// it is added
+func resolveControl(w *workspace.Workspace, controlName string) (*modconfig.Control, error) {
+	parsedName, err := modconfig.ParseResourceName(controlName)
+	if err == nil && parsedName.ItemType == "" {
+		parsedName, err = modconfig.ParseResourceName(modconfig.BuildModResourceName(schema.BlockTypeControl, controlName))
+	}
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "invalid control name '%s'", controlName)
+	}
+	if parsedName.ItemType != schema.BlockTypeControl {
+		return nil, sperr.New("'%s' is not a control", controlName)
+	}
+
+	resource, found := w.GetResource(parsedName)
+	if !found || helpers.IsNil(resource) {
+		return nil, sperr.New("control '%s' not found in %s (%s)", controlName, w.Mod.Name(), w.Path)
+	}
+	control, ok := resource.(*modconfig.Control)
+	if !ok {
+		return nil, sperr.New("'%s' is not a control", controlName)
+	}
+	return control, nil
+}
+
+func newControlResult(run *controlexecute.ControlRun) *ControlResult {
+	res := &ControlResult{
+		ControlName: run.FullName,
+		Title:       run.Title,
+		Severity:    run.Severity,
+		Summary:     *run.Summary,
+		Status:      run.Summary.Status(),
+		Error:       run.GetError(),
+		Duration:    run.Duration,
+	}
+	if res.Error != nil {
+		res.Status = constants.ControlError
+	}
+	for _, row := range run.Rows {
+		res.Rows = append(res.Rows, ControlResultRow{
+			Status:     row.Status,
+			Reason:     row.Reason,
+			Resource:   row.Resource,
+			Dimensions: row.Dimensions,
+		})
+	}
+	return res
+}
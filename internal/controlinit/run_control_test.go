@@ -0,0 +1,101 @@
+package controlinit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/workspace"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/initialisation"
+)
+
+const runControlTestMod = `
+mod "test" {
+  title = "test"
+}
+
+control "buckets" {
+  title    = "Buckets"
+  severity = "high"
+  sql      = <<-EOQ
+    select 'b1' as resource, 'alarm' as status, 'b1 is public' as reason, 'us-east-1' as region
+    union all
+    select 'b2' as resource, 'ok' as status, 'b2 is private' as reason, 'us-east-2' as region
+  EOQ
+}
+
+control "broken" {
+  sql = "select * from missing_table"
+}
+`
+
+func TestRunControl(t *testing.T) {
+	ctx := context.Background()
+	app_specific.ModDataExtensions = []string{".pp"}
+	app_specific.VariablesExtensions = []string{".ppvars"}
+	app_specific.AutoVariablesExtensions = []string{".auto.ppvars"}
+	app_specific.DefaultVarsFileName = "powerpipe.ppvars"
+	app_specific.WorkspaceIgnoreFile = ".powerpipeignore"
+	app_specific.WorkspaceDataDir = ".powerpipe"
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mod.pp"), []byte(runControlTestMod), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx, dir)
+	if err := errAndWarnings.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(dir, "test.db")
+	if err := os.WriteFile(dbPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := db_client.NewDbClient(ctx, "sqlite://"+dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(ctx)
+
+	i := initialisation.NewInitDataWithWorkspace[*modconfig.Control](w)
+	i.SetClient(client)
+
+	for _, name := range []string{"buckets", "control.buckets", "test.control.buckets"} {
+		res, err := RunControl(ctx, i, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if res.ControlName != "test.control.buckets" || res.Status != "alarm" || res.Severity != "high" {
+			t.Errorf("%s: unexpected result %+v", name, res)
+		}
+		if res.Summary.Alarm != 1 || res.Summary.Ok != 1 || len(res.Rows) != 2 {
+			t.Fatalf("%s: expected 1 alarm and 1 ok row, got %+v", name, res.Summary)
+		}
+		row := res.Rows[0]
+		if row.Resource != "b1" || row.Reason != "b1 is public" || len(row.Dimensions) != 1 || row.Dimensions[0].Value != "us-east-1" {
+			t.Errorf("%s: unexpected row %+v", name, row)
+		}
+	}
+
+	// a failed control query is reported in the result
+	res, err := RunControl(ctx, i, "broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "error" || res.Error == nil {
+		t.Errorf("expected an error result, got %+v", res)
+	}
+
+	for _, name := range []string{"missing", "query.buckets", "a.b.c.d.e"} {
+		if _, err := RunControl(ctx, i, name); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := RunControl(ctx, initialisation.NewInitDataWithWorkspace[*modconfig.Control](w), "buckets"); err == nil || !strings.Contains(err.Error(), "must be initialised") {
+		t.Errorf("expected an error if there is no client, got %v", err)
+	}
+}
@@ -7,4 +7,5 @@ type ControlRunStatusProvider interface {
 	GetControlId() string
 	GetRunStatus() dashboardtypes.RunStatus
 	GetStatusSummary() *StatusSummary
+	GetPath() []string
 }
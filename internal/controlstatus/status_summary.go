@@ -1,5 +1,7 @@
 package controlstatus
 
+import "github.com/turbot/pipe-fittings/constants"
+
 // StatusSummary is a struct containing the counts of each possible control status
 type StatusSummary struct {
 	Alarm int `json:"alarm"`
@@ -28,3 +30,19 @@ func (s *StatusSummary) Merge(summary *StatusSummary) {
 	s.Skip += summary.Skip
 	s.Error += summary.Error
 }
+
+// Status returns the most severe status of the summarised results - if there are no results, this is skip
+func (s *StatusSummary) Status() string {
+	switch {
+	case s.Error > 0:
+		return constants.ControlError
+	case s.Alarm > 0:
+		return constants.ControlAlarm
+	case s.Ok > 0:
+		return constants.ControlOk
+	case s.Info > 0:
+		return constants.ControlInfo
+	default:
+		return constants.ControlSkip
+	}
+}
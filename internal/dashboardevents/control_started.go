@@ -0,0 +1,19 @@
+package dashboardevents
+
+import (
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+type ControlStarted struct {
+	Control     controlstatus.ControlRunStatusProvider
+	Progress    *controlstatus.ControlProgress
+	Name        string
+	Session     string
+	ExecutionId string
+	Timestamp   time.Time
+}
+
+// IsDashboardEvent implements DashboardEvent interface
+func (*ControlStarted) IsDashboardEvent() {}
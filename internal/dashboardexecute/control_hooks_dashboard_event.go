@@ -9,7 +9,7 @@ import (
 )
 
 // DashboardEventControlHooks is a struct which implements ControlHooks,
-// and raises ControlComplete and ControlError dashboard events
+// and raises ControlStarted, ControlComplete and ControlError dashboard events
 type DashboardEventControlHooks struct {
 	CheckRun *CheckRun
 }
@@ -24,7 +24,16 @@ func (c *DashboardEventControlHooks) OnStart(ctx context.Context, _ *controlstat
 	// nothing to do
 }
 
-func (c *DashboardEventControlHooks) OnControlStart(context.Context, controlstatus.ControlRunStatusProvider, *controlstatus.ControlProgress) {
+func (c *DashboardEventControlHooks) OnControlStart(ctx context.Context, controlRun controlstatus.ControlRunStatusProvider, progress *controlstatus.ControlProgress) {
+	event := &dashboardevents.ControlStarted{
+		Control:     controlRun,
+		Progress:    progress,
+		Name:        c.CheckRun.Name,
+		ExecutionId: c.CheckRun.executionTree.id,
+		Session:     c.CheckRun.SessionId,
+		Timestamp:   time.Now(),
+	}
+	c.CheckRun.executionTree.workspace.PublishDashboardEvent(ctx, event)
 }
 
 func (c *DashboardEventControlHooks) OnControlComplete(ctx context.Context, controlRun controlstatus.ControlRunStatusProvider, progress *controlstatus.ControlProgress) {
@@ -44,6 +44,8 @@ type DashboardExecutionTree struct {
 	// active database and search path config (unless overridden at the resource level)
 	database         string
 	searchPathConfig backend.SearchPathConfig
+	// false if the root resource contains no queries, i.e. it can be executed without a database client
+	requiresDatabase bool
 }
 
 func newDashboardExecutionTree(rootResource modconfig.ModTreeItem, sessionId string, workspace *dashboardworkspace.WorkspaceEvents, defaultClientMap *db_client.ClientMap, opts ...backend.ConnectOption) (*DashboardExecutionTree, error) {
@@ -72,9 +74,13 @@ func newDashboardExecutionTree(rootResource modconfig.ModTreeItem, sessionId str
 	executionTree.database = database
 	executionTree.searchPathConfig = searchPathConfig
 	// add a client for the active database and search path
-	_, err = executionTree.getClient(context.Background(), database, searchPathConfig)
-	if err != nil {
-		return nil, err
+	// (unless the root resource has no queries - in which case no client is needed)
+	executionTree.requiresDatabase = ResourceRequiresDatabase(rootResource)
+	if executionTree.requiresDatabase {
+		_, err = executionTree.getClient(context.Background(), database, searchPathConfig)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// create the root run node (either a report run or a counter run)
@@ -123,14 +129,16 @@ func (e *DashboardExecutionTree) Execute(ctx context.Context) {
 	workspace := e.workspace
 
 	// if the default database backend supports search path, retrieve it
-	defaultClient, err := e.getClient(ctx, e.database, e.searchPathConfig)
-	if err != nil {
-		e.SetError(ctx, err)
-		return
-	}
 	var searchPath []string
-	if sp, ok := defaultClient.Backend.(backend.SearchPathProvider); ok {
-		searchPath = sp.RequiredSearchPath()
+	if e.requiresDatabase {
+		defaultClient, err := e.getClient(ctx, e.database, e.searchPathConfig)
+		if err != nil {
+			e.SetError(ctx, err)
+			return
+		}
+		if sp, ok := defaultClient.Backend.(backend.SearchPathProvider); ok {
+			searchPath = sp.RequiredSearchPath()
+		}
 	}
 
 	// perform any necessary initialisation
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"golang.org/x/exp/maps"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/turbot/pipe-fittings/backend"
@@ -225,11 +226,20 @@ func (r *LeafRun) executeQuery(ctx context.Context) error {
 		return err
 	}
 
+	// warn if the query uses features which the backend does not support
+	unsupportedFeatures := client.Capabilities(ctx).UnsupportedFeatures(r.executeSQL, r.Args...)
+	if len(unsupportedFeatures) > 0 {
+		slog.Warn("LeafRun query uses features not supported by the database backend", "name", r.resource.Name(), "backend", client.Backend.Name(), "features", unsupportedFeatures)
+	}
+
 	startTime := time.Now()
 	queryResult, err := client.ExecuteSync(ctx, r.executeSQL, r.Args...)
 	if err != nil {
 		if err.Error() == context.DeadlineExceeded.Error() {
 			err = fmt.Errorf("query execution timed out after running for %0.2fs", time.Since(startTime).Seconds())
+		} else if len(unsupportedFeatures) > 0 {
+			// the failure is likely to be due to the unsupported features - include these in the error
+			err = fmt.Errorf("%w (the %s backend does not support %s)", err, client.Backend.Name(), strings.Join(unsupportedFeatures, ", "))
 		}
 		slog.Debug("LeafRun query failed", "name", r.resource.Name(), "error", err.Error())
 		return err
@@ -0,0 +1,48 @@
+package dashboardexecute
+
+import (
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// ResourceRequiresDatabase returns whether executing the given resource requires a database connection,
+// i.e. whether the resource, or any of its descendants, has a query or SQL to execute
+// (resources which only contain static content, such as text and images, can be executed without a database)
+func ResourceRequiresDatabase(item modconfig.ModTreeItem) bool {
+	if hclResourceRequiresDatabase(item) {
+		return true
+	}
+	for _, child := range item.GetChildren() {
+		if ResourceRequiresDatabase(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourcesRequireDatabase returns whether any of the resources in the given resource maps requires a database connection
+func ResourcesRequireDatabase(resourceMaps *modconfig.ResourceMaps) bool {
+	var requiresDatabase bool
+	// NOTE: the resource func never returns an error
+	_ = resourceMaps.WalkResources(func(resource modconfig.HclResource) (bool, error) {
+		requiresDatabase = hclResourceRequiresDatabase(resource)
+		// stop walking as soon as a resource requiring a database is found
+		return !requiresDatabase, nil
+	})
+	return requiresDatabase
+}
+
+// hclResourceRequiresDatabase returns whether the resource itself (ignoring any children) has a query or SQL,
+// or has a `with` block which has a query or SQL
+func hclResourceRequiresDatabase(resource modconfig.HclResource) bool {
+	if queryProvider, ok := resource.(modconfig.QueryProvider); ok && queryProvider.RequiresExecution(queryProvider) {
+		return true
+	}
+	if withProvider, ok := resource.(modconfig.WithProvider); ok {
+		for _, with := range withProvider.GetWiths() {
+			if with.RequiresExecution(with) {
+				return true
+			}
+		}
+	}
+	return false
+}
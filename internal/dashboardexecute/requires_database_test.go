@@ -0,0 +1,59 @@
+package dashboardexecute
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func newTestDashboard(mod *modconfig.Mod, children ...modconfig.ModTreeItem) *modconfig.Dashboard {
+	dashboard := modconfig.NewDashboard(&hcl.Block{Type: "dashboard", Labels: []string{"d1"}}, mod, "d1").(*modconfig.Dashboard)
+	dashboard.SetChildren(children)
+	return dashboard
+}
+
+func TestResourceRequiresDatabase(t *testing.T) {
+	mod := modconfig.NewMod("test", "", hcl.Range{})
+	text := modconfig.NewDashboardText(&hcl.Block{Type: "text", Labels: []string{"t1"}}, mod, "t1").(*modconfig.DashboardText)
+	staticCard := modconfig.NewDashboardCard(&hcl.Block{Type: "card", Labels: []string{"c1"}}, mod, "c1").(*modconfig.DashboardCard)
+	queryCard := modconfig.NewDashboardCard(&hcl.Block{Type: "card", Labels: []string{"c2"}}, mod, "c2").(*modconfig.DashboardCard)
+	sql := "select 1 as value"
+	queryCard.SQL = &sql
+
+	tests := map[string]struct {
+		resource modconfig.ModTreeItem
+		expected bool
+	}{
+		"static dashboard":                 {resource: newTestDashboard(mod, text, staticCard), expected: false},
+		"dashboard with query":             {resource: newTestDashboard(mod, text, queryCard), expected: true},
+		"nested dashboard with query":      {resource: newTestDashboard(mod, newTestDashboard(mod, queryCard)), expected: true},
+		"card with sql":                    {resource: queryCard, expected: true},
+		"empty dashboard":                  {resource: newTestDashboard(mod), expected: false},
+		"nested dashboard without queries": {resource: newTestDashboard(mod, newTestDashboard(mod, text)), expected: false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ResourceRequiresDatabase(test.resource); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestResourcesRequireDatabase(t *testing.T) {
+	mod := modconfig.NewMod("test", "", hcl.Range{})
+	text := modconfig.NewDashboardText(&hcl.Block{Type: "text", Labels: []string{"t1"}}, mod, "t1").(*modconfig.DashboardText)
+	mod.ResourceMaps.DashboardTexts[text.FullName] = text
+	if ResourcesRequireDatabase(mod.ResourceMaps) {
+		t.Errorf("expected resources containing only text to not require a database")
+	}
+
+	query := modconfig.NewQuery(&hcl.Block{Type: "query", Labels: []string{"q1"}}, mod, "q1").(*modconfig.Query)
+	sql := "select 1"
+	query.SQL = &sql
+	mod.ResourceMaps.Queries[query.FullName] = query
+	if !ResourcesRequireDatabase(mod.ResourceMaps) {
+		t.Errorf("expected resources containing a query to require a database")
+	}
+}
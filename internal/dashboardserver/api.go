@@ -17,7 +17,7 @@ import (
 	"gopkg.in/olahol/melody.v1"
 )
 
-func startAPIAsync(ctx context.Context, webSocket *melody.Melody) chan struct{} {
+func startAPIAsync(ctx context.Context, webSocket *melody.Melody, progressEvents *progressEventBus) chan struct{} {
 	doneChan := make(chan struct{})
 
 	go func() {
@@ -34,6 +34,9 @@ func startAPIAsync(ctx context.Context, webSocket *melody.Melody) chan struct{}
 			webSocket.HandleRequest(c.Writer, c.Request) //nolint:errcheck // TODO: fix this
 		})
 
+		// stream control progress events to clients as server-sent events
+		router.GET(ProgressEventsPath, progressEvents.serveSSE)
+
 		router.NoRoute(func(c *gin.Context) {
 			// https://stackoverflow.com/questions/49547/how-do-we-control-web-page-caching-across-all-browsers
 			c.Header("Cache-Control", "no-cache, no-store, must-revalidate") // HTTP 1.1.
@@ -0,0 +1,148 @@
+package dashboardserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+)
+
+const (
+	// ProgressEventsPath is the path of the server-sent events endpoint which streams control progress events
+	ProgressEventsPath = "/api/events/progress"
+
+	progressEventControlStarted   = "control_started"
+	progressEventControlCompleted = "control_completed"
+
+	// the number of events buffered for each subscriber - if a subscriber falls this far behind, events are dropped
+	progressEventBufferSize = 256
+	// the interval at which a comment is sent to idle subscribers, so proxies do not close the connection
+	progressEventKeepAliveInterval = 15 * time.Second
+)
+
+// ProgressEvent is a control progress event, streamed to subscribers of the progress events endpoint
+type ProgressEvent struct {
+	Type        string `json:"type"`
+	ExecutionId string `json:"execution_id"`
+	// the name of the executing benchmark (or control)
+	Name string `json:"name"`
+	// the names of the groups containing the control (outermost first), followed by the control name
+	ControlPath []string `json:"control_path"`
+	// 'running' for a started control, otherwise the control status, e.g. ok, alarm or error
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// newProgressEvent builds the progress event for a dashboard event, returning false if the event is not a
+// control progress event
+func newProgressEvent(event dashboardevents.DashboardEvent) (ProgressEvent, bool) {
+	newControlEvent := func(eventType, status string, control controlstatus.ControlRunStatusProvider, name, executionId string, timestamp time.Time) ProgressEvent {
+		return ProgressEvent{
+			Type:        eventType,
+			ExecutionId: executionId,
+			Name:        name,
+			ControlPath: control.GetPath(),
+			Status:      status,
+			Timestamp:   timestamp,
+		}
+	}
+	switch e := event.(type) {
+	case *dashboardevents.ControlStarted:
+		return newControlEvent(progressEventControlStarted, "running", e.Control, e.Name, e.ExecutionId, e.Timestamp), true
+	case *dashboardevents.ControlComplete:
+		return newControlEvent(progressEventControlCompleted, e.Control.GetStatusSummary().Status(), e.Control, e.Name, e.ExecutionId, e.Timestamp), true
+	case *dashboardevents.ControlError:
+		return newControlEvent(progressEventControlCompleted, "error", e.Control, e.Name, e.ExecutionId, e.Timestamp), true
+	}
+	return ProgressEvent{}, false
+}
+
+// progressEventBus relays control progress events to the subscribers of the progress events endpoint
+type progressEventBus struct {
+	mut         sync.Mutex
+	subscribers map[chan ProgressEvent]struct{}
+}
+
+func newProgressEventBus() *progressEventBus {
+	return &progressEventBus{subscribers: make(map[chan ProgressEvent]struct{})}
+}
+
+// subscribe returns a channel which receives the published events, and a function which must be called to unsubscribe
+func (b *progressEventBus) subscribe() (chan ProgressEvent, func()) {
+	events := make(chan ProgressEvent, progressEventBufferSize)
+	b.mut.Lock()
+	b.subscribers[events] = struct{}{}
+	b.mut.Unlock()
+
+	return events, func() {
+		b.mut.Lock()
+		delete(b.subscribers, events)
+		b.mut.Unlock()
+	}
+}
+
+// publish sends the event to all subscribers
+// this does not block - if a subscriber is not keeping up, the event is dropped for that subscriber
+func (b *progressEventBus) publish(event ProgressEvent) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			slog.Debug("progress event subscriber is not keeping up - dropping event", "type", event.Type, "control_path", event.ControlPath)
+		}
+	}
+}
+
+// serveSSE streams the published events to the client as server-sent events, until the client disconnects
+// (the events of a single execution may be selected using the execution_id query parameter)
+func (b *progressEventBus) serveSSE(c *gin.Context) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	executionId := c.Query("execution_id")
+
+	events, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(progressEventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event := <-events:
+			if executionId != "" && event.ExecutionId != executionId {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.Warn("failed to marshal progress event", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
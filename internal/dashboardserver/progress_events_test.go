@@ -0,0 +1,123 @@
+package dashboardserver
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/dashboardevents"
+	"github.com/turbot/powerpipe/internal/dashboardtypes"
+)
+
+type testControlRun struct {
+	path    []string
+	summary controlstatus.StatusSummary
+}
+
+func (r *testControlRun) GetControlId() string                           { return r.path[len(r.path)-1] }
+func (r *testControlRun) GetRunStatus() dashboardtypes.RunStatus         { return dashboardtypes.RunComplete }
+func (r *testControlRun) GetStatusSummary() *controlstatus.StatusSummary { return &r.summary }
+func (r *testControlRun) GetPath() []string                              { return r.path }
+
+func TestNewProgressEvent(t *testing.T) {
+	control := &testControlRun{path: []string{"benchmark.b", "control.c"}, summary: controlstatus.StatusSummary{Ok: 1, Alarm: 1}}
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		event     dashboardevents.DashboardEvent
+		eventType string
+		status    string
+	}{
+		{&dashboardevents.ControlStarted{Control: control, Name: "benchmark.b", ExecutionId: "e1", Timestamp: timestamp}, progressEventControlStarted, "running"},
+		{&dashboardevents.ControlComplete{Control: control, Name: "benchmark.b", ExecutionId: "e1", Timestamp: timestamp}, progressEventControlCompleted, "alarm"},
+		{&dashboardevents.ControlError{Control: control, Name: "benchmark.b", ExecutionId: "e1", Timestamp: timestamp}, progressEventControlCompleted, "error"},
+	}
+	for _, tc := range testCases {
+		event, ok := newProgressEvent(tc.event)
+		if !ok {
+			t.Fatalf("%T: expected a progress event", tc.event)
+		}
+		if event.Type != tc.eventType || event.Status != tc.status || event.ExecutionId != "e1" || !event.Timestamp.Equal(timestamp) || !slices.Equal(event.ControlPath, control.path) {
+			t.Errorf("%T: unexpected progress event %+v", tc.event, event)
+		}
+	}
+
+	if _, ok := newProgressEvent(&dashboardevents.ExecutionStarted{}); ok {
+		t.Errorf("expected no progress event for an execution started event")
+	}
+}
+
+func TestProgressEventsSSE(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bus := newProgressEventBus()
+	router := gin.New()
+	router.GET(ProgressEventsPath, bus.serveSSE)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+ProgressEventsPath+"?execution_id=e1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s", contentType)
+	}
+
+	// wait for the subscription, then publish an event for another execution (which is filtered out) and a matching event
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		bus.mut.Lock()
+		subscribed := len(bus.subscribers) > 0
+		bus.mut.Unlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	bus.publish(ProgressEvent{Type: progressEventControlStarted, ExecutionId: "e2", ControlPath: []string{"control.other"}})
+	bus.publish(ProgressEvent{Type: progressEventControlCompleted, ExecutionId: "e1", ControlPath: []string{"benchmark.b", "control.c"}, Status: "ok"})
+
+	reader := bufio.NewReader(resp.Body)
+	eventLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventLine != "event: control_completed\n" || !strings.Contains(dataLine, `"control_path":["benchmark.b","control.c"]`) || !strings.Contains(dataLine, `"status":"ok"`) {
+		t.Errorf("unexpected event:\n%s%s", eventLine, dataLine)
+	}
+
+	// the subscriber is removed when the client disconnects
+	cancel()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		bus.mut.Lock()
+		subscribed := len(bus.subscribers) > 0
+		bus.mut.Unlock()
+		if !subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the subscriber to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -29,6 +29,8 @@ type Server struct {
 	dashboardClients map[string]*DashboardClientInfo
 	webSocket        *melody.Melody
 	workspace        *dashboardworkspace.WorkspaceEvents
+	// relays control progress events to the progress events endpoint
+	progressEvents *progressEventBus
 }
 
 func NewServer(ctx context.Context, w *dashboardworkspace.WorkspaceEvents, webSocket *melody.Melody) (*Server, error) {
@@ -43,6 +45,7 @@ func NewServer(ctx context.Context, w *dashboardworkspace.WorkspaceEvents, webSo
 		dashboardClients: dashboardClients,
 		webSocket:        webSocket,
 		workspace:        w,
+		progressEvents:   newProgressEventBus(),
 	}
 
 	w.RegisterDashboardEventHandler(ctx, server.HandleDashboardEvent)
@@ -57,7 +60,7 @@ func NewServer(ctx context.Context, w *dashboardworkspace.WorkspaceEvents, webSo
 // it returns a channel which is signalled when the API server terminates
 func (s *Server) Start(ctx context.Context) chan struct{} {
 	s.InitAsync(ctx)
-	return startAPIAsync(ctx, s.webSocket)
+	return startAPIAsync(ctx, s.webSocket, s.progressEvents)
 }
 
 // Shutdown stops the API server
@@ -87,6 +90,11 @@ func (s *Server) HandleDashboardEvent(ctx context.Context, event dashboardevents
 		}
 	}()
 
+	// relay control progress events to the progress events endpoint
+	if progressEvent, ok := newProgressEvent(event); ok {
+		s.progressEvents.publish(progressEvent)
+	}
+
 	switch e := event.(type) {
 
 	case *dashboardevents.WorkspaceError:
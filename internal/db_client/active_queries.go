@@ -0,0 +1,55 @@
+package db_client
+
+import (
+	"context"
+	"log/slog"
+)
+
+// trackQuery returns a context for executing a query which is cancelled if CancelQueries is called,
+// and a function to call once the query (including reading its rows) is complete
+func (c *DbClient) trackQuery(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	c.activeQueriesLock.Lock()
+	defer c.activeQueriesLock.Unlock()
+	if c.activeQueries == nil {
+		c.activeQueries = make(map[uint64]context.CancelFunc)
+	}
+	id := c.nextQueryId
+	c.nextQueryId++
+	c.activeQueries[id] = cancel
+
+	return ctx, func() {
+		c.activeQueriesLock.Lock()
+		delete(c.activeQueries, id)
+		c.activeQueriesLock.Unlock()
+		// NOTE: this must only be called once the rows have been read - cancelling the context of a running
+		// query causes pgx to cancel the query
+		cancel()
+	}
+}
+
+// CancelQueries cancels all in-flight queries, returning the number of queries cancelled
+// the query contexts are cancelled, so queries which are starting or streaming rows stop immediately,
+// and for postgres, pgx sends a cancel request for the running statement (equivalent to pg_cancel_backend)
+// NOTE: this should be called before Close when shutting down, otherwise Close waits for the running queries to complete
+func (c *DbClient) CancelQueries() int {
+	c.activeQueriesLock.Lock()
+	defer c.activeQueriesLock.Unlock()
+	count := len(c.activeQueries)
+	for id, cancel := range c.activeQueries {
+		cancel()
+		delete(c.activeQueries, id)
+	}
+	if count > 0 {
+		slog.Debug("cancelled in-flight queries", "count", count)
+	}
+	return count
+}
+
+// ActiveQueryCount returns the number of in-flight queries
+func (c *DbClient) ActiveQueryCount() int {
+	c.activeQueriesLock.Lock()
+	defer c.activeQueriesLock.Unlock()
+	return len(c.activeQueries)
+}
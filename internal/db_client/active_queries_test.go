@@ -0,0 +1,54 @@
+package db_client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCancelQueries(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSqliteClient(t)
+
+	if client.CancelQueries() != 0 {
+		t.Errorf("expected no queries to be cancelled when none are running")
+	}
+
+	// a query which never completes
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := client.ExecuteSync(ctx, "with recursive c(x) as (select 1 union all select x + 1 from c) select count(*) from c")
+		errChan <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.ActiveQueryCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the query was not tracked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cancelled := client.CancelQueries(); cancelled != 1 {
+		t.Errorf("expected 1 query to be cancelled, got %d", cancelled)
+	}
+
+	select {
+	case err := <-errChan:
+		if err == nil {
+			t.Errorf("expected the cancelled query to return an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query was not cancelled")
+	}
+	if count := client.ActiveQueryCount(); count != 0 {
+		t.Errorf("expected no active queries, got %d", count)
+	}
+
+	// the client is still usable
+	if _, err := client.ExecuteSync(ctx, "select 1"); err != nil {
+		t.Errorf("expected a query to succeed after cancelling: %v", err)
+	}
+	if count := client.ActiveQueryCount(); count != 0 {
+		t.Errorf("expected completed queries to no longer be tracked, got %d", count)
+	}
+}
@@ -0,0 +1,175 @@
+package db_client
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	duckDBScheme         = "duckdb://"
+	duckDBPrefix         = "duckdb:"
+	sqliteScheme         = "sqlite://"
+	sqlitePrefix         = "sqlite:"
+	postgresScheme       = "postgresql://"
+	inMemoryDatabasePath = ":memory:"
+)
+
+// newBackend creates the Backend for the given connection string, using the connection string scheme
+// to determine the backend type. Connection strings with no scheme are treated as postgres (postgres keyword/value
+// connection strings, e.g. host=/var/run/postgresql dbname=steampipe, are converted to the equivalent url)
+// the client config application name and TLS certificates are added to postgres connection strings,
+// and if read-only mode is set, the connection is configured to be read-only
+func newBackend(ctx context.Context, connectionString string, clientConfig *ClientConfig) (backend.Backend, error) {
+	switch {
+	case backend.IsDuckDBConnectionString(connectionString):
+		return newDuckDBBackend(connectionString, clientConfig.ReadOnly)
+	case backend.IsSqliteConnectionString(connectionString):
+		return newSqliteBackend(connectionString, clientConfig.ReadOnly)
+	case strings.TrimSpace(connectionString) == "":
+		return nil, &connectionError{kind: ErrNoConnectionString, err: sperr.New("connection string is empty")}
+	case isKeywordValueConnectionString(connectionString):
+		var err error
+		if connectionString, err = keywordValueToPostgresURL(connectionString); err != nil {
+			return nil, err
+		}
+	case !hasConnectionStringScheme(connectionString):
+		connectionString = postgresScheme + connectionString
+	case !backend.HasBackend(connectionString):
+		scheme, _, _ := strings.Cut(connectionString, "://")
+		return nil, sperr.New("unsupported scheme '%s' in connection string %s", scheme, utils.RedactConnectionString(connectionString))
+	}
+	if backend.IsPostgresConnectionString(connectionString) {
+		if err := validatePostgresConnectionString(connectionString); err != nil {
+			return nil, err
+		}
+		if err := validateUnixSocketHosts(connectionString); err != nil {
+			return nil, err
+		}
+		var err error
+		if connectionString, err = withApplicationName(connectionString, clientConfig.ApplicationName); err != nil {
+			return nil, err
+		}
+		if clientConfig.TLS != nil {
+			if err := clientConfig.TLS.Validate(); err != nil {
+				return nil, err
+			}
+			if connectionString, err = withPostgresTLS(connectionString, *clientConfig.TLS); err != nil {
+				return nil, err
+			}
+		}
+		if clientConfig.ReadOnly {
+			if connectionString, err = withPostgresReadOnly(connectionString); err != nil {
+				return nil, err
+			}
+		}
+	} else if clientConfig.ReadOnly {
+		// to get here, this must be a mysql backend
+		return nil, sperr.New("read-only mode is not supported by the mysql backend - it cannot be enforced for connection string %s", utils.RedactConnectionString(connectionString))
+	} else if clientConfig.TLS != nil && !clientConfig.TLS.Empty() {
+		return nil, sperr.New("database ssl certificates are not supported by the mysql backend - they cannot be used for connection string %s", utils.RedactConnectionString(connectionString))
+	}
+	return backend.FromConnectionString(ctx, connectionString)
+}
+
+func hasConnectionStringScheme(connectionString string) bool {
+	return backend.HasBackend(connectionString) || strings.Contains(connectionString, "://")
+}
+
+// newDuckDBBackend creates a DuckDB backend for a connection string of the form duckdb://<path>[?<options>]
+// (the duckdb:<path> form is also supported)
+// if readOnly is set, the database is opened with access_mode=read_only
+func newDuckDBBackend(connectionString string, readOnly bool) (backend.Backend, error) {
+	dbPath, options := databaseFilePath(connectionString, duckDBScheme, duckDBPrefix)
+
+	// duckdb will silently create a new database if the file does not exist - we would rather fail
+	if err := validateDatabaseFile("duckdb", dbPath); err != nil {
+		return nil, err
+	}
+	if readOnly {
+		if dbPath == "" || dbPath == inMemoryDatabasePath {
+			return nil, sperr.New("read-only mode is not supported for in-memory duckdb databases")
+		}
+		readOnlyOptions, err := withOption(options, "access_mode", "read_only")
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "invalid duckdb connection string options '%s'", options)
+		}
+		options = readOnlyOptions
+	}
+	if options != "" {
+		dbPath += "?" + options
+	}
+	return backend.NewDuckDBBackend(duckDBPrefix + dbPath), nil
+}
+
+// newSqliteBackend creates a SQLite backend for a connection string of the form sqlite://<path>[?<options>]
+// (the sqlite:<path> form is also supported)
+// to open the database read-only, specify the mode=ro option, e.g. sqlite://audit.db?mode=ro
+// (if readOnly is set, the mode=ro option is always used)
+func newSqliteBackend(connectionString string, readOnly bool) (backend.Backend, error) {
+	dbPath, options := databaseFilePath(connectionString, sqliteScheme, sqlitePrefix)
+
+	// sqlite will silently create a new database if the file does not exist - we would rather fail
+	if err := validateDatabaseFile("sqlite", dbPath); err != nil {
+		return nil, err
+	}
+	if readOnly {
+		readOnlyOptions, err := withOption(options, "mode", "ro")
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "invalid sqlite connection string options '%s'", options)
+		}
+		options = readOnlyOptions
+	}
+	if options != "" {
+		if _, err := url.ParseQuery(options); err != nil {
+			return nil, sperr.WrapWithMessage(err, "invalid sqlite connection string options '%s'", options)
+		}
+		// options such as mode=ro are only respected by sqlite for URI filenames
+		dbPath = "file:" + dbPath + "?" + options
+	}
+	return backend.NewSqliteBackend(sqlitePrefix + dbPath), nil
+}
+
+// databaseFilePath returns the database file path and any options from a file based connection string
+// the pipe-fittings backends expect this to be in the form <prefix><path>, so strip the scheme
+// (otherwise relative paths would be resolved from the filesystem root)
+func databaseFilePath(connectionString, scheme, prefix string) (string, string) {
+	connectionString = strings.TrimSpace(connectionString)
+	if strings.HasPrefix(connectionString, scheme) {
+		connectionString = strings.TrimPrefix(connectionString, scheme)
+	} else {
+		connectionString = strings.TrimPrefix(connectionString, prefix)
+	}
+	dbPath, options, _ := strings.Cut(connectionString, "?")
+	return dbPath, options
+}
+
+// validateDatabaseFile returns an error if the database file does not exist
+// (an empty or in-memory database path is valid)
+func validateDatabaseFile(backendName, dbPath string) error {
+	if dbPath == "" || dbPath == inMemoryDatabasePath {
+		return nil
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		if os.IsNotExist(err) {
+			return sperr.New("%s database file '%s' does not exist", backendName, dbPath)
+		}
+		return sperr.WrapWithMessage(err, "could not access %s database file '%s'", backendName, dbPath)
+	}
+	return nil
+}
+
+// withOption sets the option in the url encoded connection string options, overriding any existing value
+func withOption(options, key, value string) (string, error) {
+	values, err := url.ParseQuery(options)
+	if err != nil {
+		return "", err
+	}
+	values.Set(key, value)
+	return values.Encode(), nil
+}
@@ -0,0 +1,193 @@
+package db_client
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/marcboeker/go-duckdb"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// createDatabaseFile creates a database file using the driver, returning its path
+func createDatabaseFile(t *testing.T, driver, name string) string {
+	dbPath := filepath.Join(t.TempDir(), name)
+	db, err := sql.Open(driver, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("create table findings (id int, details varchar)"); err != nil {
+		t.Fatal(err)
+	}
+	return dbPath
+}
+
+func TestNewBackend(t *testing.T) {
+	duckDBPath := createDatabaseFile(t, "duckdb", "analytics.duckdb")
+	sqlitePath := createDatabaseFile(t, "sqlite3", "audit.db")
+	// the postgres backend connects when it is created - nothing listens on port 1, so the connection fails
+	postgres := "postgres://user@127.0.0.1:1/db?connect_timeout=1"
+	postgresConnectError := "failed to connect to `user=user database=db`"
+
+	tests := map[string]struct {
+		connectionString     string
+		wantName             string
+		wantConnectionString string
+		wantErr              string
+	}{
+		"duckdb scheme": {
+			connectionString:     "duckdb://" + duckDBPath,
+			wantName:             constants.DuckDBBackendName,
+			wantConnectionString: duckDBPath,
+		},
+		"duckdb prefix": {
+			connectionString:     "duckdb:" + duckDBPath,
+			wantName:             constants.DuckDBBackendName,
+			wantConnectionString: duckDBPath,
+		},
+		"duckdb in-memory": {
+			connectionString: "duckdb://:memory:",
+			wantName:         constants.DuckDBBackendName,
+		},
+		"duckdb missing file": {
+			connectionString: "duckdb://" + filepath.Join(t.TempDir(), "missing.duckdb"),
+			wantErr:          "duckdb database file",
+		},
+		"sqlite scheme": {
+			connectionString:     "sqlite://" + sqlitePath,
+			wantName:             constants.SQLiteBackendName,
+			wantConnectionString: sqlitePath,
+		},
+		"sqlite prefix": {
+			connectionString:     "sqlite:" + sqlitePath,
+			wantName:             constants.SQLiteBackendName,
+			wantConnectionString: sqlitePath,
+		},
+		"sqlite missing file": {
+			connectionString: "sqlite://" + filepath.Join(t.TempDir(), "missing.db"),
+			wantErr:          "sqlite database file",
+		},
+		"postgres scheme": {
+			connectionString: postgres,
+			wantErr:          postgresConnectError,
+		},
+		"no scheme defaults to postgres": {
+			connectionString: strings.TrimPrefix(postgres, "postgres://"),
+			wantErr:          postgresConnectError,
+		},
+		"mysql scheme": {
+			connectionString: "mysql://user@tcp(localhost:3306)/db",
+			wantName:         constants.MySQLBackendName,
+		},
+		"unsupported scheme": {
+			connectionString: "oracle://user@localhost/db",
+			wantErr:          "unsupported scheme 'oracle'",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, err := newBackend(context.Background(), test.connectionString, NewClientConfig())
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("newBackend() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newBackend() unexpected error: %v", err)
+			}
+			if b.Name() != test.wantName {
+				t.Errorf("newBackend() backend = %s, want %s", b.Name(), test.wantName)
+			}
+			if test.wantConnectionString != "" && b.ConnectionString() != test.wantConnectionString {
+				t.Errorf("newBackend() connection string = %s, want %s", b.ConnectionString(), test.wantConnectionString)
+			}
+		})
+	}
+}
+
+func TestTranslatedQueriesRunOnDuckDB(t *testing.T) {
+	// NOTE: this uses the driver directly, as the DuckDB backend installs the json extension when connecting,
+	// which requires network access
+	db, err := sql.Open("duckdb", createDatabaseFile(t, "duckdb", "analytics.duckdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("LOAD json"); err != nil {
+		t.Skipf("the duckdb json extension is not available: %v", err)
+	}
+	if _, err := db.Exec("insert into findings values (1, '{\"region\": \"us-east-1\"}')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a postgres query using jsonb casts and functions
+	query := `select jsonb_build_object('id', id)::jsonb ->> 'id', jsonb_array_length('[1, 2]'::jsonb), jsonb_agg(details::jsonb ->> 'region') from findings group by id`
+	var id, length, regions string
+	if err := db.QueryRow(translateQuery(constants.DuckDBBackendName, query)).Scan(&id, &length, &regions); err != nil {
+		t.Fatalf("translated query failed: %v", err)
+	}
+	if id != "1" || length != "2" || regions != `["us-east-1"]` {
+		t.Errorf("translated query returned %s, %s, %s - want 1, 2, [\"us-east-1\"]", id, length, regions)
+	}
+}
+
+func TestGetDbClientSqliteMissingFile(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "missing.db")
+	for _, connectionString := range []string{"sqlite://" + dbPath, "sqlite://" + dbPath + "?mode=ro"} {
+		_, errAndWarnings := GetDbClient(context.Background(), []string{connectionString})
+		want := "sqlite database file '" + dbPath + "' does not exist"
+		if err := errAndWarnings.GetError(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("GetDbClient(%s) error = %v, want it to contain %q", connectionString, err, want)
+		}
+	}
+}
+
+func TestSqliteReadOnlyQueryParam(t *testing.T) {
+	dbPath := createDatabaseFile(t, "sqlite3", "audit.db")
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		connectionString string
+		clientConfig     *ClientConfig
+		wantWriteErr     bool
+	}{
+		"read-write": {
+			connectionString: "sqlite://" + dbPath,
+			clientConfig:     NewClientConfig(),
+		},
+		"mode=ro": {
+			connectionString: "sqlite://" + dbPath + "?mode=ro",
+			clientConfig:     NewClientConfig(),
+			wantWriteErr:     true,
+		},
+		"read-only mode overrides mode=rw": {
+			connectionString: "sqlite://" + dbPath + "?mode=rw",
+			clientConfig:     NewClientConfig(WithReadOnly()),
+			wantWriteErr:     true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, err := newDbClient(ctx, test.connectionString, test.clientConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close(ctx)
+
+			_, err = client.ExecuteSync(ctx, "insert into findings values (1, 'details')")
+			if test.wantWriteErr && err == nil {
+				t.Errorf("expected a write query to fail")
+			} else if !test.wantWriteErr && err != nil {
+				t.Errorf("expected a write query to succeed: %v", err)
+			}
+			if _, err := client.ExecuteSync(ctx, "select count(*) from findings"); err != nil {
+				t.Errorf("expected a read query to succeed: %v", err)
+			}
+		})
+	}
+}
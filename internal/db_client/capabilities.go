@@ -0,0 +1,100 @@
+package db_client
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// BackendCapabilities describes the SQL features supported by a database backend
+type BackendCapabilities struct {
+	// common table expressions, i.e. WITH queries
+	CTEs bool
+	// JSON functions and operators
+	JSONFunctions bool
+	// prepared statements (used for queries with args)
+	PreparedStatements bool
+}
+
+// CapabilitiesProvider may be implemented by a Backend to report its capabilities
+// - if the backend does not implement this, the capabilities are detected by probing the database
+type CapabilitiesProvider interface {
+	Capabilities(ctx context.Context) (BackendCapabilities, error)
+}
+
+var (
+	cteRegex = regexp.MustCompile(`(?is)^\s*with\s`)
+	// matches json/jsonb functions (e.g. json_extract, jsonb_array_elements), json operators and json casts
+	jsonRegex = regexp.MustCompile(`(?i)\bjsonb?(_\w+)?\s*\(|->>?|::\s*jsonb?\b`)
+)
+
+// UnsupportedFeatures returns the features used by the query which are not supported by the backend
+// NOTE: this is a heuristic based on the query text, intended for warnings rather than validation
+func (c BackendCapabilities) UnsupportedFeatures(query string, args ...any) []string {
+	var res []string
+	if !c.CTEs && cteRegex.MatchString(query) {
+		res = append(res, "common table expressions")
+	}
+	if !c.JSONFunctions && jsonRegex.MatchString(query) {
+		res = append(res, "json functions")
+	}
+	if !c.PreparedStatements && len(args) > 0 {
+		res = append(res, "prepared statements")
+	}
+	return res
+}
+
+// Capabilities returns the capabilities of the client backend - these are detected the first time this is called
+func (c *DbClient) Capabilities(ctx context.Context) BackendCapabilities {
+	c.capabilitiesLock.Lock()
+	defer c.capabilitiesLock.Unlock()
+
+	if c.capabilities == nil {
+		capabilities := c.detectCapabilities(ctx)
+		// do not store capabilities detected with a cancelled context - the probes will have failed
+		if ctx.Err() != nil {
+			return capabilities
+		}
+		c.capabilities = &capabilities
+	}
+	return *c.capabilities
+}
+
+func (c *DbClient) detectCapabilities(ctx context.Context) BackendCapabilities {
+	if provider, ok := c.Backend.(CapabilitiesProvider); ok {
+		capabilities, err := provider.Capabilities(ctx)
+		if err == nil {
+			return capabilities
+		}
+		slog.Warn("failed to get backend capabilities - detecting them instead", "backend", c.Backend.Name(), "error", err)
+	}
+
+	probe := func(query string) bool {
+		var value any
+		return c.getDb().QueryRowContext(ctx, query).Scan(&value) == nil
+	}
+
+	capabilities := BackendCapabilities{
+		CTEs:          probe("WITH probe AS (SELECT 1 AS a) SELECT a FROM probe"),
+		JSONFunctions: probe(c.jsonProbeQuery()),
+	}
+	if stmt, err := c.getDb().PrepareContext(ctx, "SELECT 1"); err == nil {
+		_ = stmt.Close()
+		capabilities.PreparedStatements = true
+	}
+	slog.Debug("detected backend capabilities", "backend", c.Backend.Name(), "capabilities", capabilities)
+	return capabilities
+}
+
+// jsonProbeQuery returns a query using a json function, in the dialect of the backend
+func (c *DbClient) jsonProbeQuery() string {
+	switch c.Backend.Name() {
+	case constants.PostgresBackendName, constants.SteampipeBackendName:
+		return `SELECT '{"a": 1}'::jsonb ->> 'a'`
+	default:
+		// sqlite, duckdb and mysql
+		return `SELECT json_extract('{"a": 1}', '$.a')`
+	}
+}
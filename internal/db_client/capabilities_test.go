@@ -0,0 +1,34 @@
+package db_client
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUnsupportedFeatures(t *testing.T) {
+	none := BackendCapabilities{}
+	all := BackendCapabilities{CTEs: true, JSONFunctions: true, PreparedStatements: true}
+
+	tests := map[string]struct {
+		capabilities BackendCapabilities
+		query        string
+		args         []any
+		want         []string
+	}{
+		"plain query":          {capabilities: none, query: "select name from users"},
+		"cte":                  {capabilities: none, query: "  WITH recent AS (select 1) select * from recent", want: []string{"common table expressions"}},
+		"with in string":       {capabilities: none, query: "select 'with x as' from users"},
+		"json function":        {capabilities: none, query: "select jsonb_array_elements(tags) from users", want: []string{"json functions"}},
+		"json operator":        {capabilities: none, query: "select tags ->> 'owner' from users", want: []string{"json functions"}},
+		"json cast":            {capabilities: none, query: "select tags::jsonb from users", want: []string{"json functions"}},
+		"args":                 {capabilities: none, query: "select * from users where id = $1", args: []any{1}, want: []string{"prepared statements"}},
+		"all supported":        {capabilities: all, query: "with t as (select tags ->> 'a' from u) select * from t where id = $1", args: []any{1}},
+		"multiple unsupported": {capabilities: none, query: "with t as (select json_extract(tags, '$.a') from u) select * from t", want: []string{"common table expressions", "json functions"}},
+	}
+	for name, test := range tests {
+		got := test.capabilities.UnsupportedFeatures(test.query, test.args...)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", name, test.want, got)
+		}
+	}
+}
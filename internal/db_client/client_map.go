@@ -19,7 +19,12 @@ func NewClientMap() *ClientMap {
 	}
 }
 
+// Add stores the client in the map, keyed by its connection string and the search path config
+// if the client is nil (i.e. no database is required), the map is left unchanged
 func (e *ClientMap) Add(client *DbClient, searchPathConfig backend.SearchPathConfig) *ClientMap {
+	if client == nil {
+		return e
+	}
 	e.clientsMut.Lock()
 	defer e.clientsMut.Unlock()
 
@@ -0,0 +1,79 @@
+package db_client
+
+import (
+	"github.com/turbot/pipe-fittings/backend"
+)
+
+// ClientConfig contains the configuration used when creating a DbClient
+type ClientConfig struct {
+	ConnectOptions []backend.ConnectOption
+	// the pool sizing - if this is not set, the pool is sized using GetPoolConfig
+	Pool *PoolConfig
+	// if set, this is reported to the database as the client application name (postgres only)
+	ApplicationName string
+	// if set, the connection is read-only, so any write query fails
+	// (an error is returned when connecting if the backend does not support enforcing this)
+	ReadOnly bool
+	// if set, these are used to obtain the connection strings rather than the connection strings passed to GetDbClient
+	CredentialProviders []CredentialProvider
+	// if set, the TLS certificates used for postgres connections
+	TLS *TLSConfig
+}
+
+// ClientOption is used to customise the DbClient created by InitData
+type ClientOption func(*ClientConfig)
+
+// NewClientConfig builds a ClientConfig from the given options
+func NewClientConfig(opts ...ClientOption) *ClientConfig {
+	c := &ClientConfig{}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// WithConnectOptions adds backend connect options which are passed to the backend when connecting
+func WithConnectOptions(opts ...backend.ConnectOption) ClientOption {
+	return func(c *ClientConfig) {
+		c.ConnectOptions = append(c.ConnectOptions, opts...)
+	}
+}
+
+// WithPoolConfig sets the sizing of the database connection pool
+func WithPoolConfig(pool PoolConfig) ClientOption {
+	return func(c *ClientConfig) {
+		c.Pool = &pool
+	}
+}
+
+// WithApplicationName sets the application name reported to the database, allowing queries to be attributed to a run
+// (this is only supported by postgres backends, and does not override an application_name set in the connection string)
+func WithApplicationName(applicationName string) ClientOption {
+	return func(c *ClientConfig) {
+		c.ApplicationName = applicationName
+	}
+}
+
+// WithCredentialProviders sets the providers used to obtain the connection strings when connecting
+// - these are tried in order, and replace the connection strings passed to GetDbClient
+func WithCredentialProviders(providers ...CredentialProvider) ClientOption {
+	return func(c *ClientConfig) {
+		c.CredentialProviders = append(c.CredentialProviders, providers...)
+	}
+}
+
+// WithReadOnly makes the client connection read-only
+// - for postgres this sets default_transaction_read_only, for sqlite mode=ro and for duckdb access_mode=read_only
+func WithReadOnly() ClientOption {
+	return func(c *ClientConfig) {
+		c.ReadOnly = true
+	}
+}
+
+// WithTLSConfig sets the certificates used for TLS connections - the root certificate is used to verify the server
+// certificate, and the client certificate and key (if set) are used to authenticate (postgres only)
+func WithTLSConfig(tlsConfig TLSConfig) ClientOption {
+	return func(c *ClientConfig) {
+		c.TLS = &tlsConfig
+	}
+}
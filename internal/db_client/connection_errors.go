@@ -0,0 +1,117 @@
+package db_client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// postgres error code returned while the database is starting up or shutting down
+const pgErrorCodeCannotConnectNow = "57P03"
+
+// IsTransientConnectionError returns whether the given connection error may succeed if retried,
+// for example if the database is still starting up
+func IsTransientConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	// cancellation is never transient
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgErrorCodeCannotConnectNow
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// fall back to checking the error text - not all drivers wrap the underlying errors
+	msg := err.Error()
+	for _, s := range []string{"connection refused", "the database system is starting up", "i/o timeout"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// postgres error codes returned when the database rejects the client credentials
+const (
+	pgErrorCodeInvalidPassword      = "28P01"
+	pgErrorCodeInvalidAuthorization = "28000"
+)
+
+// the categories of error returned by GetDbClient - the returned errors match these using errors.Is,
+// e.g. errors.Is(err, db_client.ErrAuthenticationFailed)
+var (
+	// ErrNoConnectionString is returned if no database connection string (or credential provider) is specified
+	ErrNoConnectionString = errors.New("no database connection string specified")
+	// ErrAuthenticationFailed is matched by connection errors caused by the database rejecting the credentials
+	ErrAuthenticationFailed = errors.New("database authentication failed")
+	// ErrConnectionFailed is matched by any other connection error
+	ErrConnectionFailed = errors.New("failed to connect to the database")
+)
+
+// connectionError categorises a connection error, without changing its message
+type connectionError struct {
+	kind error
+	err  error
+}
+
+func (e *connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.err
+}
+
+func (e *connectionError) Is(target error) bool {
+	return target == e.kind
+}
+
+// newConnectionError wraps the connection error so it matches ErrAuthenticationFailed (if the database
+// rejected the credentials) or ErrConnectionFailed
+// cancellation errors and errors which are already categorised are returned unchanged
+func newConnectionError(err error) error {
+	if err == nil ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrNoConnectionString) || errors.Is(err, ErrAuthenticationFailed) || errors.Is(err, ErrConnectionFailed) {
+		return err
+	}
+	kind := ErrConnectionFailed
+	if isAuthenticationError(err) {
+		kind = ErrAuthenticationFailed
+	}
+	return &connectionError{kind: kind, err: err}
+}
+
+// isAuthenticationError returns whether the connection error was caused by the database rejecting the credentials
+func isAuthenticationError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgErrorCodeInvalidPassword || pgErr.Code == pgErrorCodeInvalidAuthorization
+	}
+
+	// fall back to checking the error text - not all drivers wrap the underlying errors
+	msg := err.Error()
+	for _, s := range []string{"password authentication failed", "Access denied for user"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package db_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestNewConnectionError(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected error
+	}{
+		"invalid password":   {fmt.Errorf("failed to connect: %w", &pgconn.PgError{Code: pgErrorCodeInvalidPassword}), ErrAuthenticationFailed},
+		"mysql access":       {errors.New("Error 1045 (28000): Access denied for user 'steampipe'@'localhost'"), ErrAuthenticationFailed},
+		"connection refused": {errors.New("dial tcp 127.0.0.1:9193: connect: connection refused"), ErrConnectionFailed},
+	}
+	for name, test := range tests {
+		err := newConnectionError(test.err)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("%s: expected error to match %v", name, test.expected)
+		}
+		// the error message is unchanged, and the underlying error is still available
+		if err.Error() != test.err.Error() || !errors.Is(err, test.err) {
+			t.Errorf("%s: expected the error to wrap the connection error", name)
+		}
+	}
+
+	if err := newConnectionError(context.Canceled); errors.Is(err, ErrConnectionFailed) {
+		t.Errorf("expected cancellation not to be categorised as a connection failure")
+	}
+}
+
+func TestGetDbClientNoConnectionString(t *testing.T) {
+	_, errAndWarnings := GetDbClient(context.Background(), nil)
+	if !errors.Is(errAndWarnings.Error, ErrNoConnectionString) {
+		t.Errorf("expected ErrNoConnectionString, got %v", errAndWarnings.Error)
+	}
+	_, errAndWarnings = GetDbClient(context.Background(), []string{" "})
+	if !errors.Is(errAndWarnings.Error, ErrNoConnectionString) {
+		t.Errorf("expected ErrNoConnectionString for an empty connection string, got %v", errAndWarnings.Error)
+	}
+}
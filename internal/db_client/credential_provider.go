@@ -0,0 +1,44 @@
+package db_client
+
+import (
+	"context"
+	"time"
+)
+
+// CredentialProvider provides the connection string used to connect to the database
+// ConnectionString is called each time a connection is attempted (including retries),
+// allowing rotated credentials to be fetched at connect time, e.g. from Vault or a cloud secret manager
+type CredentialProvider interface {
+	ConnectionString(ctx context.Context) (string, error)
+}
+
+// ExpiringCredentialProvider is a CredentialProvider whose credentials expire, e.g. short-lived auth tokens
+// a client connected using an ExpiringCredentialProvider obtains new credentials whenever it reconnects,
+// and re-establishes its connection before the credentials expire (see DbClient.startCredentialRefresh)
+type ExpiringCredentialProvider interface {
+	CredentialProvider
+	// CredentialLifetime returns how long the credentials may be used to open new connections after they are obtained
+	CredentialLifetime() time.Duration
+}
+
+// StaticCredentialProvider is a CredentialProvider which always returns the configured connection string
+type StaticCredentialProvider struct {
+	connectionString string
+}
+
+func NewStaticCredentialProvider(connectionString string) *StaticCredentialProvider {
+	return &StaticCredentialProvider{connectionString: connectionString}
+}
+
+func (p *StaticCredentialProvider) ConnectionString(context.Context) (string, error) {
+	return p.connectionString, nil
+}
+
+// staticCredentialProviders returns a StaticCredentialProvider for each of the connection strings
+func staticCredentialProviders(connectionStrings []string) []CredentialProvider {
+	res := make([]CredentialProvider, len(connectionStrings))
+	for idx, connectionString := range connectionStrings {
+		res[idx] = NewStaticCredentialProvider(connectionString)
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package db_client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/powerpipe/internal/utils"
+)
+
+// startCredentialRefresh stores the provider of the expiring credentials the client connected with, and starts
+// re-establishing the connection with new credentials before they expire (until the client is closed)
+// NOTE: connections in the pool are opened on demand using the credentials the db handle was created with,
+// so the db handle must be replaced before the credentials expire, rather than only when a connection fails
+func (c *DbClient) startCredentialRefresh(ctx context.Context, provider ExpiringCredentialProvider) {
+	c.dbLock.Lock()
+	defer c.dbLock.Unlock()
+	c.credentialProvider = provider
+
+	interval := credentialRefreshInterval(provider.CredentialLifetime())
+	if interval <= 0 {
+		return
+	}
+	// NOTE: the refresh must not be cancelled when the context used to connect is cancelled (typically the Init context)
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c.stopCredentialRefresh = cancel
+
+	go c.refreshCredentials(ctx, interval)
+}
+
+func (c *DbClient) refreshCredentials(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// the reconnect is allowed up to the refresh interval to complete
+			reconnectCtx, cancel := context.WithTimeout(ctx, interval)
+			err := c.reconnect(reconnectCtx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				// the existing connections remain usable - we will try again at the next interval
+				slog.Warn("failed to refresh the database credentials", "connection", utils.RedactConnectionString(c.connectionString), "error", utils.RedactConnectionStringError(err))
+				continue
+			}
+			slog.Debug("database credentials refreshed", "connection", utils.RedactConnectionString(c.connectionString))
+		}
+	}
+}
+
+// newCredentialBackend creates a backend using a connection string with new credentials from the credential provider
+func (c *DbClient) newCredentialBackend(ctx context.Context) (backend.Backend, error) {
+	connectionString, err := c.credentialProvider.ConnectionString(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expandedConnectionString, err := expandConnectionStringEnv(connectionString)
+	if err != nil {
+		return nil, err
+	}
+	return newBackend(ctx, expandedConnectionString, c.clientConfig)
+}
+
+// credentialRefreshInterval returns the interval at which credentials with the given lifetime are refreshed
+// - this leaves a third of the lifetime to obtain the new credentials and reconnect
+func credentialRefreshInterval(lifetime time.Duration) time.Duration {
+	return lifetime - lifetime/3
+}
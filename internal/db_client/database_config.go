@@ -7,6 +7,7 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/steampipeconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 	"github.com/turbot/powerpipe/internal/powerpipeconfig"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
@@ -98,11 +99,31 @@ func GetDefaultDatabaseConfig(opts ...backend.ConnectOption) (string, backend.Se
 
 	defaultDatabase := viper.GetString(constants.ArgDatabase)
 
-	// if no database is set, use the default connection
-	if defaultDatabase == "" {
-		defaultConnection := powerpipeconfig.GlobalConfig.GetDefaultConnection()
+	// if a connection string file is set, this takes precedence over the database arg (and the mod database)
+	// NOTE: ArgConnectionStrings, if set, takes precedence over both (see GetDefaultConnectionStrings)
+	if connectionStringFile := viper.GetString(localconstants.ArgConnectionStringFile); connectionStringFile != "" {
+		var err error
+		defaultDatabase, err = readConnectionStringFile(connectionStringFile)
+		if err != nil {
+			return "", backend.SearchPathConfig{}, err
+		}
+	}
+
+	// if a named connection is set, this takes precedence over the database arg and the connection string file
+	// otherwise, if no database is set, use the default connection
+	var defaultConnection connection.ConnectionStringProvider
+	if connectionName := viper.GetString(localconstants.ArgConnection); connectionName != "" {
+		var err error
+		defaultConnection, err = powerpipeconfig.GlobalConfig.GetConnectionStringProvider(connectionName)
+		if err != nil {
+			return "", backend.SearchPathConfig{}, err
+		}
+	} else if defaultDatabase == "" {
+		defaultConnection = powerpipeconfig.GlobalConfig.GetDefaultConnection()
+	}
+	if defaultConnection != nil {
 		defaultDatabase = defaultConnection.GetConnectionString()
-		// if no search path has been set, use the default connection
+		// if no search path has been set, use the connection search path
 		if defaultSearchPathConfig.Empty() {
 			if spp, ok := defaultConnection.(connection.SearchPathProvider); ok {
 				defaultSearchPathConfig = backend.SearchPathConfig{
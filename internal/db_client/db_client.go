@@ -3,29 +3,80 @@ package db_client
 import (
 	"context"
 	"database/sql"
+	"sync"
+
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/constants"
 
 	"github.com/turbot/pipe-fittings/backend"
 	"github.com/turbot/pipe-fittings/utils"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 )
 
 // DbClient wraps over `sql.DB` and gives an interface to the database
 type DbClient struct {
 	connectionString string
 
-	// db handle
-	db *sql.DB
+	// db handle - this is replaced if the connection is re-established by the keep-alive (see StartKeepAlive)
+	db     *sql.DB
+	dbLock sync.RWMutex
+	// the options used to connect, and the pool config - these are reused when reconnecting
+	connectOpts []backend.ConnectOption
+	pool        PoolConfig
+	// the client config - if the credentials expire, this is used to create a backend using new credentials when reconnecting
+	clientConfig *ClientConfig
+	// if set, the provider of the (expiring) credentials the client connected with
+	credentialProvider ExpiringCredentialProvider
+	// cancels the keep-alive and credential refresh goroutines (if running)
+	stopKeepAlive         context.CancelFunc
+	stopCredentialRefresh context.CancelFunc
+	closed                bool
+
+	// the cancel functions of the in-flight queries, keyed by query id - these are cancelled by CancelQueries
+	activeQueries     map[uint64]context.CancelFunc
+	nextQueryId       uint64
+	activeQueriesLock sync.Mutex
 
 	// the Backend
 	Backend backend.Backend
+
+	// the backend capabilities - populated the first time Capabilities is called
+	capabilities     *BackendCapabilities
+	capabilitiesLock sync.Mutex
 }
 
 func NewDbClient(ctx context.Context, connectionString string, opts ...backend.ConnectOption) (_ *DbClient, err error) {
+	return newDbClient(ctx, connectionString, NewClientConfig(WithConnectOptions(opts...)))
+}
+
+func newDbClient(ctx context.Context, connectionString string, clientConfig *ClientConfig) (_ *DbClient, err error) {
 	utils.LogTime("db_client.NewDbClient start")
 	defer utils.LogTime("db_client.NewDbClient end")
 
-	b, err := backend.FromConnectionString(ctx, connectionString)
+	// read-only mode may also be enabled using ArgReadOnly - this applies to every client,
+	// including those created for dashboards which specify their own database
+	if viper.GetBool(localconstants.ArgReadOnly) && !clientConfig.ReadOnly {
+		readOnlyConfig := *clientConfig
+		readOnlyConfig.ReadOnly = true
+		clientConfig = &readOnlyConfig
+	}
+
+	pool := GetPoolConfig()
+	if clientConfig.Pool != nil {
+		pool = *clientConfig.Pool
+	}
+	if err := pool.Validate(); err != nil {
+		return nil, err
+	}
+
+	// expand any environment variable references in the connection string
+	// NOTE: the unexpanded connection string is stored in the client, so expanded secrets are not exposed
+	expandedConnectionString, err := expandConnectionStringEnv(connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := newBackend(ctx, expandedConnectionString, clientConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +84,8 @@ func NewDbClient(ctx context.Context, connectionString string, opts ...backend.C
 	client := &DbClient{
 		connectionString: connectionString,
 		Backend:          b,
+		pool:             pool,
+		clientConfig:     clientConfig,
 	}
 
 	defer func() {
@@ -43,8 +96,8 @@ func NewDbClient(ctx context.Context, connectionString string, opts ...backend.C
 	}()
 
 	// process options - searhc path may have been passed in
-	config := backend.NewConnectConfig(opts)
-	config.MaxOpenConns = MaxDbConnections()
+	config := backend.NewConnectConfig(clientConfig.ConnectOptions)
+	config.MaxOpenConns = pool.MaxConns
 	// if no search path override passed in as an option, use the viper config
 	if config.SearchPathConfig.Empty() {
 		config.SearchPathConfig = backend.SearchPathConfig{
@@ -60,14 +113,38 @@ func NewDbClient(ctx context.Context, connectionString string, opts ...backend.C
 	return client, nil
 }
 
+// PoolConfig returns the sizing of the client connection pool
+func (c *DbClient) PoolConfig() PoolConfig {
+	return c.pool
+}
+
 func (c *DbClient) GetConnectionString() string {
 	return c.connectionString
 }
 
 // Close closes the connection to the database and shuts down the Backend
+// (this also stops the keep-alive and credential refresh, if they are running)
 func (c *DbClient) Close(context.Context) error {
+	c.dbLock.Lock()
+	defer c.dbLock.Unlock()
+	c.closed = true
+	if c.stopKeepAlive != nil {
+		c.stopKeepAlive()
+		c.stopKeepAlive = nil
+	}
+	if c.stopCredentialRefresh != nil {
+		c.stopCredentialRefresh()
+		c.stopCredentialRefresh = nil
+	}
 	if c.db != nil {
 		return c.db.Close()
 	}
 	return nil
 }
+
+// getDb returns the current db handle
+func (c *DbClient) getDb() *sql.DB {
+	c.dbLock.RLock()
+	defer c.dbLock.RUnlock()
+	return c.db
+}
@@ -2,6 +2,7 @@ package db_client
 
 import (
 	"context"
+	"database/sql"
 	"github.com/turbot/pipe-fittings/backend"
 	"github.com/turbot/pipe-fittings/utils"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
@@ -11,11 +12,26 @@ func (c *DbClient) connect(ctx context.Context, opts ...backend.ConnectOption) e
 	utils.LogTime("db_client.establishConnectionPool start")
 	defer utils.LogTime("db_client.establishConnectionPool end")
 
-	db, err := c.Backend.Connect(ctx, opts...)
+	db, err := c.openDb(ctx, c.Backend, opts...)
 	if err != nil {
-		return sperr.WrapWithMessage(err, "unable to connect to Backend")
+		return err
 	}
 
+	// store the options so the same connection can be re-established if it drops
+	c.connectOpts = opts
 	c.db = db
 	return nil
 }
+
+// openDb connects to the backend using the given options and applies the pool config to the resulting db handle
+func (c *DbClient) openDb(ctx context.Context, b backend.Backend, opts ...backend.ConnectOption) (*sql.DB, error) {
+	db, err := b.Connect(ctx, opts...)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "unable to connect to Backend")
+	}
+	if err := c.pool.apply(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/turbot/pipe-fittings/queryresult"
 	"github.com/turbot/pipe-fittings/statushooks"
 	localqueryresult "github.com/turbot/powerpipe/internal/queryresult"
+	"github.com/turbot/powerpipe/internal/telemetry"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
@@ -21,20 +22,33 @@ import (
 // NOTE: The returned Result MUST be fully read - otherwise the connection will block and will prevent further communication
 func (c *DbClient) Execute(ctx context.Context, query string, args ...any) (*localqueryresult.Result, error) {
 	// acquire a connection
-	databaseConnection, err := c.db.Conn(ctx)
+	databaseConnection, err := c.getDb().Conn(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// define callback to close session when the async execution is complete
 	closeSessionCallback := func() { _ = databaseConnection.Close() }
+
+	// if a query timeout has been set in the context, apply this as the statement timeout
+	if timeout, ok := queryTimeoutFromContext(ctx); ok {
+		resetStatementTimeout, err := c.setStatementTimeout(ctx, databaseConnection, timeout)
+		if err != nil {
+			closeSessionCallback()
+			return nil, err
+		}
+		closeSessionCallback = func() {
+			resetStatementTimeout()
+			_ = databaseConnection.Close()
+		}
+	}
 	return c.executeOnConnection(ctx, databaseConnection, closeSessionCallback, query, args...)
 }
 
 // ExecuteSync executes a query against this client and wait for the result
 func (c *DbClient) ExecuteSync(ctx context.Context, query string, args ...any) (*queryresult.SyncQueryResult, error) {
 	// acquire a connection
-	dbConn, err := c.db.Conn(ctx)
+	dbConn, err := c.getDb().Conn(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -84,11 +98,17 @@ func (c *DbClient) executeOnConnection(ctx context.Context, dbConn *sql.Conn, on
 	// we don't use the cancelFn from this timeout context, since usage will lead to 'pgx'
 	// prematurely closing the database connection that this query executed in
 	ctxExecute := c.getExecuteContext(ctx)
+	// track the query, so it is cancelled if CancelQueries is called (e.g. on shutdown)
+	ctxExecute, untrackQuery := c.trackQuery(ctxExecute)
 
 	var tx *sql.Tx
 
+	// record the query metrics - the query is complete once the rows have been read
+	queryDone := telemetry.QueryStarted(ctx)
+
 	defer func() {
 		if err != nil {
+			queryDone(err)
 			// stop spinner in case of error
 			statushooks.Done(ctxExecute)
 			// error - rollback transaction if we have one
@@ -99,6 +119,7 @@ func (c *DbClient) executeOnConnection(ctx context.Context, dbConn *sql.Conn, on
 			if onComplete != nil {
 				onComplete()
 			}
+			untrackQuery()
 		}
 	}()
 
@@ -120,12 +141,13 @@ func (c *DbClient) executeOnConnection(ctx context.Context, dbConn *sql.Conn, on
 	// read the rows in a go routine
 	go func() {
 		// read in the rows and stream to the query result object
-		c.readRows(ctxExecute, rows, result)
+		queryDone(c.readRows(ctxExecute, rows, result))
 
 		// call the completion callback - if one was provided
 		if onComplete != nil {
 			onComplete()
 		}
+		untrackQuery()
 	}()
 
 	return result, nil
@@ -133,6 +155,10 @@ func (c *DbClient) executeOnConnection(ctx context.Context, dbConn *sql.Conn, on
 
 func (c *DbClient) getExecuteContext(ctx context.Context) context.Context {
 	queryTimeout := time.Duration(viper.GetInt(constants.ArgDatabaseQueryTimeout)) * time.Second
+	// a query timeout set in the context overrides the arg
+	if timeout, ok := queryTimeoutFromContext(ctx); ok {
+		queryTimeout = timeout
+	}
 	// if timeout is zero, do not set a timeout
 	if queryTimeout == 0 {
 		return ctx
@@ -148,6 +174,9 @@ func (c *DbClient) getExecuteContext(ctx context.Context) context.Context {
 // StartQuery runs query in a goroutine, so we can check for cancellation
 // in case the client becomes unresponsive and does not respect context cancellation
 func (c *DbClient) StartQuery(ctx context.Context, dbConn *sql.Conn, query string, args ...any) (rows *sql.Rows, err error) {
+	// translate any postgres specific parts of the query to the backend dialect
+	query = translateQuery(c.Backend.Name(), query)
+
 	doneChan := make(chan bool)
 	go func() {
 		// start asynchronous query
@@ -164,14 +193,15 @@ func (c *DbClient) StartQuery(ctx context.Context, dbConn *sql.Conn, query strin
 	return
 }
 
-func (c *DbClient) readRows(ctx context.Context, rows *sql.Rows, result *localqueryresult.Result) {
+// readRows streams the rows to the result, returning the error (if any) which was streamed
+func (c *DbClient) readRows(ctx context.Context, rows *sql.Rows, result *localqueryresult.Result) (err error) {
 	// defer this, so that these get cleaned up even if there is an unforeseen error
 	defer func() {
 		// we are done fetching results. time for display. clear the status indication
 		statushooks.Done(ctx)
 		// close the sql rows object
 		rows.Close()
-		if err := rows.Err(); err != nil {
+		if err = rows.Err(); err != nil {
 			result.StreamError(err)
 		}
 		// close the channels in the result object
@@ -205,6 +235,8 @@ Loop:
 			rowCount++
 		}
 	}
+	// the error is set in the defer
+	return nil
 }
 
 func (c *DbClient) rowValues(rows *sql.Rows, cols []*queryresult.ColumnDef) ([]any, error) {
@@ -1 +1,230 @@
 package db_client
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/turbot/go-kit/helpers"
+	"github.com/turbot/pipe-fittings/utils"
+	localutils "github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	// the postgres connection parameter used to report the client application name
+	applicationNameParam = "application_name"
+	// the postgres connection parameter used to make all transactions read-only
+	readOnlyParam = "default_transaction_read_only"
+)
+
+// matches an escaped $ ($$), or an environment variable reference (${VAR} or $VAR) in a connection string
+var envReferenceRegex = regexp.MustCompile(`\$\$|\$\{[A-Za-z_][A-Za-z0-9_]*\}|\$[A-Za-z_][A-Za-z0-9_]*`)
+
+// matches the start of a postgres keyword/value connection string, e.g. host=/var/run/postgresql dbname=steampipe
+var keywordValueRegex = regexp.MustCompile(`^\s*\w+\s*=`)
+
+// expandConnectionStringEnv expands ${VAR} and $VAR references in the connection string using the process environment,
+// where VAR is a valid environment variable name ([A-Za-z_][A-Za-z0-9_]*). $$ is an escaped $, and any other $ is left
+// unchanged (so a password containing a $ does not need escaping, unless the $ is followed by a variable name).
+// An error is returned if any referenced variable is not set
+func expandConnectionStringEnv(connectionString string) (string, error) {
+	var missing []string
+	res := envReferenceRegex.ReplaceAllStringFunc(connectionString, func(reference string) string {
+		if reference == "$$" {
+			return "$"
+		}
+		name := strings.Trim(reference, "${}")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		missing = helpers.StringSliceDistinct(missing)
+		return "", sperr.New("connection string references unset environment %s: %s", utils.Pluralize("variable", len(missing)), strings.Join(missing, ", "))
+	}
+	return res, nil
+}
+
+// readConnectionStringFile reads the connection string from the given file, trimming any surrounding whitespace
+// (e.g. the trailing newline added by most secret managers)
+// NOTE: the error messages do not include the file contents, as these are credentials
+func readConnectionStringFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "failed to read connection string file '%s'", path)
+	}
+	connectionString := strings.TrimSpace(string(content))
+	if connectionString == "" {
+		return "", sperr.New("connection string file '%s' is empty", path)
+	}
+	return connectionString, nil
+}
+
+// isKeywordValueConnectionString returns whether the connection string is a postgres keyword/value connection string,
+// e.g. host=/var/run/postgresql dbname=steampipe, rather than a url
+func isKeywordValueConnectionString(connectionString string) bool {
+	return !strings.Contains(connectionString, "://") && keywordValueRegex.MatchString(connectionString)
+}
+
+// keywordValueToPostgresURL converts a postgres keyword/value connection string to the equivalent connection url,
+// with each keyword passed as a parameter, e.g. host=/var/run/postgresql dbname=steampipe is converted to
+// postgresql://?dbname=steampipe&host=%2Fvar%2Frun%2Fpostgresql
+// Values may be single quoted, and may contain backslash escaped quotes and backslashes, as supported by libpq
+// NOTE: the error messages do not include the connection string - if it is malformed, the password may not be redacted
+func keywordValueToPostgresURL(connectionString string) (string, error) {
+	params := url.Values{}
+	s := strings.TrimSpace(connectionString)
+	for len(s) > 0 {
+		key, rest, ok := strings.Cut(s, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t\n") {
+			return "", sperr.New("invalid keyword/value connection string: expected keyword=value pairs")
+		}
+		s = strings.TrimLeft(rest, " \t\n")
+
+		var value strings.Builder
+		quoted := strings.HasPrefix(s, "'")
+		if quoted {
+			s = s[1:]
+		}
+		i := 0
+		for ; i < len(s); i++ {
+			c := s[i]
+			if quoted && c == '\'' {
+				break
+			}
+			if !quoted && strings.ContainsRune(" \t\n", rune(c)) {
+				break
+			}
+			if c == '\\' && i+1 < len(s) {
+				i++
+				c = s[i]
+			}
+			value.WriteByte(c)
+		}
+		if quoted && i == len(s) {
+			return "", sperr.New("invalid keyword/value connection string: unterminated quoted value for '%s'", key)
+		}
+		if quoted {
+			// skip the closing quote
+			i++
+		}
+		params.Set(key, value.String())
+		s = strings.TrimLeft(s[i:], " \t\n")
+	}
+	return postgresScheme + "?" + params.Encode(), nil
+}
+
+// validatePostgresConnectionString checks a postgres connection url is well formed before attempting to connect,
+// returning a friendly error rather than an obscure driver error.
+// Multiple hosts are supported, e.g. postgres://user@host1:5432,host2:5432/db
+// The host may be omitted, e.g. postgres:///steampipe - the driver then uses PGHOST, or the default unix socket
+// directory, or localhost
+// NOTE: the error messages only include the redacted connection string, and never the url parse error
+// (which may include the password)
+func validatePostgresConnectionString(connectionString string) error {
+	redacted := localutils.RedactConnectionString(connectionString)
+
+	scheme, rest, _ := strings.Cut(connectionString, "://")
+	authority, path := rest, ""
+	if i := strings.IndexAny(rest, "/?"); i != -1 {
+		authority, path = rest[:i], rest[i:]
+	}
+	var userInfo string
+	hosts := authority
+	if i := strings.LastIndex(authority, "@"); i != -1 {
+		userInfo, hosts = authority[:i+1], authority[i+1:]
+	}
+
+	// validate each of the hosts, and replace them with the first so the remainder of the url may be parsed
+	var firstHost string
+	if hosts != "" {
+		for _, host := range strings.Split(hosts, ",") {
+			if err := validateConnectionStringHost(host, redacted); err != nil {
+				return err
+			}
+		}
+		firstHost, _, _ = strings.Cut(hosts, ",")
+	}
+
+	u, err := url.Parse(scheme + "://" + userInfo + firstHost + path)
+	if err != nil {
+		return sperr.New("invalid connection string %s: could not parse as a url", redacted)
+	}
+	if u.User != nil && u.User.Username() == "" {
+		return sperr.New("missing user in connection string %s", redacted)
+	}
+	return nil
+}
+
+// validateConnectionStringHost validates a host[:port] from a connection url
+// the host may be empty, e.g. :5432, in which case the driver uses the default host
+func validateConnectionStringHost(host, redactedConnectionString string) error {
+	var port string
+	if strings.HasPrefix(host, "[") {
+		// ipv6 address, e.g. [::1]:5432
+		end := strings.Index(host, "]")
+		if end == -1 {
+			return sperr.New("invalid host '%s' in connection string %s", host, redactedConnectionString)
+		}
+		port = strings.TrimPrefix(host[end+1:], ":")
+		host = host[1:end]
+	} else if i := strings.LastIndex(host, ":"); i != -1 {
+		host, port = host[:i], host[i+1:]
+	}
+	if port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return sperr.New("invalid port '%s' in connection string %s", port, redactedConnectionString)
+		}
+	}
+	return nil
+}
+
+// withApplicationName adds the application_name parameter to a postgres connection url
+// if the connection string already sets an application name, it is not overridden
+func withApplicationName(connectionString, applicationName string) (string, error) {
+	if applicationName == "" {
+		return connectionString, nil
+	}
+	u, err := url.Parse(connectionString)
+	if err != nil {
+		return "", sperr.New("invalid connection string %s", localutils.RedactConnectionString(connectionString))
+	}
+	if u.Query().Has(applicationNameParam) {
+		return connectionString, nil
+	}
+	return withConnectionParam(connectionString, applicationNameParam, url.QueryEscape(applicationName)), nil
+}
+
+// withPostgresReadOnly adds the default_transaction_read_only parameter to a postgres connection url,
+// so any write query fails
+// an error is returned if the connection string already sets this to a different value
+func withPostgresReadOnly(connectionString string) (string, error) {
+	u, err := url.Parse(connectionString)
+	if err != nil {
+		return "", sperr.New("invalid connection string %s", localutils.RedactConnectionString(connectionString))
+	}
+	query := u.Query()
+	if query.Has(readOnlyParam) {
+		if !isPostgresTrue(query.Get(readOnlyParam)) {
+			return "", sperr.New("connection string %s sets %s, which conflicts with read-only mode", localutils.RedactConnectionString(connectionString), readOnlyParam)
+		}
+		return connectionString, nil
+	}
+	return withConnectionParam(connectionString, readOnlyParam, "on"), nil
+}
+
+// isPostgresTrue returns whether the value is one of the (case insensitive) spellings postgres accepts for a true
+// boolean parameter - on, 1, or true or yes (or a prefix of these, e.g. t or y)
+func isPostgresTrue(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return false
+	}
+	return value == "on" || value == "1" || strings.HasPrefix("true", value) || strings.HasPrefix("yes", value)
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // ExecuteQuery executes a single query. If shutdownAfterCompletion is true, shutdown the client after completion
-func ExecuteQuery(ctx context.Context, client DbClient, queryString string, args ...any) (*queryresult.ResultStreamer, error) {
+func ExecuteQuery(ctx context.Context, client *DbClient, queryString string, args ...any) (*queryresult.ResultStreamer, error) {
 	utils.LogTime("db.ExecuteQuery start")
 	defer utils.LogTime("db.ExecuteQuery end")
 
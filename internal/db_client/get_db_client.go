@@ -0,0 +1,141 @@
+package db_client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/steampipeconfig"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// GetDbClient attempts to create a DbClient for each of the given connection strings in order,
+// returning the first client which connects successfully.
+// If credential providers are set using WithCredentialProviders, the connection strings are obtained from these instead
+// A warning is added for each connection string which fails to connect - an error is only returned if ALL fail
+func GetDbClient(ctx context.Context, connectionStrings []string, opts ...ClientOption) (*DbClient, error_helpers.ErrorAndWarnings) {
+	res := error_helpers.EmptyErrorsAndWarning()
+	clientConfig := NewClientConfig(opts...)
+
+	providers := clientConfig.CredentialProviders
+	if len(providers) == 0 {
+		providers = staticCredentialProviders(connectionStrings)
+	}
+	if len(providers) == 0 {
+		res.Error = ErrNoConnectionString
+		return nil, res
+	}
+
+	var lastErr error
+	for _, provider := range providers {
+		connectionString, err := provider.ConnectionString(ctx)
+		if err != nil {
+			// the error may include credentials - ensure these are not leaked into warnings, errors or logs
+			err = sperr.WrapWithMessage(utils.RedactConnectionStringError(err), "failed to get the database connection string")
+			if ctx.Err() != nil {
+				res.Error = ctx.Err()
+				return nil, res
+			}
+			slog.Warn("failed to get database connection string", "error", err)
+			res.AddWarning(err.Error())
+			lastErr = err
+			continue
+		}
+
+		client, err := newDbClient(ctx, connectionString, clientConfig)
+		if err == nil {
+			// if the credentials expire (e.g. IAM auth tokens), new credentials must be obtained to open new connections
+			if expiring, ok := provider.(ExpiringCredentialProvider); ok {
+				client.startCredentialRefresh(ctx, expiring)
+			}
+			return client, res
+		}
+		// the error may include the connection string - ensure credentials are not leaked into warnings, errors or logs
+		err = utils.RedactConnectionStringError(err)
+
+		// if the context was cancelled, there is no point trying the remaining connection strings
+		if ctx.Err() != nil {
+			res.Error = ctx.Err()
+			return nil, res
+		}
+
+		redacted := utils.RedactConnectionString(connectionString)
+		slog.Warn("failed to connect to database", "connection", redacted, "error", err)
+		res.AddWarning(fmt.Sprintf("failed to connect to %s: %s", redacted, err.Error()))
+		lastErr = err
+	}
+
+	// to get here, all connection attempts have failed
+	// if there was only a single connection string, just return the underlying error
+	// the error is categorised (using the last connection error) so callers can identify the kind of failure
+	if len(providers) == 1 {
+		return nil, error_helpers.NewErrorsAndWarning(newConnectionError(lastErr))
+	}
+	res.Error = newConnectionError(sperr.WrapWithMessage(lastErr, "failed to connect to any of the %d configured databases", len(providers)))
+	return nil, res
+}
+
+// ResolveDefaultConnectionStrings returns the ordered list of connection strings and the search path config which
+// Init uses to connect the default client. The connection strings are resolved with the following precedence:
+//   - ArgConnectionStrings, if set
+//   - otherwise the connection string of the named connection ArgConnection, if set
+//   - otherwise the contents of ArgConnectionStringFile, if set
+//   - otherwise ArgDatabase, if set (this defaults to the mod database)
+//   - otherwise the default connection
+//
+// Pipes workspace identifiers are resolved. Environment variable references are NOT expanded - these are expanded
+// when connecting, so the expanded values (which may be credentials) are not retained
+func ResolveDefaultConnectionStrings() ([]string, backend.SearchPathConfig, error) {
+	defaultDatabase, searchPathConfig, err := GetDefaultDatabaseConfig()
+	if err != nil {
+		return nil, searchPathConfig, err
+	}
+	connectionStrings, err := GetDefaultConnectionStrings(defaultDatabase)
+	if err != nil {
+		return nil, searchPathConfig, err
+	}
+	return connectionStrings, searchPathConfig, nil
+}
+
+// ResolveConnectionString returns the effective connection string which Init would use to connect the default client,
+// without connecting - this is the first of the connection strings returned by ResolveDefaultConnectionStrings,
+// with any environment variable references expanded
+// NOTE: the returned connection string may contain credentials - use RedactConnectionString before displaying it
+func ResolveConnectionString() (string, error) {
+	connectionStrings, _, err := ResolveDefaultConnectionStrings()
+	if err != nil {
+		return "", err
+	}
+	if len(connectionStrings) == 0 || connectionStrings[0] == "" {
+		return "", ErrNoConnectionString
+	}
+	return expandConnectionStringEnv(connectionStrings[0])
+}
+
+// GetDefaultConnectionStrings returns the ordered list of connection strings to use for the default client.
+// If ArgConnectionStrings has been set, this is used, otherwise the default database is used
+func GetDefaultConnectionStrings(defaultDatabase string) ([]string, error) {
+	connectionStrings := viper.GetStringSlice(localconstants.ArgConnectionStrings)
+	if len(connectionStrings) == 0 {
+		return []string{defaultDatabase}, nil
+	}
+
+	var res []string
+	for _, connectionString := range connectionStrings {
+		// if the database is a cloud workspace, resolve the connection string
+		if steampipeconfig.IsPipesWorkspaceIdentifier(connectionString) {
+			var err error
+			connectionString, err = GetCloudWorkspaceConnectionString(connectionString)
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, connectionString)
+	}
+	return res, nil
+}
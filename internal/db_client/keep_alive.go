@@ -0,0 +1,102 @@
+package db_client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// StartKeepAlive starts pinging the database at the given interval until the client is closed (or ctx is cancelled)
+// if a ping fails, the connection is assumed to have dropped and is re-established using the connection string
+// and options the client was created with - subsequent queries then use the new connection
+// if the interval is not positive, this does nothing
+func (c *DbClient) StartKeepAlive(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	c.dbLock.Lock()
+	defer c.dbLock.Unlock()
+	// if the keep-alive is already running, stop it - it is restarted with the new interval
+	if c.stopKeepAlive != nil {
+		c.stopKeepAlive()
+	}
+	// NOTE: the keep-alive must not be cancelled when the context used to start it is cancelled (typically the
+	// Init context), so the context values are kept but the cancellation is not
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c.stopKeepAlive = cancel
+
+	go c.keepAlive(ctx, interval)
+}
+
+func (c *DbClient) keepAlive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.pingOrReconnect(ctx, interval); err != nil {
+				// we will try again at the next interval
+				slog.Warn("database connection lost - failed to reconnect", "connection", utils.RedactConnectionString(c.connectionString), "error", utils.RedactConnectionStringError(err))
+			}
+		}
+	}
+}
+
+// pingOrReconnect pings the database and, if the ping fails, re-establishes the connection
+// the ping and reconnect are each allowed up to the keep-alive interval to complete
+func (c *DbClient) pingOrReconnect(ctx context.Context, timeout time.Duration) error {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	pingErr := c.getDb().PingContext(pingCtx)
+	if pingErr == nil || ctx.Err() != nil {
+		return nil
+	}
+	slog.Debug("database keep-alive ping failed - reconnecting", "error", utils.RedactConnectionStringError(pingErr))
+
+	reconnectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := c.reconnect(reconnectCtx); err != nil {
+		return err
+	}
+	slog.Warn("database connection was lost and has been re-established", "connection", utils.RedactConnectionString(c.connectionString), "error", utils.RedactConnectionStringError(pingErr))
+	return nil
+}
+
+// reconnect connects to the backend using the stored connect options and replaces the db handle
+// the previous db handle is closed once the queries already running on it have completed
+// if the client credentials expire, new credentials are obtained from the credential provider
+func (c *DbClient) reconnect(ctx context.Context) error {
+	b := c.Backend
+	if c.credentialProvider != nil {
+		var err error
+		if b, err = c.newCredentialBackend(ctx); err != nil {
+			return sperr.WrapWithMessage(err, "failed to reconnect to the database")
+		}
+	}
+	db, err := c.openDb(ctx, b, c.connectOpts...)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to reconnect to the database")
+	}
+
+	c.dbLock.Lock()
+	// if the client was closed while reconnecting, discard the new connection
+	if c.closed {
+		c.dbLock.Unlock()
+		return db.Close()
+	}
+	previous := c.db
+	c.db = db
+	c.dbLock.Unlock()
+
+	if previous != nil {
+		go func() { _ = previous.Close() }()
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package db_client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestSqliteClient(t *testing.T) *DbClient {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "keep_alive.db")
+	if err := os.WriteFile(dbPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := newDbClient(context.Background(), "sqlite://"+dbPath, NewClientConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close(context.Background()) })
+	return client
+}
+
+func TestPingOrReconnect(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSqliteClient(t)
+
+	// a healthy connection is not replaced
+	db := client.getDb()
+	if err := client.pingOrReconnect(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if client.getDb() != db {
+		t.Errorf("expected a healthy connection to be kept")
+	}
+
+	// simulate the connection dropping
+	_ = db.Close()
+	if err := client.pingOrReconnect(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if client.getDb() == db {
+		t.Errorf("expected a dropped connection to be replaced")
+	}
+	if _, err := client.ExecuteSync(ctx, "select 1"); err != nil {
+		t.Errorf("expected a query to succeed after reconnecting: %v", err)
+	}
+}
+
+func TestKeepAliveReconnects(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSqliteClient(t)
+
+	// a non-positive interval disables the keep-alive
+	client.StartKeepAlive(ctx, 0)
+	if client.stopKeepAlive != nil {
+		t.Fatal("expected the keep-alive not to be started for a zero interval")
+	}
+
+	db := client.getDb()
+	client.StartKeepAlive(ctx, 10*time.Millisecond)
+	_ = db.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.getDb() == db {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the keep-alive to re-establish the dropped connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := client.ExecuteSync(ctx, "select 1"); err != nil {
+		t.Errorf("expected a query to succeed after reconnecting: %v", err)
+	}
+}
+
+func TestReconnectAfterClose(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSqliteClient(t)
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// reconnecting a closed client must not reopen it
+	db := client.getDb()
+	if err := client.reconnect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if client.getDb() != db {
+		t.Errorf("expected a closed client not to be reconnected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/turbot/pipe-fittings/constants"
 )
 
+// MaxDbConnections returns the max parallelism - this is ArgMaxParallel if set, otherwise the default max connections
 func MaxDbConnections() int {
 	maxParallel := constants.DefaultMaxConnections
 	if viper.IsSet(constants.ArgMaxParallel) {
@@ -0,0 +1,96 @@
+package db_client
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// PoolConfig is the sizing of the database connection pool
+type PoolConfig struct {
+	// the maximum number of open connections
+	MaxConns int
+	// the number of connections which are opened when the client is created and kept open while idle
+	MinConns int
+}
+
+// GetPoolConfig returns the pool config from the ArgDbPoolMaxConns and ArgDbPoolMinConns args
+// if ArgDbPoolMaxConns is not set, the max connections defaults to MaxDbConnections
+func GetPoolConfig() PoolConfig {
+	maxConns := MaxDbConnections()
+	if viper.IsSet(localconstants.ArgDbPoolMaxConns) {
+		maxConns = viper.GetInt(localconstants.ArgDbPoolMaxConns)
+	}
+	return PoolConfig{
+		MaxConns: maxConns,
+		MinConns: viper.GetInt(localconstants.ArgDbPoolMinConns),
+	}
+}
+
+func (c PoolConfig) Validate() error {
+	if c.MaxConns < 1 {
+		return sperr.New("database pool max connections must be at least 1")
+	}
+	if c.MinConns < 0 {
+		return sperr.New("database pool min connections must not be negative")
+	}
+	if c.MaxConns < c.MinConns {
+		return sperr.New("database pool max connections (%d) must be greater than or equal to min connections (%d)", c.MaxConns, c.MinConns)
+	}
+	return nil
+}
+
+// apply sets the pool idle size and opens the minimum number of connections
+func (c PoolConfig) apply(ctx context.Context, db *sql.DB) error {
+	if c.MinConns == 0 {
+		return nil
+	}
+	// ensure the min connections are retained when idle
+	db.SetMaxIdleConns(c.MinConns)
+
+	if _, err := openPoolConns(ctx, db, c.MinConns); err != nil {
+		return sperr.WrapWithMessage(err, "failed to open database pool connections")
+	}
+	return nil
+}
+
+// WarmUp opens the given number of pool connections (capped at the pool max connections) and releases them back into
+// the pool, where they are kept open while idle - this avoids the first queries waiting for connections to be opened
+// the number of connections opened is returned, along with the error which stopped the warm-up (if any)
+func (c *DbClient) WarmUp(ctx context.Context, count int) (int, error) {
+	count = min(count, c.pool.MaxConns)
+	if count <= 0 {
+		return 0, nil
+	}
+	db := c.getDb()
+	// ensure the warmed connections are retained when idle
+	db.SetMaxIdleConns(max(count, c.pool.MinConns))
+
+	opened, err := openPoolConns(ctx, db, count)
+	if err != nil {
+		return opened, sperr.WrapWithMessage(err, "failed to open database pool connection %d of %d", opened+1, count)
+	}
+	return opened, nil
+}
+
+// openPoolConns opens the given number of connections, then releases them back into the pool
+// the number of connections opened is returned
+func openPoolConns(ctx context.Context, db *sql.DB, count int) (int, error) {
+	conns := make([]*sql.Conn, 0, count)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	for range count {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return len(conns), err
+		}
+		conns = append(conns, conn)
+	}
+	return len(conns), nil
+}
@@ -0,0 +1,30 @@
+package db_client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	ctx := context.Background()
+	client := newTestSqliteClient(t)
+	client.pool = PoolConfig{MaxConns: 3}
+
+	// the warm-up is capped at the pool max connections, and the connections are kept open while idle
+	opened, err := client.WarmUp(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened != 3 {
+		t.Errorf("expected 3 connections to be opened, got %d", opened)
+	}
+	if idle := client.getDb().Stats().Idle; idle != 3 {
+		t.Errorf("expected 3 idle connections, got %d", idle)
+	}
+
+	// a warm-up failure returns the number of connections opened
+	_ = client.getDb().Close()
+	if opened, err = client.WarmUp(ctx, 2); err == nil || opened != 0 {
+		t.Errorf("expected the warm-up of a closed pool to fail with no connections opened, got %d (%v)", opened, err)
+	}
+}
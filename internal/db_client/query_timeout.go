@@ -0,0 +1,80 @@
+package db_client
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// postgres error code returned when a statement is cancelled, e.g. due to the statement timeout
+const pgErrorCodeQueryCanceled = "57014"
+
+type queryTimeoutContextKey struct{}
+
+// ContextWithQueryTimeout returns a context which overrides the query timeout (ArgDatabaseQueryTimeout)
+// for queries executed using DbClient.Execute.
+// For postgres backends, the timeout is also applied as the session statement timeout,
+// so the database cancels the query even if the client is unresponsive
+func ContextWithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutContextKey{}, timeout)
+}
+
+func queryTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(queryTimeoutContextKey{}).(time.Duration)
+	return timeout, ok && timeout > 0
+}
+
+// QueryTimeoutError is returned when a query is cancelled because it exceeded its timeout
+type QueryTimeoutError struct {
+	Timeout time.Duration
+	err     error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	return fmt.Sprintf("query timed out after %s", e.Timeout)
+}
+
+func (e *QueryTimeoutError) Unwrap() error { return e.err }
+
+// NewQueryTimeoutError returns a QueryTimeoutError if the error was caused by the query exceeding its timeout -
+// either the context deadline or the postgres statement timeout - otherwise the error is returned unchanged
+func NewQueryTimeoutError(err error, timeout time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pgErr) && pgErr.Code == pgErrorCodeQueryCanceled) {
+		return &QueryTimeoutError{Timeout: timeout, err: err}
+	}
+	return err
+}
+
+// supportsStatementTimeout returns whether the backend supports the postgres statement_timeout setting
+func (c *DbClient) supportsStatementTimeout() bool {
+	name := c.Backend.Name()
+	return name == constants.PostgresBackendName || name == constants.SteampipeBackendName
+}
+
+// setStatementTimeout sets the statement timeout of the connection session, returning a func to reset it
+// (this must be called before the connection is returned to the pool)
+// if the backend does not support statement timeouts, this is a no-op
+func (c *DbClient) setStatementTimeout(ctx context.Context, dbConn *sql.Conn, timeout time.Duration) (func(), error) {
+	if !c.supportsStatementTimeout() {
+		return func() {}, nil
+	}
+	if _, err := dbConn.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, err
+	}
+	return func() {
+		// use a fresh context - the query context may have been cancelled by the timeout
+		if _, err := dbConn.ExecContext(context.Background(), "RESET statement_timeout"); err != nil {
+			slog.Warn("failed to reset the statement timeout", "error", err)
+		}
+	}, nil
+}
@@ -0,0 +1,46 @@
+package db_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestNewQueryTimeoutError(t *testing.T) {
+	tests := map[string]struct {
+		err       error
+		isTimeout bool
+	}{
+		"deadline exceeded":   {err: fmt.Errorf("query failed: %w", context.DeadlineExceeded), isTimeout: true},
+		"statement timeout":   {err: &pgconn.PgError{Code: pgErrorCodeQueryCanceled, Message: "canceling statement due to statement timeout"}, isTimeout: true},
+		"context cancelled":   {err: context.Canceled},
+		"other postgres code": {err: &pgconn.PgError{Code: "42P01", Message: "relation does not exist"}},
+	}
+	for name, test := range tests {
+		err := NewQueryTimeoutError(test.err, 30*time.Second)
+		var timeoutErr *QueryTimeoutError
+		if errors.As(err, &timeoutErr) != test.isTimeout {
+			t.Errorf("%s: expected timeout error %v, got %v", name, test.isTimeout, err)
+			continue
+		}
+		if test.isTimeout && (timeoutErr.Timeout != 30*time.Second || !errors.Is(err, test.err)) {
+			t.Errorf("%s: expected the timeout error to have the timeout and wrap the original error", name)
+		}
+	}
+}
+
+func TestQueryTimeoutFromContext(t *testing.T) {
+	if _, ok := queryTimeoutFromContext(context.Background()); ok {
+		t.Errorf("expected no query timeout")
+	}
+	if timeout, ok := queryTimeoutFromContext(ContextWithQueryTimeout(context.Background(), time.Minute)); !ok || timeout != time.Minute {
+		t.Errorf("expected a query timeout of 1m, got %v", timeout)
+	}
+	if _, ok := queryTimeoutFromContext(ContextWithQueryTimeout(context.Background(), 0)); ok {
+		t.Errorf("expected a zero query timeout to be ignored")
+	}
+}
@@ -0,0 +1,165 @@
+package db_client
+
+import (
+	"strings"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+// duckDBFunctionTranslations maps postgres json functions to the equivalent DuckDB json extension functions
+var duckDBFunctionTranslations = map[string]string{
+	"json_agg":           "json_group_array",
+	"jsonb_agg":          "json_group_array",
+	"json_object_agg":    "json_group_object",
+	"jsonb_object_agg":   "json_group_object",
+	"json_build_array":   "json_array",
+	"jsonb_build_array":  "json_array",
+	"json_build_object":  "json_object",
+	"jsonb_build_object": "json_object",
+	"jsonb_array_length": "json_array_length",
+	"to_jsonb":           "to_json",
+}
+
+// translateQuery translates the postgres specific parts of a query to the dialect of the backend
+// mod queries are generally written for postgres (steampipe) - for DuckDB:
+//   - casts to the jsonb type are translated to json (DuckDB has no jsonb type), e.g. ::jsonb
+//   - postgres json functions are translated to the DuckDB equivalents (see duckDBFunctionTranslations)
+//
+// string literals, quoted identifiers and comments are not translated
+// queries for other backends are returned unchanged
+func translateQuery(backendName, query string) string {
+	if backendName != constants.DuckDBBackendName {
+		return query
+	}
+	return translateDuckDBQuery(query)
+}
+
+func translateDuckDBQuery(query string) string {
+	var res strings.Builder
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := quotedEnd(query, i, c)
+			res.WriteString(query[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				end = len(query) - i
+			}
+			res.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			res.WriteString(query[i : i+end])
+			i += end
+		case c == '$' && dollarQuoteTag(query[i:]) != "":
+			tag := dollarQuoteTag(query[i:])
+			end := strings.Index(query[i+len(tag):], tag)
+			if end == -1 {
+				end = len(query) - i
+			} else {
+				end += 2 * len(tag)
+			}
+			res.WriteString(query[i : i+end])
+			i += end
+		case isIdentifierStart(c):
+			end := i + 1
+			for end < len(query) && isIdentifierChar(query[end]) {
+				end++
+			}
+			res.WriteString(translateDuckDBIdentifier(query[i:end], res.String(), query[end:]))
+			i = end
+		case c >= '0' && c <= '9':
+			// copy numbers (and parameter placeholders, e.g. $1) as a whole, so a trailing identifier is not translated
+			end := i + 1
+			for end < len(query) && isIdentifierChar(query[end]) {
+				end++
+			}
+			res.WriteString(query[i:end])
+			i = end
+		default:
+			res.WriteByte(c)
+			i++
+		}
+	}
+	return res.String()
+}
+
+// translateDuckDBIdentifier returns the DuckDB translation of the identifier (or keyword), using the preceding
+// (translated) query and the remainder of the query to determine whether it is a function name or a type name
+func translateDuckDBIdentifier(identifier, preceding, remainder string) string {
+	preceding = strings.TrimRight(preceding, " \t\r\n")
+	// do not translate qualified names, e.g. a column t.jsonb
+	if strings.HasSuffix(preceding, ".") {
+		return identifier
+	}
+	name := strings.ToLower(identifier)
+
+	// a function call, e.g. jsonb_build_object(...)
+	if strings.HasPrefix(strings.TrimLeft(remainder, " \t\r\n"), "(") {
+		if translated, ok := duckDBFunctionTranslations[name]; ok {
+			return translated
+		}
+		return identifier
+	}
+	// a cast to jsonb, i.e. ::jsonb or CAST(... AS jsonb)
+	if name == "jsonb" && (strings.HasSuffix(preceding, "::") || endsWithKeyword(preceding, "as")) {
+		return "json"
+	}
+	return identifier
+}
+
+// endsWithKeyword returns whether s ends with the (case insensitive) keyword
+func endsWithKeyword(s, keyword string) bool {
+	if len(s) < len(keyword) || !strings.EqualFold(s[len(s)-len(keyword):], keyword) {
+		return false
+	}
+	return len(s) == len(keyword) || !isIdentifierChar(s[len(s)-len(keyword)-1])
+}
+
+// quotedEnd returns the index after the closing quote of the string literal or quoted identifier starting at start
+// (a doubled quote is an escaped quote)
+func quotedEnd(query string, start int, quote byte) int {
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+// dollarQuoteTag returns the tag of the dollar quoted string at the start of s, e.g. $$ or $body$,
+// or an empty string if s does not start with a dollar quote (e.g. a $1 parameter placeholder)
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case isIdentifierStart(s[i]) || (i > 1 && isIdentifierChar(s[i])):
+			continue
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
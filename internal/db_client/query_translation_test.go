@@ -0,0 +1,73 @@
+package db_client
+
+import (
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+func TestTranslateQuery(t *testing.T) {
+	tests := map[string]struct {
+		backendName string
+		query       string
+		want        string
+	}{
+		"jsonb cast": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select details::jsonb from findings",
+			want:        "select details::json from findings",
+		},
+		"jsonb cast with whitespace": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select details :: JSONB from findings",
+			want:        "select details :: json from findings",
+		},
+		"cast as jsonb": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select cast(details as jsonb) from findings",
+			want:        "select cast(details as json) from findings",
+		},
+		"json functions": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select jsonb_build_object('id', id), json_agg(id), JSONB_ARRAY_LENGTH (details::jsonb) from findings",
+			want:        "select json_object('id', id), json_group_array(id), json_array_length (details::json) from findings",
+		},
+		"column named jsonb": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select jsonb, f.jsonb, id as jsonb_id from findings f",
+			want:        "select jsonb, f.jsonb, id as jsonb_id from findings f",
+		},
+		"qualified function": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select my_schema.json_agg(id) from findings",
+			want:        "select my_schema.json_agg(id) from findings",
+		},
+		"string literals and quoted identifiers": {
+			backendName: constants.DuckDBBackendName,
+			query:       `select 'it''s ::jsonb', "json_agg"(id), $$jsonb_agg(id)$$, $tag$::jsonb$tag$ from findings`,
+			want:        `select 'it''s ::jsonb', "json_agg"(id), $$jsonb_agg(id)$$, $tag$::jsonb$tag$ from findings`,
+		},
+		"comments": {
+			backendName: constants.DuckDBBackendName,
+			query:       "select id -- ::jsonb\n/* json_agg(id) */ from findings where id = $1::jsonb",
+			want:        "select id -- ::jsonb\n/* json_agg(id) */ from findings where id = $1::json",
+		},
+		"postgres": {
+			backendName: constants.PostgresBackendName,
+			query:       "select jsonb_agg(details::jsonb) from findings",
+			want:        "select jsonb_agg(details::jsonb) from findings",
+		},
+		"sqlite": {
+			backendName: constants.SQLiteBackendName,
+			query:       "select jsonb_agg(details::jsonb) from findings",
+			want:        "select jsonb_agg(details::jsonb) from findings",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := translateQuery(test.backendName, test.query); got != test.want {
+				t.Errorf("translateQuery() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package db_client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	// RDS IAM auth tokens may be used to open new connections for 15 minutes after they are generated
+	rdsIAMTokenLifetime = 15 * time.Minute
+	// the SHA-256 hash of an empty payload - the auth token request has no body
+	emptyPayloadHash    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	defaultPostgresPort = "5432"
+)
+
+// RDSIAMCredentialProvider is a CredentialProvider for RDS (and Aurora) postgres databases which use IAM authentication
+// ConnectionString generates a new IAM auth token each time it is called, which is used as the connection password
+// the AWS credentials and region are resolved using the AWS default credential chain and shared config
+// (environment variables, shared config/credentials files, container and instance roles)
+type RDSIAMCredentialProvider struct {
+	connectionString string
+
+	// the AWS credentials used to sign the auth token - these are loaded the first time a token is generated
+	credentials aws.CredentialsProvider
+	// the region configured in the AWS shared config - this is used if the region cannot be determined from the host
+	configuredRegion string
+	configLock       sync.Mutex
+}
+
+// NewRDSIAMCredentialProvider returns a provider for the connection string, which must specify the database user
+// and must not specify a password
+func NewRDSIAMCredentialProvider(connectionString string) *RDSIAMCredentialProvider {
+	return &RDSIAMCredentialProvider{connectionString: connectionString}
+}
+
+// RDSIAMCredentialProviders returns an RDSIAMCredentialProvider for each of the connection strings
+func RDSIAMCredentialProviders(connectionStrings []string) []CredentialProvider {
+	res := make([]CredentialProvider, len(connectionStrings))
+	for idx, connectionString := range connectionStrings {
+		res[idx] = NewRDSIAMCredentialProvider(connectionString)
+	}
+	return res
+}
+
+// ConnectionString returns the connection string with a newly generated IAM auth token as the password
+func (p *RDSIAMCredentialProvider) ConnectionString(ctx context.Context) (string, error) {
+	connectionString, err := expandConnectionStringEnv(p.connectionString)
+	if err != nil {
+		return "", err
+	}
+	if !hasConnectionStringScheme(connectionString) {
+		connectionString = postgresScheme + connectionString
+	}
+	if !backend.IsPostgresConnectionString(connectionString) {
+		return "", sperr.New("rds-iam authentication is only supported for postgres databases - it cannot be used for connection string %s", utils.RedactConnectionString(connectionString))
+	}
+	u, err := url.Parse(connectionString)
+	if err != nil {
+		// the parse error includes the connection string
+		return "", sperr.New("invalid connection string %s", utils.RedactConnectionString(connectionString))
+	}
+	user := u.User.Username()
+	if user == "" {
+		return "", sperr.New("connection string %s must specify the database user for rds-iam authentication", utils.RedactConnectionString(connectionString))
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return "", sperr.New("connection string %s must not specify a password for rds-iam authentication", utils.RedactConnectionString(connectionString))
+	}
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		return "", sperr.New("connection string %s must specify the database host for rds-iam authentication", utils.RedactConnectionString(connectionString))
+	}
+	if port == "" {
+		port = defaultPostgresPort
+	}
+
+	creds, configuredRegion, err := p.loadConfig(ctx)
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "failed to load the AWS credentials for rds-iam authentication")
+	}
+	region := rdsRegionFromHost(host)
+	if region == "" {
+		region = configuredRegion
+	}
+	if region == "" {
+		return "", sperr.New("could not determine the AWS region of database host '%s' - set the AWS_REGION environment variable", host)
+	}
+
+	token, err := buildRDSAuthToken(ctx, net.JoinHostPort(host, port), region, user, creds)
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "failed to generate the rds-iam auth token for database user '%s'", user)
+	}
+	u.User = url.UserPassword(user, token)
+	return u.String(), nil
+}
+
+// CredentialLifetime implements ExpiringCredentialProvider
+func (p *RDSIAMCredentialProvider) CredentialLifetime() time.Duration {
+	return rdsIAMTokenLifetime
+}
+
+// loadConfig returns the AWS credentials and configured region, loading the AWS config the first time it is called
+func (p *RDSIAMCredentialProvider) loadConfig(ctx context.Context) (aws.CredentialsProvider, string, error) {
+	p.configLock.Lock()
+	defer p.configLock.Unlock()
+	if p.credentials == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		p.credentials = cfg.Credentials
+		p.configuredRegion = cfg.Region
+	}
+	return p.credentials, p.configuredRegion, nil
+}
+
+// buildRDSAuthToken returns an IAM auth token for the database user - this is a presigned URL (without the scheme)
+// for the rds-db:connect action on the database endpoint, which is valid for 15 minutes
+// (this is the token generated by BuildAuthToken in the aws-sdk-go-v2 feature/rds/auth module)
+func buildRDSAuthToken(ctx context.Context, endpoint, region, user string, creds aws.CredentialsProvider) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://"+endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	query.Set("Action", "connect")
+	query.Set("DBUser", user)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(rdsIAMTokenLifetime.Seconds())))
+	req.URL.RawQuery = query.Encode()
+
+	credentials, err := creds.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	signedURL, _, err := v4.NewSigner().PresignHTTP(ctx, credentials, req, emptyPayloadHash, "rds-db", region, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(signedURL, "https://"), nil
+}
+
+// rdsRegionFromHost returns the region of an RDS endpoint, e.g. mydb.abc123.us-east-1.rds.amazonaws.com
+// an empty string is returned if the host is not an RDS endpoint (e.g. it is a custom DNS name)
+func rdsRegionFromHost(host string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i := 1; i < len(labels)-1; i++ {
+		if labels[i] == "rds" && labels[i+1] == "amazonaws" {
+			return labels[i-1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package db_client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// ErrSteampipeVersionUnavailable is returned by SteampipeVersion if the Steampipe server does not report its version
+// (the server settings table was added in Steampipe v0.21.0)
+var ErrSteampipeVersionUnavailable = errors.New("the Steampipe server does not report its version")
+
+// pgUndefinedTable is the postgres error code returned when a table does not exist
+const pgUndefinedTable = "42P01"
+
+// SteampipeVersion returns the version of the Steampipe server the client is connected to
+// nil is returned if the client backend is not Steampipe
+func (c *DbClient) SteampipeVersion(ctx context.Context) (*semver.Version, error) {
+	if _, ok := c.Backend.(*backend.SteampipeBackend); !ok {
+		return nil, nil
+	}
+
+	var versionString string
+	err := c.getDb().QueryRowContext(ctx, "SELECT steampipe_version FROM steampipe_internal.steampipe_server_settings").Scan(&versionString)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable {
+			return nil, ErrSteampipeVersionUnavailable
+		}
+		return nil, sperr.WrapWithMessage(err, "failed to read the Steampipe server version")
+	}
+	version, err := semver.NewVersion(versionString)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "invalid Steampipe server version '%s'", versionString)
+	}
+	return version, nil
+}
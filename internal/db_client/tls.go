@@ -0,0 +1,163 @@
+package db_client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// the postgres connection parameters used to configure TLS
+const (
+	sslModeParam     = "sslmode"
+	sslRootCertParam = "sslrootcert"
+	sslCertParam     = "sslcert"
+	sslKeyParam      = "sslkey"
+	// the ssl mode used if a root certificate is set and the connection string does not set an ssl mode
+	// (with the postgres default of 'prefer', the server certificate is not verified)
+	defaultRootCertSslMode = "verify-full"
+)
+
+// TLSConfig is the TLS configuration for postgres connections - this allows the server certificate to be verified
+// using a private certificate authority, and a client certificate to be used to authenticate
+type TLSConfig struct {
+	// the path of a PEM file containing the certificate authority certificate(s) used to verify the server
+	RootCert string
+	// the paths of the PEM client certificate and its private key
+	Cert string
+	Key  string
+}
+
+// GetTLSConfig returns the TLS config from the ArgDbSslRootCert, ArgDbSslCert and ArgDbSslKey args
+func GetTLSConfig() TLSConfig {
+	return TLSConfig{
+		RootCert: viper.GetString(localconstants.ArgDbSslRootCert),
+		Cert:     viper.GetString(localconstants.ArgDbSslCert),
+		Key:      viper.GetString(localconstants.ArgDbSslKey),
+	}
+}
+
+func (c TLSConfig) Empty() bool {
+	return c.RootCert == "" && c.Cert == "" && c.Key == ""
+}
+
+// Validate checks the certificate and key files exist and are well formed, so a missing or malformed file
+// is reported clearly, rather than as a TLS handshake failure
+func (c TLSConfig) Validate() error {
+	if c.RootCert != "" {
+		if err := validateRootCertFile(c.RootCert); err != nil {
+			return err
+		}
+	}
+	if (c.Cert == "") != (c.Key == "") {
+		return sperr.New("both the database ssl client certificate and key must be set")
+	}
+	if c.Cert != "" {
+		if err := validateTLSFile("client certificate", c.Cert); err != nil {
+			return err
+		}
+		if err := validateTLSFile("client key", c.Key); err != nil {
+			return err
+		}
+		if _, err := tls.LoadX509KeyPair(c.Cert, c.Key); err != nil {
+			return sperr.New("invalid database ssl client certificate '%s' or key '%s': %s", c.Cert, c.Key, err.Error())
+		}
+	}
+	return nil
+}
+
+// validateRootCertFile checks the file contains at least one certificate, and that all certificates are valid
+func validateRootCertFile(path string) error {
+	if err := validateTLSFile("root certificate", path); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to read database ssl root certificate '%s'", path)
+	}
+	count := 0
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return sperr.New("database ssl root certificate '%s' contains an invalid certificate: %s", path, err.Error())
+		}
+		count++
+	}
+	if count == 0 {
+		return sperr.New("database ssl root certificate '%s' does not contain any PEM encoded certificates", path)
+	}
+	return nil
+}
+
+func validateTLSFile(description, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sperr.New("database ssl %s '%s' does not exist", description, path)
+		}
+		return sperr.WrapWithMessage(err, "could not access database ssl %s '%s'", description, path)
+	}
+	if info.IsDir() {
+		return sperr.New("database ssl %s '%s' is a directory", description, path)
+	}
+	return nil
+}
+
+// withPostgresTLS adds the TLS parameters to a postgres connection url
+// if a root certificate is set and the connection string does not set an ssl mode, verify-full is used
+// an error is returned if the connection string already sets one of the parameters to a different value
+func withPostgresTLS(connectionString string, tlsConfig TLSConfig) (string, error) {
+	params := []struct {
+		name  string
+		value string
+	}{
+		{sslRootCertParam, tlsConfig.RootCert},
+		{sslCertParam, tlsConfig.Cert},
+		{sslKeyParam, tlsConfig.Key},
+	}
+	// compare the decoded values of any parameters the connection string already sets
+	u, err := url.Parse(connectionString)
+	if err != nil {
+		return "", sperr.New("invalid connection string %s", utils.RedactConnectionString(connectionString))
+	}
+	query := u.Query()
+	for _, param := range params {
+		if param.value == "" {
+			continue
+		}
+		if query.Has(param.name) {
+			if query.Get(param.name) != param.value {
+				return "", sperr.New("connection string %s sets %s, which conflicts with the configured database ssl certificates", utils.RedactConnectionString(connectionString), param.name)
+			}
+			continue
+		}
+		connectionString = withConnectionParam(connectionString, param.name, url.QueryEscape(param.value))
+	}
+	if tlsConfig.RootCert != "" && !query.Has(sslModeParam) {
+		connectionString = withConnectionParam(connectionString, sslModeParam, defaultRootCertSslMode)
+	}
+	return connectionString, nil
+}
+
+// withConnectionParam appends the (escaped) parameter to a connection url
+func withConnectionParam(connectionString, name, escapedValue string) string {
+	separator := "?"
+	if strings.Contains(connectionString, "?") {
+		separator = "&"
+	}
+	return connectionString + separator + name + "=" + escapedValue
+}
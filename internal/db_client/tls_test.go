@@ -0,0 +1,160 @@
+package db_client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key to PEM files in the directory
+func writeTestCertificate(t *testing.T, dir string) (certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCertificate(t, dir)
+	notPem := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPem, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	malformed := filepath.Join(dir, "malformed.pem")
+	if err := os.WriteFile(malformed, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		config TLSConfig
+		err    string
+	}{
+		"root cert":              {config: TLSConfig{RootCert: certPath}},
+		"client cert":            {config: TLSConfig{RootCert: certPath, Cert: certPath, Key: keyPath}},
+		"missing root cert":      {config: TLSConfig{RootCert: filepath.Join(dir, "missing.pem")}, err: "does not exist"},
+		"root cert is directory": {config: TLSConfig{RootCert: dir}, err: "is a directory"},
+		"root cert not pem":      {config: TLSConfig{RootCert: notPem}, err: "does not contain any PEM encoded certificates"},
+		"malformed root cert":    {config: TLSConfig{RootCert: malformed}, err: "contains an invalid certificate"},
+		"cert without key":       {config: TLSConfig{Cert: certPath}, err: "both the database ssl client certificate and key must be set"},
+		"missing key":            {config: TLSConfig{Cert: certPath, Key: filepath.Join(dir, "missing.key")}, err: "client key"},
+		"key is not a key":       {config: TLSConfig{Cert: certPath, Key: certPath}, err: "invalid database ssl client certificate"},
+	}
+	for name, test := range tests {
+		err := test.config.Validate()
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error containing %q, got %v", name, test.err, err)
+		}
+	}
+}
+
+func TestWithPostgresTLS(t *testing.T) {
+	tests := map[string]struct {
+		connectionString string
+		config           TLSConfig
+		want             string
+		err              bool
+	}{
+		"root cert": {
+			connectionString: "postgresql://user@host/db",
+			config:           TLSConfig{RootCert: "/certs/ca.pem"},
+			want:             "postgresql://user@host/db?sslrootcert=%2Fcerts%2Fca.pem&sslmode=verify-full",
+		},
+		"existing ssl mode is retained": {
+			connectionString: "postgresql://user@host/db?sslmode=verify-ca",
+			config:           TLSConfig{RootCert: "/certs/ca.pem"},
+			want:             "postgresql://user@host/db?sslmode=verify-ca&sslrootcert=%2Fcerts%2Fca.pem",
+		},
+		"client cert": {
+			connectionString: "postgresql://user@host/db",
+			config:           TLSConfig{Cert: "client.pem", Key: "client.key"},
+			want:             "postgresql://user@host/db?sslcert=client.pem&sslkey=client.key",
+		},
+		"same param": {
+			connectionString: "postgresql://user@host/db?sslrootcert=ca.pem&sslmode=require",
+			config:           TLSConfig{RootCert: "ca.pem"},
+			want:             "postgresql://user@host/db?sslrootcert=ca.pem&sslmode=require",
+		},
+		"same param with an escaped path": {
+			connectionString: "postgresql://user@host/db?sslrootcert=%2Fcerts%2Fca.pem",
+			config:           TLSConfig{RootCert: "/certs/ca.pem"},
+			want:             "postgresql://user@host/db?sslrootcert=%2Fcerts%2Fca.pem&sslmode=verify-full",
+		},
+		"same param with an unescaped path": {
+			connectionString: "postgresql://user@host/db?sslrootcert=/certs/ca.pem&sslmode=verify-ca",
+			config:           TLSConfig{RootCert: "/certs/ca.pem"},
+			want:             "postgresql://user@host/db?sslrootcert=/certs/ca.pem&sslmode=verify-ca",
+		},
+		"same param with a space in the path": {
+			connectionString: "postgresql://user@host/db?sslcert=my%20certs%2Fclient.pem&sslkey=my+certs/client.key",
+			config:           TLSConfig{Cert: "my certs/client.pem", Key: "my certs/client.key"},
+			want:             "postgresql://user@host/db?sslcert=my%20certs%2Fclient.pem&sslkey=my+certs/client.key",
+		},
+		"conflicting param with a common prefix": {
+			connectionString: "postgresql://user@host/db?sslrootcert=%2Fcerts%2Fca.pem.bak",
+			config:           TLSConfig{RootCert: "/certs/ca.pem"},
+			err:              true,
+		},
+		"conflicting param": {
+			connectionString: "postgresql://user@host/db?sslrootcert=other.pem",
+			config:           TLSConfig{RootCert: "ca.pem"},
+			err:              true,
+		},
+	}
+	for name, test := range tests {
+		got, err := withPostgresTLS(test.connectionString, test.config)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %s, got %s", name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package db_client
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// validateUnixSocketHosts checks that the unix domain socket hosts of a postgres connection url exist, returning a
+// clear error rather than the driver dial error.
+// A unix domain socket host is the absolute path of the directory containing the server socket, passed using the
+// host parameter, e.g. postgres:///db?host=/var/run/postgresql - the server socket in this directory is named
+// .s.PGSQL.<port>, e.g. /var/run/postgresql/.s.PGSQL.5432
+// As the driver tries each host in turn, an error is only returned if none of the hosts are usable - a tcp host is
+// assumed to be usable.
+// NOTE: the error messages only include the redacted connection string
+func validateUnixSocketHosts(connectionString string) error {
+	u, err := url.Parse(connectionString)
+	if err != nil {
+		// the connection string has already been validated - leave it to the driver to report the error
+		return nil
+	}
+	query := u.Query()
+	if query.Get("host") == "" {
+		return nil
+	}
+
+	hosts := strings.Split(query.Get("host"), ",")
+	ports := strings.Split(query.Get("port"), ",")
+	var socketErrors []string
+	for i, host := range hosts {
+		if !strings.HasPrefix(host, "/") {
+			return nil
+		}
+		// as with the driver, the ports correspond to the hosts, unless there is a single port used for all hosts
+		port := defaultPostgresPort
+		if len(ports) == len(hosts) && ports[i] != "" {
+			port = ports[i]
+		} else if len(ports) == 1 && ports[0] != "" {
+			port = ports[0]
+		}
+		err := validateUnixSocket(host, port)
+		if err == nil {
+			return nil
+		}
+		socketErrors = append(socketErrors, err.Error())
+	}
+
+	redacted := utils.RedactConnectionString(connectionString)
+	if len(socketErrors) == 1 {
+		return sperr.New("%s (connection string %s)", socketErrors[0], redacted)
+	}
+	return sperr.New("none of the unix socket hosts are usable: %s (connection string %s)", strings.Join(socketErrors, "; "), redacted)
+}
+
+// validateUnixSocket checks the socket directory and the server socket for the port exist
+func validateUnixSocket(dir, port string) error {
+	info, err := os.Stat(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sperr.New("unix socket directory '%s' does not exist", dir)
+	}
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to access unix socket directory '%s'", dir)
+	}
+	if !info.IsDir() {
+		return sperr.New("unix socket directory '%s' is not a directory", dir)
+	}
+
+	socketPath := filepath.Join(dir, ".s.PGSQL."+port)
+	if _, err := os.Stat(socketPath); errors.Is(err, fs.ErrNotExist) {
+		return sperr.New("unix socket '%s' does not exist - check the database server is running and listening on port %s", socketPath, port)
+	} else if err != nil {
+		return sperr.WrapWithMessage(err, "failed to access unix socket '%s'", socketPath)
+	}
+	return nil
+}
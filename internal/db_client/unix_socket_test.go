@@ -0,0 +1,143 @@
+package db_client
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenUnixSocket listens on a postgres server socket for the port in a new socket directory, returning the directory
+// and a channel which receives a value when a connection is accepted (the connection is closed immediately)
+func listenUnixSocket(t *testing.T, port string) (string, chan struct{}) {
+	// use a short directory name - socket paths are limited to around 100 characters
+	dir, err := os.MkdirTemp("", "pgsock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	listener, err := net.Listen("unix", filepath.Join(dir, ".s.PGSQL."+port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	accepted := make(chan struct{}, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+	return dir, accepted
+}
+
+func TestValidateUnixSocketHosts(t *testing.T) {
+	dir, _ := listenUnixSocket(t, "5433")
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "no host parameter", input: "postgres://user@localhost/db"},
+		{name: "no host", input: "postgresql://?dbname=steampipe"},
+		{name: "tcp host parameter", input: "postgres:///db?host=localhost"},
+		{name: "socket", input: "postgres:///db?host=" + dir + "&port=5433"},
+		{name: "socket and tcp hosts", input: "postgres:///db?host=localhost," + dir + "&port=5432,5433"},
+		{name: "missing directory", input: "postgres://user:secret@/db?host=/does/not/exist", wantErr: "unix socket directory '/does/not/exist' does not exist"},
+		{name: "not a directory", input: "postgres:///db?host=" + file, wantErr: "is not a directory"},
+		{name: "missing socket for default port", input: "postgres:///db?host=" + dir, wantErr: ".s.PGSQL.5432' does not exist"},
+		{name: "missing socket for port", input: "postgres:///db?host=" + dir + "&port=6000", wantErr: "listening on port 6000"},
+		// the driver tries each host in turn, so only fail if none of the hosts are usable
+		{name: "missing socket host and tcp host", input: "postgres:///db?host=/does/not/exist,localhost"},
+		{name: "missing socket host and socket host", input: "postgres:///db?host=/does/not/exist," + dir + "&port=5433"},
+		{name: "socket host and missing socket host", input: "postgres:///db?host=" + dir + ",/does/not/exist&port=5433"},
+		{name: "socket host and missing socket for port", input: "postgres:///db?host=" + dir + "," + dir + "&port=6000,5433"},
+		{
+			name:    "missing socket hosts",
+			input:   "postgres://user:secret@/db?host=/does/not/exist," + dir + "&port=5432,6000",
+			wantErr: "none of the unix socket hosts are usable: unix socket directory '/does/not/exist' does not exist; unix socket '" + filepath.Join(dir, ".s.PGSQL.6000") + "' does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUnixSocketHosts(tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateUnixSocketHosts() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateUnixSocketHosts() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if strings.Contains(err.Error(), "secret") {
+				t.Errorf("validateUnixSocketHosts() error leaks the password: %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestGetDbClientUnixSocket(t *testing.T) {
+	dir, accepted := listenUnixSocket(t, "5432")
+
+	tests := map[string]string{
+		"keyword/value": "host=" + dir + " dbname=steampipe user=steampipe",
+		"url":           "postgres://steampipe@/steampipe?host=" + dir,
+	}
+	for name, connectionString := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// the listener is not a postgres server, so the connection fails - but it must be made using the socket
+			_, errAndWarnings := GetDbClient(ctx, []string{connectionString})
+			if errAndWarnings.GetError() == nil {
+				t.Fatal("GetDbClient() expected an error connecting to the test listener")
+			}
+			select {
+			case <-accepted:
+			default:
+				t.Fatalf("GetDbClient() did not connect using the unix socket: %v", errAndWarnings.GetError())
+			}
+		})
+	}
+
+	t.Run("keyword/value with no host", func(t *testing.T) {
+		// with no host, the driver uses PGHOST (and then the default unix socket directory)
+		t.Setenv("PGHOST", dir)
+		t.Setenv("PGPORT", "")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, errAndWarnings := GetDbClient(ctx, []string{"dbname=steampipe"})
+		if err := errAndWarnings.GetError(); err == nil || strings.Contains(err.Error(), "missing host") {
+			t.Fatalf("GetDbClient() error = %v, want an error connecting to the test listener", err)
+		}
+		select {
+		case <-accepted:
+		default:
+			t.Fatalf("GetDbClient() did not connect using the unix socket: %v", errAndWarnings.GetError())
+		}
+	})
+
+	t.Run("missing socket directory", func(t *testing.T) {
+		_, errAndWarnings := GetDbClient(context.Background(), []string{"host=/does/not/exist dbname=steampipe"})
+		if err := errAndWarnings.GetError(); err == nil || !strings.Contains(err.Error(), "unix socket directory '/does/not/exist' does not exist") {
+			t.Fatalf("GetDbClient() error = %v, want a missing socket directory error", err)
+		}
+	})
+}
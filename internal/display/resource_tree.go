@@ -0,0 +1,72 @@
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+// ResourceTreeNode is a resource in the tree of resources which a run would execute
+type ResourceTreeNode struct {
+	Name      string
+	BlockType string
+	Title     string
+	// if set, this is shown after the resource, e.g. to indicate that a control would be skipped
+	Note     string
+	Children []*ResourceTreeNode
+}
+
+// WriteResourceTree writes the resources of the trees (indented by depth), followed by the number of
+// resources of each type - a resource which appears more than once in the trees is only counted once
+func WriteResourceTree(w io.Writer, roots ...*ResourceTreeNode) error {
+	var buf bytes.Buffer
+	// map of block type to the set of resource names of that type
+	resources := make(map[string]map[string]struct{})
+
+	var writeNode func(node *ResourceTreeNode, depth int)
+	writeNode = func(node *ResourceTreeNode, depth int) {
+		buf.WriteString(strings.Repeat("  ", depth))
+		buf.WriteString(node.Name)
+		if node.Title != "" {
+			fmt.Fprintf(&buf, " (%s)", node.Title)
+		}
+		if node.Note != "" {
+			fmt.Fprintf(&buf, " [%s]", node.Note)
+		}
+		buf.WriteString("\n")
+
+		if resources[node.BlockType] == nil {
+			resources[node.BlockType] = make(map[string]struct{})
+		}
+		resources[node.BlockType][node.Name] = struct{}{}
+
+		for _, child := range node.Children {
+			writeNode(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		writeNode(root, 0)
+	}
+
+	blockTypes := make([]string, 0, len(resources))
+	for blockType := range resources {
+		blockTypes = append(blockTypes, blockType)
+	}
+	sort.Strings(blockTypes)
+	counts := make([]string, len(blockTypes))
+	for idx, blockType := range blockTypes {
+		count := len(resources[blockType])
+		counts[idx] = fmt.Sprintf("%d %s", count, utils.Pluralize(blockType, count))
+	}
+	if len(counts) == 0 {
+		counts = append(counts, "no resources")
+	}
+	fmt.Fprintf(&buf, "\nTotal: %s\n", strings.Join(counts, ", "))
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
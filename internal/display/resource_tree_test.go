@@ -0,0 +1,53 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteResourceTree(t *testing.T) {
+	shared := &ResourceTreeNode{Name: "m.control.shared", BlockType: "control"}
+	roots := []*ResourceTreeNode{
+		{
+			Name:      "m.benchmark.b1",
+			BlockType: "benchmark",
+			Title:     "CIS",
+			Children: []*ResourceTreeNode{
+				{Name: "m.control.c1", BlockType: "control", Title: "Control 1"},
+				{Name: "m.control.c2", BlockType: "control", Note: "skipped"},
+				shared,
+			},
+		},
+		{
+			Name:      "m.benchmark.b2",
+			BlockType: "benchmark",
+			Children:  []*ResourceTreeNode{shared},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResourceTree(&buf, roots...); err != nil {
+		t.Fatal(err)
+	}
+	// the shared control is listed under both benchmarks, but only counted once
+	expected := `m.benchmark.b1 (CIS)
+  m.control.c1 (Control 1)
+  m.control.c2 [skipped]
+  m.control.shared
+m.benchmark.b2
+  m.control.shared
+
+Total: 2 benchmarks, 3 controls
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteResourceTree(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\nTotal: no resources\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
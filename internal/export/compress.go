@@ -0,0 +1,27 @@
+package export
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// gzipExtension is appended to the file name of compressed exports
+const gzipExtension = ".gz"
+
+// gzipReader returns a reader which reads the gzip compressed content of the source
+// the gzip writer is closed (flushing the gzip footer) once the source is fully read - if compression fails,
+// the error is returned from Read
+// the returned reader must be closed
+func gzipReader(source io.Reader) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		gzipWriter := gzip.NewWriter(writer)
+		_, err := io.Copy(gzipWriter, source)
+		// always close the gzip writer, but return the copy error in preference
+		if closeErr := gzipWriter.Close(); err == nil {
+			err = closeErr
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader
+}
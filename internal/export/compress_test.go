@@ -0,0 +1,79 @@
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// contentExporter writes fixed content to the destination path
+type contentExporter struct {
+	testExporter
+	content string
+}
+
+func (e *contentExporter) Export(_ context.Context, _ ExportSourceData, destPath string) error {
+	return os.WriteFile(destPath, []byte(e.content), 0600)
+}
+
+func TestCompressedExport(t *testing.T) {
+	tempDir := t.TempDir()
+	exporter := &contentExporter{testExporter: dummyJSONExporter, content: `{"status":"ok"}`}
+
+	m := NewManager()
+	if err := m.Register(exporter); err != nil {
+		t.Fatal(err)
+	}
+	m.SetCompress(true)
+
+	filePath := filepath.Join(tempDir, "check.json")
+	targets, err := m.resolveTargetsFromArgs(context.Background(), []string{filePath}, "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].destination() != filePath+gzipExtension {
+		t.Fatalf("expected a single target with the gzip extension, got %+v", targets)
+	}
+	if _, err := targets[0].Export(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the uncompressed file should not be written
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("expected no uncompressed export to be written")
+	}
+	f, err := os.Open(filePath + gzipExtension)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(gzipReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != exporter.content {
+		t.Errorf("expected decompressed content '%s', got '%s'", exporter.content, string(content))
+	}
+}
+
+func TestCompressedExportFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	exporter := &testExporter{extension: ".json", name: "json", err: errors.New("disk full")}
+
+	target := &Target{exporter: exporter, filePath: filepath.Join(tempDir, "check.json"), compress: true}
+	if _, err := target.Export(context.Background(), nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	// no partial file should be left behind
+	if _, err := os.Stat(target.fileName()); !os.IsNotExist(err) {
+		t.Errorf("expected no export to be written")
+	}
+}
@@ -0,0 +1,32 @@
+package export
+
+import (
+	"context"
+
+	"github.com/turbot/pipe-fittings/export"
+)
+
+// Exporter and ExportSourceData are defined in pipe-fittings - alias them here so exporters implemented
+// in pipe-fittings (e.g. export.SnapshotExporter) can be registered with the powerpipe Manager
+
+type Exporter = export.Exporter
+
+type ExportSourceData = export.ExportSourceData
+
+// DatabaseExporter is an exporter which writes to a database rather than a file - it is used for exports whose
+// destination is a connection string with one of the exporter's schemes (e.g. postgres://user@host/db), and the
+// connection string is passed to Export as the destination
+type DatabaseExporter interface {
+	Exporter
+	// ConnectionStringSchemes returns the connection string schemes of the databases the exporter writes to
+	ConnectionStringSchemes() []string
+}
+
+// MultiFileExporter is an exporter which may write more than one file for an export (e.g. the asff exporter, which
+// splits the findings into batch files) - the additional files are written alongside the destination path
+type MultiFileExporter interface {
+	Exporter
+	// ExportFiles exports in the same way as Export, returning the paths of all the files written
+	// (the first of which is the destination path)
+	ExportFiles(ctx context.Context, input ExportSourceData, destPath string) ([]string, error)
+}
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// ParseFileMode parses an octal file permission string, e.g. "0640" or "640"
+func ParseFileMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > uint64(os.ModePerm) {
+		return 0, sperr.New("invalid export file mode '%s' - this must be an octal permission value, e.g. 0640", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// dirModeForFileMode returns the mode used for directories created for export files with the given mode
+// - each class (user, group, other) which can read the files can also list (and enter) the directory,
+// e.g. 0640 -> 0750
+func dirModeForFileMode(mode os.FileMode) os.FileMode {
+	mode = mode.Perm()
+	return mode | (mode&0444)>>2
+}
+
+// createParentDirs creates the missing parent directories of the file path with the given mode
+// the mode is set explicitly, so it is not restricted by the process umask
+func createParentDirs(filePath string, mode os.FileMode) error {
+	dir := filepath.Dir(filePath)
+
+	// find the directories which do not exist yet, so only these have their mode set
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, d)
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return sperr.WrapWithMessage(err, "failed to create export directory '%s'", dir)
+	}
+	for _, d := range missing {
+		if err := os.Chmod(d, mode); err != nil {
+			return sperr.WrapWithMessage(err, "failed to set the mode of export directory '%s'", d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestExportFileMode(t *testing.T) {
+	// the mode must be applied regardless of the umask
+	oldUmask := syscall.Umask(0077)
+	defer syscall.Umask(oldUmask)
+
+	tempDir := t.TempDir()
+	exporter := &contentExporter{testExporter: dummyJSONExporter, content: `{"status":"ok"}`}
+
+	m := NewManager()
+	if err := m.Register(exporter); err != nil {
+		t.Fatal(err)
+	}
+	m.SetFileMode(0640)
+
+	filePath := filepath.Join(tempDir, "exports", "nested", "check.json")
+	targets, err := m.resolveTargetsFromArgs(context.Background(), []string{filePath}, "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := targets[0].Export(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected file mode 0640, got %o", info.Mode().Perm())
+	}
+	for _, dir := range []string{filepath.Dir(filePath), filepath.Join(tempDir, "exports")} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0750 {
+			t.Errorf("expected directory %s mode 0750, got %o", dir, info.Mode().Perm())
+		}
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != exporter.content {
+		t.Errorf("expected content '%s', got '%s'", exporter.content, string(content))
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := map[string]struct {
+		expected os.FileMode
+		wantErr  bool
+	}{
+		"0640": {expected: 0640},
+		"600":  {expected: 0600},
+		"0777": {expected: 0777},
+		"1777": {wantErr: true},
+		"0680": {wantErr: true},
+		"rw-r": {wantErr: true},
+	}
+	for input, test := range tests {
+		mode, err := ParseFileMode(input)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseFileMode(%s): expected an error", input)
+			}
+			continue
+		}
+		if err != nil || mode != test.expected {
+			t.Errorf("ParseFileMode(%s): expected %o, got %o (%v)", input, test.expected, mode, err)
+		}
+	}
+}
+
+func TestDirModeForFileMode(t *testing.T) {
+	for fileMode, expected := range map[os.FileMode]os.FileMode{0640: 0750, 0600: 0700, 0644: 0755, 0200: 0200} {
+		if got := dirModeForFileMode(fileMode); got != expected {
+			t.Errorf("dirModeForFileMode(%o): expected %o, got %o", fileMode, expected, got)
+		}
+	}
+}
@@ -0,0 +1,606 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/export"
+	"github.com/turbot/pipe-fittings/statushooks"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// maxParallelExports is the maximum number of export targets which are exported concurrently
+const maxParallelExports = 4
+
+// SourceFilter transforms the source data before it is passed to the exporters
+type SourceFilter func(ExportSourceData) (ExportSourceData, error)
+
+// ChainSourceFilters returns a SourceFilter which applies the filters in order
+func ChainSourceFilters(filters ...SourceFilter) SourceFilter {
+	return func(source ExportSourceData) (ExportSourceData, error) {
+		var err error
+		for _, filter := range filters {
+			if source, err = filter(source); err != nil {
+				return nil, err
+			}
+		}
+		return source, nil
+	}
+}
+
+// Manager resolves export arguments into export targets and runs the registered exporters
+type Manager struct {
+	registeredExporters  map[string]Exporter
+	registeredExtensions map[string]Exporter
+	// if set, the source data is filtered before being exported (this applies to all exporters)
+	sourceFilter SourceFilter
+	// if set, this is used to build the file path for exports specified by format name (e.g. --export=json)
+	pathTemplate string
+	// if set, all exports are gzip compressed
+	compress bool
+	// if set, local export files are created with this mode
+	fileMode os.FileMode
+	// if set, relative local export paths are resolved against this directory, rather than the working directory
+	outputDir string
+	// the resource type being exported (e.g. benchmark) - exports of formats restricted to other resource types are
+	// skipped
+	resourceType string
+	// map of export format to the resource types it is restricted to (formats with no entry apply to all resource types)
+	resourceTypeFormats map[string][]string
+	// if set, a checksum (and signature) file is written for each local export file
+	signer *Signer
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		registeredExporters:  make(map[string]Exporter),
+		registeredExtensions: make(map[string]Exporter),
+	}
+}
+
+func (m *Manager) Register(exporter Exporter) error {
+	name := exporter.Name()
+	if _, ok := m.registeredExporters[name]; ok {
+		return fmt.Errorf("failed to register exporter - duplicate name %s", name)
+	}
+	m.registeredExporters[exporter.Name()] = exporter
+
+	// if the exporter has an alias, also register by alias
+	if alias := exporter.Alias(); alias != "" {
+		if _, ok := m.registeredExporters[alias]; ok {
+			return fmt.Errorf("failed to register exporter - duplicate name %s", name)
+		}
+		m.registeredExporters[alias] = exporter
+	}
+
+	// now register extension (database exporters have no file extension)
+	ext := exporter.FileExtension()
+	if ext == "" {
+		return nil
+	}
+	m.registerExporterByExtension(exporter, ext)
+	// if the extension has multiple segments, try to register for the short version as well
+	if shortExtension := path.Ext(ext); shortExtension != ext {
+		m.registerExporterByExtension(exporter, shortExtension)
+	}
+	return nil
+}
+
+// SetSourceFilter sets a filter which is applied to the source data before it is passed to any of the exporters
+func (m *Manager) SetSourceFilter(filter SourceFilter) {
+	m.sourceFilter = filter
+}
+
+// SetSigner sets the signer used to write a checksum (and signature) file for each local export file
+func (m *Manager) SetSigner(signer *Signer) {
+	m.signer = signer
+}
+
+// SupportedFormats returns the sorted names (and aliases) of all registered exporters
+func (m *Manager) SupportedFormats() []string {
+	formats := maps.Keys(m.registeredExporters)
+	slices.Sort(formats)
+	return formats
+}
+
+// HasFormat returns true if there is a registered exporter for the given format name, alias or file extension
+func (m *Manager) HasFormat(name string) bool {
+	if _, ok := m.registeredExporters[name]; ok {
+		return true
+	}
+	_, ok := m.registeredExtensions[path.Ext(name)]
+	return ok
+}
+
+// SetPathTemplate sets the template used to build the file path for exports specified by format name
+// the template may contain the tokens {name}, {format}, {ext}, {timestamp} and {git_sha},
+// e.g. "benchmark-{name}-{timestamp}.{ext}"
+// exports specified by file name are not affected
+func (m *Manager) SetPathTemplate(pathTemplate string) {
+	m.pathTemplate = pathTemplate
+}
+
+// SetCompress sets whether exports are gzip compressed - if so, the gzip extension is appended to each export file name
+func (m *Manager) SetCompress(compress bool) {
+	m.compress = compress
+}
+
+// SetFileMode sets the mode of the files created for local exports, e.g. 0640
+// this applies to all exporters, and overrides the process umask - any missing parent directories of the export files
+// are created with a corresponding directory mode (the execute bit is added for each class with read permission)
+// if the mode is zero, files are created with the default mode
+func (m *Manager) SetFileMode(mode os.FileMode) {
+	m.fileMode = mode.Perm()
+}
+
+// SetOutputDir sets the base directory for local exports - relative export file paths (including those built from the
+// path template) are resolved against this directory, which is created if it is missing
+// absolute export file paths, stdout exports and object store exports are not affected
+func (m *Manager) SetOutputDir(dir string) {
+	m.outputDir = dir
+}
+
+// SetResourceType sets the type of the resource being exported, e.g. benchmark
+func (m *Manager) SetResourceType(resourceType string) {
+	m.resourceType = resourceType
+}
+
+// SetResourceTypeFormats restricts export formats to resource types - the map is keyed by format name (or alias),
+// and exports of a format are skipped unless the resource being exported has one of its resource types
+// formats which are not in the map apply to all resource types (see ParseResourceTypeFormats)
+func (m *Manager) SetResourceTypeFormats(resourceTypeFormats map[string][]string) {
+	m.resourceTypeFormats = resourceTypeFormats
+}
+
+func (m *Manager) registerExporterByExtension(exporter Exporter, ext string) {
+	// do we already have an exporter registered for this extension?
+	if existing, ok := m.registeredExtensions[ext]; ok {
+
+		// check if either the existing or new template is the default for extension
+		existingIsDefaultForExt := isDefaultExporterForExtension(existing)
+		newIsDefaultForExt := isDefaultExporterForExtension(exporter)
+
+		// if  NEITHER are default for the extension, there is a clash which cannot be resolved -
+		// we must remove the existing key
+		if !newIsDefaultForExt && !existingIsDefaultForExt {
+			delete(m.registeredExtensions, ext)
+		}
+
+		// if existing is default and new isn't, nothing to do
+		if existingIsDefaultForExt {
+			return
+		}
+
+		// to get here, new must be default exporter for extension
+		// (it is impossible for both to be default as that implies duplicate exporter names)
+		// fall through to...
+	}
+
+	// register the extension
+	m.registeredExtensions[ext] = exporter
+}
+
+// an exporter is the 'default for extension' if the exporter name is the same as the extension name
+// i.e. json exporter would be the default for the `.json` extension
+func isDefaultExporterForExtension(existing Exporter) bool {
+	return strings.TrimPrefix(existing.FileExtension(), ".") == existing.Name()
+}
+
+// resolveTargetsFromArgs resolves the export args into export targets, in the order the exports were specified
+// (exports of formats which do not apply to the resource type being exported are skipped)
+// export args are additive - each --export flag (and each comma separated value of a flag) adds an export, so
+// '--export json --export csv' exports both formats. Identical exports (the same format and options, written to
+// the same destination) are only exported once
+// if different exports resolve to the same destination:
+//   - for exports specified by format name, the default file name is made unique (see uniqueDefaultFilePath)
+//   - otherwise (i.e. exports specified by file name, stdout exports and path template exports) this is an error,
+//     as one of the exports would be silently overwritten
+func (m *Manager) resolveTargetsFromArgs(ctx context.Context, exportArgs []string, executionName string) ([]*Target, error) {
+	var targets []*Target
+	// map of destination to the target exported to it
+	var destinations = make(map[string]*Target)
+	// map of the destination each target resolved to (before it was made unique) to the targets - this is used to
+	// identify duplicate exports
+	var resolvedDestinations = make(map[string][]*Target)
+	var targetErrors []error
+
+	var pathData *pathTemplateData
+	if m.pathTemplate != "" {
+		pathData = newPathTemplateData(ctx, m.pathTemplate, executionName)
+	}
+
+	for _, exportArg := range joinExportOptionArgs(exportArgs) {
+		exportArg = strings.TrimSpace(exportArg)
+		if len(exportArg) == 0 {
+			// if this is an empty string, ignore
+			continue
+		}
+
+		t, err := m.getExportTarget(exportArg, executionName)
+		if err != nil {
+			targetErrors = append(targetErrors, err)
+			continue
+		}
+		// skip exports of formats which do not apply to the resource type being exported
+		if !m.appliesToResourceType(t.exporter) {
+			continue
+		}
+
+		// if there is a path template, use it to build the file path for unnamed targets
+		if pathData != nil && !t.isNamedTarget && !t.toDatabase {
+			t.filePath, err = expandPathTemplate(m.pathTemplate, t.exporter, pathData)
+			if err != nil {
+				targetErrors = append(targetErrors, err)
+				continue
+			}
+		}
+
+		// resolve relative local file paths against the output directory
+		if m.outputDir != "" && t.isLocalFile() && !filepath.IsAbs(t.filePath) {
+			t.filePath = filepath.Join(m.outputDir, t.filePath)
+			t.createDirs = true
+		}
+
+		// database exports are not files, so are never compressed
+		t.compress = m.compress && !t.toDatabase
+		t.fileMode = m.fileMode
+
+		destination := t.destination()
+		if slices.ContainsFunc(resolvedDestinations[destination], t.isDuplicateOf) {
+			// the same export has been specified more than once - just export it once
+			continue
+		}
+		if existing, ok := destinations[destination]; ok {
+			switch {
+			case t.toStdout:
+				targetErrors = append(targetErrors, sperr.New("only one export may be written to stdout"))
+				continue
+			case pathData != nil:
+				// the template does not distinguish between formats - do not silently drop an export
+				targetErrors = append(targetErrors, sperr.New("export path template '%s' resolves to the same file '%s' for the %s and %s exports", m.pathTemplate, t.destination(), existing.exporter.Name(), t.exporter.Name()))
+				continue
+			case t.isNamedTarget:
+				targetErrors = append(targetErrors, sperr.New("the %s and %s exports are both written to '%s'", existing.exporter.Name(), t.exporter.Name(), t.destination()))
+				continue
+			default:
+				t.filePath = uniqueDefaultFilePath(t, destinations)
+			}
+		}
+		resolvedDestinations[destination] = append(resolvedDestinations[destination], t)
+		destinations[t.destination()] = t
+		targets = append(targets, t)
+	}
+
+	return targets, error_helpers.CombineErrors(targetErrors...)
+}
+
+// uniqueDefaultFilePath returns a file path for an unnamed target which does not collide with any of the existing
+// destinations - the format name is inserted before the file extension (e.g. check.20240102T030405.asff.json),
+// followed by a number if this still collides (e.g. the same format exported with different options)
+func uniqueDefaultFilePath(t *Target, destinations map[string]*Target) string {
+	ext := t.exporter.FileExtension()
+	base := fmt.Sprintf("%s.%s", strings.TrimSuffix(t.filePath, ext), t.exporter.Name())
+
+	candidate := *t
+	candidate.filePath = base + ext
+	for i := 2; ; i++ {
+		if _, ok := destinations[candidate.destination()]; !ok {
+			return candidate.filePath
+		}
+		candidate.filePath = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// getExportTarget returns the target for the export arg
+// the arg may include exporter options after the format or file name, e.g. csv:delimiter=;,header=false
+// (for stdout exports, the options precede the stdout suffix, e.g. csv:header=false:-)
+func (m *Manager) getExportTarget(exportArg, executionName string) (*Target, error) {
+	stdoutSuffix := ""
+	if trimmed, ok := strings.CutSuffix(exportArg, StdoutTargetSuffix); ok {
+		exportArg, stdoutSuffix = trimmed, StdoutTargetSuffix
+	}
+	exportArg, options, err := splitExportOptions(exportArg)
+	if err != nil {
+		return nil, &exportOptionsError{err}
+	}
+
+	t, err := m.resolveExportTarget(exportArg+stdoutSuffix, executionName)
+	if err != nil {
+		return nil, err
+	}
+	if t.exporter, err = configureExporter(t.exporter, options); err != nil {
+		return nil, &exportOptionsError{err}
+	}
+	t.options = options
+	return t, nil
+}
+
+// resolveExportTarget returns the target for an export arg with no exporter options
+func (m *Manager) resolveExportTarget(exportArg, executionName string) (*Target, error) {
+	// is this a stdout export (e.g. json:-)
+	if format, ok := strings.CutSuffix(exportArg, StdoutTargetSuffix); ok {
+		e, ok := m.registeredExporters[format]
+		if !ok {
+			return nil, fmt.Errorf("formatter '%s' not found - supported formats: %s", format, strings.Join(m.SupportedFormats(), ", "))
+		}
+		// a stdout export is a single destination, so is treated in the same way as a named file
+		t := &Target{
+			exporter:      e,
+			filePath:      stdoutFilePath,
+			isNamedTarget: true,
+			toStdout:      true,
+		}
+		return t, nil
+	}
+
+	// is this a database export (e.g. postgres://user@host:5432/db)
+	if e := m.databaseExporterFor(exportArg); e != nil {
+		t := &Target{
+			exporter:   e,
+			filePath:   exportArg,
+			toDatabase: true,
+		}
+		return t, nil
+	}
+
+	// is this an object store export (e.g. s3://bucket/prefix/file.json or json:s3://bucket/prefix/)
+	if isObjectStoreURL(exportArg) {
+		return m.getObjectStoreExportTarget("", exportArg, executionName)
+	}
+	if format, destination, ok := strings.Cut(exportArg, ":"); ok && isObjectStoreURL(destination) {
+		return m.getObjectStoreExportTarget(format, destination, executionName)
+	}
+
+	if e, ok := m.registeredExporters[exportArg]; ok {
+		if databaseExporter, ok := e.(DatabaseExporter); ok {
+			return nil, sperr.New("the %s export requires a connection string, e.g. %s://user@host:5432/db", e.Name(), databaseExporter.ConnectionStringSchemes()[0])
+		}
+		t := &Target{
+			exporter: e,
+			filePath: export.GenerateDefaultExportFileName(executionName, e.FileExtension()),
+		}
+		return t, nil
+	}
+
+	// now try by extension
+	ext := path.Ext(exportArg)
+	if e, ok := m.registeredExtensions[ext]; ok {
+		t := &Target{
+			exporter:      e,
+			filePath:      exportArg,
+			isNamedTarget: true,
+		}
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("formatter satisfying '%s' not found - supported formats: %s", exportArg, strings.Join(m.SupportedFormats(), ", "))
+}
+
+// databaseExporterFor returns the registered database exporter for the export arg, if the arg is a connection string
+// with one of the exporter schemes (or nil if there is none)
+func (m *Manager) databaseExporterFor(exportArg string) DatabaseExporter {
+	for _, e := range m.registeredExporters {
+		databaseExporter, ok := e.(DatabaseExporter)
+		if !ok {
+			continue
+		}
+		for _, scheme := range databaseExporter.ConnectionStringSchemes() {
+			if strings.HasPrefix(exportArg, scheme+"://") {
+				return databaseExporter
+			}
+		}
+	}
+	return nil
+}
+
+// getObjectStoreExportTarget returns the target for an object store URL
+// if the URL has an object name, the exporter is determined from the extension (unless a format is given)
+// if the URL is a prefix, the format must be given and the object name is generated, as for a local export
+func (m *Manager) getObjectStoreExportTarget(format, destination, executionName string) (*Target, error) {
+	location, name, err := parseObjectStoreURL(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	var e Exporter
+	var ok bool
+	switch {
+	case format != "":
+		if e, ok = m.registeredExporters[format]; !ok {
+			return nil, fmt.Errorf("formatter '%s' not found - supported formats: %s", format, strings.Join(m.SupportedFormats(), ", "))
+		}
+	case name == "":
+		return nil, sperr.New("object store export '%s' has no object name - specify the format to generate the name, e.g. json:%s", destination, destination)
+	default:
+		if e, ok = m.registeredExtensions[path.Ext(name)]; !ok {
+			return nil, fmt.Errorf("formatter satisfying '%s' not found - supported formats: %s", destination, strings.Join(m.SupportedFormats(), ", "))
+		}
+	}
+
+	t := &Target{
+		exporter:      e,
+		filePath:      name,
+		isNamedTarget: name != "",
+		objectStore:   location,
+	}
+	if name == "" {
+		t.filePath = export.GenerateDefaultExportFileName(executionName, e.FileExtension())
+	}
+	return t, nil
+}
+
+// ExportResult is the result of a successful export to a single target
+type ExportResult struct {
+	// a message describing the export, e.g. "File exported to /path/check.json" (empty for stdout exports)
+	Message string
+	// the absolute file path or object URL the export was written to (empty for stdout and null exports)
+	Location string
+}
+
+// DoExport exports the source data to each of the targets resolved from the export args, returning a message
+// describing each export
+func (m *Manager) DoExport(ctx context.Context, targetName string, source ExportSourceData, exports []string) ([]string, error) {
+	results, err := m.Export(ctx, targetName, source, exports)
+	var messages []string
+	for _, result := range results {
+		if result.Message != "" {
+			messages = append(messages, result.Message)
+		}
+	}
+	return messages, err
+}
+
+// Export exports the source data to each of the targets resolved from the export args, returning the result of each
+// successful export
+// targets are exported concurrently (bounded by maxParallelExports) - a failure exporting one target
+// does not prevent the others from completing
+func (m *Manager) Export(ctx context.Context, targetName string, source ExportSourceData, exports []string) ([]ExportResult, error) {
+	if len(exports) == 0 {
+		return nil, nil
+	}
+
+	targets, err := m.resolveTargetsFromArgs(ctx, exports, targetName)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply the source filter (if any) once, so all targets export the same data
+	if m.sourceFilter != nil {
+		source, err = m.sourceFilter(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		// store results by target index so the returned results are in a consistent order
+		results   = make([]*ExportResult, len(targets))
+		errors    = make([]error, len(targets))
+		completed int
+		statusMut sync.Mutex
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, maxParallelExports)
+	)
+
+	statushooks.SetStatus(ctx, fmt.Sprintf("Exporting 0 of %d", len(targets)))
+	for idx, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, target *Target) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			msg, files, err := target.export(ctx, source)
+			if err == nil && m.signer != nil && target.isSigned() {
+				// sign each of the files written (a multi file exporter may write more than one)
+				for _, file := range files {
+					if err = m.signer.signFile(file, target.fileMode); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				errors[idx] = sperr.WrapWithMessage(err, "%s export failed", target.exporter.Name())
+			} else {
+				results[idx] = &ExportResult{Message: msg, Location: target.location()}
+			}
+
+			statusMut.Lock()
+			completed++
+			statushooks.SetStatus(ctx, fmt.Sprintf("Exporting %d of %d", completed, len(targets)))
+			statusMut.Unlock()
+		}(idx, target)
+	}
+	wg.Wait()
+
+	var res []ExportResult
+	for _, result := range results {
+		if result != nil {
+			res = append(res, *result)
+		}
+	}
+	return res, error_helpers.CombineErrors(errors...)
+}
+
+// HasStdoutExport returns true if any of the export arguments writes to stdout (--export=json:-)
+func (m *Manager) HasStdoutExport(exports []string) bool {
+	return slices.ContainsFunc(exports, func(exportArg string) bool {
+		return strings.HasSuffix(strings.TrimSpace(exportArg), StdoutTargetSuffix)
+	})
+}
+
+// HasFormatExport returns true if any of the export arguments uses the exporter with the given name
+// (whether specified by format name, alias or file name) and applies to the resource type being exported
+func (m *Manager) HasFormatExport(exports []string, name string) bool {
+	for _, exportArg := range joinExportOptionArgs(exports) {
+		target, err := m.getExportTarget(strings.TrimSpace(exportArg), "dummy_exec_name")
+		if err == nil && target.exporter.Name() == name && m.appliesToResourceType(target.exporter) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNamedExport returns true if any of the export arguments has a filename (--export=file.json) instead of the format name (--export=json)
+// panics if a target is not valid
+func (m *Manager) HasNamedExport(exports []string) bool {
+	for _, exportArg := range joinExportOptionArgs(exports) {
+		target, err := m.getExportTarget(exportArg, "dummy_exec_name")
+		error_helpers.FailOnError(err)
+		if target.isNamedTarget {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) ValidateExportFormat(exports []string) error {
+	var invalidFormats []string
+	var targets []*Target
+	stdoutCount := 0
+	for _, exportArg := range joinExportOptionArgs(exports) {
+		target, err := m.getExportTarget(exportArg, "dummy_exec_name")
+		if err != nil {
+			// the format is valid but the options are not - return the error, which describes the valid options
+			if isExportOptionsError(err) {
+				return err
+			}
+			// the format is a database export with no connection string - return the error, which gives an example
+			if _, ok := m.registeredExporters[exportArg].(DatabaseExporter); ok {
+				return err
+			}
+			invalidFormats = append(invalidFormats, exportArg)
+			continue
+		}
+		if target.toStdout {
+			stdoutCount++
+		}
+		targets = append(targets, target)
+	}
+	if invalidCount := len(invalidFormats); invalidCount > 0 {
+		return fmt.Errorf("invalid export %s: '%s' (supported formats: %s)", utils.Pluralize("format", invalidCount), strings.Join(invalidFormats, "','"), strings.Join(m.SupportedFormats(), ", "))
+	}
+	if stdoutCount > 1 {
+		return sperr.New("only one export may be written to stdout")
+	}
+	// verify all are either named or unnamed but not both (database exports may be combined with either)
+	hasNamed := slices.ContainsFunc(targets, func(t *Target) bool { return t.isNamedTarget })
+	hasUnnamed := slices.ContainsFunc(targets, func(t *Target) bool { return !t.isNamedTarget && !t.toDatabase })
+
+	if hasNamed && hasUnnamed {
+		return sperr.New("combination of named and unnamed exports is not supported")
+	}
+
+	return nil
+}
@@ -0,0 +1,439 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+type testExporter struct {
+	alias     string
+	extension string
+	name      string
+	err       error
+}
+
+func (t *testExporter) Export(ctx context.Context, input ExportSourceData, destPath string) error {
+	return t.err
+}
+func (t *testExporter) FileExtension() string { return t.extension }
+func (t *testExporter) Name() string          { return t.name }
+func (t *testExporter) Alias() string         { return t.alias }
+
+var dummyCSVExporter = testExporter{alias: "", extension: ".csv", name: "csv"}
+var dummyJSONExporter = testExporter{alias: "", extension: ".json", name: "json"}
+var dummyASFFExporter = testExporter{alias: "asff.json", extension: ".json", name: "asff"}
+var dummyNUNITExporter = testExporter{alias: "nunit3.xml", extension: ".xml", name: "nunit3"}
+var dummyPPSExporter = testExporter{alias: "pps", extension: constants.SnapshotExtension, name: constants.OutputFormatSnapshot}
+
+type exporterTestCase struct {
+	name   string
+	input  string
+	expect interface{}
+}
+
+var exporterTestCases = []exporterTestCase{
+	{
+		name:   "Bad Format",
+		input:  "bad-format",
+		expect: "ERROR",
+	},
+	{
+		name:   "csv file name",
+		input:  "file.csv",
+		expect: &dummyCSVExporter,
+	},
+	{
+		name:   "csv format name",
+		input:  "csv",
+		expect: &dummyCSVExporter,
+	},
+	{
+		name:   "Snapshot file name",
+		input:  "file.pps",
+		expect: &dummyPPSExporter,
+	},
+	{
+		name:   "Snapshot format name",
+		input:  "pps",
+		expect: &dummyPPSExporter,
+	},
+	{
+		name:   "json file name",
+		input:  "file.json",
+		expect: &dummyJSONExporter,
+	},
+	{
+		name:   "json format name",
+		input:  "json",
+		expect: &dummyJSONExporter,
+	},
+	{
+		name:   "asff json file name",
+		input:  "file.asff.json",
+		expect: &dummyASFFExporter,
+	},
+	{
+		name:   "asff json format name",
+		input:  "asff.json",
+		expect: &dummyASFFExporter,
+	},
+	{
+		name:   "nunit3 file name",
+		input:  "file.nunit3.xml",
+		expect: &dummyNUNITExporter,
+	},
+	{
+		name:   "nunit3 format name",
+		input:  "nunit3.xml",
+		expect: &dummyNUNITExporter,
+	},
+}
+
+func TestDoExport(t *testing.T) {
+	exportersToRegister := []*testExporter{
+		&dummyJSONExporter,
+		&dummyCSVExporter,
+		&dummyPPSExporter,
+		&dummyASFFExporter,
+		&dummyNUNITExporter,
+	}
+
+	m := NewManager()
+	for _, e := range exportersToRegister {
+		// ignore error - test will fail if this fails
+		_ = m.Register(e)
+	}
+	for _, testCase := range exporterTestCases {
+		targets, err := m.resolveTargetsFromArgs(context.Background(), []string{testCase.input}, "dummy_execution_name")
+		shouldError := testCase.expect == "ERROR"
+		if shouldError {
+			if err == nil {
+				t.Errorf("Request for '%s' should have errored - but did not", testCase.input)
+			}
+			continue
+		}
+		if !shouldError {
+			if err != nil {
+				t.Errorf("Request for '%s' should not have errored - but did: %v", testCase.input, err)
+			}
+			continue
+		}
+
+		if len(targets) != 1 {
+			t.Errorf("%v with %v input => expected one target - got %d", testCase.name, testCase.input, len(targets))
+			continue
+		}
+		actualTarget := targets[0]
+		expectedTargetExporter := testCase.expect.(*testExporter)
+
+		if actualTarget.exporter != expectedTargetExporter {
+			t.Errorf("%v with %v input => expected %s target - got %s", testCase.name, testCase.input, testCase.expect.(*testExporter).Name(), actualTarget.exporter.Name())
+			continue
+		}
+	}
+}
+
+func TestDoExportContinuesAfterFailure(t *testing.T) {
+	failingExporter := &testExporter{extension: ".csv", name: "csv", err: errors.New("disk full")}
+	exportersToRegister := []*testExporter{
+		failingExporter,
+		&dummyJSONExporter,
+		&dummyNUNITExporter,
+	}
+
+	m := NewManager()
+	for _, e := range exportersToRegister {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages, err := m.DoExport(context.Background(), "dummy_execution_name", nil, []string{"csv", "json", "nunit3"})
+	if err == nil {
+		t.Fatalf("expected an error from the failing exporter - got none")
+	}
+	if !strings.Contains(err.Error(), "csv export failed") {
+		t.Errorf("expected error to identify the failing format - got: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Errorf("expected 2 successful exports - got %d", len(messages))
+	}
+}
+
+func TestSupportedFormats(t *testing.T) {
+	m := NewManager()
+	for _, e := range []*testExporter{&dummyJSONExporter, &dummyCSVExporter, &dummyASFFExporter} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"asff", "asff.json", "csv", "json"}
+	if got := m.SupportedFormats(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected supported formats %v - got %v", expected, got)
+	}
+
+	for input, expected := range map[string]bool{"csv": true, "asff.json": true, "file.json": true, "nunit3": false, "file.xml": false} {
+		if got := m.HasFormat(input); got != expected {
+			t.Errorf("HasFormat(%s): expected %v - got %v", input, expected, got)
+		}
+	}
+
+	err := m.ValidateExportFormat([]string{"nunit3"})
+	if err == nil || !strings.Contains(err.Error(), "asff, asff.json, csv, json") {
+		t.Errorf("expected the invalid format error to list the supported formats - got: %v", err)
+	}
+}
+
+func TestStdoutExport(t *testing.T) {
+	m := NewManager()
+	for _, e := range []*testExporter{&dummyJSONExporter, &dummyCSVExporter} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !m.HasStdoutExport([]string{"file.csv", "json:-"}) {
+		t.Errorf("expected json:- to be a stdout export")
+	}
+	if m.HasStdoutExport([]string{"json", "file.csv"}) {
+		t.Errorf("expected no stdout export")
+	}
+
+	if err := m.ValidateExportFormat([]string{"json:-", "file.csv"}); err != nil {
+		t.Errorf("expected a stdout export and a file export to be valid - got %v", err)
+	}
+	if err := m.ValidateExportFormat([]string{"json:-", "csv:-"}); err == nil {
+		t.Errorf("expected an error for multiple stdout exports")
+	}
+	if err := m.ValidateExportFormat([]string{"xml:-"}); err == nil {
+		t.Errorf("expected an error for an unknown stdout export format")
+	}
+}
+
+// fileTestExporter writes the name of the exporter to the destination file
+type fileTestExporter struct {
+	testExporter
+}
+
+func (e *fileTestExporter) Export(_ context.Context, _ ExportSourceData, destPath string) error {
+	return os.WriteFile(destPath, []byte(e.name), 0600)
+}
+
+func TestOutputDir(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "exports", "nested")
+	absPath := filepath.Join(t.TempDir(), "absolute.json")
+
+	m := NewManager()
+	if err := m.Register(&fileTestExporter{dummyJSONExporter}); err != nil {
+		t.Fatal(err)
+	}
+	m.SetOutputDir(outputDir)
+	m.SetPathTemplate("{name}/report.{ext}")
+
+	targets, err := m.resolveTargetsFromArgs(context.Background(), []string{"json", absPath, "json:-"}, "benchmark")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[string]bool)
+	for _, target := range targets {
+		paths[target.filePath] = true
+	}
+	for _, expected := range []string{
+		// relative paths built from the template are resolved against the output directory
+		filepath.Join(outputDir, "benchmark", "report.json"),
+		// absolute paths and stdout are not affected
+		absPath,
+		stdoutFilePath,
+	} {
+		if !paths[expected] {
+			t.Errorf("expected a target with path %s, got %v", expected, paths)
+		}
+	}
+
+	// the output directory is created if missing
+	messages, err := m.DoExport(context.Background(), "benchmark", nil, []string{"json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportPath := filepath.Join(outputDir, "benchmark", "report.json")
+	if _, err := os.Stat(exportPath); err != nil {
+		t.Errorf("expected the export to be written to the output directory: %v", err)
+	}
+	if len(messages) != 1 || messages[0] != "File exported to "+exportPath {
+		t.Errorf("unexpected export messages %v", messages)
+	}
+}
+
+func TestRepeatedExports(t *testing.T) {
+	m := NewManager()
+	for _, e := range []Exporter{&dummyJSONExporter, &dummyASFFExporter, &testConfigurableExporter{testExporter: dummyCSVExporter}} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resolve := func(exportArgs ...string) ([]string, error) {
+		targets, err := m.resolveTargetsFromArgs(context.Background(), exportArgs, "check")
+		var paths []string
+		for _, target := range targets {
+			paths = append(paths, target.filePath)
+		}
+		return paths, err
+	}
+
+	// repeated exports are additive, and identical exports are only exported once
+	paths, err := resolve("json", "csv", "json", "csv:header=false,delimiter=;", "csv:delimiter=;,header=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 targets, got %v", paths)
+	}
+	// exports are resolved in the order they are specified
+	if !strings.HasSuffix(paths[0], ".json") || !strings.HasSuffix(paths[1], ".csv") {
+		t.Errorf("expected the json export followed by the csv export, got %v", paths)
+	}
+	// the same format with different options is exported to a different file
+	if expected := strings.TrimSuffix(paths[1], ".csv") + ".csv.csv"; paths[2] != expected {
+		t.Errorf("expected the csv export with options to be written to %s, got %s", expected, paths[2])
+	}
+
+	// formats which share a file extension are exported to different files
+	paths, err = resolve("json", "asff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] == paths[1] || !strings.HasSuffix(paths[1], ".asff.json") {
+		t.Errorf("expected distinct json and asff files, got %v", paths)
+	}
+
+	// a named file is only exported once, but different exports cannot be written to the same named file
+	if paths, err = resolve("out.csv", "out.csv"); err != nil || len(paths) != 1 {
+		t.Errorf("expected a single target, got %v (%v)", paths, err)
+	}
+	if _, err = resolve("out.csv", "out.csv:header=false"); err == nil || !strings.Contains(err.Error(), "both written to 'out.csv'") {
+		t.Errorf("expected an error for exports written to the same file, got %v", err)
+	}
+}
+
+type testDatabaseExporter struct {
+	testExporter
+}
+
+func (t *testDatabaseExporter) ConnectionStringSchemes() []string { return []string{"postgres"} }
+
+func TestDatabaseExports(t *testing.T) {
+	m := NewManager()
+	for _, e := range []Exporter{&dummyJSONExporter, &testDatabaseExporter{testExporter{name: "postgres"}}} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a connection string is exported to the database, rather than a file
+	targets, err := m.resolveTargetsFromArgs(context.Background(), []string{"json", "postgres://u@host/db?table=results"}, "check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || !targets[1].toDatabase || targets[1].filePath != "postgres://u@host/db?table=results" {
+		t.Fatalf("expected a json file target and a database target, got %v", targets)
+	}
+	if targets[1].isLocalFile() {
+		t.Errorf("expected the database target not to be a local file")
+	}
+
+	// a database export requires a connection string
+	if _, err = m.resolveTargetsFromArgs(context.Background(), []string{"postgres"}, "check"); err == nil || !strings.Contains(err.Error(), "requires a connection string") {
+		t.Errorf("expected a connection string error, got %v", err)
+	}
+	if err = m.ValidateExportFormat([]string{"postgres"}); err == nil || !strings.Contains(err.Error(), "requires a connection string") {
+		t.Errorf("expected validation to return the connection string error, got %v", err)
+	}
+	// database exports may be combined with named exports
+	if err = m.ValidateExportFormat([]string{"out.json", "postgres://u@host/db"}); err != nil {
+		t.Errorf("expected a named export and a database export to be valid, got %v", err)
+	}
+}
+
+func TestResourceTypeFormats(t *testing.T) {
+	resourceTypeFormats, err := ParseResourceTypeFormats([]string{"csv=benchmark", "json=dashboard", "json=query", "json=query"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resourceTypeFormats["json"]) != 2 {
+		t.Errorf("expected json to apply to 2 resource types, got %v", resourceTypeFormats["json"])
+	}
+	for _, arg := range []string{"csv", "csv=", "csv=variable"} {
+		if _, err := ParseResourceTypeFormats([]string{arg}); err == nil {
+			t.Errorf("expected an error parsing '%s'", arg)
+		}
+	}
+
+	m := NewManager()
+	for _, e := range []Exporter{&dummyJSONExporter, &dummyCSVExporter, &dummyPPSExporter} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.SetResourceTypeFormats(resourceTypeFormats)
+	resolve := func(resourceType string) []string {
+		m.SetResourceType(resourceType)
+		targets, err := m.resolveTargetsFromArgs(context.Background(), []string{"json", "out.csv", "pps"}, "check")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var formats []string
+		for _, target := range targets {
+			formats = append(formats, target.exporter.Name())
+		}
+		return formats
+	}
+
+	// formats which are not restricted (pps) are exported for all resource types
+	if formats := strings.Join(resolve("benchmark"), ","); formats != "csv,snapshot" {
+		t.Errorf("expected csv and snapshot exports for a benchmark, got %s", formats)
+	}
+	if formats := strings.Join(resolve("dashboard"), ","); formats != "json,snapshot" {
+		t.Errorf("expected json and snapshot exports for a dashboard, got %s", formats)
+	}
+	if formats := strings.Join(resolve("control"), ","); formats != "snapshot" {
+		t.Errorf("expected a snapshot export for a control, got %s", formats)
+	}
+}
+
+// testSourceData is export source data which records the filters applied to it
+type testSourceData struct {
+	filters []string
+}
+
+func (*testSourceData) IsExportSourceData() {}
+
+func TestChainSourceFilters(t *testing.T) {
+	newFilter := func(name string, err error) SourceFilter {
+		return func(source ExportSourceData) (ExportSourceData, error) {
+			if err != nil {
+				return nil, err
+			}
+			data := source.(*testSourceData)
+			return &testSourceData{filters: append(data.filters, name)}, nil
+		}
+	}
+
+	res, err := ChainSourceFilters(newFilter("a", nil), newFilter("b", nil))(&testSourceData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filters := res.(*testSourceData).filters; strings.Join(filters, ",") != "a,b" {
+		t.Errorf("expected filters a,b to be applied in order - got %v", filters)
+	}
+
+	if _, err := ChainSourceFilters(newFilter("a", errors.New("failed")), newFilter("b", nil))(&testSourceData{}); err == nil {
+		t.Error("expected the filter error to be returned")
+	}
+}
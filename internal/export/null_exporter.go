@@ -0,0 +1,95 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	nullFormatName    = "null"
+	nullFileExtension = ".null"
+)
+
+// RecordSource calls the visit func for each record which would be written when exporting the source data
+type RecordSource func(ctx context.Context, source ExportSourceData, visit func(record any) error) error
+
+// NullExporter is an exporter which serialises the records of the source data, but writes nothing
+// this is used to measure the cost of the export pipeline (e.g. source filtering and serialisation)
+// separately from the cost of writing the export
+//
+// targets using the NullExporter ignore the destination (including stdout and object store destinations)
+// and report the number of records which would have been written
+type NullExporter struct {
+	records RecordSource
+}
+
+// NewNullExporter returns a NullExporter which serialises the records returned by the record source
+// if the record source is nil, the source data is serialised as a single record
+func NewNullExporter(records RecordSource) *NullExporter {
+	return &NullExporter{records: records}
+}
+
+func (e *NullExporter) Export(ctx context.Context, input ExportSourceData, _ string) error {
+	_, err := e.discard(ctx, input)
+	return err
+}
+
+func (e *NullExporter) FileExtension() string {
+	return nullFileExtension
+}
+
+func (e *NullExporter) Name() string {
+	return nullFormatName
+}
+
+func (e *NullExporter) Alias() string {
+	return ""
+}
+
+// nullExportResult is the number of records (and bytes) a null export would have written
+type nullExportResult struct {
+	records int
+	bytes   int64
+}
+
+func (r nullExportResult) String() string {
+	return fmt.Sprintf("Null export serialised %d records (%d bytes) - nothing was written", r.records, r.bytes)
+}
+
+// discard serialises each record to a writer which discards the output, counting the records and bytes
+func (e *NullExporter) discard(ctx context.Context, input ExportSourceData) (nullExportResult, error) {
+	counter := &countingWriter{w: io.Discard}
+	encoder := json.NewEncoder(counter)
+	var res nullExportResult
+
+	visit := func(record any) error {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		res.records++
+		return nil
+	}
+
+	var err error
+	if e.records == nil {
+		err = visit(input)
+	} else {
+		err = e.records(ctx, input, visit)
+	}
+	res.bytes = counter.n
+	return res, err
+}
+
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
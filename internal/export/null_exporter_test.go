@@ -0,0 +1,72 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRecords is export source data made up of a list of records
+type testRecords []string
+
+func (testRecords) IsExportSourceData() {}
+
+// testSource is export source data which is serialised as a single record
+type testSource struct {
+	A int `json:"a"`
+}
+
+func (testSource) IsExportSourceData() {}
+
+func TestNullExport(t *testing.T) {
+	records := func(_ context.Context, source ExportSourceData, visit func(any) error) error {
+		for _, r := range source.(testRecords) {
+			if err := visit(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	m := NewManager()
+	if err := m.Register(NewNullExporter(records)); err != nil {
+		t.Fatal(err)
+	}
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "check.null")
+
+	messages, err := m.DoExport(context.Background(), "exec", testRecords{"a", "b", "c"}, []string{"null", filePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 export messages, got %v", messages)
+	}
+	for _, msg := range messages {
+		// each record is serialised as a json string with a trailing newline, e.g. "a"\n
+		if !strings.Contains(msg, "3 records (12 bytes)") {
+			t.Errorf("unexpected export message '%s'", msg)
+		}
+	}
+
+	// nothing should be written
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no files to be written, got %d", len(entries))
+	}
+}
+
+func TestNullExportWholeSource(t *testing.T) {
+	res, err := NewNullExporter(nil).discard(context.Background(), testSource{A: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.records != 1 || res.bytes != int64(len(`{"a":1}`+"\n")) {
+		t.Errorf("expected a single record of 8 bytes, got %+v", res)
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// supported object store URL schemes
+const (
+	s3Scheme  = "s3"
+	gcsScheme = "gs"
+)
+
+// objectStoreLocation is a bucket and key prefix in an object store
+type objectStoreLocation struct {
+	scheme string
+	bucket string
+	// the key prefix - this is either empty or ends with "/"
+	prefix string
+}
+
+// objectURL returns the URL of the object with the given name in this location
+func (l *objectStoreLocation) objectURL(name string) string {
+	return fmt.Sprintf("%s://%s/%s%s", l.scheme, l.bucket, l.prefix, name)
+}
+
+// isObjectStoreURL returns true if the export destination is an object store URL (s3:// or gs://)
+func isObjectStoreURL(destination string) bool {
+	return strings.HasPrefix(destination, s3Scheme+"://") || strings.HasPrefix(destination, gcsScheme+"://")
+}
+
+// parseObjectStoreURL parses an object store URL of the form s3://bucket/prefix/[name] or gs://bucket/prefix/[name]
+// returning the location and the object name (which is empty if the URL is a prefix, i.e. ends with "/")
+func parseObjectStoreURL(destination string) (*objectStoreLocation, string, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return nil, "", sperr.WrapWithMessage(err, "invalid object store URL '%s'", destination)
+	}
+	if u.Scheme != s3Scheme && u.Scheme != gcsScheme {
+		return nil, "", sperr.New("invalid object store URL '%s' - only %s:// and %s:// are supported", destination, s3Scheme, gcsScheme)
+	}
+	if u.Host == "" {
+		return nil, "", sperr.New("invalid object store URL '%s' - no bucket specified", destination)
+	}
+
+	location := &objectStoreLocation{scheme: u.Scheme, bucket: u.Host}
+	key := strings.TrimPrefix(u.Path, "/")
+	if key == "" || strings.HasSuffix(key, "/") {
+		location.prefix = key
+		return location, "", nil
+	}
+	if dir := path.Dir(key); dir != "." {
+		location.prefix = dir + "/"
+	}
+	return location, path.Base(key), nil
+}
+
+// objectStoreUploader uploads objects to an object store
+type objectStoreUploader interface {
+	upload(ctx context.Context, bucket, key string, content io.Reader) error
+}
+
+// newObjectStoreUploader returns the uploader for the object store scheme
+// credentials are resolved using the standard provider chain for the object store
+func newObjectStoreUploader(scheme string) (objectStoreUploader, error) {
+	switch scheme {
+	case s3Scheme:
+		return &s3Uploader{}, nil
+	case gcsScheme:
+		return &gcsUploader{}, nil
+	}
+	return nil, sperr.New("unsupported object store scheme '%s'", scheme)
+}
+
+// gcsUploader uploads to Google Cloud Storage, using Application Default Credentials
+type gcsUploader struct{}
+
+func (u *gcsUploader) upload(ctx context.Context, bucket, key string, content io.Reader) error {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	writer := client.Bucket(bucket).Object(key).NewWriter(ctx)
+	if _, err := io.Copy(writer, content); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	// the object is only written when the writer is closed
+	return writer.Close()
+}
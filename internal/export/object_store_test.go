@@ -0,0 +1,127 @@
+package export
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseObjectStoreURL(t *testing.T) {
+	tests := map[string]struct {
+		url            string
+		expectedBucket string
+		expectedPrefix string
+		expectedName   string
+		err            bool
+	}{
+		"s3 object":    {url: "s3://bucket/reports/check.json", expectedBucket: "bucket", expectedPrefix: "reports/", expectedName: "check.json"},
+		"s3 prefix":    {url: "s3://bucket/reports/daily/", expectedBucket: "bucket", expectedPrefix: "reports/daily/"},
+		"s3 bucket":    {url: "s3://bucket", expectedBucket: "bucket"},
+		"gcs root obj": {url: "gs://bucket/check.json", expectedBucket: "bucket", expectedName: "check.json"},
+		"no bucket":    {url: "s3:///check.json", err: true},
+		"bad scheme":   {url: "ftp://bucket/check.json", err: true},
+	}
+	for name, test := range tests {
+		location, objectName, err := parseObjectStoreURL(test.url)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if location.bucket != test.expectedBucket || location.prefix != test.expectedPrefix || objectName != test.expectedName {
+			t.Errorf("%s: expected bucket '%s', prefix '%s', name '%s' - got '%s', '%s', '%s'", name, test.expectedBucket, test.expectedPrefix, test.expectedName, location.bucket, location.prefix, objectName)
+		}
+	}
+}
+
+func TestObjectStoreExportTarget(t *testing.T) {
+	m := NewManager()
+	for _, e := range []*testExporter{&dummyJSONExporter, &dummyCSVExporter} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an object name determines the exporter from the extension
+	target, err := m.getExportTarget("s3://bucket/reports/check.csv", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.exporter != &dummyCSVExporter || !target.isNamedTarget || target.destination() != "s3://bucket/reports/check.csv" {
+		t.Errorf("unexpected target for object export: %+v", target)
+	}
+
+	// a prefix requires the format, and the object name is generated
+	target, err = m.getExportTarget("json:gs://bucket/reports/", "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.exporter != &dummyJSONExporter || target.isNamedTarget || !strings.HasPrefix(target.destination(), "gs://bucket/reports/exec.") {
+		t.Errorf("unexpected target for prefix export: %+v", target)
+	}
+
+	if _, err := m.getExportTarget("s3://bucket/reports/", "exec"); err == nil {
+		t.Errorf("expected an error for a prefix export with no format")
+	}
+}
+
+// fileExporter writes fixed content to the destination file
+type fileExporter struct {
+	testExporter
+}
+
+func (e *fileExporter) Export(_ context.Context, _ ExportSourceData, destPath string) error {
+	return os.WriteFile(destPath, []byte("content"), 0600)
+}
+
+func TestObjectStoreUploadKeys(t *testing.T) {
+	tests := map[string]struct {
+		destination string
+		// the expected bucket and key - if keyPrefix is set, the key is generated, so only the prefix is checked
+		key       string
+		keyPrefix string
+	}{
+		"object":                {destination: "s3://bucket/reports/check.json", key: "bucket/reports/check.json"},
+		"nested object":         {destination: "s3://bucket/reports/2024/01/check.json", key: "bucket/reports/2024/01/check.json"},
+		"object in bucket root": {destination: "gs://bucket/check.json", key: "bucket/check.json"},
+		"prefix":                {destination: "json:s3://bucket/reports/daily/", keyPrefix: "bucket/reports/daily/exec."},
+		"bucket root prefix":    {destination: "json:gs://bucket", keyPrefix: "bucket/exec."},
+		"object with spaces":    {destination: "s3://bucket/my reports/check 1.json", key: "bucket/my reports/check 1.json"},
+		"empty prefix segment":  {destination: "json:s3://bucket/reports//", keyPrefix: "bucket/reports//exec."},
+	}
+	for name, test := range tests {
+		m := NewManager()
+		if err := m.Register(&fileExporter{testExporter{extension: ".json", name: "json"}}); err != nil {
+			t.Fatal(err)
+		}
+		target, err := m.getExportTarget(test.destination, "exec")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		uploader := &recordingUploader{}
+		target.uploader = uploader
+		if _, err := target.Export(context.Background(), nil); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if len(uploader.uploads) != 1 {
+			t.Errorf("%s: expected a single upload, got %v", name, uploader.uploads)
+			continue
+		}
+		for key, content := range uploader.uploads {
+			if (test.key != "" && key != test.key) || (test.keyPrefix != "" && (!strings.HasPrefix(key, test.keyPrefix) || !strings.HasSuffix(key, ".json"))) {
+				t.Errorf("%s: unexpected key %s", name, key)
+			}
+			if content != "content" {
+				t.Errorf("%s: unexpected content %s", name, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ExporterOption describes an option accepted by a ConfigurableExporter
+type ExporterOption struct {
+	Name        string
+	Description string
+}
+
+// ConfigurableExporter is an Exporter which accepts options - these are passed in the export argument after the
+// format, as a comma separated list of key=value pairs, e.g. --export csv:delimiter=;,header=false
+type ConfigurableExporter interface {
+	Exporter
+	// ExportOptions returns the options accepted by the exporter - if this is empty, the exporter accepts no options
+	ExportOptions() []ExporterOption
+	// WithExportOptions returns a copy of the exporter configured with the options
+	// (the option names have already been validated against ExportOptions)
+	// NOTE: the exporter itself must not be modified, as it is shared by all export targets
+	WithExportOptions(options map[string]string) (Exporter, error)
+}
+
+// exportOptionsError is returned if the options in an export argument are invalid, or are not accepted by the exporter
+// (this allows validation to report the option error, rather than just the invalid export argument)
+type exportOptionsError struct {
+	err error
+}
+
+func (e *exportOptionsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exportOptionsError) Unwrap() error {
+	return e.err
+}
+
+func isExportOptionsError(err error) bool {
+	var optionsErr *exportOptionsError
+	return errors.As(err, &optionsErr)
+}
+
+// exportOptionsRegex matches the options suffix of an export argument, i.e. ':key=value[,key=value]'
+// the first option must start with a key, so the suffix is not confused with a url or windows path
+var exportOptionsRegex = regexp.MustCompile(`:([A-Za-z_][\w-]*=[^:]*)$`)
+
+// exportOptionContinuationRegex matches an export arg which is a continuation of the options of the previous arg
+// (optionally followed by the stdout suffix)
+var exportOptionContinuationRegex = regexp.MustCompile(`^([A-Za-z_][\w-]*=[^:]*)?(:-)?$`)
+
+// joinExportOptionArgs rejoins export args which were split at the commas separating exporter options
+// - the export args are parsed from a string slice flag, so csv:delimiter=;,header=false is split into
+// csv:delimiter=; and header=false (and an empty arg follows a comma value, e.g. csv:delimiter=,)
+func joinExportOptionArgs(exportArgs []string) []string {
+	var res []string
+	for _, exportArg := range exportArgs {
+		if len(res) > 0 {
+			previous := strings.TrimSuffix(strings.TrimSpace(res[len(res)-1]), StdoutTargetSuffix)
+			if exportOptionsRegex.MatchString(previous) && exportOptionContinuationRegex.MatchString(exportArg) {
+				res[len(res)-1] += "," + exportArg
+				continue
+			}
+		}
+		res = append(res, exportArg)
+	}
+	return res
+}
+
+// splitExportOptions splits the options (if any) from the export argument, returning the export argument without
+// the options, and the options, e.g. csv:delimiter=;,header=false returns csv and {delimiter: ;, header: false}
+func splitExportOptions(exportArg string) (string, map[string]string, error) {
+	match := exportOptionsRegex.FindStringSubmatchIndex(exportArg)
+	if match == nil {
+		return exportArg, nil, nil
+	}
+	options, err := parseExportOptions(exportArg[match[2]:match[3]])
+	if err != nil {
+		return "", nil, sperr.WrapWithMessage(err, "invalid export '%s'", exportArg)
+	}
+	return exportArg[:match[0]], options, nil
+}
+
+// parseExportOptions parses a comma separated list of key=value pairs
+// NOTE: a comma is only treated as a separator if it is followed by another key=value pair,
+// so values may contain commas, e.g. delimiter=,
+func parseExportOptions(optionsString string) (map[string]string, error) {
+	var pairs []string
+	for _, segment := range strings.Split(optionsString, ",") {
+		if len(pairs) > 0 && !strings.Contains(segment, "=") {
+			// this is part of the value of the previous option
+			pairs[len(pairs)-1] += "," + segment
+			continue
+		}
+		pairs = append(pairs, segment)
+	}
+
+	options := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, _ := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, sperr.New("export option '%s' has no name", pair)
+		}
+		if _, ok := options[key]; ok {
+			return nil, sperr.New("export option '%s' is specified more than once", key)
+		}
+		options[key] = value
+	}
+	return options, nil
+}
+
+// configureExporter returns the exporter configured with the options - an error is returned if the exporter
+// does not accept the options, listing the options it does accept
+func configureExporter(e Exporter, options map[string]string) (Exporter, error) {
+	if len(options) == 0 {
+		return e, nil
+	}
+	configurable, ok := e.(ConfigurableExporter)
+	if !ok || len(configurable.ExportOptions()) == 0 {
+		return nil, sperr.New("the %s exporter does not accept any options", e.Name())
+	}
+
+	validOptions := make(map[string]bool)
+	for _, o := range configurable.ExportOptions() {
+		validOptions[o.Name] = true
+	}
+	var invalid []string
+	for key := range options {
+		if !validOptions[key] {
+			invalid = append(invalid, key)
+		}
+	}
+	if len(invalid) > 0 {
+		slices.Sort(invalid)
+		return nil, sperr.New("unknown %s export %s '%s' - valid options: %s", e.Name(), utils.Pluralize("option", len(invalid)), strings.Join(invalid, "','"), describeExportOptions(configurable.ExportOptions()))
+	}
+	return configurable.WithExportOptions(options)
+}
+
+// describeExportOptions returns the sorted option names, with their descriptions
+func describeExportOptions(options []ExporterOption) string {
+	descriptions := make(map[string]string, len(options))
+	for _, o := range options {
+		descriptions[o.Name] = fmt.Sprintf("%s (%s)", o.Name, o.Description)
+	}
+	names := maps.Keys(descriptions)
+	slices.Sort(names)
+	res := make([]string, len(names))
+	for i, name := range names {
+		res[i] = descriptions[name]
+	}
+	return strings.Join(res, ", ")
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testConfigurableExporter is a testExporter which accepts the delimiter and header options
+type testConfigurableExporter struct {
+	testExporter
+	options map[string]string
+}
+
+func (t *testConfigurableExporter) ExportOptions() []ExporterOption {
+	return []ExporterOption{
+		{Name: "header", Description: "include the header"},
+		{Name: "delimiter", Description: "the cell delimiter"},
+	}
+}
+
+func (t *testConfigurableExporter) WithExportOptions(options map[string]string) (Exporter, error) {
+	return &testConfigurableExporter{testExporter: t.testExporter, options: options}, nil
+}
+
+func TestSplitExportOptions(t *testing.T) {
+	tests := []struct {
+		exportArg string
+		expectArg string
+		options   map[string]string
+		err       bool
+	}{
+		{exportArg: "csv", expectArg: "csv"},
+		{exportArg: "file.csv", expectArg: "file.csv"},
+		{exportArg: "json:s3://bucket/prefix/", expectArg: "json:s3://bucket/prefix/"},
+		{exportArg: `C:\exports\file.csv`, expectArg: `C:\exports\file.csv`},
+		{exportArg: "csv:delimiter=;,header=false", expectArg: "csv", options: map[string]string{"delimiter": ";", "header": "false"}},
+		{exportArg: "file.csv:header=false", expectArg: "file.csv", options: map[string]string{"header": "false"}},
+		{exportArg: "csv:delimiter=,", expectArg: "csv", options: map[string]string{"delimiter": ","}},
+		{exportArg: "csv:delimiter=,,header=true", expectArg: "csv", options: map[string]string{"delimiter": ",", "header": "true"}},
+		{exportArg: "json:s3://bucket/prefix/:header=false", expectArg: "json:s3://bucket/prefix/", options: map[string]string{"header": "false"}},
+		{exportArg: "csv:header=false,header=true", err: true},
+		{exportArg: "csv:header=false,=true", err: true},
+	}
+	for _, test := range tests {
+		arg, options, err := splitExportOptions(test.exportArg)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.exportArg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.exportArg, err)
+			continue
+		}
+		if arg != test.expectArg {
+			t.Errorf("%s: expected export arg %s, got %s", test.exportArg, test.expectArg, arg)
+		}
+		if len(options) != len(test.options) || (len(options) > 0 && !reflect.DeepEqual(options, test.options)) {
+			t.Errorf("%s: expected options %v, got %v", test.exportArg, test.options, options)
+		}
+	}
+}
+
+func TestJoinExportOptionArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		expect []string
+	}{
+		{args: []string{"csv", "json"}, expect: []string{"csv", "json"}},
+		{args: []string{"csv:delimiter=;", "header=false"}, expect: []string{"csv:delimiter=;,header=false"}},
+		{args: []string{"csv:delimiter=;", "header=false", "json"}, expect: []string{"csv:delimiter=;,header=false", "json"}},
+		{args: []string{"csv:delimiter=", "", "header=false"}, expect: []string{"csv:delimiter=,,header=false"}},
+		{args: []string{"csv:delimiter=;", "header=false:-"}, expect: []string{"csv:delimiter=;,header=false:-"}},
+		// a key=value arg is only joined if the previous arg has options
+		{args: []string{"csv", "header=false"}, expect: []string{"csv", "header=false"}},
+	}
+	for _, test := range tests {
+		if got := joinExportOptionArgs(test.args); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("joinExportOptionArgs(%v): expected %v, got %v", test.args, test.expect, got)
+		}
+	}
+}
+
+func TestExportTargetOptions(t *testing.T) {
+	csvExporter := &testConfigurableExporter{testExporter: testExporter{extension: ".csv", name: "csv"}}
+	m := NewManager()
+	_ = m.Register(csvExporter)
+	_ = m.Register(&dummyJSONExporter)
+
+	// the export flag is a string slice, so the options are split at the commas
+	targets, err := m.resolveTargetsFromArgs(context.Background(), []string{"csv:delimiter=;", "header=false"}, "dummy_execution_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("expected one target, got %d", len(targets))
+	}
+	configured, ok := targets[0].exporter.(*testConfigurableExporter)
+	if !ok || configured == csvExporter {
+		t.Fatalf("expected a configured copy of the csv exporter, got %v", targets[0].exporter)
+	}
+	if expected := map[string]string{"delimiter": ";", "header": "false"}; !reflect.DeepEqual(configured.options, expected) {
+		t.Errorf("expected options %v, got %v", expected, configured.options)
+	}
+	if csvExporter.options != nil {
+		t.Errorf("expected the registered exporter not to be modified")
+	}
+
+	// stdout exports may have options
+	targets, err = m.resolveTargetsFromArgs(context.Background(), []string{"csv:header=false:-"}, "dummy_execution_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !targets[0].toStdout {
+		t.Errorf("expected a stdout target")
+	}
+
+	// unknown options are an error listing the valid options
+	err = m.ValidateExportFormat([]string{"csv:separator=;,quote=true"})
+	if err == nil {
+		t.Fatal("expected an error for unknown options")
+	}
+	for _, expected := range []string{"unknown csv export options 'quote','separator'", "delimiter (the cell delimiter), header (include the header)"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected the error to contain %q, got %s", expected, err.Error())
+		}
+	}
+
+	// exporters which are not configurable accept no options
+	if err = m.ValidateExportFormat([]string{"json:indent=2"}); err == nil || !strings.Contains(err.Error(), "the json exporter does not accept any options") {
+		t.Errorf("expected an error for a non-configurable exporter, got %v", err)
+	}
+	// an unknown format is still reported as an invalid format
+	if err = m.ValidateExportFormat([]string{"bad:header=false"}); err == nil || !strings.Contains(err.Error(), "invalid export format") {
+		t.Errorf("expected an invalid format error, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// path template tokens
+const (
+	pathTokenName      = "name"
+	pathTokenFormat    = "format"
+	pathTokenExtension = "ext"
+	pathTokenTimestamp = "timestamp"
+	pathTokenGitSha    = "git_sha"
+)
+
+var pathTemplateTokenRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// pathTemplateData contains the values substituted into an export path template
+// these are resolved once per export, so all targets share the same timestamp
+type pathTemplateData struct {
+	name      string
+	timestamp string
+	gitSha    string
+}
+
+func newPathTemplateData(ctx context.Context, pathTemplate, executionName string) *pathTemplateData {
+	now := time.Now()
+	d := &pathTemplateData{
+		name:      executionName,
+		timestamp: fmt.Sprintf("%d%02d%02dT%02d%02d%02d", now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second()),
+	}
+	// only run git if the sha is actually used
+	if strings.Contains(pathTemplate, "{"+pathTokenGitSha+"}") {
+		d.gitSha = currentGitSha(ctx)
+	}
+	return d
+}
+
+// expandPathTemplate returns the file path for the given exporter, substituting the template tokens
+// an error is returned if the template contains an unknown token, or a token with no value
+func expandPathTemplate(pathTemplate string, exporter Exporter, data *pathTemplateData) (string, error) {
+	tokens := map[string]string{
+		pathTokenName:      data.name,
+		pathTokenFormat:    exporter.Name(),
+		pathTokenExtension: strings.TrimPrefix(exporter.FileExtension(), "."),
+		pathTokenTimestamp: data.timestamp,
+		pathTokenGitSha:    data.gitSha,
+	}
+
+	var err error
+	res := pathTemplateTokenRegex.ReplaceAllStringFunc(pathTemplate, func(match string) string {
+		token := strings.TrimSuffix(strings.TrimPrefix(match, "{"), "}")
+		value, ok := tokens[token]
+		switch {
+		case err != nil:
+			// we have already failed - just return the match
+		case !ok:
+			supportedTokens := maps.Keys(tokens)
+			slices.Sort(supportedTokens)
+			err = sperr.New("unknown token '%s' in export path template '%s' - supported tokens: {%s}", match, pathTemplate, strings.Join(supportedTokens, "}, {"))
+		case value == "":
+			err = sperr.New("export path template '%s' uses token '%s' but no value is available", pathTemplate, match)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return res, nil
+}
+
+// currentGitSha returns the short sha of the current git commit of the working directory (or empty string if not available)
+func currentGitSha(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExpandPathTemplate(t *testing.T) {
+	data := &pathTemplateData{name: "aws_compliance.benchmark.cis", timestamp: "20240102T030405"}
+
+	tests := map[string]struct {
+		template string
+		expected string
+		err      string
+	}{
+		"all tokens":    {template: "benchmark-{name}-{timestamp}.{ext}", expected: "benchmark-aws_compliance.benchmark.cis-20240102T030405.json"},
+		"format":        {template: "out/{format}/{name}.{ext}", expected: "out/asff/aws_compliance.benchmark.cis.json"},
+		"no tokens":     {template: "results.json", expected: "results.json"},
+		"unknown token": {template: "{name}-{commit}.{ext}", err: "unknown token '{commit}'"},
+		"no value":      {template: "{name}-{git_sha}.{ext}", err: "no value is available"},
+	}
+	for name, test := range tests {
+		res, err := expandPathTemplate(test.template, &dummyASFFExporter, data)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected error containing '%s' - got %v", name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if res != test.expected {
+			t.Errorf("%s: expected '%s' - got '%s'", name, test.expected, res)
+		}
+	}
+}
+
+func TestResolveTargetsWithPathTemplate(t *testing.T) {
+	m := NewManager()
+	for _, e := range []*testExporter{&dummyJSONExporter, &dummyCSVExporter} {
+		if err := m.Register(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.SetPathTemplate("{name}.{format}.{ext}")
+	targets, err := m.resolveTargetsFromArgs(context.Background(), []string{"csv", "json", "named.json"}, "exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, target := range targets {
+		paths = append(paths, target.filePath)
+	}
+	for _, expected := range []string{"exec.csv.csv", "exec.json.json", "named.json"} {
+		if !strings.Contains(strings.Join(paths, ","), expected) {
+			t.Errorf("expected a target with path '%s' - got %v", expected, paths)
+		}
+	}
+
+	// a template which resolves to the same file for different formats is an error
+	m.SetPathTemplate("{name}")
+	if _, err := m.resolveTargetsFromArgs(context.Background(), []string{"csv", "json"}, "exec"); err == nil {
+		t.Errorf("expected an error for a path template which does not distinguish formats")
+	}
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/turbot/pipe-fittings/schema"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/exp/slices"
+)
+
+// exportResourceTypes are the resource types which export formats may be restricted to
+var exportResourceTypes = []string{schema.BlockTypeBenchmark, schema.BlockTypeControl, schema.BlockTypeDashboard, schema.BlockTypeQuery}
+
+// ParseResourceTypeFormats parses the export resource type args into a map of export format to the resource types
+// the format applies to
+// each arg has the form <format>=<resource type>, e.g. csv=benchmark - a format may be given more than once to apply
+// it to several resource types
+func ParseResourceTypeFormats(args []string) (map[string][]string, error) {
+	resourceTypeFormats := make(map[string][]string)
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		format, resourceType, ok := strings.Cut(arg, "=")
+		format, resourceType = strings.TrimSpace(format), strings.TrimSpace(resourceType)
+		if !ok || format == "" || resourceType == "" {
+			return nil, sperr.New("invalid export resource type '%s' - expected <format>=<resource type>, e.g. csv=benchmark", arg)
+		}
+		if !slices.Contains(exportResourceTypes, resourceType) {
+			return nil, sperr.New("invalid export resource type '%s' for format '%s' - supported resource types: %s", resourceType, format, strings.Join(exportResourceTypes, ", "))
+		}
+		if !slices.Contains(resourceTypeFormats[format], resourceType) {
+			resourceTypeFormats[format] = append(resourceTypeFormats[format], resourceType)
+		}
+	}
+	return resourceTypeFormats, nil
+}
+
+// appliesToResourceType returns whether exports using the exporter apply to the resource type being exported
+// formats which are not restricted to any resource types apply to all resource types
+func (m *Manager) appliesToResourceType(exporter Exporter) bool {
+	if m.resourceType == "" {
+		return true
+	}
+	restricted := false
+	for format, resourceTypes := range m.resourceTypeFormats {
+		// the format may be given by name or alias
+		e, ok := m.registeredExporters[format]
+		if !ok || e.Name() != exporter.Name() {
+			continue
+		}
+		if slices.Contains(resourceTypes, m.resourceType) {
+			return true
+		}
+		restricted = true
+	}
+	return !restricted
+}
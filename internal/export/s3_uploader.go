@@ -0,0 +1,153 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/encoding/httpbinding"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	// the region used to look up the region of an s3 bucket, if no region is configured
+	defaultS3Region = "us-east-1"
+	// the maximum size of an object which may be uploaded in a single PutObject request
+	maxS3PutObjectSize = 5 * 1024 * 1024 * 1024
+)
+
+// s3Uploader uploads to s3, using the AWS default credential chain and shared config
+// (environment variables, shared config/credentials files, container and instance roles)
+//
+// if a custom endpoint is configured (AWS_ENDPOINT_URL, e.g. for an s3 compatible object store), objects are
+// uploaded to the endpoint using path style URLs - otherwise the region of the bucket is looked up, and objects are
+// uploaded to the regional s3 endpoint
+type s3Uploader struct{}
+
+func (u *s3Uploader) upload(ctx context.Context, bucket, key string, content io.Reader) error {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if cfg.Credentials == nil {
+		return sperr.New("no AWS credentials are configured")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	var bucketURL string
+	if cfg.BaseEndpoint != nil {
+		bucketURL = strings.TrimSuffix(*cfg.BaseEndpoint, "/") + "/" + bucket
+	} else {
+		// the bucket may not be in the configured region (if any) - look up the bucket region
+		region, err = s3BucketRegion(ctx, cfg.HTTPClient, fmt.Sprintf("https://s3.%s.amazonaws.com", region), bucket)
+		if err != nil {
+			return sperr.WrapWithMessage(err, "failed to determine the region of bucket '%s'", bucket)
+		}
+		bucketURL = s3BucketURL(bucket, region)
+	}
+	return putS3Object(ctx, cfg.HTTPClient, cfg.Credentials, region, bucketURL, key, content)
+}
+
+// s3BucketURL returns the URL of the bucket on the regional s3 endpoint - virtual hosted style URLs are used,
+// unless the bucket name contains dots (which are not valid in the TLS certificate of the virtual host)
+func s3BucketURL(bucket, region string) string {
+	if strings.Contains(bucket, ".") {
+		return fmt.Sprintf("https://s3.%s.amazonaws.com/%s", region, bucket)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+}
+
+// s3BucketRegion returns the region of the bucket - s3 returns the bucket region in the response to an
+// (unauthenticated) HEAD bucket request to any regional endpoint, even if the request itself is not permitted
+func s3BucketRegion(ctx context.Context, client aws.HTTPClient, endpoint, bucket string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint+"/"+bucket, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if region := resp.Header.Get("X-Amz-Bucket-Region"); region != "" {
+		return region, nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return "", sperr.New("bucket '%s' does not exist", bucket)
+	}
+	return "", sperr.New("unexpected response %s", resp.Status)
+}
+
+// putS3Object uploads the content to the key in the bucket using a single, SigV4 signed, PutObject request
+func putS3Object(ctx context.Context, client aws.HTTPClient, credentialsProvider aws.CredentialsProvider, region, bucketURL, key string, content io.Reader) error {
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if len(body) > maxS3PutObjectSize {
+		return sperr.New("the export is %d bytes - exports larger than 5GB cannot be uploaded to s3", len(body))
+	}
+	hash := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(hash[:])
+
+	objectURL, err := url.Parse(bucketURL)
+	if err != nil {
+		return err
+	}
+	// s3 keys are escaped as for the SigV4 canonical URI - the key is signed as escaped (see DisableURIPathEscaping)
+	objectURL.RawPath = objectURL.EscapedPath() + "/" + httpbinding.EscapePath(key, false)
+	objectURL.Path = objectURL.Path + "/" + key
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	credentials, err := credentialsProvider.Retrieve(ctx)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to load the AWS credentials")
+	}
+	signer := v4.NewSigner(func(o *v4.SignerOptions) {
+		o.DisableURIPathEscaping = true
+	})
+	if err := signer.SignHTTP(ctx, credentials, req, payloadHash, "s3", region, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return s3ResponseError(resp)
+	}
+	return nil
+}
+
+// s3ResponseError returns an error for an unsuccessful s3 response, including the s3 error code and message, if any
+func s3ResponseError(resp *http.Response) error {
+	var s3Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if body, err := io.ReadAll(resp.Body); err == nil && xml.Unmarshal(body, &s3Error) == nil && s3Error.Code != "" {
+		return sperr.New("%s: %s (%s)", s3Error.Code, s3Error.Message, resp.Status)
+	}
+	return sperr.New("unexpected response %s", resp.Status)
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// s3TestServer records the requests made to it, responding with the given status and body
+type s3TestServer struct {
+	*httptest.Server
+	mut      sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func newS3TestServer(t *testing.T, status int, responseBody string) *s3TestServer {
+	s := &s3TestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mut.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		s.mut.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(responseBody))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setS3TestConfig configures the AWS environment to use the endpoint, with static credentials and no shared config
+func setS3TestConfig(t *testing.T, endpoint string) {
+	configDir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(configDir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(configDir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-access-key")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL", endpoint)
+}
+
+func TestS3Upload(t *testing.T) {
+	server := newS3TestServer(t, http.StatusOK, "")
+	setS3TestConfig(t, server.URL)
+
+	tests := map[string]struct {
+		key          string
+		expectedPath string
+	}{
+		"key":                {key: "check.json", expectedPath: "/bucket/check.json"},
+		"prefixed key":       {key: "reports/daily/check.json", expectedPath: "/bucket/reports/daily/check.json"},
+		"key with escapes":   {key: "my reports/check 1+2.json", expectedPath: "/bucket/my%20reports/check%201%2B2.json"},
+		"key with unicode":   {key: "rapports/vérification.json", expectedPath: "/bucket/rapports/v%C3%A9rification.json"},
+		"key with reserved":  {key: "a=b&c/d?e#f.json", expectedPath: "/bucket/a%3Db%26c/d%3Fe%23f.json"},
+		"empty prefix parts": {key: "reports//check.json", expectedPath: "/bucket/reports//check.json"},
+	}
+	for name, test := range tests {
+		server.requests, server.bodies = nil, nil
+		if err := (&s3Uploader{}).upload(context.Background(), "bucket", test.key, strings.NewReader("content")); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if len(server.requests) != 1 {
+			t.Errorf("%s: expected a single request, got %d", name, len(server.requests))
+			continue
+		}
+		req := server.requests[0]
+		if req.Method != http.MethodPut || req.URL.EscapedPath() != test.expectedPath {
+			t.Errorf("%s: expected PUT %s, got %s %s", name, test.expectedPath, req.Method, req.URL.EscapedPath())
+		}
+		if server.bodies[0] != "content" {
+			t.Errorf("%s: unexpected body %s", name, server.bodies[0])
+		}
+		hash := sha256.Sum256([]byte("content"))
+		if got := req.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(hash[:]) {
+			t.Errorf("%s: unexpected payload hash %s", name, got)
+		}
+		if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+			t.Errorf("%s: expected a request signed for s3 in eu-west-1, got %s", name, auth)
+		}
+	}
+}
+
+func TestS3UploadError(t *testing.T) {
+	server := newS3TestServer(t, http.StatusForbidden, `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+	setS3TestConfig(t, server.URL)
+
+	err := (&s3Uploader{}).upload(context.Background(), "bucket", "check.json", strings.NewReader("content"))
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied: Access Denied (403 Forbidden)") {
+		t.Errorf("expected an access denied error, got %v", err)
+	}
+}
+
+func TestS3BucketRegion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bucket":
+			// the region is returned even if the request is not permitted
+			w.Header().Set("X-Amz-Bucket-Region", "ap-southeast-2")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	region, err := s3BucketRegion(context.Background(), server.Client(), server.URL, "bucket")
+	if err != nil || region != "ap-southeast-2" {
+		t.Errorf("expected region ap-southeast-2, got %s (%v)", region, err)
+	}
+	if _, err := s3BucketRegion(context.Background(), server.Client(), server.URL, "missing"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing bucket error, got %v", err)
+	}
+}
+
+func TestS3BucketURL(t *testing.T) {
+	tests := map[string]string{
+		"bucket":         "https://bucket.s3.eu-west-1.amazonaws.com",
+		"my.dotted.name": "https://s3.eu-west-1.amazonaws.com/my.dotted.name",
+	}
+	for bucket, expected := range tests {
+		if got := s3BucketURL(bucket, "eu-west-1"); got != expected {
+			t.Errorf("s3BucketURL(%q): expected %s, got %s", bucket, expected, got)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package export
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	// checksumExtension is appended to the export file name for the checksum file - this is in the format written by
+	// sha256sum, so may be verified using 'sha256sum -c'
+	checksumExtension = ".sha256"
+	// signatureExtension is appended to the export file name for the (binary) detached signature file
+	signatureExtension = ".sig"
+)
+
+// Signer writes a detached checksum file for each local export file and, if it has a private key, a detached
+// signature file, so the exports can be verified as unmodified
+//
+// The signature is of the export file content, so may be verified using openssl with the corresponding public key:
+//   - ed25519: openssl pkeyutl -verify -pubin -inkey key.pub -rawin -in <file> -sigfile <file>.sig
+//   - rsa (PKCS #1 v1.5) or ecdsa: openssl dgst -sha256 -verify key.pub -signature <file>.sig <file>
+type Signer struct {
+	// the private key used to sign the exports - if this is nil, only checksums are written
+	key crypto.Signer
+}
+
+// NewSigner creates a Signer - if a key path is given, this must be a PEM encoded (unencrypted) ed25519, rsa or ecdsa
+// private key, and a signature is written for each export as well as a checksum
+func NewSigner(keyPath string) (*Signer, error) {
+	if keyPath == "" {
+		return &Signer{}, nil
+	}
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to read export signing key")
+	}
+	key, err := parsePrivateKey(content)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "invalid export signing key '%s'", keyPath)
+	}
+	return &Signer{key: key}, nil
+}
+
+// signFile writes the checksum (and signature, if there is a key) files for the export file, with the given mode
+// (if the mode is not set, the files are created with the default mode)
+func (s *Signer) signFile(filePath string, mode os.FileMode) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to read export file to sign")
+	}
+	digest := sha256.Sum256(content)
+	checksum := fmt.Sprintf("%s  %s\n", hex.EncodeToString(digest[:]), filepath.Base(filePath))
+	if err := writeFile(filePath+checksumExtension, strings.NewReader(checksum), mode); err != nil {
+		return sperr.WrapWithMessage(err, "failed to write export checksum file")
+	}
+	if s.key == nil {
+		return nil
+	}
+
+	signature, err := sign(s.key, content, digest[:])
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to sign export file")
+	}
+	if err := writeFile(filePath+signatureExtension, bytes.NewReader(signature), mode); err != nil {
+		return sperr.WrapWithMessage(err, "failed to write export signature file")
+	}
+	return nil
+}
+
+// VerifyExport verifies that the export file matches its checksum file and, if a public key is given, its signature
+// file - an error is returned if either does not match (or is missing)
+func VerifyExport(filePath string, publicKey crypto.PublicKey) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(content)
+
+	checksum, err := os.ReadFile(filePath + checksumExtension)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to read export checksum file")
+	}
+	expected, _, _ := strings.Cut(strings.TrimSpace(string(checksum)), " ")
+	if expected != hex.EncodeToString(digest[:]) {
+		return sperr.New("export file '%s' does not match its checksum", filePath)
+	}
+	if publicKey == nil {
+		return nil
+	}
+
+	signature, err := os.ReadFile(filePath + signatureExtension)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to read export signature file")
+	}
+	if !verify(publicKey, content, digest[:], signature) {
+		return sperr.New("export file '%s' does not match its signature", filePath)
+	}
+	return nil
+}
+
+// ParsePublicKey parses a PEM encoded (PKIX) ed25519, rsa or ecdsa public key, as used to verify export signatures
+func ParsePublicKey(content []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, sperr.New("the key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, sperr.New("unsupported public key type %T", key)
+	}
+}
+
+// parsePrivateKey parses a PEM encoded PKCS #8, PKCS #1 (rsa) or SEC 1 (ecdsa) private key
+func parsePrivateKey(content []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, sperr.New("the key is not PEM encoded")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, sperr.New("unsupported private key type %T", key)
+	}
+}
+
+// sign returns the signature of the content - ed25519 keys sign the content, other keys sign the sha256 digest
+func sign(key crypto.Signer, content, digest []byte) ([]byte, error) {
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		return key.Sign(rand.Reader, content, crypto.Hash(0))
+	}
+	return key.Sign(rand.Reader, digest, crypto.SHA256)
+}
+
+func verify(publicKey crypto.PublicKey, content, digest, signature []byte) bool {
+	switch k := publicKey.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, content, signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest, signature)
+	default:
+		return false
+	}
+}
+
+// isSigned returns whether the target export is signed - only local export files are signed
+func (t *Target) isSigned() bool {
+	_, isNull := t.exporter.(*NullExporter)
+	return t.isLocalFile() && !isNull
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestSigningKey writes the private key as a PKCS #8 PEM file, returning the path and the PEM encoded public key
+func writeTestSigningKey(t *testing.T, key crypto.Signer) (string, []byte) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	publicDer, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})
+}
+
+func writeTestExportFile(t *testing.T) string {
+	filePath := filepath.Join(t.TempDir(), "export.json")
+	if err := os.WriteFile(filePath, []byte(`{"summary":{"ok":1}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestSignExport(t *testing.T) {
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := map[string]crypto.Signer{"ed25519": ed25519Key, "rsa": rsaKey, "ecdsa": ecdsaKey}
+
+	for name, key := range keys {
+		keyPath, publicPem := writeTestSigningKey(t, key)
+		signer, err := NewSigner(keyPath)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		publicKey, err := ParsePublicKey(publicPem)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		filePath := writeTestExportFile(t)
+		if err := signer.signFile(filePath, 0); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := VerifyExport(filePath, publicKey); err != nil {
+			t.Errorf("%s: expected the export to verify: %v", name, err)
+		}
+
+		// a modified export fails verification
+		if err := os.WriteFile(filePath, []byte(`{"summary":{"ok":2}}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyExport(filePath, publicKey); err == nil {
+			t.Errorf("%s: expected a modified export to fail verification", name)
+		}
+	}
+}
+
+func TestSignExportWrongKey(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	otherPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	keyPath, _ := writeTestSigningKey(t, key)
+	signer, err := NewSigner(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePath := writeTestExportFile(t)
+	if err := signer.signFile(filePath, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyExport(filePath, otherPublicKey); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected the signature not to match, got %v", err)
+	}
+}
+
+func TestSignExportChecksumOnly(t *testing.T) {
+	signer, err := NewSigner("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePath := writeTestExportFile(t)
+	if err := signer.signFile(filePath, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// the checksum file is in sha256sum format, and has the file mode
+	checksum, err := os.ReadFile(filePath + checksumExtension)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := strings.Fields(string(checksum)); len(fields) != 2 || len(fields[0]) != 64 || fields[1] != "export.json" {
+		t.Errorf("unexpected checksum file content %q", checksum)
+	}
+	if info, err := os.Stat(filePath + checksumExtension); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("expected the checksum file to have mode 0640")
+	}
+	if _, err := os.Stat(filePath + signatureExtension); !os.IsNotExist(err) {
+		t.Errorf("expected no signature file without a key")
+	}
+	if err := VerifyExport(filePath, nil); err != nil {
+		t.Errorf("expected the export to verify: %v", err)
+	}
+}
+
+func TestNewSignerInvalidKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner(keyPath); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+	if _, err := NewSigner(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+}
@@ -0,0 +1,281 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/turbot/powerpipe/internal/utils"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// StdoutTargetSuffix is the suffix of an export argument which writes the export to stdout, e.g. --export json:-
+const StdoutTargetSuffix = ":-"
+
+// stdoutFilePath is the file path used to identify the stdout target
+const stdoutFilePath = "-"
+
+type Target struct {
+	exporter Exporter
+	// the exporter options specified in the export arg (if any)
+	options       map[string]string
+	filePath      string
+	isNamedTarget bool
+	toStdout      bool
+	// if set, the export is uploaded to this object store location, using filePath as the object name
+	objectStore *objectStoreLocation
+	// if set, this is used to upload to the object store (otherwise the uploader for the object store scheme is used)
+	uploader objectStoreUploader
+	// if set, the exporter is a DatabaseExporter, and filePath is the connection string of the database it writes to
+	toDatabase bool
+	// if set, the export is gzip compressed (and the gzip extension is appended to the file name)
+	compress bool
+	// if set, local export files (and any parent directories created for them) are created with this mode
+	fileMode os.FileMode
+	// if set, the missing parent directories of a local export file are created (this is set for files in the
+	// output directory)
+	createDirs bool
+}
+
+// isDuplicateOf returns whether the target uses the same exporter as the other target, with the same options
+// (targets which also resolve to the same destination are identical)
+func (t *Target) isDuplicateOf(other *Target) bool {
+	return t.exporter.Name() == other.exporter.Name() && maps.Equal(t.options, other.options)
+}
+
+// isLocalFile returns whether the target is written to a local file
+func (t *Target) isLocalFile() bool {
+	return !t.toStdout && t.objectStore == nil && !t.toDatabase
+}
+
+// fileName returns the name of the file (or object) the target is written to
+func (t *Target) fileName() string {
+	return t.outputName(t.filePath)
+}
+
+// outputName returns the name of the file (or object) an exported file is written to - i.e. with the gzip extension
+// appended if the target is compressed
+func (t *Target) outputName(filePath string) string {
+	if t.compress {
+		return filePath + gzipExtension
+	}
+	return filePath
+}
+
+// destination returns the location the target is exported to - this is used to identify the target
+func (t *Target) destination() string {
+	if t.objectStore != nil {
+		return t.objectStore.objectURL(t.fileName())
+	}
+	return t.fileName()
+}
+
+func (t *Target) Export(ctx context.Context, input ExportSourceData) (string, error) {
+	msg, _, err := t.export(ctx, input)
+	return msg, err
+}
+
+// export exports the input, returning the export location message and the paths of the local files written
+// (a multi file exporter may write more than one file)
+func (t *Target) export(ctx context.Context, input ExportSourceData) (string, []string, error) {
+	if e, ok := t.exporter.(*NullExporter); ok {
+		// null exports write nothing - report what would have been written
+		res, err := e.discard(ctx, input)
+		if err != nil {
+			return "", nil, err
+		}
+		return res.String(), nil, nil
+	}
+	if t.toStdout {
+		// there is no export location message - this would be written to the same stream as the export
+		return "", nil, t.exportToStdout(ctx, input)
+	}
+	if t.objectStore != nil {
+		msg, err := t.exportToObjectStore(ctx, input)
+		return msg, nil, err
+	}
+	if t.toDatabase {
+		if err := t.exporter.Export(ctx, input, t.filePath); err != nil {
+			return "", nil, utils.RedactConnectionStringError(err)
+		}
+		return fmt.Sprintf("Results exported to %s", t.location()), nil, nil
+	}
+	if t.createDirs {
+		dirMode := os.FileMode(0755)
+		if t.fileMode != 0 {
+			dirMode = dirModeForFileMode(t.fileMode)
+		}
+		if err := createParentDirs(t.fileName(), dirMode); err != nil {
+			return "", nil, err
+		}
+	}
+	var files []string
+	var err error
+	if t.compress || t.fileMode != 0 {
+		// exporters write uncompressed files with the default mode - so export to a (private) temp file
+		// and write the content with the required compression and mode
+		err = t.exportViaTempFile(ctx, input, func(filePath string, content io.Reader) error {
+			if t.fileMode != 0 {
+				if err := createParentDirs(filePath, dirModeForFileMode(t.fileMode)); err != nil {
+					return err
+				}
+			}
+			if err := writeFile(filePath, content, t.fileMode); err != nil {
+				return err
+			}
+			files = append(files, filePath)
+			return nil
+		})
+	} else {
+		files, err = exportFiles(ctx, t.exporter, input, t.filePath)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("File exported to %s", t.location()), files, nil
+}
+
+// exportFiles exports the input to the destination path, returning the paths of all the files written
+func exportFiles(ctx context.Context, exporter Exporter, input ExportSourceData, destPath string) ([]string, error) {
+	if e, ok := exporter.(MultiFileExporter); ok {
+		return e.ExportFiles(ctx, input, destPath)
+	}
+	if err := exporter.Export(ctx, input, destPath); err != nil {
+		return nil, err
+	}
+	return []string{destPath}, nil
+}
+
+// location returns the absolute file path, object URL or (redacted) connection string the target is exported to
+// (this is empty for stdout and null exports, which are not written anywhere)
+func (t *Target) location() string {
+	if _, ok := t.exporter.(*NullExporter); ok || t.toStdout {
+		return ""
+	}
+	if t.toDatabase {
+		return utils.RedactConnectionString(t.filePath)
+	}
+	if t.objectStore != nil {
+		return t.destination()
+	}
+	// the file path may be relative to the working directory, or absolute
+	exportedPath, err := filepath.Abs(t.fileName())
+	if err != nil {
+		return t.fileName()
+	}
+	return exportedPath
+}
+
+// exportToStdout exports to a temporary file and then copies this to stdout
+// (an export of multiple files cannot be written to stdout, so this is an error)
+func (t *Target) exportToStdout(ctx context.Context, input ExportSourceData) error {
+	return t.exportViaTempFile(ctx, input, func(_ string, content io.Reader) error {
+		_, err := io.Copy(os.Stdout, content)
+		return err
+	})
+}
+
+// exportToObjectStore exports to a temporary file and then uploads this to the object store
+func (t *Target) exportToObjectStore(ctx context.Context, input ExportSourceData) (string, error) {
+	objectURL := t.destination()
+	uploader := t.uploader
+	if uploader == nil {
+		var err error
+		if uploader, err = newObjectStoreUploader(t.objectStore.scheme); err != nil {
+			return "", err
+		}
+	}
+
+	err := t.exportViaTempFile(ctx, input, func(objectName string, content io.Reader) error {
+		key := t.objectStore.prefix + objectName
+		if err := uploader.upload(ctx, t.objectStore.bucket, key, content); err != nil {
+			return sperr.WrapWithMessage(err, "failed to upload export to bucket '%s', key '%s'", t.objectStore.bucket, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("File uploaded to %s", objectURL), nil
+}
+
+// exportViaTempFile exports to a temporary directory and then calls the write func with the output name (see
+// outputName) and contents of each file written (exporters only support writing to a file path)
+// if the target is compressed, the write func is passed the compressed contents
+func (t *Target) exportViaTempFile(ctx context.Context, input ExportSourceData, write func(string, io.Reader) error) error {
+	tempDir, err := os.MkdirTemp("", "powerpipe-export")
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to create temporary export directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	// the temp file has the same name as the target file, so any additional files written by a multi file exporter
+	// are named as they would be alongside the target file
+	tempName := filepath.Base(t.filePath)
+	if t.toStdout {
+		tempName = "export" + t.exporter.FileExtension()
+	}
+	tempFiles, err := exportFiles(ctx, t.exporter, input, filepath.Join(tempDir, tempName))
+	if err != nil {
+		return err
+	}
+	if t.toStdout && len(tempFiles) > 1 {
+		return sperr.New("the %s export writes %d files, so cannot be written to stdout", t.exporter.Name(), len(tempFiles))
+	}
+
+	for _, tempFile := range tempFiles {
+		filePath := filepath.Join(filepath.Dir(t.filePath), filepath.Base(tempFile))
+		if err := t.writeTempFile(tempFile, t.outputName(filePath), write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTempFile calls the write func with the output name and the (compressed, if the target is compressed)
+// contents of the temp file
+func (t *Target) writeTempFile(tempFile, outputName string, write func(string, io.Reader) error) error {
+	f, err := os.Open(tempFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if !t.compress {
+		return write(outputName, f)
+	}
+	compressed := gzipReader(f)
+	// close the reader so the compression goroutine exits if the write fails
+	defer compressed.Close()
+	return write(outputName, compressed)
+}
+
+// writeFile writes the content to the file path - if this fails, the partially written file is removed
+// if mode is set, the file mode is set to this before any content is written (regardless of the process umask,
+// or the mode of an existing file), otherwise the file is created with the default mode
+func writeFile(filePath string, content io.Reader, mode os.FileMode) error {
+	createMode := os.FileMode(0666)
+	if mode != 0 {
+		createMode = mode
+	}
+	f, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, createMode)
+	if err != nil {
+		return err
+	}
+	if mode != 0 {
+		err = f.Chmod(mode)
+	}
+	if err == nil {
+		_, err = io.Copy(f, content)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(filePath)
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// multiFileExporter writes the destination file and an additional batch file alongside it, named as the asff
+// exporter names batches, e.g. check.json and check.2.json
+type multiFileExporter struct {
+	testExporter
+}
+
+func (e *multiFileExporter) Export(ctx context.Context, input ExportSourceData, destPath string) error {
+	_, err := e.ExportFiles(ctx, input, destPath)
+	return err
+}
+
+func (e *multiFileExporter) ExportFiles(_ context.Context, _ ExportSourceData, destPath string) ([]string, error) {
+	files := []string{destPath, strings.TrimSuffix(destPath, e.extension) + ".2" + e.extension}
+	for i, file := range files {
+		if err := os.WriteFile(file, []byte(multiFileContent(i)), 0600); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func multiFileContent(i int) string {
+	return []string{`["batch 1"]`, `["batch 2"]`}[i]
+}
+
+// recordingUploader records the content uploaded for each key
+type recordingUploader struct {
+	mut     sync.Mutex
+	uploads map[string]string
+}
+
+func (u *recordingUploader) upload(_ context.Context, bucket, key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	if u.uploads == nil {
+		u.uploads = make(map[string]string)
+	}
+	u.uploads[bucket+"/"+key] = string(data)
+	return nil
+}
+
+func newMultiFileExporter() *multiFileExporter {
+	return &multiFileExporter{testExporter{extension: ".json", name: "asff"}}
+}
+
+// readTestFile returns the content of the file, decompressing it if it is gzipped
+func readTestFile(t *testing.T, filePath string) string {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(filePath, gzipExtension) {
+		return string(content)
+	}
+	return gunzipTestContent(t, content)
+}
+
+func gunzipTestContent(t *testing.T, content []byte) string {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(res)
+}
+
+func TestMultiFileExportLocal(t *testing.T) {
+	testCases := map[string]struct {
+		compress bool
+		fileMode os.FileMode
+		expected []string
+	}{
+		"uncompressed": {expected: []string{"check.json", "check.2.json"}},
+		"compressed":   {compress: true, expected: []string{"check.json.gz", "check.2.json.gz"}},
+		"file mode":    {fileMode: 0640, expected: []string{"check.json", "check.2.json"}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			target := &Target{exporter: newMultiFileExporter(), filePath: filepath.Join(tempDir, "check.json"), compress: tc.compress, fileMode: tc.fileMode}
+			_, files, err := target.export(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tc.expected) {
+				t.Fatalf("expected files %v, got %v", tc.expected, files)
+			}
+			for i, expected := range tc.expected {
+				if files[i] != filepath.Join(tempDir, expected) {
+					t.Errorf("expected file %d to be %s, got %s", i, expected, files[i])
+				}
+				if content := readTestFile(t, files[i]); content != multiFileContent(i) {
+					t.Errorf("expected %s to contain %s, got %s", expected, multiFileContent(i), content)
+				}
+				if tc.fileMode != 0 {
+					if info, err := os.Stat(files[i]); err != nil || info.Mode().Perm() != tc.fileMode {
+						t.Errorf("expected %s to have mode %o", expected, tc.fileMode)
+					}
+				}
+			}
+			entries, _ := os.ReadDir(tempDir)
+			if len(entries) != len(tc.expected) {
+				t.Errorf("expected only the exported files to be written, got %d files", len(entries))
+			}
+		})
+	}
+}
+
+func TestMultiFileExportObjectStore(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		uploader := &recordingUploader{}
+		target := &Target{
+			exporter:    newMultiFileExporter(),
+			filePath:    "check.json",
+			objectStore: &objectStoreLocation{scheme: s3Scheme, bucket: "bucket", prefix: "reports/"},
+			uploader:    uploader,
+			compress:    compress,
+		}
+		if _, err := target.Export(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		extension := ""
+		if compress {
+			extension = gzipExtension
+		}
+		keys := []string{"bucket/reports/check.json" + extension, "bucket/reports/check.2.json" + extension}
+		if len(uploader.uploads) != len(keys) {
+			t.Fatalf("compress %v: expected uploads %v, got %v", compress, keys, uploader.uploads)
+		}
+		for i, key := range keys {
+			content, ok := uploader.uploads[key]
+			if !ok {
+				t.Fatalf("compress %v: expected an upload to %s, got %v", compress, key, uploader.uploads)
+			}
+			if compress {
+				content = gunzipTestContent(t, []byte(content))
+			}
+			if content != multiFileContent(i) {
+				t.Errorf("compress %v: expected %s to contain %s, got %s", compress, key, multiFileContent(i), content)
+			}
+		}
+	}
+}
+
+func TestMultiFileExportStdout(t *testing.T) {
+	target := &Target{exporter: newMultiFileExporter(), filePath: stdoutFilePath, toStdout: true}
+	_, err := target.Export(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot be written to stdout") {
+		t.Fatalf("expected an error exporting multiple files to stdout, got %v", err)
+	}
+}
+
+func TestMultiFileExportSigned(t *testing.T) {
+	tempDir := t.TempDir()
+	m := NewManager()
+	if err := m.Register(newMultiFileExporter()); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSigner("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetSigner(signer)
+
+	filePath := filepath.Join(tempDir, "check.json")
+	if _, err := m.Export(context.Background(), "exec", nil, []string{filePath}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"check.json", "check.2.json"} {
+		if err := VerifyExport(filepath.Join(tempDir, name), nil); err != nil {
+			t.Errorf("expected %s to have a valid checksum: %v", name, err)
+		}
+	}
+}
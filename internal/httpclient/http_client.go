@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// the timeouts of the default client
+// there is no overall request timeout, as mod installation may download large repositories - callers which need one
+// should use a request context with a deadline
+const (
+	dialTimeout           = 30 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+	idleConnTimeout       = 90 * time.Second
+)
+
+var (
+	currentClient = newDefaultClient()
+	clientLock    sync.RWMutex
+)
+
+func init() {
+	installGitTransport(currentClient)
+}
+
+// SetClient sets the http client used for all outbound HTTP requests made during mod installation (git over http and
+// https) and by notifiers - e.g. to use a proxy, custom timeouts or a custom transport
+// if the client is nil, the default client is restored
+//
+// NOTE: the client must be set before mod installation starts or a notifier is created
+func SetClient(c *http.Client) {
+	if c == nil {
+		c = newDefaultClient()
+	}
+
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	currentClient = c
+	installGitTransport(c)
+}
+
+// Client returns the http client set by SetClient, or the default client if none has been set
+func Client() *http.Client {
+	clientLock.RLock()
+	defer clientLock.RUnlock()
+	return currentClient
+}
+
+// newDefaultClient returns a client which uses the proxy given by the environment (HTTP_PROXY, HTTPS_PROXY, NO_PROXY),
+// with timeouts for connecting and for the response headers
+func newDefaultClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   tlsHandshakeTimeout,
+			ResponseHeaderTimeout: responseHeaderTimeout,
+			IdleConnTimeout:       idleConnTimeout,
+			MaxIdleConns:          100,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
+
+// installGitTransport makes git use the client for http and https remotes - the mod installer uses git to list the
+// versions of and clone dependency mods
+func installGitTransport(c *http.Client) {
+	transport := githttp.NewClient(c)
+	client.InstallProtocol("http", transport)
+	client.InstallProtocol("https", transport)
+}
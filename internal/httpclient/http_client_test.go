@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// recordingTransport records the URLs of the requests it sends
+type recordingTransport struct {
+	urls []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.urls = append(r.urls, req.URL.String())
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSetClient(t *testing.T) {
+	t.Cleanup(func() { SetClient(nil) })
+
+	defaultClient := Client()
+	if defaultClient == nil || defaultClient.Transport == nil {
+		t.Fatal("expected a default client with a transport")
+	}
+
+	recorder := &recordingTransport{}
+	custom := &http.Client{Transport: recorder}
+	SetClient(custom)
+	if Client() != custom {
+		t.Error("expected the custom client to be returned")
+	}
+
+	// git must use the custom client for http remotes (the remote is not a git server, so listing it fails)
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{server.URL + "/mod.git"}})
+	_, _ = remote.List(&git.ListOptions{})
+	if len(recorder.urls) == 0 {
+		t.Error("expected git to use the custom client")
+	}
+
+	SetClient(nil)
+	if c := Client(); c == custom || c == nil {
+		t.Error("expected the default client to be restored")
+	}
+}
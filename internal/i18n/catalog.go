@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+// DefaultLocale is the locale of the built-in messages - this is used for any message which is not in the catalog
+// of the selected locale
+const DefaultLocale = "en"
+
+// MessageKey identifies a message in the catalogs
+type MessageKey string
+
+// Catalog is a map of message key to the message format for a locale
+// messages are fmt format strings, and must take the same arguments as the default (English) message
+type Catalog map[MessageKey]string
+
+var (
+	// map of locale to catalog
+	catalogs    = map[string]Catalog{DefaultLocale: defaultCatalog}
+	catalogLock sync.RWMutex
+)
+
+// RegisterCatalog adds the messages of the catalog to the catalog of the locale, e.g. "fr" or "pt-BR"
+// messages which are already registered for the locale are replaced, so an embedder may override individual messages
+// (including those of the default locale)
+func RegisterCatalog(locale string, catalog Catalog) {
+	locale = normalizeLocale(locale)
+
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+	existing, ok := catalogs[locale]
+	if !ok {
+		existing = make(Catalog, len(catalog))
+		catalogs[locale] = existing
+	}
+	for key, message := range catalog {
+		existing[key] = message
+	}
+}
+
+// Message returns the message for the key in the locale selected by ArgLocale, formatted with the args
+func Message(key MessageKey, args ...any) string {
+	return LocaleMessage(viper.GetString(localconstants.ArgLocale), key, args...)
+}
+
+// LocaleMessage returns the message for the key in the given locale, formatted with the args
+// if the locale has a region (e.g. pt-BR) and its catalog does not have the message, the catalog of the language
+// (e.g. pt) is used - if neither has the message, the default (English) message is used
+func LocaleMessage(locale string, key MessageKey, args ...any) string {
+	format := lookup(locale, key)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func lookup(locale string, key MessageKey) string {
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+
+	locale = normalizeLocale(locale)
+	candidates := []string{locale}
+	if language, _, ok := strings.Cut(locale, "-"); ok {
+		candidates = append(candidates, language)
+	}
+	for _, candidate := range append(candidates, DefaultLocale) {
+		if message, ok := catalogs[candidate][key]; ok {
+			return message
+		}
+	}
+	// every message should have a default - if not, use the key so the message is still identifiable
+	slog.Debug("no message found for key", "key", key, "locale", locale)
+	return string(key)
+}
+
+// normalizeLocale returns the locale in the form language[-region], e.g. "pt_BR.UTF-8" -> "pt-br"
+// an empty locale is the default locale
+func normalizeLocale(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" {
+		return DefaultLocale
+	}
+	return locale
+}
@@ -0,0 +1,36 @@
+package i18n
+
+import "testing"
+
+func TestLocaleMessage(t *testing.T) {
+	RegisterCatalog("xx", Catalog{
+		StatusInitializing:    "Initialisation",
+		StatusConnectingRetry: "Connexion (essai %d sur %d)",
+	})
+	RegisterCatalog("xx_YY", Catalog{StatusInitializing: "Initialisation (YY)"})
+
+	tests := []struct {
+		locale   string
+		key      MessageKey
+		args     []any
+		expected string
+	}{
+		{locale: "", key: StatusInitializing, expected: "Initializing"},
+		{locale: "xx", key: StatusInitializing, expected: "Initialisation"},
+		{locale: "xx", key: StatusConnectingRetry, args: []any{1, 3}, expected: "Connexion (essai 1 sur 3)"},
+		// the region catalog is used in preference to the language catalog
+		{locale: "xx-YY", key: StatusInitializing, expected: "Initialisation (YY)"},
+		// messages not in the region catalog fall back to the language catalog, then the default catalog
+		{locale: "xx_YY.UTF-8", key: StatusConnectingRetry, args: []any{2, 3}, expected: "Connexion (essai 2 sur 3)"},
+		{locale: "xx-YY", key: StatusLoadingWorkspace, expected: "Loading workspace"},
+		// unknown locales use the default catalog
+		{locale: "zz", key: StatusConnectingRetry, args: []any{1, 2}, expected: "Connecting to database (retry 1 of 2)"},
+		// unknown keys return the key
+		{locale: "xx", key: "status.unknown", expected: "status.unknown"},
+	}
+	for _, test := range tests {
+		if actual := LocaleMessage(test.locale, test.key, test.args...); actual != test.expected {
+			t.Errorf("%s (%s): expected %q, got %q", test.key, test.locale, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package i18n
+
+// keys of the status and warning messages shown during initialisation
+const (
+	StatusInitializing                MessageKey = "status.initializing"
+	StatusLoadingWorkspace            MessageKey = "status.loading_workspace"
+	StatusInstallingDependencies      MessageKey = "status.installing_dependencies"
+	StatusInstallingDependenciesRetry MessageKey = "status.installing_dependencies_retry"
+	StatusConnecting                  MessageKey = "status.connecting"
+	StatusConnectingRetry             MessageKey = "status.connecting_retry"
+	StatusOpeningConnections          MessageKey = "status.opening_connections"
+	StatusInitTimedOut                MessageKey = "status.init_timed_out"
+	StatusInitCancelled               MessageKey = "status.init_cancelled"
+	WarningNoControls                 MessageKey = "warning.no_controls"
+	WarningPoolWarmUpFailed           MessageKey = "warning.pool_warm_up_failed"
+)
+
+// defaultCatalog is the catalog of the default (English) messages
+var defaultCatalog = Catalog{
+	StatusInitializing:                "Initializing",
+	StatusLoadingWorkspace:            "Loading workspace",
+	StatusInstallingDependencies:      "Installing workspace dependencies",
+	StatusInstallingDependenciesRetry: "Installing workspace dependencies (retry %d of %d)",
+	StatusConnecting:                  "Connecting to database",
+	StatusConnectingRetry:             "Connecting to database (retry %d of %d)",
+	StatusOpeningConnections:          "Opening database connections",
+	StatusInitTimedOut:                "Initialization timed out while %s",
+	StatusInitCancelled:               "Initialization cancelled while %s",
+	WarningNoControls:                 "no controls or benchmarks found in current workspace",
+	WarningPoolWarmUpFailed:           "failed to warm up the database connection pool (%d of %d connections opened): %s",
+}
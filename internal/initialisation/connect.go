@@ -0,0 +1,59 @@
+package initialisation
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/statushooks"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/i18n"
+)
+
+// getDbClientWithRetry calls GetDbClient, retrying with exponential backoff if the connection fails with a transient error
+// the number of retries and base retry interval are controlled by ArgConnectionMaxRetries and ArgConnectionRetryInterval
+func getDbClientWithRetry(ctx context.Context, connectionStrings []string, opts ...db_client.ClientOption) (*db_client.DbClient, error_helpers.ErrorAndWarnings) {
+	maxRetries := viper.GetInt(localconstants.ArgConnectionMaxRetries)
+	if maxRetries <= 0 {
+		return db_client.GetDbClient(ctx, connectionStrings, opts...)
+	}
+
+	retryInterval := time.Duration(viper.GetInt(localconstants.ArgConnectionRetryInterval)) * time.Second
+	if retryInterval <= 0 {
+		retryInterval = localconstants.DefaultConnectionRetryInterval * time.Second
+	}
+	backoff := retry.WithMaxRetries(uint64(maxRetries), retry.NewExponential(retryInterval)) //nolint:gosec // maxRetries is positive
+
+	var client *db_client.DbClient
+	var errAndWarnings error_helpers.ErrorAndWarnings
+	attempt := 0
+	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
+		if attempt > 0 {
+			statushooks.SetStatus(ctx, i18n.Message(i18n.StatusConnectingRetry, attempt, maxRetries))
+		}
+		attempt++
+
+		client, errAndWarnings = db_client.GetDbClient(ctx, connectionStrings, opts...)
+		if err := errAndWarnings.Error; err != nil {
+			if db_client.IsTransientConnectionError(err) {
+				slog.Info("database connection failed with a transient error - retrying", "attempt", attempt, "error", err)
+				return retry.RetryableError(err)
+			}
+			return err
+		}
+		return nil
+	})
+
+	// if the context was cancelled while waiting to retry, retry.Do returns the context error
+	if err != nil && errAndWarnings.Error == nil {
+		errAndWarnings.Error = err
+	}
+	if ctx.Err() != nil {
+		errAndWarnings.Error = ctx.Err()
+	}
+	return client, errAndWarnings
+}
@@ -0,0 +1,46 @@
+package initialisation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+type correlationIdContextKey struct{}
+
+// ContextWithCorrelationId returns a context containing the given run correlation ID
+// if Init is called with this context (and InitData.CorrelationId is not set), this ID is used for the run
+func ContextWithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlationIdContextKey{}, correlationId)
+}
+
+// CorrelationIdFromContext returns the run correlation ID from the context, or an empty string if there is none
+func CorrelationIdFromContext(ctx context.Context) string {
+	correlationId, _ := ctx.Value(correlationIdContextKey{}).(string)
+	return correlationId
+}
+
+// resolveCorrelationId determines the correlation ID for the run - in order of precedence, this is
+// InitData.CorrelationId, the ID from the context, ArgCorrelationId, or a generated UUID
+func (i *InitData[T]) resolveCorrelationId(ctx context.Context) string {
+	switch {
+	case i.CorrelationId != "":
+		return i.CorrelationId
+	case CorrelationIdFromContext(ctx) != "":
+		return CorrelationIdFromContext(ctx)
+	case viper.GetString(localconstants.ArgCorrelationId) != "":
+		return viper.GetString(localconstants.ArgCorrelationId)
+	default:
+		return uuid.NewString()
+	}
+}
+
+// applicationName returns the application name reported to the database, which includes the correlation ID
+// so that queries may be attributed to the run
+func (i *InitData[T]) applicationName() string {
+	return fmt.Sprintf("%s/%s", app_specific.AppName, i.CorrelationId)
+}
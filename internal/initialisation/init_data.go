@@ -2,8 +2,11 @@ package initialisation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -12,7 +15,6 @@ import (
 	"github.com/turbot/pipe-fittings/backend"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/error_helpers"
-	"github.com/turbot/pipe-fittings/export"
 	"github.com/turbot/pipe-fittings/modconfig"
 	"github.com/turbot/pipe-fittings/modinstaller"
 	"github.com/turbot/pipe-fittings/plugin"
@@ -24,9 +26,14 @@ import (
 	"github.com/turbot/powerpipe/internal/dashboardexecute"
 	"github.com/turbot/powerpipe/internal/dashboardworkspace"
 	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/powerpipe/internal/export"
+	"github.com/turbot/powerpipe/internal/i18n"
 	"github.com/turbot/powerpipe/internal/powerpipeconfig"
+	"github.com/turbot/powerpipe/internal/telemetry"
+	localutils "github.com/turbot/powerpipe/internal/utils"
 	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
-	"github.com/turbot/steampipe-plugin-sdk/v5/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type InitData[T modconfig.ModTreeItem] struct {
@@ -35,9 +42,44 @@ type InitData[T modconfig.ModTreeItem] struct {
 	Result          *InitResult
 
 	ShutdownTelemetry func()
-	ExportManager     *export.Manager
-	Targets           []modconfig.ModTreeItem
-	DefaultClient     *db_client.DbClient
+	// if set, telemetry is exported using this config rather than the default telemetry configuration
+	TelemetryConfig *telemetry.Config
+	// if set, this is used as the telemetry service name rather than the app name
+	// (this allows runs launched by different tools to be distinguished)
+	TelemetryServiceName string
+	// if set, this is used as the name of the root telemetry span rather than 'init' (or ArgTraceRootSpanName)
+	TelemetryRootSpanName string
+	ExportManager         *export.Manager
+	Targets               []modconfig.ModTreeItem
+	// the default client - this is nil if no resources require a database (e.g. the dashboards are purely static)
+	DefaultClient *db_client.DbClient
+	// the dashboard executor created by Init for the default client (this is also set as dashboardexecute.Executor)
+	DashboardExecutor *dashboardexecute.DashboardExecutor
+	// the SQL features supported by the default client backend
+	BackendCapabilities db_client.BackendCapabilities
+	// the TTL of cached control results - zero if control results are not cached
+	ControlCacheTtl time.Duration
+	// the maximum number of control queries executed concurrently - zero if there is no default client
+	MaxParallel int
+	// the phase of initialisation currently being executed - used to report where a cancellation occurred
+	Phase InitPhase
+	// if set, this is called by Init as each phase of initialisation is started
+	// (this allows embedders to report init progress without using statushooks)
+	OnPhaseChange func(phase InitPhase)
+	// the ID used to correlate the database queries and telemetry of this run
+	// - if this is not set, Init populates it (see resolveCorrelationId)
+	CorrelationId string
+
+	// options used to configure the default client
+	clientOpts []db_client.ClientOption
+	// true if the default client was created by Init (rather than provided using SetClient)
+	// - only clients which are owned are closed by Cleanup
+	ownsClient bool
+	// the plugin versions available from the default client - populated once the client is connected
+	// (or loaded from the plugin version file, if one was specified)
+	pluginVersionMap *plugin.PluginVersionMap
+	// true if the workspace mods directory is already linked to the mod install directory (see NewInitData)
+	modInstallDirLinked bool
 }
 
 func NewErrorInitData[T modconfig.ModTreeItem](err error) *InitData[T] {
@@ -51,37 +93,128 @@ func NewErrorInitData[T modconfig.ModTreeItem](err error) *InitData[T] {
 func NewInitData[T modconfig.ModTreeItem](ctx context.Context, cmd *cobra.Command, cmdArgs ...string) *InitData[T] {
 	modLocation := viper.GetString(constants.ArgModLocation)
 
-	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(ctx,
+	// if a mod install directory is set, link the workspace mods directory to it while the workspace is loaded
+	// and its dependencies are installed - the workspace mods directory is restored once Init completes
+	restoreModsDir, err := linkModInstallDirFromArgs(ctx, modLocation)
+	if err != nil {
+		return NewErrorInitData[T](NewInitError(InitErrorCodeModInstall, err))
+	}
+	if restoreModsDir != nil {
+		defer func() {
+			if err := restoreModsDir(); err != nil {
+				slog.Warn("Failed to restore the workspace mods directory", "error", err)
+			}
+		}()
+	}
+
+	w, errAndWarnings := loadWorkspace(ctx, modLocation)
+	if errAndWarnings.GetError() != nil {
+		return NewErrorInitData[T](NewInitError(InitErrorCodeWorkspaceLoad, fmt.Errorf("failed to load workspace: %s", error_helpers.HandleCancelError(errAndWarnings.GetError()).Error())))
+	}
+
+	if !w.ModfileExists() && commandRequiresModfile[T](cmd, cmdArgs) {
+		return NewErrorInitData[T](NewInitError(InitErrorCodeNoModFile, localconstants.ErrorNoModDefinition{}))
+	}
+	i := NewInitDataWithWorkspace[T](w)
+	i.modInstallDirLinked = restoreModsDir != nil
+	i.Result.AddWarnings(errAndWarnings.Warnings...)
+	i.ExportManager.SetPathTemplate(viper.GetString(localconstants.ArgExportPathTemplate))
+	i.ExportManager.SetCompress(viper.GetBool(localconstants.ArgExportCompress))
+	i.ExportManager.SetOutputDir(viper.GetString(localconstants.ArgExportDir))
+	if fileMode := viper.GetString(localconstants.ArgExportFileMode); fileMode != "" {
+		mode, err := export.ParseFileMode(fileMode)
+		if err != nil {
+			return NewErrorInitData[T](NewInitError(InitErrorCodeInvalidConfig, err))
+		}
+		i.ExportManager.SetFileMode(mode)
+	}
+	if signingKey := viper.GetString(localconstants.ArgExportSigningKey); signingKey != "" || viper.GetBool(localconstants.ArgExportSign) {
+		signer, err := export.NewSigner(signingKey)
+		if err != nil {
+			return NewErrorInitData[T](NewInitError(InitErrorCodeInvalidConfig, err))
+		}
+		i.ExportManager.SetSigner(signer)
+	}
+	resourceTypeFormats, err := export.ParseResourceTypeFormats(viper.GetStringSlice(localconstants.ArgExportResourceType))
+	if err != nil {
+		return NewErrorInitData[T](NewInitError(InitErrorCodeInvalidConfig, err))
+	}
+	i.ExportManager.SetResourceType(modconfig.GenericTypeToBlockType[T]())
+	i.ExportManager.SetResourceTypeFormats(resourceTypeFormats)
+
+	setDatabaseFromMod(w)
+
+	// now do the actual initialisation
+	i.Init(ctx, cmdArgs...)
+
+	return i
+}
+
+// loadWorkspace loads the workspace from the mod location, using the standard load options
+func loadWorkspace(ctx context.Context, modLocation string) (*workspace.Workspace, error_helpers.ErrorAndWarnings) {
+	return workspace.LoadWorkspacePromptingForVariables(ctx,
 		modLocation,
 		// pass connections
 		workspace.WithPipelingConnections(powerpipeconfig.GlobalConfig.PipelingConnections),
 		// disable late binding
 		workspace.WithLateBinding(false),
 	)
-	if errAndWarnings.GetError() != nil {
-		return NewErrorInitData[T](fmt.Errorf("failed to load workspace: %s", error_helpers.HandleCancelError(errAndWarnings.GetError()).Error()))
-	}
+}
 
-	if !w.ModfileExists() && commandRequiresModfile[T](cmd, cmdArgs) {
-		return NewErrorInitData[T](localconstants.ErrorNoModDefinition{})
+// linkModInstallDirFromArgs links the workspace mods directory to the mod install directory, if one is set,
+// returning the func which restores the workspace mods directory - nil is returned if no install directory is set
+func linkModInstallDirFromArgs(ctx context.Context, workspacePath string) (func() error, error) {
+	installDir := viper.GetString(localconstants.ArgModInstallDir)
+	if installDir == "" {
+		return nil, nil
 	}
-	i := &InitData[T]{
-		Result:        &InitResult{},
-		ExportManager: export.NewManager(),
+	installDir, err := validateModInstallDir(installDir)
+	if err != nil {
+		return nil, err
 	}
+	return linkModInstallDir(ctx, workspacePath, installDir)
+}
 
-	i.Workspace = w
-	i.Result.Warnings = errAndWarnings.Warnings
-
-	// if the database is NOT set in viper, and the mod has a connection string, set it
+// setDatabaseFromMod sets the database in viper from the mod connection string -
+// this is only done if the database is NOT already set in viper
+func setDatabaseFromMod(w *workspace.Workspace) {
 	if !viper.IsSet(constants.ArgDatabase) && w.Mod.Database != nil {
 		viper.Set(constants.ArgDatabase, *w.Mod.Database)
 	}
+}
 
-	// now do the actual initialisation
-	i.Init(ctx, cmdArgs...)
+// LoadWorkspace loads the workspace from the given mod directory (using the same load options as NewInitData)
+// and assigns it to i.Workspace. Any workspace load warnings are added to the init result.
+// An error is returned if the workspace fails to load or the mod directory does not contain a mod file
+func (i *InitData[T]) LoadWorkspace(ctx context.Context, modPath string) error {
+	w, errAndWarnings := loadWorkspace(ctx, modPath)
+	if err := errAndWarnings.GetError(); err != nil {
+		return fmt.Errorf("failed to load workspace from '%s': %s", modPath, error_helpers.HandleCancelError(err).Error())
+	}
+	if !w.ModfileExists() {
+		return sperr.New("could not find a mod definition file (mod.pp) in '%s'", modPath)
+	}
 
-	return i
+	i.Workspace = w
+	if i.Result == nil {
+		i.Result = &InitResult{}
+	}
+	i.Result.AddWarnings(errAndWarnings.Warnings...)
+	setDatabaseFromMod(w)
+	return nil
+}
+
+// NewInitDataWithWorkspace creates an InitData for an already loaded workspace
+// the client options are stored and used to configure the default client when Init is called
+// NOTE: unlike NewInitData, this does not call Init
+// the workspace may be nil, in which case it must be loaded using LoadWorkspace before calling Init
+func NewInitDataWithWorkspace[T modconfig.ModTreeItem](w *workspace.Workspace, opts ...db_client.ClientOption) *InitData[T] {
+	return &InitData[T]{
+		Workspace:     w,
+		Result:        &InitResult{},
+		ExportManager: export.NewManager(),
+		clientOpts:    opts,
+	}
 }
 
 func commandRequiresModfile[T modconfig.ModTreeItem](cmd *cobra.Command, args []string) bool {
@@ -95,6 +228,24 @@ func commandRequiresModfile[T modconfig.ModTreeItem](cmd *cobra.Command, args []
 	return argIsNamedResource
 }
 
+// SetClient sets an externally managed client to use as the default client.
+// If a client is set before Init is called, Init uses it rather than creating a new client.
+// The client is not closed by Cleanup - this is the responsibility of the caller
+func (i *InitData[T]) SetClient(client *db_client.DbClient) {
+	i.DefaultClient = client
+	i.ownsClient = false
+	i.pluginVersionMap = nil
+	if client != nil {
+		i.pluginVersionMap = newPluginVersionMap(client)
+	}
+}
+
+// PluginVersionMap returns the plugin versions available from the default client
+// (this is nil until the default client has been connected or set)
+func (i *InitData[T]) PluginVersionMap() *plugin.PluginVersionMap {
+	return i.pluginVersionMap
+}
+
 func (i *InitData[T]) RegisterExporters(exporters ...export.Exporter) error {
 	for _, e := range exporters {
 		if err := i.ExportManager.Register(e); err != nil {
@@ -105,14 +256,66 @@ func (i *InitData[T]) RegisterExporters(exporters ...export.Exporter) error {
 	return nil
 }
 
+// telemetryServiceName returns the service name used for telemetry - this defaults to the app name
+func (i *InitData[T]) telemetryServiceName() string {
+	if i.TelemetryServiceName != "" {
+		return i.TelemetryServiceName
+	}
+	return app_specific.AppName
+}
+
+// telemetryRootSpanName returns the name of the root telemetry span of the run - in order of precedence, this is
+// InitData.TelemetryRootSpanName, ArgTraceRootSpanName, or 'init'
+func (i *InitData[T]) telemetryRootSpanName() string {
+	switch {
+	case i.TelemetryRootSpanName != "":
+		return i.TelemetryRootSpanName
+	case viper.GetString(localconstants.ArgTraceRootSpanName) != "":
+		return viper.GetString(localconstants.ArgTraceRootSpanName)
+	default:
+		return "init"
+	}
+}
+
+// ReserveStdoutForExport disables progress and command output if any of the exports is written to stdout,
+// so that nothing else is interleaved with the export output
+func (i *InitData[T]) ReserveStdoutForExport(exports []string) {
+	if !i.ExportManager.HasStdoutExport(exports) {
+		return
+	}
+	viper.Set(constants.ArgOutput, constants.OutputFormatNone)
+	viper.Set(constants.ArgProgress, false)
+}
+
 func (i *InitData[T]) Init(ctx context.Context, args ...string) {
+	// in quiet mode, status hooks are not rendered during init
+	ctx = cmdconfig.QuietContext(ctx)
+
+	// if an init timeout is set, the combined init phases must complete within this time
+	// NOTE: this must be deferred before the recover func below, so the context is not cancelled before
+	// the recover func checks for a timeout
+	if initTimeout := viper.GetInt(localconstants.ArgInitTimeout); initTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(initTimeout)*time.Second)
+		defer cancel()
+	}
+
+	// the root init span - this is started once telemetry has been initialised
+	var initSpan trace.Span
 	defer func() {
 		if r := recover(); r != nil {
-			i.Result.Error = helpers.ToError(r)
+			i.Result.Error = NewInitError(InitErrorCodeGeneral, helpers.ToError(r))
 		}
-		// if there is no error, return context cancellation error (if any)
-		if i.Result.Error == nil {
-			i.Result.Error = ctx.Err()
+		// if there is no error (or the error is due to the cancellation), return context cancellation error (if any),
+		// including the phase which was interrupted
+		if ctxErr := ctx.Err(); ctxErr != nil && (i.Result.Error == nil || errors.Is(i.Result.Error, ctxErr)) {
+			i.Result.Error = NewInitError(InitErrorCodeCancelled, i.cancellationError(ctx, ctxErr))
+		}
+		// end the timing of the final phase - if init failed, this is the phase which failed
+		i.Result.endPhaseTiming(time.Now())
+		telemetry.RecordInitDuration(ctx, i.Result.InitDuration(), i.Result.Error)
+		if initSpan != nil {
+			telemetry.EndSpan(initSpan, i.Result.Error)
 		}
 	}()
 
@@ -120,31 +323,62 @@ func (i *InitData[T]) Init(ctx context.Context, args ...string) {
 
 	// code after this depends of i.Workspace being defined. make sure that it is
 	if i.Workspace == nil {
-		i.Result.Error = sperr.WrapWithRootMessage(error_helpers.InvalidStateError, "InitData.Init called before setting up WorkspaceEvents")
+		i.Result.Error = NewInitError(InitErrorCodeGeneral, sperr.WrapWithRootMessage(error_helpers.InvalidStateError, "InitData.Init called before setting up WorkspaceEvents"))
 		return
 	}
 
+	// determine the run correlation ID, and make it available to anything using the context
+	i.CorrelationId = i.resolveCorrelationId(ctx)
+	ctx = ContextWithCorrelationId(ctx, i.CorrelationId)
+
 	// attempt to resolve the provided args into target resource(s)
+	i.setPhase(InitPhaseResolvingTargets)
 	i.resolveTargets(args)
 	if i.Result.Error != nil {
 		return
 	}
 
-	statushooks.SetStatus(ctx, "Initializing")
+	// if a plugin version file was specified, the mod requirements are validated against the pinned plugin versions,
+	// rather than the plugin versions of the database
+	if pluginVersionFile := viper.GetString(localconstants.ArgPluginVersionFile); pluginVersionFile != "" {
+		pluginVersionMap, err := loadPluginVersionMap(pluginVersionFile)
+		if err != nil {
+			i.Result.Error = NewInitError(InitErrorCodeInvalidConfig, err)
+			return
+		}
+		i.pluginVersionMap = pluginVersionMap
+	}
+
+	statushooks.SetStatus(ctx, i18n.Message(i18n.StatusInitializing))
 	i.WorkspaceEvents = dashboardworkspace.NewWorkspaceEvents(i.Workspace)
 
-	// initialise telemetry
-	shutdownTelemetry, err := telemetry.Init(app_specific.AppName)
+	// initialise telemetry - unless telemetry is disabled, in which case no telemetry is attempted
+	// (ShutdownTelemetry is left nil, and spans are created using the no-op global tracer)
+	i.setPhase(InitPhaseInitialisingTelemetry)
+	if telemetryEnabled() {
+		shutdownTelemetry, err := telemetry.Init(i.telemetryServiceName(), i.TelemetryConfig)
+		if err != nil {
+			i.Result.AddStructuredWarnings(NewInitWarning(WarningCodeTelemetry, WarningSeverityInfo, err.Error()))
+		} else {
+			i.ShutdownTelemetry = shutdownTelemetry
+		}
+	}
+	// if a parent trace context was passed in the environment, the init span is a child of the parent trace
+	ctx = telemetry.ContextWithEnvironmentTraceContext(ctx)
+	ctx, err := telemetry.ContextWithBaggage(ctx, viper.GetStringSlice(localconstants.ArgTraceBaggage))
 	if err != nil {
-		i.Result.AddWarnings(err.Error())
-	} else {
-		i.ShutdownTelemetry = shutdownTelemetry
+		i.Result.Error = NewInitError(InitErrorCodeInvalidConfig, err)
+		return
 	}
+	ctx, initSpan = telemetry.StartSpan(ctx, i.telemetryRootSpanName(),
+		attribute.String("mod.name", i.Workspace.Mod.Name()),
+		attribute.String("correlation_id", i.CorrelationId))
 
 	// install mod dependencies if needed (this defaults to true for dashboard and check commands
 	// and will always be false for query command)
 	if viper.GetBool(constants.ArgModInstall) {
-		statushooks.SetStatus(ctx, "Installing workspace dependencies")
+		i.setPhase(InitPhaseInstallingDeps)
+		statushooks.SetStatus(ctx, i18n.Message(i18n.StatusInstallingDependencies))
 		slog.Info("Installing workspace dependencies")
 		opts := modinstaller.NewInstallOpts(i.Workspace.Mod)
 		// arg pull should always be set (to a default at least) if ArgModInstall is set
@@ -152,96 +386,390 @@ func (i *InitData[T]) Init(ctx context.Context, args ...string) {
 		// use force install so that errors are ignored during installation
 		// (we are validating prereqs later)
 		opts.Force = true
-		_, err := modinstaller.InstallWorkspaceDependencies(ctx, opts)
+		// in dry run mode, just determine the changes which would be made and report them
+		opts.DryRun = viper.GetBool(localconstants.ArgModInstallDryRun)
+		// if a mod install directory is set (and the workspace mods directory is not already linked to it),
+		// link the workspace mods directory to it for the duration of the install
+		if !i.modInstallDirLinked {
+			restoreModsDir, err := linkModInstallDirFromArgs(ctx, i.Workspace.Mod.ModPath)
+			if err != nil {
+				i.Result.Error = NewInitError(InitErrorCodeModInstall, err)
+				return
+			}
+			if restoreModsDir != nil {
+				defer func() {
+					if err := restoreModsDir(); err != nil {
+						slog.Warn("Failed to restore the workspace mods directory", "error", err)
+					}
+				}()
+			}
+		}
+		// if a mod source directory is set, install from this rather than the registry (i.e. without network access)
+		modSource := viper.GetString(localconstants.ArgModSource)
+		installCtx, installSpan := telemetry.StartSpan(ctx, "init.install_dependencies",
+			attribute.Bool("dry_run", opts.DryRun),
+			attribute.Bool("offline", modSource != ""))
+		var installPlan []string
+		var err error
+		if modSource != "" {
+			installPlan, err = installWorkspaceDependenciesFromSource(installCtx, i.Workspace.Mod, modSource, opts.DryRun)
+		} else {
+			var installData *modinstaller.InstallData
+			installData, err = installWorkspaceDependenciesWithRetry(installCtx, opts)
+			if err == nil && opts.DryRun {
+				installPlan = buildModInstallPlan(installData)
+			}
+		}
+		telemetry.EndSpan(installSpan, err)
 		if err != nil {
-			i.Result.Error = err
+			i.Result.Error = NewInitError(InitErrorCodeModInstall, err)
+			return
+		}
+		i.Result.AddMessage(installPlan...)
+		// in dry run mode, nothing is executed - so there is no need to connect to the database
+		if opts.DryRun {
 			return
 		}
 	}
 
-	// determine whether
-
 	// create default client
-	// set the database and search patch config
-	database, searchPathConfig, err := db_client.GetDefaultDatabaseConfig()
-	if err != nil {
-		i.Result.Error = err
-		return
+	// if no resources require a database (e.g. the dashboards are purely static), or the resources are only being
+	// listed (so nothing is executed), proceed without a client - in this case DefaultClient is left nil
+	var client *db_client.DbClient
+	var searchPathConfig backend.SearchPathConfig
+	if i.DefaultClient == nil && !i.requiresDatabase() {
+		slog.Info("No resources require a database - skipping database connection")
+		initSpan.SetAttributes(attribute.Bool("db.required", false))
+		i.setPhase(InitPhaseValidating)
+	} else if i.DefaultClient == nil && viper.GetBool(localconstants.ArgList) {
+		slog.Info("Listing resources - skipping database connection")
+		initSpan.SetAttributes(attribute.Bool("db.required", false))
+		i.setPhase(InitPhaseValidating)
+	} else {
+		var err error
+		client, searchPathConfig, err = i.connect(ctx, initSpan)
+		if err != nil {
+			i.Result.Error = err
+			return
+		}
 	}
 
-	// create client
-	var opts []backend.ConnectOption
-	if !searchPathConfig.Empty() {
-		opts = append(opts, backend.WithSearchPathConfig(searchPathConfig))
+	// validate mod requirements for the root mod and all dependency mods
+	// if strict requirements are enabled, any failure is an error - otherwise failures are reported as warnings
+	_, validateSpan := telemetry.StartSpan(ctx, "init.validate_requirements")
+	validationErrors := validateModRequirementsRecursively(i.Workspace.Mod, i.pluginVersionMap)
+	validateSpan.SetAttributes(attribute.Int("validation_errors", len(validationErrors)))
+	var validationErr error
+	if len(validationErrors) > 0 && viper.GetBool(localconstants.ArgStrictRequirements) {
+		validationErr = sperr.New("mod requirements not met:\n\t%s", strings.Join(validationErrors, "\n\t"))
 	}
-	client, err := db_client.NewDbClient(ctx, database, opts...)
-	if err != nil {
-		i.Result.Error = err
+	telemetry.EndSpan(validateSpan, validationErr)
+	if validationErr != nil {
+		i.Result.Error = NewInitError(InitErrorCodeModRequirements, validationErr)
 		return
 	}
-	i.DefaultClient = client
-
-	// validate mod requirements
-	validationWarnings := validateModRequirementsRecursively(i.Workspace.Mod, client)
-	i.Result.AddWarnings(validationWarnings...)
+	i.Result.AddStructuredWarnings(newInitWarnings(WarningCodeModRequirements, WarningSeverityWarning, validationErrors...)...)
 
 	// create the dashboard executor, passing the default client inside a client map
 	clientMap := db_client.NewClientMap().Add(client, searchPathConfig)
-	dashboardexecute.Executor = dashboardexecute.NewDashboardExecutor(clientMap)
+	i.DashboardExecutor = dashboardexecute.NewDashboardExecutor(clientMap)
+	dashboardexecute.Executor = i.DashboardExecutor
+	i.setPhase(InitPhaseComplete)
 }
 
-// resolve target resource, args and any target specific search path
-func (i *InitData[T]) resolveTargets(args []string) {
-	// resolve target resources
-	targets, err := cmdconfig.ResolveTargets[T](args, i.Workspace)
+// requiresDatabase returns whether a database connection is required, i.e. whether any resource in the workspace
+// (or any of the resolved targets) has a query or SQL to execute
+func (i *InitData[T]) requiresDatabase() bool {
+	if dashboardexecute.ResourcesRequireDatabase(i.Workspace.GetResourceMaps()) {
+		return true
+	}
+	// NOTE: targets are checked as well as the workspace resources, as a target may not be a workspace resource
+	// (e.g. a raw SQL query passed to query run)
+	for _, target := range i.Targets {
+		if dashboardexecute.ResourceRequiresDatabase(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// connect creates the default client (unless one has been provided using SetClient), and determines the
+// plugin versions and SQL features supported by its backend
+// the returned error is an InitError
+func (i *InitData[T]) connect(ctx context.Context, initSpan trace.Span) (*db_client.DbClient, backend.SearchPathConfig, error) {
+	i.setPhase(InitPhaseConnecting)
+	connectionStrings, searchPathConfig, opts, err := i.getDefaultClientConfig()
 	if err != nil {
-		i.Result.Error = err
-		return
+		return nil, searchPathConfig, NewInitError(connectionErrorCode(err), err)
 	}
+	// if a client has been provided using SetClient, use it
+	client := i.DefaultClient
+	if client == nil {
+		if err := i.validatePoolConfig(opts); err != nil {
+			return nil, searchPathConfig, NewInitError(InitErrorCodeInvalidConfig, err)
+		}
+		// validate the certificate files up front, so a missing or malformed file is reported as a config error
+		if tlsConfig := db_client.NewClientConfig(opts...).TLS; tlsConfig != nil {
+			if err := tlsConfig.Validate(); err != nil {
+				return nil, searchPathConfig, NewInitError(InitErrorCodeInvalidConfig, err)
+			}
+		}
+		statushooks.SetStatus(ctx, i18n.Message(i18n.StatusConnecting))
+		connectCtx, connectSpan := telemetry.StartSpan(ctx, "init.connect")
+		var errAndWarnings error_helpers.ErrorAndWarnings
+		client, errAndWarnings = getDbClientWithRetry(connectCtx, connectionStrings, opts...)
+		if client != nil {
+			connectSpan.SetAttributes(attribute.String("db.backend", client.Backend.Name()))
+		}
+		telemetry.EndSpan(connectSpan, errAndWarnings.Error)
+		i.Result.AddStructuredWarnings(newInitWarnings(WarningCodeConnectionFallback, WarningSeverityWarning, errAndWarnings.Warnings...)...)
+		if errAndWarnings.Error != nil {
+			return nil, searchPathConfig, NewInitError(connectionErrorCode(errAndWarnings.Error), errAndWarnings.Error)
+		}
+		i.DefaultClient = client
+		i.ownsClient = true
+		// check the connection periodically, re-establishing it if it drops while idle (e.g. for long-lived servers)
+		client.StartKeepAlive(ctx, time.Duration(viper.GetInt(localconstants.ArgDbKeepAliveInterval))*time.Second)
+		// open the pool connections up front, so the first queries (e.g. the first dashboard load) are not delayed
+		i.warmUpClient(ctx, client)
+	}
+	// store the plugin versions so they can be reused without re-reading them from the client
+	// (if the plugin versions were loaded from a plugin version file, these are used instead)
+	if i.pluginVersionMap == nil {
+		i.pluginVersionMap = newPluginVersionMap(client)
+	}
+	// check the Steampipe server version is supported - an unsupported version may cause queries to fail in confusing ways
+	if err := i.validateSteampipeVersion(ctx, client); err != nil {
+		return nil, searchPathConfig, err
+	}
+	// determine the SQL features supported by the backend, so execution can warn if a query uses an unsupported feature
+	i.BackendCapabilities = client.Capabilities(ctx)
+	i.setPhase(InitPhaseValidating)
+	if i.ControlCacheTtl, err = i.resolveControlCacheTtl(); err != nil {
+		return nil, searchPathConfig, NewInitError(InitErrorCodeInvalidConfig, err)
+	}
+	if i.MaxParallel, err = resolveMaxParallel(client.PoolConfig()); err != nil {
+		return nil, searchPathConfig, NewInitError(InitErrorCodeInvalidConfig, err)
+	}
+	initSpan.SetAttributes(
+		attribute.Bool("db.required", true),
+		attribute.String("db.backend", client.Backend.Name()),
+		attribute.Bool("db.supports_ctes", i.BackendCapabilities.CTEs),
+		attribute.Bool("db.supports_json_functions", i.BackendCapabilities.JSONFunctions),
+		attribute.Bool("db.supports_prepared_statements", i.BackendCapabilities.PreparedStatements),
+	)
+	return client, searchPathConfig, nil
+}
 
-	i.Targets = targets
+// telemetryEnabled returns whether telemetry is enabled
+// setting ArgTelemetry to 'none' disables all telemetry, including traces, metrics and any local metrics collection
+func telemetryEnabled() bool {
+	return viper.GetString(constants.ArgTelemetry) != constants.TelemetryNone
 }
 
-func validateModRequirementsRecursively(mod *modconfig.Mod, client *db_client.DbClient) []string {
-	var validationErrors []string
+// setPhase sets the current init phase, recording the phase timing and notifying the OnPhaseChange callback (if set)
+func (i *InitData[T]) setPhase(phase InitPhase) {
+	i.Phase = phase
+	i.Result.startPhaseTiming(phase, time.Now())
+	if i.OnPhaseChange != nil {
+		i.OnPhaseChange(phase)
+	}
+}
 
-	var pluginVersionMap = &plugin.PluginVersionMap{
-		Database: client.Backend.ConnectionString(),
-		Backend:  client.Backend.Name(),
+// cancellationError builds the error returned when Init is cancelled or times out, describing the phase which was interrupted
+// NOTE: the returned error wraps the context error so it is still identified as a cancellation
+func (i *InitData[T]) cancellationError(ctx context.Context, ctxErr error) error {
+	phase := i.Phase
+	if phase == "" {
+		phase = InitPhaseNotStarted
 	}
-	// if the backend is steampipe, populate the available plugins
-	if steampipeBackend, ok := client.Backend.(*backend.SteampipeBackend); ok {
-		pluginVersionMap.AvailablePlugins = steampipeBackend.PluginVersions
+	dbStatus := "before the database was reached"
+	if phase.DatabaseReached() {
+		dbStatus = "after connecting to the database"
 	}
-	// validate this mod
-	for _, err := range mod.ValidateRequirements(pluginVersionMap) {
-		validationErrors = append(validationErrors, err.Error())
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		statushooks.SetStatus(ctx, i18n.Message(i18n.StatusInitTimedOut, phase))
+		slog.Warn("initialization timed out", "phase", phase, "database reached", phase.DatabaseReached())
+		return sperr.WrapWithMessage(ctxErr, "initialization timed out while %s (%s)", phase, dbStatus)
+	}
+	statushooks.SetStatus(ctx, i18n.Message(i18n.StatusInitCancelled, phase))
+	slog.Info("initialization cancelled", "phase", phase, "database reached", phase.DatabaseReached())
+	return sperr.WrapWithMessage(ctxErr, "initialization cancelled while %s (%s)", phase, dbStatus)
+}
+
+// getDefaultClientConfig returns the ordered list of connection strings to try (this may include failover databases),
+// the search path config and the client options used to create the default client
+func (i *InitData[T]) getDefaultClientConfig() ([]string, backend.SearchPathConfig, []db_client.ClientOption, error) {
+	// resolve the connection strings and search path config
+	connectionStrings, searchPathConfig, err := db_client.ResolveDefaultConnectionStrings()
+	if err != nil {
+		return nil, searchPathConfig, nil, err
 	}
 
-	// validate dependent mods
-	for childDependencyName, childMod := range mod.ResourceMaps.Mods {
-		if childDependencyName == "local" || mod.DependencyName == childMod.DependencyName {
-			// this is a reference to self - skip (otherwise we will end up with a recursion loop)
-			continue
+	var opts []db_client.ClientOption
+	if !searchPathConfig.Empty() {
+		opts = append(opts, db_client.WithConnectOptions(backend.WithSearchPathConfig(searchPathConfig)))
+	}
+	// report the correlation ID to the database, so queries may be attributed to this run
+	if i.CorrelationId != "" {
+		opts = append(opts, db_client.WithApplicationName(i.applicationName()))
+	}
+	// use the configured certificates for TLS connections
+	if tlsConfig := db_client.GetTLSConfig(); !tlsConfig.Empty() {
+		opts = append(opts, db_client.WithTLSConfig(tlsConfig))
+	}
+
+	// for rds-iam authentication, an IAM auth token is generated each time the client connects, and used as the password
+	if viper.GetString(localconstants.ArgDbAuthMode) == localconstants.DbAuthModeRdsIam {
+		opts = append(opts, db_client.WithCredentialProviders(db_client.RDSIAMCredentialProviders(connectionStrings)...))
+	}
+	// add any client options passed when creating the InitData
+	opts = append(opts, i.clientOpts...)
+	return connectionStrings, searchPathConfig, opts, nil
+}
+
+// validatePoolConfig validates the pool config which will be used for the default client,
+// and adds a warning if the pool is smaller than the configured max parallelism
+// (if the max parallelism is not set, controls are executed with the parallelism of the pool size)
+func (i *InitData[T]) validatePoolConfig(opts []db_client.ClientOption) error {
+	pool := db_client.GetPoolConfig()
+	if p := db_client.NewClientConfig(opts...).Pool; p != nil {
+		pool = *p
+	}
+	if err := pool.Validate(); err != nil {
+		return err
+	}
+	if viper.GetInt(localconstants.ArgDbPoolWarmConns) < 0 {
+		return sperr.New("'--%s' must not be negative", localconstants.ArgDbPoolWarmConns)
+	}
+	if maxParallel := db_client.MaxDbConnections(); viper.IsSet(constants.ArgMaxParallel) && pool.MaxConns < maxParallel {
+		i.Result.AddStructuredWarnings(NewInitWarning(WarningCodePoolSize, WarningSeverityWarning,
+			fmt.Sprintf("database pool max connections (%d) is smaller than the max parallelism (%d) - queries may wait for a free connection", pool.MaxConns, maxParallel)))
+	}
+	return nil
+}
+
+// warmUpClient opens ArgDbPoolWarmConns pool connections for the client
+// the warm-up is an optimisation, so a failure is added to the init result as a warning rather than failing init
+func (i *InitData[T]) warmUpClient(ctx context.Context, client *db_client.DbClient) {
+	count := viper.GetInt(localconstants.ArgDbPoolWarmConns)
+	if count <= 0 {
+		return
+	}
+	statushooks.SetStatus(ctx, i18n.Message(i18n.StatusOpeningConnections))
+	warmCtx, span := telemetry.StartSpan(ctx, "init.warm_up")
+	opened, err := client.WarmUp(warmCtx, count)
+	span.SetAttributes(attribute.Int("db.pool.warm_conns", opened))
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		i.Result.AddStructuredWarnings(NewInitWarning(WarningCodePoolWarmUp, WarningSeverityWarning,
+			i18n.Message(i18n.WarningPoolWarmUpFailed, opened, count, localutils.RedactConnectionStringError(err).Error())))
+	}
+}
+
+// resolveMaxParallel returns the maximum number of control queries to execute concurrently
+// this is ArgMaxParallel if set, otherwise the max connections of the client pool, so that controls do not wait
+// for a free connection (and do not open more connections than the pool allows)
+func resolveMaxParallel(pool db_client.PoolConfig) (int, error) {
+	if viper.IsSet(constants.ArgMaxParallel) {
+		maxParallel := viper.GetInt(constants.ArgMaxParallel)
+		if maxParallel < 1 {
+			return 0, sperr.New("'--%s' must be at least 1", constants.ArgMaxParallel)
 		}
-		childValidationErrors := validateModRequirementsRecursively(childMod, client)
-		validationErrors = append(validationErrors, childValidationErrors...)
+		return maxParallel, nil
+	}
+	if pool.MaxConns > 0 {
+		return pool.MaxConns, nil
+	}
+	return constants.DefaultMaxConnections, nil
+}
+
+// resolveControlCacheTtl validates the control result cache TTL against the caching settings of the backend
+// - if the backend client cache is disabled, control results are not cached
+// - if the backend cache has a shorter TTL, this is used, so cached control results are not older than cached query results
+func (i *InitData[T]) resolveControlCacheTtl() (time.Duration, error) {
+	ttl := viper.GetInt(localconstants.ArgControlCacheTtl)
+	if ttl < 0 {
+		return 0, sperr.New("'--%s' must be zero (disabled) or a positive number of seconds", localconstants.ArgControlCacheTtl)
+	}
+	if ttl == 0 {
+		return 0, nil
+	}
+	if viper.IsSet(constants.ArgClientCacheEnabled) && !viper.GetBool(constants.ArgClientCacheEnabled) {
+		i.Result.AddStructuredWarnings(NewInitWarning(WarningCodeResultCache, WarningSeverityWarning,
+			fmt.Sprintf("'--%s' is ignored as the database client cache is disabled", localconstants.ArgControlCacheTtl)))
+		return 0, nil
 	}
+	if backendTtl := viper.GetInt(constants.ArgCacheTtl); viper.IsSet(constants.ArgCacheTtl) && backendTtl > 0 && backendTtl < ttl {
+		i.Result.AddStructuredWarnings(NewInitWarning(WarningCodeResultCache, WarningSeverityInfo,
+			fmt.Sprintf("'--%s' (%ds) exceeds the database cache TTL - using %ds", localconstants.ArgControlCacheTtl, ttl, backendTtl)))
+		ttl = backendTtl
+	}
+	return time.Duration(ttl) * time.Second, nil
+}
 
-	return validationErrors
+// resolve target resource, args and any target specific search path
+func (i *InitData[T]) resolveTargets(args []string) {
+	// resolve target resources
+	targets, err := cmdconfig.ResolveTargets[T](args, i.Workspace)
+	if err != nil {
+		i.Result.Error = NewInitError(InitErrorCodeResolveTargets, err)
+		return
+	}
+
+	i.Targets = targets
 }
 
+// Cleanup shuts down telemetry, closes the workspace and closes the default client
+// each step is given ArgShutdownTimeout seconds to complete - if a step exceeds this a warning is logged
+// and cleanup continues with the next step
 func (i *InitData[T]) Cleanup(ctx context.Context) {
+	timeout := time.Duration(viper.GetInt(localconstants.ArgShutdownTimeout)) * time.Second
+	if timeout <= 0 {
+		timeout = localconstants.DefaultShutdownTimeout * time.Second
+	}
+
 	if i.ShutdownTelemetry != nil {
-		i.ShutdownTelemetry()
+		runCleanupStep(ctx, "shutdown telemetry", timeout, func(context.Context) {
+			i.ShutdownTelemetry()
+		})
 	}
 	if i.Workspace != nil {
-		i.Workspace.Close()
+		runCleanupStep(ctx, "close workspace", timeout, func(context.Context) {
+			i.Workspace.Close()
+		})
 	}
-	if i.DefaultClient != nil {
-		i.DefaultClient.Close(ctx)
+	// only close the client if it was created by Init
+	if i.DefaultClient != nil && i.ownsClient {
+		runCleanupStep(ctx, "close database client", timeout, func(ctx context.Context) {
+			// cancel any running queries first, otherwise closing the client waits for them to complete
+			if cancelled := i.DefaultClient.CancelQueries(); cancelled > 0 {
+				slog.Info("cancelled in-flight queries before closing the database client", "count", cancelled)
+			}
+			if err := i.DefaultClient.Close(ctx); err != nil {
+				slog.Warn("error closing database client", "error", err)
+			}
+		})
 	}
+}
+
+// runCleanupStep runs the cleanup function, waiting at most timeout for it to complete
+func runCleanupStep(ctx context.Context, name string, timeout time.Duration, cleanupFunc func(context.Context)) {
+	// use a context which is not cancelled when the parent is - we still want to clean up after a cancellation
+	stepCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
 
+	doneChan := make(chan struct{})
+	go func() {
+		defer close(doneChan)
+		cleanupFunc(stepCtx)
+	}()
+
+	select {
+	case <-doneChan:
+	case <-stepCtx.Done():
+		slog.Warn("cleanup step did not complete within the shutdown timeout", "step", name, "timeout", timeout)
+	}
 }
 
 // GetSingleTarget validates there is only a single target and returns it
@@ -0,0 +1,113 @@
+package initialisation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/workspace"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+)
+
+func TestResolveMaxParallel(t *testing.T) {
+	defer viper.Set(constants.ArgMaxParallel, nil)
+
+	// if max parallel is not set, the pool size is used
+	viper.Set(constants.ArgMaxParallel, nil)
+	if res, err := resolveMaxParallel(db_client.PoolConfig{MaxConns: 4}); err != nil || res != 4 {
+		t.Errorf("expected the pool max connections (4), got %d (%v)", res, err)
+	}
+	if res, err := resolveMaxParallel(db_client.PoolConfig{}); err != nil || res != constants.DefaultMaxConnections {
+		t.Errorf("expected the default max connections, got %d (%v)", res, err)
+	}
+
+	viper.Set(constants.ArgMaxParallel, 2)
+	if res, err := resolveMaxParallel(db_client.PoolConfig{MaxConns: 4}); err != nil || res != 2 {
+		t.Errorf("expected the max parallel arg (2), got %d (%v)", res, err)
+	}
+
+	viper.Set(constants.ArgMaxParallel, 0)
+	if _, err := resolveMaxParallel(db_client.PoolConfig{MaxConns: 4}); err == nil {
+		t.Errorf("expected an error for a max parallel of 0")
+	}
+}
+
+func TestInitModInstallDryRun(t *testing.T) {
+	setTestModFileConfig(t)
+	variablesExtensions, autoVariablesExtensions := app_specific.VariablesExtensions, app_specific.AutoVariablesExtensions
+	defaultVarsFileName, ignoreFile := app_specific.DefaultVarsFileName, app_specific.WorkspaceIgnoreFile
+	app_specific.VariablesExtensions = []string{".ppvars"}
+	app_specific.AutoVariablesExtensions = []string{".auto.ppvars"}
+	app_specific.DefaultVarsFileName = "powerpipe.ppvars"
+	app_specific.WorkspaceIgnoreFile = ".powerpipeignore"
+	t.Cleanup(func() {
+		app_specific.VariablesExtensions, app_specific.AutoVariablesExtensions = variablesExtensions, autoVariablesExtensions
+		app_specific.DefaultVarsFileName, app_specific.WorkspaceIgnoreFile = defaultVarsFileName, ignoreFile
+	})
+	sourceDir := t.TempDir()
+	workspaceDir := t.TempDir()
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v1.0.0"), "a", "")
+	writeTestModFile(t, workspaceDir, "root", `}
+
+control "c1" {
+  sql = "select 'r1' as resource, 'ok' as status, 'reason' as reason"
+`)
+	w, errAndWarnings := workspace.LoadWorkspacePromptingForVariables(context.Background(), workspaceDir)
+	if err := errAndWarnings.GetError(); err != nil {
+		t.Fatal(err)
+	}
+	// the workspace cannot be loaded with uninstalled dependencies, so add the requirement once it is loaded
+	requireDir := t.TempDir()
+	writeTestModFile(t, requireDir, "root", `  require {
+    mod "github.com/test/a" {
+      version = "^1"
+    }
+  }
+`)
+	requireMod, err := parse.LoadModfile(requireDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Mod.Require = requireMod.Require
+
+	// the database does not exist - so init fails if it attempts to connect
+	args := map[string]any{
+		constants.ArgModInstall:                true,
+		localconstants.ArgModInstallDryRun:     true,
+		localconstants.ArgModSource:            sourceDir,
+		constants.ArgTelemetry:                 constants.TelemetryNone,
+		constants.ArgDatabase:                  "sqlite://" + filepath.Join(workspaceDir, "missing.db"),
+		localconstants.ArgModInstallMaxRetries: 0,
+		// the mod install options are determined using the active command
+		constants.ConfigKeyActiveCommand: &cobra.Command{Use: "check"},
+	}
+	for arg, value := range args {
+		viper.Set(arg, value)
+		defer viper.Set(arg, nil)
+	}
+
+	i := NewInitDataWithWorkspace[*modconfig.Control](w)
+	i.Init(context.Background())
+	if i.Result.Error != nil {
+		t.Fatalf("expected a dry run init to succeed without connecting to the database, got %v", i.Result.Error)
+	}
+	if len(i.Result.Messages) != 1 || !strings.Contains(i.Result.Messages[0], "github.com/test/a@v1.0.0") {
+		t.Errorf("expected the dry run to report the dependency changes, got %v", i.Result.Messages)
+	}
+	if i.DefaultClient != nil || i.DashboardExecutor != nil {
+		t.Errorf("expected a dry run init to return before connecting")
+	}
+	if _, err := os.Stat(filepaths.WorkspaceModPath(workspaceDir)); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be installed in dry run mode")
+	}
+}
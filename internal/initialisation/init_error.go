@@ -0,0 +1,84 @@
+package initialisation
+
+import (
+	"context"
+	"errors"
+
+	"github.com/turbot/powerpipe/internal/db_client"
+)
+
+// InitErrorCode identifies the kind of failure which caused initialisation to fail
+type InitErrorCode string
+
+// error codes for the failure paths of initialisation
+const (
+	InitErrorCodeGeneral            InitErrorCode = "general"
+	InitErrorCodeWorkspaceLoad      InitErrorCode = "workspace_load"
+	InitErrorCodeNoModFile          InitErrorCode = "no_mod_file"
+	InitErrorCodeInvalidConfig      InitErrorCode = "invalid_config"
+	InitErrorCodeResolveTargets     InitErrorCode = "resolve_targets"
+	InitErrorCodeModInstall         InitErrorCode = "mod_install"
+	InitErrorCodeNoConnectionString InitErrorCode = "no_connection_string"
+	InitErrorCodeAuthFailed         InitErrorCode = "auth_failed"
+	InitErrorCodeConnectionFailed   InitErrorCode = "connection_failed"
+	InitErrorCodeModRequirements    InitErrorCode = "mod_requirements"
+	InitErrorCodeSteampipeVersion   InitErrorCode = "steampipe_version"
+	InitErrorCodeCancelled          InitErrorCode = "cancelled"
+)
+
+// InitError is an initialisation error, categorised by code so callers can distinguish the kind of failure
+// using errors.As (or InitErrorCodeOf), e.g.
+//
+//	var initErr *InitError
+//	if errors.As(initData.Result.Error, &initErr) && initErr.Code == InitErrorCodeAuthFailed {...}
+//
+// the error message is the message of the underlying error, which is available using errors.Unwrap
+// (so errors.Is still matches the underlying errors, e.g. db_client.ErrAuthenticationFailed or context.Canceled)
+type InitError struct {
+	Code InitErrorCode
+	Err  error
+}
+
+// NewInitError wraps the error with the given code - if the error is nil, nil is returned,
+// and if it is already an InitError it is returned unchanged
+func NewInitError(code InitErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	var initErr *InitError
+	if errors.As(err, &initErr) {
+		return err
+	}
+	return &InitError{Code: code, Err: err}
+}
+
+func (e *InitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InitError) Unwrap() error {
+	return e.Err
+}
+
+// InitErrorCodeOf returns the code of the InitError wrapped by err, or an empty code if err is not an InitError
+func InitErrorCodeOf(err error) InitErrorCode {
+	var initErr *InitError
+	if errors.As(err, &initErr) {
+		return initErr.Code
+	}
+	return ""
+}
+
+// connectionErrorCode returns the code for an error returned when creating the default client
+func connectionErrorCode(err error) InitErrorCode {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return InitErrorCodeCancelled
+	case errors.Is(err, db_client.ErrNoConnectionString):
+		return InitErrorCodeNoConnectionString
+	case errors.Is(err, db_client.ErrAuthenticationFailed):
+		return InitErrorCodeAuthFailed
+	default:
+		return InitErrorCodeConnectionFailed
+	}
+}
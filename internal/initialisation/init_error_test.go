@@ -0,0 +1,52 @@
+package initialisation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/turbot/powerpipe/internal/db_client"
+)
+
+func TestInitError(t *testing.T) {
+	underlying := fmt.Errorf("failed to connect: %w", db_client.ErrAuthenticationFailed)
+	err := NewInitError(connectionErrorCode(underlying), underlying)
+
+	var initErr *InitError
+	if !errors.As(err, &initErr) || initErr.Code != InitErrorCodeAuthFailed {
+		t.Fatalf("expected an InitError with code %s, got %v", InitErrorCodeAuthFailed, err)
+	}
+	if err.Error() != underlying.Error() {
+		t.Errorf("expected the message '%s', got '%s'", underlying.Error(), err.Error())
+	}
+	if !errors.Is(err, db_client.ErrAuthenticationFailed) {
+		t.Errorf("expected the error to match the underlying error")
+	}
+
+	// an error which is already an InitError keeps its code
+	if code := InitErrorCodeOf(NewInitError(InitErrorCodeGeneral, err)); code != InitErrorCodeAuthFailed {
+		t.Errorf("expected code %s, got %s", InitErrorCodeAuthFailed, code)
+	}
+	if NewInitError(InitErrorCodeGeneral, nil) != nil {
+		t.Errorf("expected nil for a nil error")
+	}
+	if code := InitErrorCodeOf(errors.New("other")); code != "" {
+		t.Errorf("expected no code for a plain error, got %s", code)
+	}
+}
+
+func TestConnectionErrorCode(t *testing.T) {
+	tests := map[error]InitErrorCode{
+		db_client.ErrNoConnectionString:                              InitErrorCodeNoConnectionString,
+		fmt.Errorf("wrapped: %w", db_client.ErrAuthenticationFailed): InitErrorCodeAuthFailed,
+		db_client.ErrConnectionFailed:                                InitErrorCodeConnectionFailed,
+		errors.New("unknown"):                                        InitErrorCodeConnectionFailed,
+		context.Canceled:                                             InitErrorCodeCancelled,
+	}
+	for err, expected := range tests {
+		if code := connectionErrorCode(err); code != expected {
+			t.Errorf("connectionErrorCode(%v): expected %s, got %s", err, expected, code)
+		}
+	}
+}
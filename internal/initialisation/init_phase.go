@@ -0,0 +1,19 @@
+package initialisation
+
+// InitPhase is the phase of initialisation currently being executed
+type InitPhase string
+
+const (
+	InitPhaseNotStarted            InitPhase = "not started"
+	InitPhaseResolvingTargets      InitPhase = "resolving targets"
+	InitPhaseInitialisingTelemetry InitPhase = "initialising telemetry"
+	InitPhaseInstallingDeps        InitPhase = "installing mod dependencies"
+	InitPhaseConnecting            InitPhase = "connecting to the database"
+	InitPhaseValidating            InitPhase = "validating mod requirements"
+	InitPhaseComplete              InitPhase = "complete"
+)
+
+// DatabaseReached returns whether the database connection has been established by the time this phase is reached
+func (p InitPhase) DatabaseReached() bool {
+	return p == InitPhaseValidating || p == InitPhaseComplete
+}
@@ -7,11 +7,17 @@ import (
 	"github.com/spf13/viper"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/error_helpers"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
 )
 
 type InitResult struct {
 	error_helpers.ErrorAndWarnings
 	Messages []string
+	// the categorised warnings - the message of each of these is also included in Warnings
+	StructuredWarnings []InitWarning
+	// the timing of each init phase, in the order the phases were started
+	// - if init fails, this contains the phases which were started, with the failed phase ending at the point of failure
+	PhaseTimings []PhaseTiming
 
 	// allow overriding of the display functions
 	DisplayMessage func(ctx context.Context, m string)
@@ -22,8 +28,28 @@ func (r *InitResult) AddMessage(messages ...string) {
 	r.Messages = append(r.Messages, messages...)
 }
 
+// AddWarnings adds uncategorised warnings
 func (r *InitResult) AddWarnings(warnings ...string) {
-	r.Warnings = append(r.Warnings, warnings...)
+	r.AddStructuredWarnings(newInitWarnings(WarningCodeGeneral, WarningSeverityWarning, warnings...)...)
+}
+
+// AddStructuredWarnings adds categorised warnings, also adding the warning messages to Warnings
+func (r *InitResult) AddStructuredWarnings(warnings ...InitWarning) {
+	for _, w := range warnings {
+		r.StructuredWarnings = append(r.StructuredWarnings, w)
+		r.Warnings = append(r.Warnings, w.Message)
+	}
+}
+
+// WarningsWithCode returns the structured warnings with the given code
+func (r *InitResult) WarningsWithCode(code string) []InitWarning {
+	var res []InitWarning
+	for _, w := range r.StructuredWarnings {
+		if w.Code == code {
+			res = append(res, w)
+		}
+	}
+	return res
 }
 
 func (r *InitResult) HasMessages() bool {
@@ -44,9 +70,10 @@ func (r *InitResult) DisplayMessages() {
 	for _, w := range r.Warnings {
 		r.DisplayWarning(context.Background(), w)
 	}
-	// do not display message in json or csv output mode
+	// do not display message in quiet mode, json or csv output mode, or if there is no output
+	// (this is the case if stdout is reserved for an export - see ReserveStdoutForExport)
 	output := viper.Get(constants.ArgOutput)
-	if output == constants.OutputFormatJSON || output == constants.OutputFormatCSV {
+	if viper.GetBool(localconstants.ArgQuiet) || output == constants.OutputFormatJSON || output == constants.OutputFormatCSV || output == constants.OutputFormatNone {
 		return
 	}
 	for _, m := range r.Messages {
@@ -56,6 +83,8 @@ func (r *InitResult) DisplayMessages() {
 
 func (r *InitResult) Merge(other InitResult) {
 	r.ErrorAndWarnings.Merge(other.ErrorAndWarnings)
+	r.StructuredWarnings = append(r.StructuredWarnings, other.StructuredWarnings...)
+	r.PhaseTimings = append(r.PhaseTimings, other.PhaseTimings...)
 
 	r.AddMessage(other.Messages...)
 }
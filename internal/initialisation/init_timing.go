@@ -0,0 +1,62 @@
+package initialisation
+
+import (
+	"log/slog"
+	"time"
+)
+
+// PhaseTiming records when an init phase started and ended
+type PhaseTiming struct {
+	Phase InitPhase
+	Start time.Time
+	// this is zero if the phase has not ended
+	End time.Time
+}
+
+// Duration returns how long the phase took (zero if the phase has not ended)
+func (t PhaseTiming) Duration() time.Duration {
+	if t.End.IsZero() {
+		return 0
+	}
+	return t.End.Sub(t.Start)
+}
+
+// PhaseTiming returns the timing of the given init phase, and whether the phase was started
+func (r *InitResult) PhaseTiming(phase InitPhase) (PhaseTiming, bool) {
+	for _, t := range r.PhaseTimings {
+		if t.Phase == phase {
+			return t, true
+		}
+	}
+	return PhaseTiming{}, false
+}
+
+// InitDuration returns the total duration of the timed init phases
+func (r *InitResult) InitDuration() time.Duration {
+	var res time.Duration
+	for _, t := range r.PhaseTimings {
+		res += t.Duration()
+	}
+	return res
+}
+
+// startPhaseTiming ends the timing of the current phase (if any) and starts timing the given phase
+// NOTE: InitPhaseComplete is not timed
+func (r *InitResult) startPhaseTiming(phase InitPhase, now time.Time) {
+	r.endPhaseTiming(now)
+	if phase != InitPhaseComplete {
+		r.PhaseTimings = append(r.PhaseTimings, PhaseTiming{Phase: phase, Start: now})
+	}
+}
+
+// endPhaseTiming ends the timing of the current phase, if it has not already ended
+func (r *InitResult) endPhaseTiming(now time.Time) {
+	if len(r.PhaseTimings) == 0 {
+		return
+	}
+	current := &r.PhaseTimings[len(r.PhaseTimings)-1]
+	if current.End.IsZero() {
+		current.End = now
+		slog.Debug("init phase ended", "phase", current.Phase, "duration", current.Duration())
+	}
+}
@@ -0,0 +1,45 @@
+package initialisation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPhaseTimings(t *testing.T) {
+	r := &InitResult{}
+	start := time.Now()
+
+	r.startPhaseTiming(InitPhaseInstallingDeps, start)
+	r.startPhaseTiming(InitPhaseConnecting, start.Add(time.Second))
+	r.startPhaseTiming(InitPhaseComplete, start.Add(3*time.Second))
+	// ending again is a no-op
+	r.endPhaseTiming(start.Add(10 * time.Second))
+
+	if len(r.PhaseTimings) != 2 {
+		t.Fatalf("expected 2 phase timings, got %d", len(r.PhaseTimings))
+	}
+	if timing, ok := r.PhaseTiming(InitPhaseConnecting); !ok || timing.Duration() != 2*time.Second {
+		t.Errorf("expected connecting to take 2s, got %v", timing.Duration())
+	}
+	if _, ok := r.PhaseTiming(InitPhaseValidating); ok {
+		t.Errorf("expected no timing for a phase which was not started")
+	}
+	if r.InitDuration() != 3*time.Second {
+		t.Errorf("expected an init duration of 3s, got %v", r.InitDuration())
+	}
+}
+
+func TestPhaseTimingsPartialFailure(t *testing.T) {
+	r := &InitResult{}
+	start := time.Now()
+
+	r.startPhaseTiming(InitPhaseConnecting, start)
+	if timing, _ := r.PhaseTiming(InitPhaseConnecting); timing.Duration() != 0 {
+		t.Errorf("expected an unfinished phase to have no duration, got %v", timing.Duration())
+	}
+	// a failure ends the current phase
+	r.endPhaseTiming(start.Add(time.Second))
+	if timing, _ := r.PhaseTiming(InitPhaseConnecting); timing.Duration() != time.Second {
+		t.Errorf("expected the failed phase to take 1s, got %v", timing.Duration())
+	}
+}
@@ -0,0 +1,46 @@
+package initialisation
+
+// WarningSeverity is the severity of an InitWarning
+type WarningSeverity string
+
+const (
+	WarningSeverityInfo    WarningSeverity = "info"
+	WarningSeverityWarning WarningSeverity = "warning"
+)
+
+// warning codes for the categories of warning raised during initialisation
+const (
+	WarningCodeGeneral            = "general"
+	WarningCodeTelemetry          = "telemetry"
+	WarningCodeConnectionFallback = "connection_fallback"
+	WarningCodeModRequirements    = "mod_requirements"
+	WarningCodePoolSize           = "pool_size"
+	WarningCodeSteampipeVersion   = "steampipe_version"
+	WarningCodeResultCache        = "result_cache"
+	WarningCodePoolWarmUp         = "pool_warm_up"
+)
+
+// InitWarning is a warning raised during initialisation, categorised by code and severity
+// so that it can be filtered and displayed appropriately
+type InitWarning struct {
+	Code     string          `json:"code"`
+	Message  string          `json:"message"`
+	Severity WarningSeverity `json:"severity"`
+}
+
+func NewInitWarning(code string, severity WarningSeverity, message string) InitWarning {
+	return InitWarning{
+		Code:     code,
+		Message:  message,
+		Severity: severity,
+	}
+}
+
+// newInitWarnings creates an InitWarning with the given code and severity for each message
+func newInitWarnings(code string, severity WarningSeverity, messages ...string) []InitWarning {
+	res := make([]InitWarning, len(messages))
+	for idx, m := range messages {
+		res[idx] = NewInitWarning(code, severity, m)
+	}
+	return res
+}
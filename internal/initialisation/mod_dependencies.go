@@ -0,0 +1,70 @@
+package initialisation
+
+import (
+	typehelpers "github.com/turbot/go-kit/types"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+// ResolvedModDependency is a dependency mod which was resolved (and loaded) for the workspace
+type ResolvedModDependency struct {
+	// the dependency name, e.g. github.com/turbot/steampipe-mod-aws-compliance
+	Name string
+	// the resolved version - if the dependency was resolved to a branch or tag rather than a version, this is the
+	// branch or tag name, and for local file dependencies this is empty
+	Version string
+	// the dependency path, e.g. github.com/turbot/steampipe-mod-aws-compliance@v1.0.0,
+	// or the file path for local file dependencies
+	Source string
+}
+
+// ResolvedModDependencies returns the dependency mods (including transitive dependencies) loaded for the workspace,
+// in dependency tree order (depth first, with dependencies ordered by name)
+// each dependency is only included once, and the self-reference entries of the mod resource maps are excluded
+// this should be called after Init, once the dependencies have been installed and the workspace loaded
+func (i *InitData[T]) ResolvedModDependencies() []ResolvedModDependency {
+	if i.Workspace == nil || i.Workspace.Mod == nil {
+		return nil
+	}
+	return resolvedModDependencies(i.Workspace.Mod)
+}
+
+func resolvedModDependencies(mod *modconfig.Mod) []ResolvedModDependency {
+	// the tree walk skips self references (and does not follow cycles)
+	mods, _ := modDependencyTree(mod)
+
+	var res []ResolvedModDependency
+	seen := make(map[string]struct{})
+	// the first mod is the workspace mod itself
+	for _, m := range mods[1:] {
+		dependency := newResolvedModDependency(m)
+		// a dependency may be required by more than one mod
+		key := dependency.Name + "@" + dependency.Source
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, dependency)
+	}
+	return res
+}
+
+func newResolvedModDependency(mod *modconfig.Mod) ResolvedModDependency {
+	dependency := ResolvedModDependency{
+		Name:   modDependencyName(mod),
+		Source: typehelpers.SafeString(mod.DependencyPath),
+	}
+	if v := mod.Version; v != nil {
+		switch {
+		case v.Version != nil:
+			dependency.Version = v.Version.String()
+		case v.Branch != "":
+			dependency.Version = v.Branch
+		case v.Tag != "":
+			dependency.Version = v.Tag
+		}
+		if v.FilePath != "" {
+			dependency.Source = v.FilePath
+		}
+	}
+	return dependency
+}
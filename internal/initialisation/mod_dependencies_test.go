@@ -0,0 +1,44 @@
+package initialisation
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func TestResolvedModDependencies(t *testing.T) {
+	root := newTestMod("root", "")
+	a := newTestMod("a", "github.com/test/a")
+	a.Version = &modconfig.DependencyVersion{Version: semver.MustParse("1.2.0")}
+	aPath := "github.com/test/a@v1.2.0"
+	a.DependencyPath = &aPath
+	b := newTestMod("b", "github.com/test/b")
+	b.Version = &modconfig.DependencyVersion{FilePath: "../b"}
+	c := newTestMod("c", "github.com/test/c")
+	c.Version = &modconfig.DependencyVersion{Branch: "main"}
+
+	addTestDependency(root, b)
+	addTestDependency(root, a)
+	addTestDependency(a, c)
+	// c is required by both a and b - it should only be listed once
+	addTestDependency(b, c)
+	// self references are excluded
+	root.ResourceMaps.Mods["local"] = root
+	a.ResourceMaps.Mods[a.DependencyName] = a
+
+	expected := []ResolvedModDependency{
+		{Name: "github.com/test/a", Version: "1.2.0", Source: aPath},
+		{Name: "github.com/test/c", Version: "main"},
+		{Name: "github.com/test/b", Source: "../b"},
+	}
+	got := resolvedModDependencies(root)
+	if len(got) != len(expected) {
+		t.Fatalf("expected dependencies %+v - got %+v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("dependency %d: expected %+v - got %+v", i, expected[i], got[i])
+		}
+	}
+}
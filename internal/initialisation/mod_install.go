@@ -0,0 +1,109 @@
+package initialisation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sethvargo/go-retry"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modinstaller"
+	"github.com/turbot/pipe-fittings/statushooks"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	// the mod installer uses git, which sends http requests using the client set by httpclient.SetClient
+	_ "github.com/turbot/powerpipe/internal/httpclient"
+	"github.com/turbot/powerpipe/internal/i18n"
+)
+
+// installWorkspaceDependenciesWithRetry calls modinstaller.InstallWorkspaceDependencies, retrying with exponential
+// backoff if the install fails with a transient network error
+// the number of retries and base retry interval are controlled by ArgModInstallMaxRetries and ArgModInstallRetryInterval
+func installWorkspaceDependenciesWithRetry(ctx context.Context, opts *modinstaller.InstallOpts) (*modinstaller.InstallData, error) {
+	return installWithRetry(ctx, func(ctx context.Context) (*modinstaller.InstallData, error) {
+		return modinstaller.InstallWorkspaceDependencies(ctx, opts)
+	})
+}
+
+func installWithRetry(ctx context.Context, install func(context.Context) (*modinstaller.InstallData, error)) (*modinstaller.InstallData, error) {
+	maxRetries := viper.GetInt(localconstants.ArgModInstallMaxRetries)
+	if maxRetries <= 0 {
+		return install(ctx)
+	}
+
+	retryInterval := time.Duration(viper.GetInt(localconstants.ArgModInstallRetryInterval)) * time.Second
+	if retryInterval <= 0 {
+		retryInterval = localconstants.DefaultModInstallRetryInterval * time.Second
+	}
+	backoff := retry.WithMaxRetries(uint64(maxRetries), retry.NewExponential(retryInterval)) //nolint:gosec // maxRetries is positive
+
+	var installData *modinstaller.InstallData
+	var installErr error
+	attempt := 0
+	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
+		if attempt > 0 {
+			statushooks.SetStatus(ctx, i18n.Message(i18n.StatusInstallingDependenciesRetry, attempt, maxRetries))
+		}
+		attempt++
+
+		installData, installErr = install(ctx)
+		if installErr != nil {
+			if isTransientModInstallError(installErr) {
+				slog.Info("mod install failed with a transient error - retrying", "attempt", attempt, "error", installErr)
+				return retry.RetryableError(installErr)
+			}
+			return installErr
+		}
+		return nil
+	})
+
+	// if the context was cancelled while waiting to retry, retry.Do returns the context error
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if installErr != nil {
+		return nil, installErr
+	}
+	return installData, err
+}
+
+// http status codes returned by the git server for a temporary failure, e.g. 'status code: 503'
+var transientGitStatusRegex = regexp.MustCompile(`status code: 5(00|02|03|04)\b`)
+
+// isTransientModInstallError returns whether the given mod install error is a network error which may succeed
+// if retried - other errors (for example an invalid mod reference or an unsatisfiable version constraint) are not
+func isTransientModInstallError(err error) bool {
+	if err == nil {
+		return false
+	}
+	// cancellation is never transient
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ETIMEDOUT) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsTemporary {
+		return true
+	}
+
+	// fall back to checking the error text - the installer does not always wrap the underlying errors
+	msg := err.Error()
+	for _, s := range []string{"connection refused", "connection reset", "i/o timeout", "TLS handshake timeout", "temporary failure in name resolution", "unexpected EOF"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return transientGitStatusRegex.MatchString(msg)
+}
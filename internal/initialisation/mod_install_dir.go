@@ -0,0 +1,130 @@
+package initialisation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	// the name of the lock file held in the mod install directory while the workspace mods directory is linked to it
+	modInstallDirLockFile = ".powerpipe-install.lock"
+	// the interval at which an unavailable lock is retried
+	modInstallDirLockRetryInterval = 250 * time.Millisecond
+	// a lock older than this is assumed to have been left by a process which did not exit cleanly, and is removed
+	modInstallDirStaleLockAge = 30 * time.Minute
+)
+
+// validateModInstallDir verifies the mod install directory is writable, creating it if it does not exist
+// and returning its absolute path
+func validateModInstallDir(installDir string) (string, error) {
+	installDir, err := filepath.Abs(installDir)
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "invalid mod install directory '%s'", installDir)
+	}
+	if err := os.MkdirAll(installDir, 0755); err != nil {
+		return "", sperr.WrapWithMessage(err, "failed to create mod install directory '%s'", installDir)
+	}
+	// verify the directory is writable by creating (and removing) a temporary file
+	f, err := os.CreateTemp(installDir, ".write-test-*")
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "mod install directory '%s' is not writable", installDir)
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return installDir, nil
+}
+
+// linkModInstallDir temporarily links the workspace mods directory to the mod install directory, so the workspace
+// loads its dependencies from, and the mod installer installs them into, the install directory
+// (the mod installer has no install directory option - it always installs into the workspace mods directory)
+//
+// the returned func removes the link and restores the workspace mods directory - an existing workspace mods
+// directory is moved aside while linked, so any mods installed in the workspace are left untouched.
+// A lock file is held in the install directory until the restore func is called, so concurrent runs sharing
+// the install directory do not install into it at the same time
+func linkModInstallDir(ctx context.Context, workspacePath, installDir string) (func() error, error) {
+	unlock, err := lockModInstallDir(ctx, installDir)
+	if err != nil {
+		return nil, err
+	}
+
+	modsPath := filepaths.WorkspaceModPath(workspacePath)
+	// the existing workspace mods directory (or link) is moved aside while linked
+	movedPath := modsPath + ".powerpipe-moved"
+	if _, err := os.Lstat(movedPath); err == nil {
+		// a previous run which did not exit cleanly left the moved directory - this is the original workspace
+		// mods directory, and any current workspace mods directory is the stale link
+		if err := os.Remove(modsPath); err != nil && !os.IsNotExist(err) {
+			unlock()
+			return nil, sperr.WrapWithMessage(err, "failed to remove the stale workspace mods link '%s'", modsPath)
+		}
+	} else if _, err := os.Lstat(modsPath); err == nil {
+		if err := os.Rename(modsPath, movedPath); err != nil {
+			unlock()
+			return nil, sperr.WrapWithMessage(err, "failed to move the workspace mods directory '%s' aside", modsPath)
+		}
+	} else if os.IsNotExist(err) {
+		movedPath = ""
+	} else {
+		unlock()
+		return nil, sperr.WrapWithMessage(err, "failed to read the workspace mods directory")
+	}
+
+	restore := func() error {
+		defer unlock()
+		if err := os.Remove(modsPath); err != nil && !os.IsNotExist(err) {
+			return sperr.WrapWithMessage(err, "failed to remove the workspace mods link '%s'", modsPath)
+		}
+		if movedPath != "" {
+			if err := os.Rename(movedPath, modsPath); err != nil {
+				return sperr.WrapWithMessage(err, "failed to restore the workspace mods directory '%s'", modsPath)
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(modsPath), 0755); err != nil {
+		_ = restore()
+		return nil, sperr.WrapWithMessage(err, "failed to create the workspace data directory")
+	}
+	if err := os.Symlink(installDir, modsPath); err != nil {
+		_ = restore()
+		if runtime.GOOS == "windows" {
+			return nil, sperr.WrapWithMessage(err, "failed to link the workspace mods directory to '%s' - on Windows, creating symbolic links requires Developer Mode to be enabled or administrator privileges", installDir)
+		}
+		return nil, sperr.WrapWithMessage(err, "failed to link the workspace mods directory to '%s'", installDir)
+	}
+	return restore, nil
+}
+
+// lockModInstallDir creates the lock file in the mod install directory, waiting until it is available
+// (or the context is cancelled) if it is held by another run, and returns a func which releases the lock
+func lockModInstallDir(ctx context.Context, installDir string) (func(), error) {
+	lockPath := filepath.Join(installDir, modInstallDirLockFile)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, sperr.WrapWithMessage(err, "failed to lock the mod install directory '%s'", installDir)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > modInstallDirStaleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, sperr.WrapWithMessage(ctx.Err(), "timed out waiting for the lock on the mod install directory '%s'", installDir)
+		case <-time.After(modInstallDirLockRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package initialisation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/pipe-fittings/filepaths"
+)
+
+func TestValidateModInstallDir(t *testing.T) {
+	// the directory is created if it does not exist
+	installDir, err := validateModInstallDir(filepath.Join(t.TempDir(), "cache", "mods"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(installDir); err != nil || len(entries) != 0 {
+		t.Errorf("expected an empty install directory, got %v (%v)", entries, err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("the unwritable directory check does not apply to root")
+	}
+	readOnlyDir := t.TempDir()
+	if err := os.Chmod(readOnlyDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validateModInstallDir(readOnlyDir); err == nil || !strings.Contains(err.Error(), "is not writable") {
+		t.Errorf("expected a not writable error, got %v", err)
+	}
+}
+
+func TestLinkModInstallDir(t *testing.T) {
+	setTestModFileConfig(t)
+	ctx := context.Background()
+	workspaceDir := t.TempDir()
+	installDir := t.TempDir()
+	modsPath := filepaths.WorkspaceModPath(workspaceDir)
+
+	// while linked, mods installed in the workspace are written to the install directory
+	restore, err := linkModInstallDir(ctx, workspaceDir, installDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(modsPath, "github.com", "turbot", "mod@v1.0.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, "github.com", "turbot", "mod@v1.0.0")); err != nil {
+		t.Errorf("expected the mod to be installed in the install directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, modInstallDirLockFile)); err != nil {
+		t.Errorf("expected the install directory to be locked while linked: %v", err)
+	}
+
+	// restoring removes the link and the lock - the workspace had no mods directory, so none is left
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(modsPath); !os.IsNotExist(err) {
+		t.Errorf("expected the workspace mods link to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, modInstallDirLockFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the install directory lock to be released, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, "github.com", "turbot", "mod@v1.0.0")); err != nil {
+		t.Errorf("expected the installed mod to remain in the install directory: %v", err)
+	}
+}
+
+func TestLinkModInstallDirPreservesWorkspaceMods(t *testing.T) {
+	setTestModFileConfig(t)
+	ctx := context.Background()
+	workspaceDir := t.TempDir()
+	installDir := t.TempDir()
+	modsPath := filepaths.WorkspaceModPath(workspaceDir)
+	workspaceMod := filepath.Join(modsPath, "github.com", "turbot", "workspace-mod@v1.0.0")
+	if err := os.MkdirAll(workspaceMod, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// the workspace mods directory is moved aside while linked
+	restore, err := linkModInstallDir(ctx, workspaceDir, installDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(modsPath); err != nil || target != installDir {
+		t.Errorf("expected the workspace mods directory to link to %s, got %s (%v)", installDir, target, err)
+	}
+	if _, err := os.Stat(workspaceMod); !os.IsNotExist(err) {
+		t.Errorf("expected the workspace mods to be hidden while linked, got %v", err)
+	}
+
+	// restoring returns the workspace mods directory, with its mods, to its original location
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(modsPath); err != nil || !info.IsDir() {
+		t.Fatalf("expected the workspace mods directory to be restored, got %v", err)
+	}
+	if _, err := os.Stat(workspaceMod); err != nil {
+		t.Errorf("expected the workspace mods to be restored: %v", err)
+	}
+
+	// a stale link left by a run which did not exit cleanly is replaced, and the original directory restored
+	if _, err := linkModInstallDir(ctx, workspaceDir, installDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(installDir, modInstallDirLockFile)); err != nil {
+		t.Fatal(err)
+	}
+	restore, err = linkModInstallDir(ctx, workspaceDir, installDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(workspaceMod); err != nil {
+		t.Errorf("expected the workspace mods to be restored after a stale link: %v", err)
+	}
+}
+
+func TestLinkModInstallDirLock(t *testing.T) {
+	setTestModFileConfig(t)
+	installDir := t.TempDir()
+
+	restore, err := linkModInstallDir(context.Background(), t.TempDir(), installDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a concurrent run sharing the install directory waits for the lock
+	ctx, cancel := context.WithTimeout(context.Background(), 3*modInstallDirLockRetryInterval)
+	defer cancel()
+	if _, err := linkModInstallDir(ctx, t.TempDir(), installDir); err == nil || !strings.Contains(err.Error(), "timed out waiting for the lock") {
+		t.Errorf("expected a lock timeout error, got %v", err)
+	}
+
+	// once released, the lock is available
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+	restore, err = linkModInstallDir(context.Background(), t.TempDir(), installDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a stale lock is removed
+	lockPath := filepath.Join(installDir, modInstallDirLockFile)
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	staleTime := time.Now().Add(-2 * modInstallDirStaleLockAge)
+	if err := os.Chtimes(lockPath, staleTime, staleTime); err != nil {
+		t.Fatal(err)
+	}
+	restore, err = linkModInstallDir(context.Background(), t.TempDir(), installDir)
+	if err != nil {
+		t.Fatalf("expected a stale lock to be removed, got %v", err)
+	}
+	_ = restore()
+}
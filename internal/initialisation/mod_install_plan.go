@@ -0,0 +1,38 @@
+package initialisation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turbot/pipe-fittings/modinstaller"
+	"github.com/turbot/pipe-fittings/utils"
+)
+
+// buildModInstallPlan returns a message for each category of change a dry run mod install would make
+func buildModInstallPlan(installData *modinstaller.InstallData) []string {
+	if installData == nil {
+		return nil
+	}
+
+	var messages []string
+	addPlanMessage := func(verb string, depPaths [][]string) {
+		if len(depPaths) == 0 {
+			return
+		}
+		mods := make([]string, len(depPaths))
+		for idx, depPath := range depPaths {
+			// the dependency path runs from the workspace mod to the dependency - display the full path
+			mods[idx] = strings.Join(depPath, " -> ")
+		}
+		messages = append(messages, fmt.Sprintf("%s %d %s:\n\t%s", verb, len(mods), utils.Pluralize("mod", len(mods)), strings.Join(mods, "\n\t")))
+	}
+	addPlanMessage("Would install", installData.Installed)
+	addPlanMessage("Would upgrade", installData.Upgraded)
+	addPlanMessage("Would downgrade", installData.Downgraded)
+	addPlanMessage("Would uninstall", installData.Uninstalled)
+
+	if len(messages) == 0 {
+		messages = append(messages, "All mods are up to date")
+	}
+	return messages
+}
@@ -0,0 +1,70 @@
+package initialisation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/modinstaller"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+func TestIsTransientModInstallError(t *testing.T) {
+	tests := map[string]struct {
+		err       error
+		transient bool
+	}{
+		"nil":                {nil, false},
+		"connection refused": {fmt.Errorf("failed to clone: %w", syscall.ECONNREFUSED), true},
+		"connection reset":   {errors.New("read tcp 10.0.0.1:443: connection reset by peer"), true},
+		"server error":       {errors.New(`unexpected client error: unexpected requesting "https://github.com/turbot/steampipe-mod-aws-compliance/info/refs" status code: 503`), true},
+		"not found":          {errors.New("repository not found"), false},
+		"bad version":        {errors.New("no version of github.com/turbot/steampipe-mod-aws-compliance found satisfying version constraint: ^99"), false},
+		"cancelled":          {fmt.Errorf("install failed: %w", context.Canceled), false},
+	}
+	for name, test := range tests {
+		if got := isTransientModInstallError(test.err); got != test.transient {
+			t.Errorf("%s: expected transient %v, got %v", name, test.transient, got)
+		}
+	}
+}
+
+func TestInstallWithRetry(t *testing.T) {
+	viper.Set(localconstants.ArgModInstallMaxRetries, 2)
+	defer viper.Set(localconstants.ArgModInstallMaxRetries, nil)
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		attempts := 0
+		installData, err := installWithRetry(context.Background(), func(context.Context) (*modinstaller.InstallData, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, syscall.ECONNRESET
+			}
+			return &modinstaller.InstallData{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attempts != 2 || installData == nil {
+			t.Errorf("expected the install to succeed on the second attempt - got %d attempts", attempts)
+		}
+	})
+
+	t.Run("fails fast for other errors", func(t *testing.T) {
+		attempts := 0
+		installErr := errors.New("repository not found")
+		_, err := installWithRetry(context.Background(), func(context.Context) (*modinstaller.InstallData, error) {
+			attempts++
+			return nil, installErr
+		})
+		if !errors.Is(err, installErr) {
+			t.Errorf("expected the install error to be returned, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected a single attempt - got %d", attempts)
+		}
+	})
+}
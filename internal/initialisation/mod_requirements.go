@@ -0,0 +1,134 @@
+package initialisation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/turbot/pipe-fittings/backend"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/plugin"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// maxParallelRequirementValidations is the maximum number of mods whose requirements are validated concurrently
+const maxParallelRequirementValidations = 10
+
+// newPluginVersionMap builds the plugin version map for the client backend
+// (the plugin versions are only available for steampipe backends, and are read when the client connects)
+func newPluginVersionMap(client *db_client.DbClient) *plugin.PluginVersionMap {
+	var pluginVersionMap = &plugin.PluginVersionMap{
+		Database: client.Backend.ConnectionString(),
+		Backend:  client.Backend.Name(),
+	}
+	// if the backend is steampipe, populate the available plugins
+	if steampipeBackend, ok := client.Backend.(*backend.SteampipeBackend); ok {
+		pluginVersionMap.AvailablePlugins = steampipeBackend.PluginVersions
+	}
+	return pluginVersionMap
+}
+
+// validateModRequirementsRecursively validates the requirements of the mod and all of its dependency mods
+// the mods are validated concurrently - the validation errors are returned in dependency tree order
+// (depth first, with dependencies ordered by name) so the output is stable
+// any cyclic dependencies are reported as validation errors
+func validateModRequirementsRecursively(mod *modconfig.Mod, pluginVersionMap *plugin.PluginVersionMap) []string {
+	var validationErrors []string
+	for _, requirementErrors := range modRequirementErrors(mod, pluginVersionMap) {
+		for _, err := range requirementErrors.errors {
+			validationErrors = append(validationErrors, err.Error())
+		}
+	}
+	return validationErrors
+}
+
+// requirementErrors are the requirement validation errors of a mod
+type requirementErrors struct {
+	// the mod whose requirements failed validation - this is nil for cyclic dependency errors
+	mod    *modconfig.Mod
+	errors []error
+}
+
+// modRequirementErrors validates the requirements of the mod and all of its dependency mods concurrently, returning
+// the errors of each mod with failed requirements in dependency tree order, followed by any cyclic dependency errors
+func modRequirementErrors(mod *modconfig.Mod, pluginVersionMap *plugin.PluginVersionMap) []requirementErrors {
+	mods, cycleErrors := modDependencyTree(mod)
+
+	// store errors by mod index so they can be combined in tree order
+	var (
+		modErrors = make([][]error, len(mods))
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, maxParallelRequirementValidations)
+	)
+	for idx, m := range mods {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, m *modconfig.Mod) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			modErrors[idx] = m.ValidateRequirements(pluginVersionMap)
+		}(idx, m)
+	}
+	wg.Wait()
+
+	var res []requirementErrors
+	for idx, errs := range modErrors {
+		if len(errs) > 0 {
+			res = append(res, requirementErrors{mod: mods[idx], errors: errs})
+		}
+	}
+	for _, cycleError := range cycleErrors {
+		res = append(res, requirementErrors{errors: []error{errors.New(cycleError)}})
+	}
+	return res
+}
+
+// modDependencyTree returns the mod followed by its dependency mods (recursively), depth first,
+// and an error message for each cyclic dependency found (cyclic dependencies are not followed)
+func modDependencyTree(mod *modconfig.Mod) ([]*modconfig.Mod, []string) {
+	var cycleErrors []string
+	mods := walkModDependencies(mod, nil, &cycleErrors)
+	return mods, cycleErrors
+}
+
+// walkModDependencies returns the mod and its dependency mods - path is the dependency names of the
+// mods above this mod in the tree, and is used to detect cycles
+func walkModDependencies(mod *modconfig.Mod, path []string, cycleErrors *[]string) []*modconfig.Mod {
+	res := []*modconfig.Mod{mod}
+	// copy the path so sibling dependencies do not share it
+	path = append(slices.Clone(path), modDependencyName(mod))
+
+	// sort the dependency names so the order is deterministic
+	dependencyNames := maps.Keys(mod.ResourceMaps.Mods)
+	slices.Sort(dependencyNames)
+
+	for _, childDependencyName := range dependencyNames {
+		childMod := mod.ResourceMaps.Mods[childDependencyName]
+		if childDependencyName == "local" || mod.DependencyName == childMod.DependencyName {
+			// this is a reference to self - skip (otherwise we will end up with a recursion loop)
+			continue
+		}
+		// if this mod is already in the path, this is a cycle
+		if idx := slices.Index(path, modDependencyName(childMod)); idx != -1 {
+			cycle := append(slices.Clone(path[idx:]), modDependencyName(childMod))
+			*cycleErrors = append(*cycleErrors, fmt.Sprintf("cyclic mod dependency: %s", strings.Join(cycle, " -> ")))
+			continue
+		}
+		res = append(res, walkModDependencies(childMod, path, cycleErrors)...)
+	}
+	return res
+}
+
+// modDependencyName returns the name used to identify the mod in the dependency tree
+// (the root mod has no dependency name, so its short name is used)
+func modDependencyName(mod *modconfig.Mod) string {
+	if mod.DependencyName != "" {
+		return mod.DependencyName
+	}
+	return mod.ShortName
+}
@@ -0,0 +1,66 @@
+package initialisation
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func newTestMod(shortName, dependencyName string) *modconfig.Mod {
+	mod := modconfig.NewMod(shortName, "", hcl.Range{})
+	mod.DependencyName = dependencyName
+	return mod
+}
+
+func addTestDependency(parent, child *modconfig.Mod) {
+	parent.ResourceMaps.Mods[child.DependencyName] = child
+}
+
+func TestModDependencyTree(t *testing.T) {
+	root := newTestMod("root", "")
+	a := newTestMod("a", "github.com/test/a")
+	b := newTestMod("b", "github.com/test/b")
+	c := newTestMod("c", "github.com/test/c")
+	addTestDependency(root, b)
+	addTestDependency(root, a)
+	addTestDependency(a, c)
+	// b -> c is not a cycle, even though c has already been visited
+	addTestDependency(b, c)
+
+	mods, cycleErrors := modDependencyTree(root)
+	var names []string
+	for _, m := range mods {
+		names = append(names, modDependencyName(m))
+	}
+	expected := []string{"root", "github.com/test/a", "github.com/test/c", "github.com/test/b", "github.com/test/c"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected mods %v - got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected mods %v - got %v", expected, names)
+		}
+	}
+	if len(cycleErrors) != 0 {
+		t.Errorf("expected no cycle errors - got %v", cycleErrors)
+	}
+}
+
+func TestModDependencyTreeCycle(t *testing.T) {
+	root := newTestMod("root", "")
+	a := newTestMod("a", "github.com/test/a")
+	b := newTestMod("b", "github.com/test/b")
+	addTestDependency(root, a)
+	addTestDependency(a, b)
+	addTestDependency(b, a)
+
+	mods, cycleErrors := modDependencyTree(root)
+	if len(mods) != 3 {
+		t.Errorf("expected 3 mods - got %d", len(mods))
+	}
+	expected := "cyclic mod dependency: github.com/test/a -> github.com/test/b -> github.com/test/a"
+	if len(cycleErrors) != 1 || cycleErrors[0] != expected {
+		t.Errorf("expected cycle error '%s' - got %v", expected, cycleErrors)
+	}
+}
@@ -0,0 +1,219 @@
+package initialisation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/otiai10/copy"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/utils"
+	"github.com/turbot/pipe-fittings/versionmap"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/exp/slices"
+)
+
+// modSourceInstaller installs the workspace mod dependencies from a local mod source directory, without network access
+// (e.g. for air-gapped environments, where the mods have been downloaded in advance)
+//
+// the source directory has the same layout as the workspace mod installation directory, i.e. each mod is in a folder
+// named for its dependency path, e.g. github.com/turbot/steampipe-mod-aws-compliance@v0.90.0
+// (or github.com/turbot/steampipe-mod-aws-compliance@<tag> and github.com/turbot/steampipe-mod-aws-compliance#<branch>
+// for tag and branch requirements)
+type modSourceInstaller struct {
+	sourceDir string
+	// the existing workspace lock - locked versions are installed in preference to the latest version
+	lock *versionmap.WorkspaceLock
+	// the resolved dependencies, keyed by parent install cache key - this is the content of the workspace lock
+	installCache versionmap.InstalledDependencyVersionsMap
+	// the dependency paths of the mods to copy from the source directory, in resolution order
+	installPaths []string
+	// the dependencies which could not be resolved from the source directory
+	errors []error
+}
+
+// installWorkspaceDependenciesFromSource resolves the dependencies of the workspace mod (recursively) from the source
+// directory, copies them to the workspace mod installation directory and writes the workspace lock file
+// if any required mod is not present in the source directory, an error listing the missing mods is returned,
+// and nothing is installed
+// in dry run mode, nothing is installed, and the mods which would be installed are returned as messages
+func installWorkspaceDependenciesFromSource(ctx context.Context, workspaceMod *modconfig.Mod, sourceDir string, dryRun bool) ([]string, error) {
+	if info, err := os.Stat(sourceDir); err != nil || !info.IsDir() {
+		return nil, sperr.New("mod source directory '%s' does not exist", sourceDir)
+	}
+
+	existingLock, err := versionmap.LoadWorkspaceLock(workspaceMod.ModPath)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to load the workspace lock file")
+	}
+	i := &modSourceInstaller{
+		sourceDir:    sourceDir,
+		lock:         existingLock,
+		installCache: make(versionmap.InstalledDependencyVersionsMap),
+	}
+	i.resolveDependencies(workspaceMod, nil)
+	if len(i.errors) > 0 {
+		return nil, error_helpers.CombineErrorsWithPrefix(fmt.Sprintf("%d %s could not be installed from the mod source directory", len(i.errors), utils.Pluralize("dependency", len(i.errors))), i.errors...)
+	}
+
+	if dryRun {
+		if len(i.installPaths) == 0 {
+			return []string{"All mods are up to date"}, nil
+		}
+		return []string{fmt.Sprintf("Would install %d %s from '%s':\n\t%s", len(i.installPaths), utils.Pluralize("mod", len(i.installPaths)), sourceDir, strings.Join(i.installPaths, "\n\t"))}, nil
+	}
+
+	modsPath := filepaths.WorkspaceModPath(workspaceMod.ModPath)
+	for _, installPath := range i.installPaths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		destination := filepath.Join(modsPath, installPath)
+		// if this version is already installed, there is nothing to copy
+		if _, exists := parse.ModFileExists(destination); exists {
+			continue
+		}
+		if err := copy.Copy(filepath.Join(sourceDir, installPath), destination); err != nil {
+			return nil, sperr.WrapWithMessage(err, "failed to install mod '%s' from the mod source directory", installPath)
+		}
+	}
+
+	lock := &versionmap.WorkspaceLock{
+		WorkspacePath: workspaceMod.ModPath,
+		InstallCache:  i.installCache,
+	}
+	if err := lock.Save(); err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to write the workspace lock file")
+	}
+	return nil, nil
+}
+
+// resolveDependencies resolves the requirements of the mod from the source directory, and recursively resolves
+// the requirements of each resolved mod - path is the dependency paths of the mods above this mod in the tree,
+// and is used to avoid following cyclic dependencies
+func (i *modSourceInstaller) resolveDependencies(mod *modconfig.Mod, path []string) {
+	if mod.Require == nil {
+		return
+	}
+	for _, requirement := range mod.Require.Mods {
+		installed, dependency, err := i.resolveRequirement(requirement, mod)
+		if err != nil {
+			i.errors = append(i.errors, err)
+			continue
+		}
+		i.installCache.AddDependency(mod.GetInstallCacheKey(), installed)
+
+		dependencyPath := installed.DependencyPath()
+		if slices.Contains(path, dependencyPath) {
+			continue
+		}
+		if installed.FilePath == "" && !slices.Contains(i.installPaths, dependencyPath) {
+			i.installPaths = append(i.installPaths, dependencyPath)
+		}
+		// copy the path so sibling dependencies do not share it
+		i.resolveDependencies(dependency, append(slices.Clone(path), dependencyPath))
+	}
+}
+
+// resolveRequirement finds the mod in the source directory which satisfies the requirement - for version constraints
+// this is the locked version if it satisfies the constraint, otherwise the latest version which satisfies it
+// local file requirements are resolved from their file path, as for a normal install
+func (i *modSourceInstaller) resolveRequirement(requirement *modconfig.ModVersionConstraint, parent *modconfig.Mod) (*versionmap.InstalledModVersion, *modconfig.Mod, error) {
+	var version *modconfig.DependencyVersion
+	var modDir string
+	switch {
+	case requirement.FilePath != "":
+		filePath := requirement.FilePath
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(parent.ModPath, filePath)
+		}
+		version = &modconfig.DependencyVersion{FilePath: filePath}
+		modDir = filePath
+	case requirement.VersionConstraint() != nil:
+		version = i.lockedVersion(requirement, parent)
+		if version == nil {
+			version = i.latestVersionSatisfying(requirement)
+		}
+		if version == nil {
+			return nil, nil, sperr.New("no version of '%s' satisfying version constraint '%s' found in mod source directory '%s'", requirement.Name, requirement.VersionString, i.sourceDir)
+		}
+	case requirement.Tag != "":
+		version = &modconfig.DependencyVersion{Tag: requirement.Tag}
+	case requirement.BranchName != "":
+		version = &modconfig.DependencyVersion{Branch: requirement.BranchName}
+	default:
+		return nil, nil, sperr.New("mod requirement '%s' has no version, tag, branch or file path", requirement.Name)
+	}
+
+	dependencyPath := modconfig.BuildModDependencyPath(requirement.Name, version)
+	if modDir == "" {
+		modDir = filepath.Join(i.sourceDir, dependencyPath)
+	}
+	dependency, err := parse.LoadModfile(modDir)
+	if err != nil {
+		return nil, nil, sperr.WrapWithMessage(err, "failed to load mod '%s' from '%s'", dependencyPath, modDir)
+	}
+	if dependency == nil {
+		return nil, nil, sperr.New("mod '%s' not found in mod source directory '%s'", dependencyPath, i.sourceDir)
+	}
+	if version.FilePath == "" {
+		// set the dependency path, so the install cache key of the mod matches the workspace lock
+		if err := dependency.SetDependencyConfig(dependencyPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	installed := &versionmap.InstalledModVersion{
+		ResolvedVersionConstraint: versionmap.NewResolvedVersionConstraint(version, requirement.Name, nil),
+		Alias:                     dependency.ShortName,
+	}
+	return installed, dependency, nil
+}
+
+// lockedVersion returns the version of the required mod in the workspace lock, or nil if there is no locked version
+// or it does not satisfy the version constraint
+// (the locked version is used even if it is not currently installed in the workspace, e.g. for a new checkout)
+func (i *modSourceInstaller) lockedVersion(requirement *modconfig.ModVersionConstraint, parent *modconfig.Mod) *modconfig.DependencyVersion {
+	parentKey := parent.GetInstallCacheKey()
+	// LoadWorkspaceLock moves versions which are not installed from the install cache to the missing versions
+	for _, versions := range []versionmap.InstalledDependencyVersionsMap{i.lock.InstallCache, i.lock.MissingVersions} {
+		locked := versions[parentKey][requirement.Name]
+		if locked != nil && locked.Version != nil && locked.SatisfiesConstraint(requirement) {
+			return &modconfig.DependencyVersion{Version: locked.Version}
+		}
+	}
+	return nil
+}
+
+// latestVersionSatisfying returns the latest version of the required mod in the source directory which satisfies
+// the version constraint, or nil if there is none
+func (i *modSourceInstaller) latestVersionSatisfying(requirement *modconfig.ModVersionConstraint) *modconfig.DependencyVersion {
+	// the versions of a mod are sibling folders, named <mod name>@v<version>
+	matches, err := filepath.Glob(filepath.Join(i.sourceDir, requirement.Name) + "@v*")
+	if err != nil {
+		return nil
+	}
+	constraint := requirement.VersionConstraint()
+
+	var latest *semver.Version
+	for _, match := range matches {
+		_, versionString, _ := strings.Cut(filepath.Base(match), "@")
+		v, err := semver.NewVersion(versionString)
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return &modconfig.DependencyVersion{Version: latest}
+}
@@ -0,0 +1,179 @@
+package initialisation
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/filepaths"
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/pipe-fittings/parse"
+	"github.com/turbot/pipe-fittings/versionmap"
+)
+
+// writeTestModFile writes a mod file with the given requirements to the directory
+func writeTestModFile(t *testing.T, dir, name, require string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "mod \"" + name + "\" {\n" + require + "}\n"
+	if err := os.WriteFile(filepath.Join(dir, "mod.pp"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setTestModFileConfig(t *testing.T) {
+	extensions, dataDir := app_specific.ModDataExtensions, app_specific.WorkspaceDataDir
+	app_specific.ModDataExtensions = []string{".pp"}
+	app_specific.WorkspaceDataDir = ".powerpipe"
+	t.Cleanup(func() {
+		app_specific.ModDataExtensions, app_specific.WorkspaceDataDir = extensions, dataDir
+	})
+}
+
+func TestInstallWorkspaceDependenciesFromSource(t *testing.T) {
+	setTestModFileConfig(t)
+	sourceDir := t.TempDir()
+	workspaceDir := t.TempDir()
+
+	// a depends on b - the latest version of a satisfying the constraint should be installed
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v1.0.0"), "a", "")
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v1.2.0"), "a", `  require {
+    mod "github.com/test/b" {
+      version = "^2"
+    }
+  }
+`)
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v2.0.0"), "a", "")
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/b@v2.1.0"), "b", "")
+	writeTestModFile(t, workspaceDir, "root", `  require {
+    mod "github.com/test/a" {
+      version = "^1"
+    }
+  }
+`)
+	workspaceMod, err := parse.LoadModfile(workspaceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a dry run should report the mods, but not install anything
+	messages, err := installWorkspaceDependenciesFromSource(context.Background(), workspaceMod, sourceDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "github.com/test/a@v1.2.0\n\tgithub.com/test/b@v2.1.0") {
+		t.Errorf("unexpected dry run messages %v", messages)
+	}
+	if _, err := os.Stat(filepaths.WorkspaceLockPath(workspaceDir)); !os.IsNotExist(err) {
+		t.Errorf("expected no lock file to be written in dry run mode")
+	}
+
+	if _, err := installWorkspaceDependenciesFromSource(context.Background(), workspaceMod, sourceDir, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, installPath := range []string{"github.com/test/a@v1.2.0", "github.com/test/b@v2.1.0"} {
+		if _, exists := parse.ModFileExists(filepath.Join(filepaths.WorkspaceModPath(workspaceDir), installPath)); !exists {
+			t.Errorf("expected mod %s to be installed", installPath)
+		}
+	}
+
+	lockContent, err := os.ReadFile(filepaths.WorkspaceLockPath(workspaceDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lock map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(lockContent, &lock); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lock["root"]["github.com/test/a"]; !ok {
+		t.Errorf("expected the lock file to contain the workspace dependency, got %s", lockContent)
+	}
+	if _, ok := lock["github.com/test/a@v1.2.0"]["github.com/test/b"]; !ok {
+		t.Errorf("expected the lock file to contain the transitive dependency, got %s", lockContent)
+	}
+}
+
+func TestInstallWorkspaceDependenciesFromSourceMissing(t *testing.T) {
+	setTestModFileConfig(t)
+	sourceDir := t.TempDir()
+	workspaceDir := t.TempDir()
+
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v1.0.0"), "a", "")
+	writeTestModFile(t, workspaceDir, "root", `  require {
+    mod "github.com/test/a" {
+      version = "^2"
+    }
+  }
+`)
+	workspaceMod, err := parse.LoadModfile(workspaceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = installWorkspaceDependenciesFromSource(context.Background(), workspaceMod, sourceDir, false)
+	if err == nil || !strings.Contains(err.Error(), "no version of 'github.com/test/a' satisfying version constraint '^2'") {
+		t.Fatalf("expected a missing mod error, got %v", err)
+	}
+	if _, err := os.Stat(filepaths.WorkspaceModPath(workspaceDir)); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be installed")
+	}
+}
+
+func TestInstallWorkspaceDependenciesFromSourceLocked(t *testing.T) {
+	setTestModFileConfig(t)
+	sourceDir := t.TempDir()
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v1.0.0"), "a", "")
+	writeTestModFile(t, filepath.Join(sourceDir, "github.com/test/a@v1.2.0"), "a", "")
+
+	tests := []struct {
+		name          string
+		lockedVersion string
+		want          string
+	}{
+		{name: "locked version satisfies constraint", lockedVersion: "1.0.0", want: "github.com/test/a@v1.0.0"},
+		{name: "locked version does not satisfy constraint", lockedVersion: "2.0.0", want: "github.com/test/a@v1.2.0"},
+		{name: "no locked version", want: "github.com/test/a@v1.2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceDir := t.TempDir()
+			writeTestModFile(t, workspaceDir, "root", `  require {
+    mod "github.com/test/a" {
+      version = "^1"
+    }
+  }
+`)
+			workspaceMod, err := parse.LoadModfile(workspaceDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the locked version is not installed in the workspace (e.g. a new checkout of a workspace with a lock file)
+			if tt.lockedVersion != "" {
+				installCache := make(versionmap.InstalledDependencyVersionsMap)
+				installCache.AddDependency(workspaceMod.GetInstallCacheKey(), &versionmap.InstalledModVersion{
+					ResolvedVersionConstraint: versionmap.NewResolvedVersionConstraint(&modconfig.DependencyVersion{Version: semver.MustParse(tt.lockedVersion)}, "github.com/test/a", nil),
+					Alias:                     "a",
+				})
+				lock := &versionmap.WorkspaceLock{WorkspacePath: workspaceDir, InstallCache: installCache}
+				if err := lock.Save(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			messages, err := installWorkspaceDependenciesFromSource(context.Background(), workspaceMod, sourceDir, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(messages) != 1 || !strings.HasSuffix(messages[0], "\n\t"+tt.want) {
+				t.Errorf("expected %s to be installed, got %v", tt.want, messages)
+			}
+		})
+	}
+}
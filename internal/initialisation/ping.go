@@ -0,0 +1,41 @@
+package initialisation
+
+import (
+	"context"
+
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// Ping verifies the database is reachable by running a trivial query.
+// If Init has already created the default client this is used, otherwise a client is created (using the same
+// connection strings as Init) and closed once the query completes.
+// Unlike Init, this does not install mod dependencies or initialise telemetry, and does not modify i.Result
+func (i *InitData[T]) Ping(ctx context.Context) error {
+	client := i.DefaultClient
+	if client == nil {
+		var err error
+		client, err = i.newPingClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer client.Close(ctx)
+	}
+
+	if _, err := client.ExecuteSync(ctx, "select 1"); err != nil {
+		return sperr.WrapWithMessage(err, "database is unreachable")
+	}
+	return nil
+}
+
+func (i *InitData[T]) newPingClient(ctx context.Context) (*db_client.DbClient, error) {
+	connectionStrings, _, opts, err := i.getDefaultClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, errAndWarnings := db_client.GetDbClient(ctx, connectionStrings, opts...)
+	if errAndWarnings.Error != nil {
+		return nil, sperr.WrapWithMessage(errAndWarnings.Error, "database is unreachable")
+	}
+	return client, nil
+}
@@ -0,0 +1,60 @@
+package initialisation
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/turbot/pipe-fittings/constants"
+	"github.com/turbot/pipe-fittings/plugin"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// pluginVersionFile is the content of a plugin version file, which pins the plugin versions used to validate the mod
+// plugin requirements (rather than reading the plugin versions from the database), e.g.
+//
+//	{
+//	  "database": "ci",
+//	  "plugins": {
+//	    "hub.steampipe.io/plugins/turbot/aws@latest": "0.120.0",
+//	    "gcp": "local"
+//	  }
+//	}
+type pluginVersionFile struct {
+	// the backend name - this defaults to Steampipe, as plugin requirements are only validated for Steampipe backends
+	Backend string `json:"backend"`
+	// the database described by the file - this is only used in validation errors
+	Database string `json:"database"`
+	// map of plugin image ref (or short name) to version (a semver version or 'local')
+	Plugins map[string]string `json:"plugins"`
+}
+
+// loadPluginVersionMap loads the plugin version map from a plugin version file
+// (see pluginVersionFile for the format)
+func loadPluginVersionMap(path string) (*plugin.PluginVersionMap, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to read plugin version file")
+	}
+	var file pluginVersionFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to parse plugin version file '%s'", path)
+	}
+
+	if file.Backend == "" {
+		file.Backend = constants.SteampipeBackendName
+	}
+	if file.Database == "" {
+		file.Database = path
+	}
+	// NOTE: the available plugins must not be nil - a nil map is treated as a Steampipe version which does not
+	// provide plugin versions, and the plugin requirements would not be validated
+	availablePlugins := make(map[string]*plugin.PluginVersionString, len(file.Plugins))
+	for name, version := range file.Plugins {
+		pluginVersion, err := plugin.NewPluginVersionString(version)
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "invalid version for plugin '%s' in plugin version file '%s'", name, path)
+		}
+		availablePlugins[name] = pluginVersion
+	}
+	return plugin.NewPluginVersionMap(file.Backend, file.Database, availablePlugins), nil
+}
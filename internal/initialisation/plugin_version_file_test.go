@@ -0,0 +1,53 @@
+package initialisation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/constants"
+)
+
+func writePluginVersionFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "plugins.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPluginVersionMap(t *testing.T) {
+	path := writePluginVersionFile(t, `{"plugins": {"hub.steampipe.io/plugins/turbot/aws@latest": "0.120.0", "gcp": "local"}}`)
+	pluginVersionMap, err := loadPluginVersionMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the backend defaults to steampipe, and the database to the file path
+	if pluginVersionMap.Backend != constants.SteampipeBackendName || pluginVersionMap.Database != path {
+		t.Errorf("unexpected backend %s and database %s", pluginVersionMap.Backend, pluginVersionMap.Database)
+	}
+	if aws := pluginVersionMap.AvailablePlugins["hub.steampipe.io/plugins/turbot/aws@latest"]; aws == nil || aws.Semver().String() != "0.120.0" {
+		t.Errorf("expected aws version 0.120.0, got %v", aws)
+	}
+	if gcp := pluginVersionMap.AvailablePlugins["gcp"]; gcp == nil || !gcp.IsLocal() {
+		t.Errorf("expected a local gcp version, got %v", gcp)
+	}
+
+	// a file with no plugins has an empty (rather than nil) plugin map, so plugin requirements are still validated
+	pluginVersionMap, err = loadPluginVersionMap(writePluginVersionFile(t, `{"backend": "Steampipe", "database": "ci"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pluginVersionMap.AvailablePlugins == nil || pluginVersionMap.Database != "ci" {
+		t.Errorf("unexpected plugin version map %+v", pluginVersionMap)
+	}
+
+	for _, invalid := range []string{`{"plugins": {"aws": "latest"}}`, `{"plugins": ["aws"]}`} {
+		if _, err := loadPluginVersionMap(writePluginVersionFile(t, invalid)); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+	if _, err := loadPluginVersionMap(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,66 @@
+package initialisation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/viper"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"github.com/turbot/powerpipe/internal/db_client"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+// validateSteampipeVersion checks the version of the Steampipe server the client is connected to (if the backend
+// is Steampipe) is in the supported range - if it is not, a warning is added describing the mismatch
+// if strict requirements are enabled, a mismatch is an error
+// the returned error is an InitError
+func (i *InitData[T]) validateSteampipeVersion(ctx context.Context, client *db_client.DbClient) error {
+	version, err := client.SteampipeVersion(ctx)
+	if err != nil && !errors.Is(err, db_client.ErrSteampipeVersionUnavailable) {
+		// do not fail (or warn) because the version could not be read - the queries may still succeed
+		slog.Warn("failed to determine the Steampipe server version", "error", err)
+		return nil
+	}
+	if version == nil && err == nil {
+		// not a Steampipe backend
+		return nil
+	}
+
+	mismatch, err := steampipeVersionMismatch(version, localconstants.SupportedSteampipeVersions)
+	if err != nil {
+		return NewInitError(InitErrorCodeGeneral, err)
+	}
+	if mismatch == "" {
+		return nil
+	}
+	if viper.GetBool(localconstants.ArgStrictRequirements) {
+		return NewInitError(InitErrorCodeSteampipeVersion, sperr.New("%s", mismatch))
+	}
+	i.Result.AddStructuredWarnings(NewInitWarning(WarningCodeSteampipeVersion, WarningSeverityWarning, mismatch))
+	return nil
+}
+
+// steampipeVersionMismatch returns a message describing the mismatch if the Steampipe server version does not satisfy
+// the supported versions constraint - an empty string is returned if it does
+// a nil version means the server did not report its version
+func steampipeVersionMismatch(version *semver.Version, supportedVersions string) (string, error) {
+	constraint, err := semver.NewConstraint(supportedVersions)
+	if err != nil {
+		return "", sperr.WrapWithMessage(err, "invalid supported Steampipe versions '%s'", supportedVersions)
+	}
+	if version == nil {
+		return fmt.Sprintf("the Steampipe server version could not be determined (it is older than v0.21.0), but Powerpipe supports Steampipe versions %s - queries may fail", supportedVersions), nil
+	}
+	// compare the release version, as pre-release versions never satisfy a constraint which does not include a pre-release
+	release, err := version.SetPrerelease("")
+	if err != nil {
+		return "", err
+	}
+	if constraint.Check(&release) {
+		return "", nil
+	}
+	return fmt.Sprintf("connected to Steampipe v%s, but Powerpipe supports Steampipe versions %s - queries may fail", version.String(), supportedVersions), nil
+}
@@ -0,0 +1,53 @@
+package initialisation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+)
+
+func TestSteampipeVersionMismatch(t *testing.T) {
+	tests := map[string]struct {
+		version  string
+		mismatch bool
+	}{
+		"supported":              {version: "0.24.2"},
+		"minimum supported":      {version: "0.21.0"},
+		"supported major":        {version: "1.0.3"},
+		"supported pre-release":  {version: "1.1.0-rc.2"},
+		"too old":                {version: "0.20.12", mismatch: true},
+		"unsupported major":      {version: "2.0.0", mismatch: true},
+		"unsupported prerelease": {version: "2.0.0-alpha.1", mismatch: true},
+		"not reported":           {mismatch: true},
+	}
+	for name, test := range tests {
+		var version *semver.Version
+		if test.version != "" {
+			version = semver.MustParse(test.version)
+		}
+		mismatch, err := steampipeVersionMismatch(version, localconstants.SupportedSteampipeVersions)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !test.mismatch {
+			if mismatch != "" {
+				t.Errorf("%s: expected no mismatch, got %s", name, mismatch)
+			}
+			continue
+		}
+		// the message must include both the detected and supported versions
+		if !strings.Contains(mismatch, localconstants.SupportedSteampipeVersions) {
+			t.Errorf("%s: expected the supported versions in the message, got %s", name, mismatch)
+		}
+		if test.version != "" && !strings.Contains(mismatch, "v"+test.version) {
+			t.Errorf("%s: expected the detected version in the message, got %s", name, mismatch)
+		}
+	}
+
+	if _, err := steampipeVersionMismatch(semver.MustParse("1.0.0"), "not a constraint"); err == nil {
+		t.Errorf("expected an error for an invalid constraint")
+	}
+}
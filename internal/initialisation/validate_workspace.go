@@ -0,0 +1,273 @@
+package initialisation
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/viper"
+	"github.com/turbot/pipe-fittings/app_specific"
+	"github.com/turbot/pipe-fittings/error_helpers"
+	"github.com/turbot/pipe-fittings/hclhelpers"
+	"github.com/turbot/pipe-fittings/plugin"
+	localconstants "github.com/turbot/powerpipe/internal/constants"
+	"golang.org/x/exp/slices"
+)
+
+// ValidationSeverity is the severity of a ValidationProblem
+type ValidationSeverity string
+
+const (
+	ValidationSeverityError   ValidationSeverity = "error"
+	ValidationSeverityWarning ValidationSeverity = "warning"
+)
+
+// ValidationLocation is the location in the mod files of a ValidationProblem
+type ValidationLocation struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// ValidationProblem is a problem found validating a workspace
+type ValidationProblem struct {
+	Severity ValidationSeverity `json:"severity"`
+	Message  string             `json:"message"`
+	// the resource the problem relates to, if known, e.g. dashboard.cis
+	Resource string `json:"resource,omitempty"`
+	// the location of the problem, if known
+	Location *ValidationLocation `json:"location,omitempty"`
+}
+
+// String returns the problem in the form <filename>:<line>:<column>: <severity>: <message>
+// (the location is omitted if it is not known)
+func (p ValidationProblem) String() string {
+	res := fmt.Sprintf("%s: %s", p.Severity, p.Message)
+	if p.Location != nil {
+		res = fmt.Sprintf("%s:%d:%d: %s", p.Location.Filename, p.Location.Line, p.Location.Column, res)
+	}
+	return res
+}
+
+var (
+	// matches the range appended to a diagnostic message, e.g. (/path/mod.pp:4,3-12)
+	diagnosticRangeRegex = regexp.MustCompile(`^\((.+):(\d+),(\d+)(?:-[\d,]+)?\)$`)
+	// matches the prefix of a mod load error, e.g. "Internal Error: Failed to decode mod: "
+	loadErrorPrefixRegex = regexp.MustCompile(`^(?:[\w ]+: )?Failed to [\w ]+: `)
+	// matches an unresolved block of a dependency error, e.g. "dashboard.x -> query.a,query.b"
+	unresolvedBlockRegex = regexp.MustCompile(`^(\S+) -> (\S+)$`)
+	// matches a missing dependency of a dependency error, e.g. "MISSING: query.a"
+	missingDependencyRegex = regexp.MustCompile(`^MISSING: (\S+)$`)
+)
+
+// ValidateWorkspace loads the workspace in the mod location and validates it, without connecting to the database
+// or running any queries - the problems found are returned (the result is empty if the workspace is valid):
+//   - workspace load errors and warnings, including unresolved references to resources
+//   - the requirements of the mod and all of its dependency mods (see validateModRequirementsRecursively) - plugin
+//     requirements are only validated if ArgPluginVersionFile is set, and failures are warnings unless
+//     ArgStrictRequirements is set
+func ValidateWorkspace(ctx context.Context, modLocation string) []ValidationProblem {
+	w, errAndWarnings := loadWorkspace(ctx, modLocation)
+	problems := loadProblems(modLocation, errAndWarnings)
+	if errAndWarnings.GetError() != nil {
+		return problems
+	}
+	if !w.ModfileExists() {
+		return append(problems, ValidationProblem{Severity: ValidationSeverityError, Message: localconstants.ErrorNoModDefinition{}.Error()})
+	}
+
+	var pluginVersionMap *plugin.PluginVersionMap
+	if pluginVersionFile := viper.GetString(localconstants.ArgPluginVersionFile); pluginVersionFile != "" {
+		var err error
+		if pluginVersionMap, err = loadPluginVersionMap(pluginVersionFile); err != nil {
+			return append(problems, ValidationProblem{Severity: ValidationSeverityError, Message: err.Error()})
+		}
+	}
+
+	severity := ValidationSeverityWarning
+	if viper.GetBool(localconstants.ArgStrictRequirements) {
+		severity = ValidationSeverityError
+	}
+	for _, requirementErrors := range modRequirementErrors(w.Mod, pluginVersionMap) {
+		var location *ValidationLocation
+		var resource string
+		if m := requirementErrors.mod; m != nil {
+			resource = m.Name()
+			if m.Require != nil {
+				location = newValidationLocation(m.Require.DeclRange)
+			}
+		}
+		for _, err := range requirementErrors.errors {
+			problems = append(problems, ValidationProblem{Severity: severity, Message: err.Error(), Resource: resource, Location: location})
+		}
+	}
+	return problems
+}
+
+// HasValidationErrors returns whether any of the problems is an error
+func HasValidationErrors(problems []ValidationProblem) bool {
+	return slices.ContainsFunc(problems, func(p ValidationProblem) bool { return p.Severity == ValidationSeverityError })
+}
+
+// loadProblems returns the problems for the workspace load error and warnings
+func loadProblems(modLocation string, errAndWarnings error_helpers.ErrorAndWarnings) []ValidationProblem {
+	var problems []ValidationProblem
+	if err := errAndWarnings.GetError(); err != nil {
+		message := error_helpers.HandleCancelError(err).Error()
+		if dependencyProblems := unresolvedDependencyProblems(modLocation, message); len(dependencyProblems) > 0 {
+			problems = append(problems, dependencyProblems...)
+		} else {
+			problems = append(problems, diagnosticProblems(ValidationSeverityError, message)...)
+		}
+	}
+	for _, warning := range errAndWarnings.Warnings {
+		problems = append(problems, diagnosticProblems(ValidationSeverityWarning, warning)...)
+	}
+	return problems
+}
+
+// diagnosticProblems returns a problem for each diagnostic in the message - diagnostics are formatted as the
+// diagnostic message, optionally followed by a line with the diagnostic range, e.g.
+//
+//	Failed to decode mod: Unsupported attribute: 'foo' not expected here.
+//	(/path/mod.pp:4,3-12)
+func diagnosticProblems(severity ValidationSeverity, message string) []ValidationProblem {
+	var problems []ValidationProblem
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if match := diagnosticRangeRegex.FindStringSubmatch(line); match != nil && len(problems) > 0 && problems[len(problems)-1].Location == nil {
+			lineNumber, _ := strconv.Atoi(match[2])
+			column, _ := strconv.Atoi(match[3])
+			problems[len(problems)-1].Location = &ValidationLocation{Filename: match[1], Line: lineNumber, Column: column}
+			continue
+		}
+		problems = append(problems, ValidationProblem{Severity: severity, Message: loadErrorPrefixRegex.ReplaceAllString(line, "")})
+	}
+	return problems
+}
+
+// unresolvedDependencyProblems returns a problem for each unresolved reference in a dependency error, located using
+// the mod files (nil is returned if the message is not a dependency error)
+// dependency errors list each unresolved block with its dependencies, followed by the missing dependencies, e.g.
+//
+//	Failed to resolve dependencies after 2 passes. Unresolved blocks:
+//	   dashboard.x -> query.a
+//	     MISSING: query.a
+func unresolvedDependencyProblems(modLocation, message string) []ValidationProblem {
+	if !strings.Contains(message, "Unresolved blocks:") {
+		return nil
+	}
+
+	// map of unresolved block name to its dependencies, in the order listed
+	var blockNames []string
+	blockDependencies := make(map[string][]string)
+	missing := make(map[string]struct{})
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if match := unresolvedBlockRegex.FindStringSubmatch(line); match != nil {
+			blockNames = append(blockNames, match[1])
+			blockDependencies[match[1]] = strings.Split(match[2], ",")
+		} else if match := missingDependencyRegex.FindStringSubmatch(line); match != nil {
+			missing[match[1]] = struct{}{}
+		}
+	}
+
+	blocks := modFileBlocks(modLocation)
+	var problems []ValidationProblem
+	for _, blockName := range blockNames {
+		for _, dependency := range blockDependencies[blockName] {
+			message := fmt.Sprintf("%s depends on %s, which could not be resolved", blockName, dependency)
+			if _, ok := missing[dependency]; ok {
+				message = fmt.Sprintf("%s references %s, which does not exist", blockName, dependency)
+			}
+			problems = append(problems, ValidationProblem{
+				Severity: ValidationSeverityError,
+				Message:  message,
+				Resource: blockName,
+				Location: referenceLocation(blocks[blockName], dependency),
+			})
+		}
+	}
+	return problems
+}
+
+// modFileBlocks parses the mod files in the mod location (excluding dependency mods) and returns a map of the
+// top level blocks, keyed by <block type>.<name> - files which fail to parse are ignored
+func modFileBlocks(modLocation string) map[string]*hclsyntax.Block {
+	blocks := make(map[string]*hclsyntax.Block)
+	_ = filepath.WalkDir(modLocation, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		// skip hidden directories, which include the dependency mod directory
+		if d.IsDir() {
+			if path != modLocation && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(app_specific.ModDataExtensions, filepath.Ext(path)) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		file, diags := hclsyntax.ParseConfig(content, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil
+		}
+		for _, block := range body.Blocks {
+			if len(block.Labels) > 0 {
+				blocks[fmt.Sprintf("%s.%s", block.Type, block.Labels[0])] = block
+			}
+		}
+		return nil
+	})
+	return blocks
+}
+
+// referenceLocation returns the location of the first reference to the dependency in the block, or the location of
+// the block if there is no reference (nil is returned if the block is nil)
+func referenceLocation(block *hclsyntax.Block, dependency string) *ValidationLocation {
+	if block == nil {
+		return nil
+	}
+	var reference *ValidationLocation
+	_ = hclsyntax.VisitAll(block.Body, func(node hclsyntax.Node) hcl.Diagnostics {
+		expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || reference != nil {
+			return nil
+		}
+		// the reference may be to an attribute of the dependency, e.g. query.a.sql
+		if name := hclhelpers.TraversalAsString(expr.Traversal); name == dependency || strings.HasPrefix(name, dependency+".") {
+			reference = newValidationLocation(expr.SrcRange)
+		}
+		return nil
+	})
+	if reference != nil {
+		return reference
+	}
+	return newValidationLocation(block.DefRange())
+}
+
+func newValidationLocation(r hcl.Range) *ValidationLocation {
+	if r.Filename == "" {
+		return nil
+	}
+	return &ValidationLocation{Filename: r.Filename, Line: r.Start.Line, Column: r.Start.Column}
+}
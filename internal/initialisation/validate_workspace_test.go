@@ -0,0 +1,74 @@
+package initialisation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiagnosticProblems(t *testing.T) {
+	message := `Internal Error: Failed to decode mod: Unsupported attribute: 'foo' not expected here.
+(/path/mod.pp:4,3-12)
+Missing required argument: 'sql' is required
+`
+	problems := diagnosticProblems(ValidationSeverityError, message)
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems - got %d: %v", len(problems), problems)
+	}
+	if problems[0].Message != "Unsupported attribute: 'foo' not expected here." {
+		t.Errorf("unexpected message %q", problems[0].Message)
+	}
+	if l := problems[0].Location; l == nil || l.Filename != "/path/mod.pp" || l.Line != 4 || l.Column != 3 {
+		t.Errorf("unexpected location %+v", l)
+	}
+	if problems[1].Location != nil {
+		t.Errorf("expected no location for the second problem - got %+v", problems[1].Location)
+	}
+	if got := problems[0].String(); got != "/path/mod.pp:4:3: error: Unsupported attribute: 'foo' not expected here." {
+		t.Errorf("unexpected string %q", got)
+	}
+}
+
+func TestUnresolvedDependencyProblems(t *testing.T) {
+	setTestModFileConfig(t)
+	modLocation := t.TempDir()
+	content := `mod "m" {}
+
+dashboard "x" {
+  card {
+    sql = query.nope.sql
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(modLocation, "mod.pp"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	message := `Bad Request: Failed to resolve dependencies after 2 passes. Unresolved blocks:
+   dashboard.x -> query.nope
+     MISSING: query.nope`
+
+	problems := unresolvedDependencyProblems(modLocation, message)
+	if len(problems) != 1 {
+		t.Fatalf("expected 1 problem - got %d: %v", len(problems), problems)
+	}
+	p := problems[0]
+	if p.Resource != "dashboard.x" || p.Message != "dashboard.x references query.nope, which does not exist" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	if l := p.Location; l == nil || l.Line != 5 || l.Column != 11 {
+		t.Errorf("unexpected location %+v", l)
+	}
+
+	if problems := unresolvedDependencyProblems(modLocation, "Failed to decode mod"); problems != nil {
+		t.Errorf("expected no problems for a non dependency error - got %v", problems)
+	}
+}
+
+func TestHasValidationErrors(t *testing.T) {
+	if HasValidationErrors([]ValidationProblem{{Severity: ValidationSeverityWarning}}) {
+		t.Error("expected warnings not to be errors")
+	}
+	if !HasValidationErrors([]ValidationProblem{{Severity: ValidationSeverityWarning}, {Severity: ValidationSeverityError}}) {
+		t.Error("expected errors")
+	}
+}
@@ -0,0 +1,122 @@
+package initialisation
+
+import (
+	"context"
+	"sync"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// WorkspaceRegistry holds multiple named InitData instances, allowing a single process to serve several workspaces,
+// each with its own mod and database client.
+// Telemetry is process wide, so the telemetry shutdown of each registered InitData is taken over by the registry
+// and only called by Cleanup, once all workspaces have been cleaned up
+// NOTE: Init sets the global dashboardexecute.Executor - to route dashboard executions to a workspace,
+// use the InitData.DashboardExecutor of that workspace
+type WorkspaceRegistry[T modconfig.ModTreeItem] struct {
+	workspaces         map[string]*InitData[T]
+	telemetryShutdowns []func()
+	mut                sync.RWMutex
+}
+
+func NewWorkspaceRegistry[T modconfig.ModTreeItem]() *WorkspaceRegistry[T] {
+	return &WorkspaceRegistry[T]{
+		workspaces: make(map[string]*InitData[T]),
+	}
+}
+
+// Register adds the InitData with the given workspace name - an error is returned if the name is already registered
+func (r *WorkspaceRegistry[T]) Register(name string, initData *InitData[T]) error {
+	if initData == nil {
+		return sperr.New("cannot register workspace '%s': init data is nil", name)
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if _, ok := r.workspaces[name]; ok {
+		return sperr.New("workspace '%s' is already registered", name)
+	}
+	r.workspaces[name] = initData
+	r.takeTelemetryShutdown(initData)
+	return nil
+}
+
+// takeTelemetryShutdown takes over the telemetry shutdown of the InitData, so cleaning up a single workspace
+// does not shut down the shared telemetry
+// this is called on registration, and again before cleanup (in case Init was called after registration)
+// NOTE: the caller must hold the lock
+func (r *WorkspaceRegistry[T]) takeTelemetryShutdown(initData *InitData[T]) {
+	if initData.ShutdownTelemetry != nil {
+		r.telemetryShutdowns = append(r.telemetryShutdowns, initData.ShutdownTelemetry)
+		initData.ShutdownTelemetry = nil
+	}
+}
+
+// Get returns the InitData registered with the given workspace name
+func (r *WorkspaceRegistry[T]) Get(name string) (*InitData[T], bool) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	initData, ok := r.workspaces[name]
+	return initData, ok
+}
+
+// Names returns the sorted names of the registered workspaces
+func (r *WorkspaceRegistry[T]) Names() []string {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	names := maps.Keys(r.workspaces)
+	slices.Sort(names)
+	return names
+}
+
+// Deregister removes the workspace with the given name and cleans it up
+// (the shared telemetry is not shut down)
+func (r *WorkspaceRegistry[T]) Deregister(ctx context.Context, name string) error {
+	r.mut.Lock()
+	initData, ok := r.workspaces[name]
+	if ok {
+		delete(r.workspaces, name)
+		r.takeTelemetryShutdown(initData)
+	}
+	r.mut.Unlock()
+
+	if !ok {
+		return sperr.New("workspace '%s' is not registered", name)
+	}
+	initData.Cleanup(ctx)
+	return nil
+}
+
+// Cleanup cleans up all registered workspaces, then shuts down the shared telemetry
+func (r *WorkspaceRegistry[T]) Cleanup(ctx context.Context) {
+	r.mut.Lock()
+	workspaces := r.workspaces
+	for _, initData := range workspaces {
+		r.takeTelemetryShutdown(initData)
+	}
+	telemetryShutdowns := r.telemetryShutdowns
+	r.workspaces = make(map[string]*InitData[T])
+	r.telemetryShutdowns = nil
+	r.mut.Unlock()
+
+	// clean up the workspaces in parallel - each cleanup step is already bounded by the shutdown timeout
+	var wg sync.WaitGroup
+	for _, initData := range workspaces {
+		wg.Add(1)
+		go func(initData *InitData[T]) {
+			defer wg.Done()
+			initData.Cleanup(ctx)
+		}(initData)
+	}
+	wg.Wait()
+
+	for _, shutdown := range telemetryShutdowns {
+		shutdown()
+	}
+}
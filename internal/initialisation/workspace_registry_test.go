@@ -0,0 +1,65 @@
+package initialisation
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/turbot/pipe-fittings/modconfig"
+)
+
+func TestWorkspaceRegistry(t *testing.T) {
+	r := NewWorkspaceRegistry[*modconfig.Dashboard]()
+
+	var shutdownCount int
+	shutdown := func() { shutdownCount++ }
+
+	tenantA := NewInitDataWithWorkspace[*modconfig.Dashboard](nil)
+	tenantA.ShutdownTelemetry = shutdown
+	tenantB := NewInitDataWithWorkspace[*modconfig.Dashboard](nil)
+
+	if err := r.Register("tenant_b", tenantB); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("tenant_a", tenantA); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("tenant_a", tenantB); err == nil {
+		t.Errorf("expected an error registering a duplicate workspace name")
+	}
+	if err := r.Register("tenant_c", nil); err == nil {
+		t.Errorf("expected an error registering nil init data")
+	}
+
+	if names := r.Names(); !slices.Equal(names, []string{"tenant_a", "tenant_b"}) {
+		t.Errorf("unexpected workspace names: %v", names)
+	}
+	if got, ok := r.Get("tenant_a"); !ok || got != tenantA {
+		t.Errorf("expected to get the tenant_a init data")
+	}
+	if _, ok := r.Get("tenant_c"); ok {
+		t.Errorf("expected no init data for an unregistered workspace")
+	}
+
+	// a telemetry shutdown set after registration (i.e. by Init) is also taken over by the registry
+	tenantB.ShutdownTelemetry = shutdown
+
+	// deregistering a workspace does not shut down the shared telemetry
+	if err := r.Deregister(context.Background(), "tenant_a"); err != nil {
+		t.Fatal(err)
+	}
+	if shutdownCount != 0 {
+		t.Errorf("expected telemetry not to be shut down when a workspace is deregistered")
+	}
+	if err := r.Deregister(context.Background(), "tenant_a"); err == nil {
+		t.Errorf("expected an error deregistering an unregistered workspace")
+	}
+
+	r.Cleanup(context.Background())
+	if shutdownCount != 2 {
+		t.Errorf("expected each telemetry shutdown to be called once by Cleanup, got %d", shutdownCount)
+	}
+	if len(r.Names()) != 0 {
+		t.Errorf("expected no workspaces to be registered after Cleanup")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/turbot/pipe-fittings/app_specific"
 	"github.com/turbot/pipe-fittings/constants"
 	"github.com/turbot/pipe-fittings/constants/runtime"
+	"github.com/turbot/powerpipe/internal/utils"
 )
 
 func Initialize() {
@@ -41,6 +42,13 @@ func PowerpipeLogger() *slog.Logger {
 
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			sanitized := sanitize.Instance.SanitizeKeyValue(a.Key, a.Value.Any())
+			// also mask any database credentials
+			switch v := sanitized.(type) {
+			case string:
+				sanitized = utils.RedactConnectionString(v)
+			case error:
+				sanitized = utils.RedactConnectionStringError(v)
+			}
 
 			return slog.Attr{
 				Key:   a.Key,
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+	"github.com/turbot/powerpipe/internal/httpclient"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+)
+
+const (
+	// the timeout for a webhook request (this applies in addition to any timeout of the http client)
+	webhookTimeout = 30 * time.Second
+	// the maximum length of the response body included in the error for a failed request
+	maxWebhookErrorBodyLength = 200
+
+	StatusPassed = "passed"
+	StatusFailed = "failed"
+)
+
+// WebhookPayload is the summary of a completed run which is sent to the webhook
+// by default this is sent as JSON - the Text field makes this a valid Slack incoming webhook message
+// a custom payload template is passed the payload as its data
+type WebhookPayload struct {
+	// the name of the run, e.g. the benchmark name
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	// passed, or failed if there are any alarm or error results
+	Status   string                      `json:"status"`
+	Controls int                         `json:"controls"`
+	Summary  controlstatus.StatusSummary `json:"summary"`
+	Duration float64                     `json:"duration_seconds"`
+	// the file paths (or object URLs) of the exported reports
+	Reports []string `json:"reports,omitempty"`
+	// a human readable summary of the run
+	Text string `json:"text"`
+}
+
+// NewWebhookPayload builds the payload for the executed tree
+func NewWebhookPayload(name string, tree *controlexecute.ExecutionTree, reports []string) *WebhookPayload {
+	res := &WebhookPayload{
+		Name:     name,
+		Controls: len(tree.ControlRuns),
+		Duration: tree.EndTime.Sub(tree.StartTime).Seconds(),
+		Reports:  reports,
+	}
+	if tree.Root != nil {
+		res.Title = tree.Root.Title
+		if tree.Root.Summary != nil {
+			res.Summary = tree.Root.Summary.Status
+		}
+	}
+	if res.Title == "" {
+		res.Title = name
+	}
+	res.Status = StatusPassed
+	if res.Summary.FailedCount() > 0 {
+		res.Status = StatusFailed
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s %s: %d ok, %d alarm, %d error, %d skip, %d info (%d controls)",
+		res.Title, res.Status, res.Summary.Ok, res.Summary.Alarm, res.Summary.Error, res.Summary.Skip, res.Summary.Info, res.Controls)
+	for _, report := range reports {
+		fmt.Fprintf(&text, "\nReport: %s", report)
+	}
+	res.Text = text.String()
+	return res
+}
+
+// WebhookNotifier posts the summary of a completed run to a webhook URL
+type WebhookNotifier struct {
+	url string
+	// if set, this is used to render the request body, rather than sending the payload as JSON
+	template *template.Template
+	client   *http.Client
+}
+
+// NewWebhookNotifier creates a notifier for the webhook URL - if a template path is given, the template is used to
+// render the request body (the template is passed the WebhookPayload, and must render valid JSON)
+// requests are sent using the http client set by httpclient.SetClient
+func NewWebhookNotifier(webhookUrl, templatePath string) (*WebhookNotifier, error) {
+	parsedUrl, err := url.Parse(webhookUrl)
+	if err != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+		return nil, sperr.New("invalid webhook URL '%s' - an http or https URL is required", webhookUrl)
+	}
+	res := &WebhookNotifier{
+		url:    webhookUrl,
+		client: httpclient.Client(),
+	}
+	if templatePath != "" {
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "failed to read webhook payload template")
+		}
+		res.template, err = template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(string(content))
+		if err != nil {
+			return nil, sperr.WrapWithMessage(err, "failed to parse webhook payload template '%s'", templatePath)
+		}
+	}
+	return res, nil
+}
+
+// Notify posts the payload to the webhook - an error is returned if the request fails or the webhook does not
+// return a success status
+func (n *WebhookNotifier) Notify(ctx context.Context, payload *WebhookPayload) error {
+	body, err := n.renderBody(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "webhook request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBodyLength))
+		return sperr.New("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// renderBody returns the request body for the payload - either the payload as JSON, or the rendered template
+func (n *WebhookNotifier) renderBody(payload *WebhookPayload) ([]byte, error) {
+	if n.template == nil {
+		return json.Marshal(payload)
+	}
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, payload); err != nil {
+		return nil, sperr.WrapWithMessage(err, "failed to render webhook payload template")
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, sperr.New("webhook payload template did not render valid JSON - use the json function to quote values, e.g. {{ json .Text }}")
+	}
+	return buf.Bytes(), nil
+}
+
+// toJSON is a template function which returns the value as JSON, e.g. a quoted and escaped string
+func toJSON(value any) (string, error) {
+	res, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbot/powerpipe/internal/controlexecute"
+	"github.com/turbot/powerpipe/internal/controlstatus"
+)
+
+func newWebhookTestTree() *controlexecute.ExecutionTree {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &controlexecute.ExecutionTree{
+		Root: &controlexecute.ResultGroup{
+			Title:   "CIS \"v1\"",
+			Summary: &controlexecute.GroupSummary{Status: controlstatus.StatusSummary{Ok: 3, Alarm: 2, Skip: 1}},
+		},
+		ControlRuns: map[string]*controlexecute.ControlRun{"c1": {}, "c2": {}},
+		StartTime:   start,
+		EndTime:     start.Add(1500 * time.Millisecond),
+	}
+}
+
+// newWebhookTestServer returns a server which records the request bodies, responding with the given status
+func newWebhookTestServer(t *testing.T, status int, bodies *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s with content type %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("invalid_token"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var bodies []string
+	server := newWebhookTestServer(t, http.StatusOK, &bodies)
+
+	notifier, err := NewWebhookNotifier(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := NewWebhookPayload("mod.benchmark.cis", newWebhookTestTree(), []string{"/tmp/cis.json"})
+	if err := notifier.Notify(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+
+	var received WebhookPayload
+	if err := json.Unmarshal([]byte(bodies[0]), &received); err != nil {
+		t.Fatalf("payload is not valid JSON: %s", err)
+	}
+	if received.Status != StatusFailed || received.Summary.Alarm != 2 || received.Controls != 2 || received.Duration != 1.5 {
+		t.Errorf("unexpected payload %+v", received)
+	}
+	expectedText := "CIS \"v1\" failed: 3 ok, 2 alarm, 0 error, 1 skip, 0 info (2 controls)\nReport: /tmp/cis.json"
+	if received.Text != expectedText {
+		t.Errorf("expected text %q, got %q", expectedText, received.Text)
+	}
+}
+
+func TestWebhookNotifyTemplate(t *testing.T) {
+	var bodies []string
+	server := newWebhookTestServer(t, http.StatusOK, &bodies)
+
+	templatePath := filepath.Join(t.TempDir(), "payload.tmpl")
+	template := `{"content": {{ json .Title }}, "passed": {{ if eq .Status "passed" }}true{{ else }}false{{ end }}}`
+	if err := os.WriteFile(templatePath, []byte(template), 0600); err != nil {
+		t.Fatal(err)
+	}
+	notifier, err := NewWebhookNotifier(server.URL, templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), NewWebhookPayload("mod.benchmark.cis", newWebhookTestTree(), nil)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"content": "CIS \"v1\"", "passed": false}`; bodies[0] != expected {
+		t.Errorf("expected body %s, got %s", expected, bodies[0])
+	}
+
+	// a template which does not render JSON is an error
+	if err := os.WriteFile(templatePath, []byte(`{"content": {{ .Title }}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	notifier, err = NewWebhookNotifier(server.URL, templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(context.Background(), NewWebhookPayload("cis", newWebhookTestTree(), nil)); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Errorf("expected an invalid JSON error, got %v", err)
+	}
+}
+
+func TestWebhookErrors(t *testing.T) {
+	var bodies []string
+	server := newWebhookTestServer(t, http.StatusForbidden, &bodies)
+
+	notifier, err := NewWebhookNotifier(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = notifier.Notify(context.Background(), NewWebhookPayload("cis", newWebhookTestTree(), nil))
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: invalid_token") {
+		t.Errorf("expected an error for the failed request, got %v", err)
+	}
+
+	for _, invalidUrl := range []string{"", "hooks.slack.com/services/x", "ftp://example.com"} {
+		if _, err := NewWebhookNotifier(invalidUrl, ""); err == nil {
+			t.Errorf("%q: expected an invalid URL error", invalidUrl)
+		}
+	}
+	if _, err := NewWebhookNotifier(server.URL, filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Errorf("expected an error for a missing template")
+	}
+}
@@ -3,10 +3,13 @@ package powerpipeconfig
 import (
 	"github.com/turbot/powerpipe/internal/constants"
 	"log/slog"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/turbot/pipe-fittings/app_specific_connection"
 	"github.com/turbot/pipe-fittings/connection"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
 )
 
 type PowerpipeConfig struct {
@@ -42,6 +45,33 @@ func (c *PowerpipeConfig) GetDefaultConnection() connection.ConnectionStringProv
 	return c.PipelingConnections[constants.DefaultConnection].(connection.ConnectionStringProvider)
 }
 
+// GetConnectionStringProvider returns the connection with the given name, e.g. postgres.ci (or connection.postgres.ci)
+// an error is returned if there is no such connection, or the connection does not provide a connection string
+func (c *PowerpipeConfig) GetConnectionStringProvider(name string) (connection.ConnectionStringProvider, error) {
+	name = strings.TrimPrefix(name, "connection.")
+	conn, ok := c.PipelingConnections[name]
+	if !ok {
+		return nil, sperr.New("connection '%s' is not defined (defined connections: %s)", name, strings.Join(c.connectionStringProviderNames(), ", "))
+	}
+	provider, ok := conn.(connection.ConnectionStringProvider)
+	if !ok {
+		return nil, sperr.New("connection '%s' does not provide a database connection string", name)
+	}
+	return provider, nil
+}
+
+// connectionStringProviderNames returns the sorted names of the connections which provide a connection string
+func (c *PowerpipeConfig) connectionStringProviderNames() []string {
+	var names []string
+	for name, conn := range c.PipelingConnections {
+		if _, ok := conn.(connection.ConnectionStringProvider); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *PowerpipeConfig) SetDefaultConnection(defaultConnection connection.PipelingConnection) {
 	c.PipelingConnections[constants.DefaultConnection] = defaultConnection
 }
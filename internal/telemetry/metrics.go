@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/turbot/powerpipe"
+
+// the status attribute values of query and init metrics
+const (
+	statusOk    = "ok"
+	statusError = "error"
+)
+
+// instruments are the powerpipe metric instruments
+// NOTE: these are created using the global meter provider - if this is set after the instruments are created
+// (i.e. when telemetry is initialised), the instruments delegate to it
+type instruments struct {
+	activeQueries metric.Int64UpDownCounter
+	queryDuration metric.Float64Histogram
+	initDuration  metric.Float64Histogram
+}
+
+var (
+	metricInstruments     *instruments
+	metricInstrumentsOnce sync.Once
+)
+
+func getInstruments() *instruments {
+	metricInstrumentsOnce.Do(func() {
+		meter := otel.Meter(meterName)
+		res := &instruments{}
+		var err error
+		// if an instrument cannot be created, a no-op instrument is returned with the error, so this is not fatal
+		if res.activeQueries, err = meter.Int64UpDownCounter("powerpipe.db.queries.active",
+			metric.WithDescription("The number of database queries currently executing")); err != nil {
+			slog.Warn("failed to create metric instrument", "error", err)
+		}
+		if res.queryDuration, err = meter.Float64Histogram("powerpipe.db.query.duration",
+			metric.WithDescription("The duration of database queries, including reading the results"),
+			metric.WithUnit("s")); err != nil {
+			slog.Warn("failed to create metric instrument", "error", err)
+		}
+		if res.initDuration, err = meter.Float64Histogram("powerpipe.init.duration",
+			metric.WithDescription("The duration of initialisation"),
+			metric.WithUnit("s")); err != nil {
+			slog.Warn("failed to create metric instrument", "error", err)
+		}
+		metricInstruments = res
+	})
+	return metricInstruments
+}
+
+// QueryStarted records the start of a database query, and returns a function which must be called when the query
+// has completed (i.e. all results have been read), with the query error (if any)
+func QueryStarted(ctx context.Context) func(err error) {
+	i := getInstruments()
+	startTime := time.Now()
+	i.activeQueries.Add(ctx, 1)
+
+	var once sync.Once
+	return func(err error) {
+		// the query may only be completed once
+		once.Do(func() {
+			i.activeQueries.Add(ctx, -1)
+			i.queryDuration.Record(ctx, time.Since(startTime).Seconds(), metric.WithAttributes(statusAttribute(err)))
+		})
+	}
+}
+
+// RecordInitDuration records the duration of initialisation, and whether it failed
+func RecordInitDuration(ctx context.Context, duration time.Duration, err error) {
+	getInstruments().initDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(statusAttribute(err)))
+}
+
+func statusAttribute(err error) attribute.KeyValue {
+	if err != nil {
+		return attribute.String("status", statusError)
+	}
+	return attribute.String("status", statusOk)
+}
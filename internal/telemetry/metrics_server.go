@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// MetricsPath is the path of the metrics endpoint
+const MetricsPath = "/metrics"
+
+// metricsReader collects the metrics served by the metrics endpoint - this is nil unless the endpoint is enabled
+var metricsReader *sdkmetric.ManualReader
+
+// StartMetricsServer starts an http server listening on the given address, which serves the powerpipe metrics at
+// MetricsPath in the Prometheus exposition format. The server is shut down when the context is cancelled.
+//
+// NOTE: this must be called before Init, which adds the metrics reader to the meter provider
+func StartMetricsServer(ctx context.Context, address string) error {
+	// listen synchronously, so that failure to bind the address is returned
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return sperr.WrapWithMessage(err, "failed to start the metrics server on %s", address)
+	}
+
+	metricsReader = sdkmetric.NewManualReader()
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, newMetricsHandler(metricsReader))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("metrics server stopped", "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("metrics server started", "address", listener.Addr().String())
+	return nil
+}
+
+// newMetricsHandler returns an http handler which collects the metrics from the reader and writes them
+// in the Prometheus exposition format
+func newMetricsHandler(reader sdkmetric.Reader) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&prometheusCollector{reader: reader})
+	// if collection fails, return an error response rather than a partial response
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.HTTPErrorOnError})
+}
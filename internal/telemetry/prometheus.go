@@ -0,0 +1,143 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// prometheusUnitSuffixes maps the (UCUM) units of instruments to the suffix added to the Prometheus metric name
+var prometheusUnitSuffixes = map[string]string{
+	"s":  "seconds",
+	"ms": "milliseconds",
+	"By": "bytes",
+}
+
+// prometheusCollector is a prometheus.Collector which collects the metrics of an OpenTelemetry metric reader
+// sums are collected as counters (if monotonic) or gauges, and histograms as Prometheus histograms
+// NOTE: the metrics must use cumulative temporality (the default for sdkmetric readers)
+type prometheusCollector struct {
+	reader sdkmetric.Reader
+}
+
+// Describe implements prometheus.Collector - no descriptors are sent, as the metrics are not known in advance,
+// so this is an unchecked collector
+func (c *prometheusCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector
+func (c *prometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	var rm metricdata.ResourceMetrics
+	if err := c.reader.Collect(context.Background(), &rm); err != nil {
+		ch <- prometheus.NewInvalidMetric(prometheus.NewDesc("powerpipe_metrics_collection_error", "Failed to collect metrics", nil, nil), err)
+		return
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			name := prometheusMetricName(m.Name, m.Unit)
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				collectPrometheusSum(ch, name, m.Description, data.IsMonotonic, data.DataPoints)
+			case metricdata.Sum[float64]:
+				collectPrometheusSum(ch, name, m.Description, data.IsMonotonic, data.DataPoints)
+			case metricdata.Gauge[int64]:
+				collectPrometheusValues(ch, name, m.Description, prometheus.GaugeValue, data.DataPoints)
+			case metricdata.Gauge[float64]:
+				collectPrometheusValues(ch, name, m.Description, prometheus.GaugeValue, data.DataPoints)
+			case metricdata.Histogram[int64]:
+				collectPrometheusHistogram(ch, name, m.Description, data.DataPoints)
+			case metricdata.Histogram[float64]:
+				collectPrometheusHistogram(ch, name, m.Description, data.DataPoints)
+			}
+			// other aggregations (e.g. exponential histograms) are not used, and are not supported
+		}
+	}
+}
+
+func collectPrometheusSum[N int64 | float64](ch chan<- prometheus.Metric, name, description string, monotonic bool, points []metricdata.DataPoint[N]) {
+	if monotonic {
+		collectPrometheusValues(ch, name+"_total", description, prometheus.CounterValue, points)
+		return
+	}
+	collectPrometheusValues(ch, name, description, prometheus.GaugeValue, points)
+}
+
+func collectPrometheusValues[N int64 | float64](ch chan<- prometheus.Metric, name, description string, valueType prometheus.ValueType, points []metricdata.DataPoint[N]) {
+	for _, p := range points {
+		labelNames, labelValues := prometheusLabels(p.Attributes)
+		desc := prometheus.NewDesc(name, description, labelNames, nil)
+		ch <- newPrometheusMetric(desc)(prometheus.NewConstMetric(desc, valueType, float64(p.Value), labelValues...))
+	}
+}
+
+func collectPrometheusHistogram[N int64 | float64](ch chan<- prometheus.Metric, name, description string, points []metricdata.HistogramDataPoint[N]) {
+	for _, p := range points {
+		labelNames, labelValues := prometheusLabels(p.Attributes)
+		desc := prometheus.NewDesc(name, description, labelNames, nil)
+		// prometheus buckets are cumulative (the +Inf bucket is added from the count)
+		buckets := make(map[float64]uint64, len(p.Bounds))
+		var cumulative uint64
+		for i, bound := range p.Bounds {
+			cumulative += p.BucketCounts[i]
+			buckets[bound] = cumulative
+		}
+		ch <- newPrometheusMetric(desc)(prometheus.NewConstHistogram(desc, p.Count, float64(p.Sum), buckets, labelValues...))
+	}
+}
+
+// newPrometheusMetric returns a func which returns the metric, or an invalid metric for the descriptor if there is
+// an error (so the error is reported when the metrics are gathered)
+func newPrometheusMetric(desc *prometheus.Desc) func(prometheus.Metric, error) prometheus.Metric {
+	return func(metric prometheus.Metric, err error) prometheus.Metric {
+		if err != nil {
+			return prometheus.NewInvalidMetric(desc, err)
+		}
+		return metric
+	}
+}
+
+// prometheusLabels returns the label names and values for the attributes
+func prometheusLabels(attributes attribute.Set) ([]string, []string) {
+	names := make([]string, 0, attributes.Len())
+	values := make([]string, 0, attributes.Len())
+	// the attribute set iterator returns the attributes sorted by key
+	for iter := attributes.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		names = append(names, sanitisePrometheusName(string(kv.Key)))
+		values = append(values, kv.Value.Emit())
+	}
+	return names, values
+}
+
+// prometheusMetricName converts an OpenTelemetry instrument name to a Prometheus metric name,
+// e.g. powerpipe.db.query.duration with the unit 's' is converted to powerpipe_db_query_duration_seconds
+func prometheusMetricName(name, unit string) string {
+	name = sanitisePrometheusName(name)
+	if suffix, ok := prometheusUnitSuffixes[unit]; ok && !strings.HasSuffix(name, "_"+suffix) {
+		name += "_" + suffix
+	}
+	return name
+}
+
+// sanitisePrometheusName replaces the characters which are not valid in Prometheus metric and label names with '_'
+func sanitisePrometheusName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			// names must not start with a digit
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
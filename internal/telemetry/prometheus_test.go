@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	active, _ := meter.Int64UpDownCounter("test.queries.active", metric.WithDescription("Active queries"))
+	active.Add(ctx, 2)
+	total, _ := meter.Int64Counter("test.queries", metric.WithDescription("Total\nqueries"))
+	total.Add(ctx, 3, metric.WithAttributes(attribute.String("status", `a "quoted" value`)))
+	duration, _ := meter.Float64Histogram("test.query.duration", metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.1, 1))
+	duration.Record(ctx, 0.05, metric.WithAttributes(attribute.String("status", "ok")))
+	duration.Record(ctx, 0.5, metric.WithAttributes(attribute.String("status", "ok")))
+
+	recorder := httptest.NewRecorder()
+	newMetricsHandler(reader).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("expected the prometheus text format content type, got %s", contentType)
+	}
+
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		"# HELP test_queries_active Active queries\n# TYPE test_queries_active gauge\ntest_queries_active 2\n",
+		"# HELP test_queries_total Total\\nqueries\n# TYPE test_queries_total counter\n",
+		`test_queries_total{status="a \"quoted\" value"} 3` + "\n",
+		"# TYPE test_query_duration_seconds histogram\n",
+		`test_query_duration_seconds_bucket{status="ok",le="0.1"} 1` + "\n",
+		`test_query_duration_seconds_bucket{status="ok",le="1"} 2` + "\n",
+		`test_query_duration_seconds_bucket{status="ok",le="+Inf"} 2` + "\n",
+		`test_query_duration_seconds_sum{status="ok"} 0.55` + "\n",
+		`test_query_duration_seconds_count{status="ok"} 2` + "\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics do not contain %q\n%s", expected, body)
+		}
+	}
+}
+
+func TestPrometheusMetricName(t *testing.T) {
+	tests := []struct {
+		name     string
+		unit     string
+		expected string
+	}{
+		{"powerpipe.db.query.duration", "s", "powerpipe_db_query_duration_seconds"},
+		{"powerpipe.db.queries.active", "", "powerpipe_db_queries_active"},
+		{"request_duration_seconds", "s", "request_duration_seconds"},
+		{"2xx-responses", "{response}", "_2xx_responses"},
+	}
+	for _, test := range tests {
+		if got := prometheusMetricName(test.name, test.unit); got != test.expected {
+			t.Errorf("prometheusMetricName(%q, %q): expected %q, got %q", test.name, test.unit, test.expected, got)
+		}
+	}
+}
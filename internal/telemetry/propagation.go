@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/turbot/steampipe-plugin-sdk/v5/sperr"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// the environment variables used to pass a W3C trace context (and baggage) to a child process
+// (see https://opentelemetry.io/docs/specs/otel/context/env-carriers/)
+const (
+	envTraceParent = "TRACEPARENT"
+	envTraceState  = "TRACESTATE"
+	envBaggage     = "BAGGAGE"
+)
+
+// environmentPropagator extracts the W3C trace context and baggage - this is used rather than the global propagator,
+// which is only set if telemetry is initialised using a custom config
+var environmentPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// ContextWithEnvironmentTraceContext returns a context containing the W3C trace context and baggage passed in
+// the TRACEPARENT, TRACESTATE and BAGGAGE environment variables (if any)
+// - spans started with the returned context are children of the parent trace
+func ContextWithEnvironmentTraceContext(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	for key, envVar := range map[string]string{"traceparent": envTraceParent, "tracestate": envTraceState, "baggage": envBaggage} {
+		if value := os.Getenv(envVar); value != "" {
+			carrier.Set(key, value)
+		}
+	}
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return environmentPropagator.Extract(ctx, carrier)
+}
+
+// ContextWithBaggage returns a context containing the baggage of the given context with the given key=value members
+// added (replacing any existing members with the same key)
+// the baggage members are added as attributes of all spans started using the returned context (see StartSpan)
+func ContextWithBaggage(ctx context.Context, keyValues []string) (context.Context, error) {
+	if len(keyValues) == 0 {
+		return ctx, nil
+	}
+	bag := baggage.FromContext(ctx)
+	for _, keyValue := range keyValues {
+		key, value, ok := strings.Cut(keyValue, "=")
+		if !ok || key == "" {
+			return ctx, sperr.New("invalid baggage '%s' - expected key=value", keyValue)
+		}
+		member, err := baggage.NewMemberRaw(key, value)
+		if err != nil {
+			return ctx, sperr.WrapWithMessage(err, "invalid baggage '%s'", keyValue)
+		}
+		if bag, err = bag.SetMember(member); err != nil {
+			return ctx, sperr.WrapWithMessage(err, "invalid baggage '%s'", keyValue)
+		}
+	}
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
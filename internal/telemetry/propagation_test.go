@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextWithEnvironmentTraceContext(t *testing.T) {
+	t.Setenv(envTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	t.Setenv(envBaggage, "team=platform")
+
+	ctx := ContextWithEnvironmentTraceContext(context.Background())
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsRemote() || spanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the remote parent trace context, got %+v", spanContext)
+	}
+	if value := baggage.FromContext(ctx).Member("team").Value(); value != "platform" {
+		t.Errorf("expected the environment baggage, got %q", value)
+	}
+}
+
+func TestContextWithBaggage(t *testing.T) {
+	parent := baggage.ContextWithBaggage(context.Background(), mustBaggage(t, "team=platform,env=dev"))
+
+	// members are added to the existing baggage, replacing any with the same key
+	ctx, err := ContextWithBaggage(parent, []string{"env=prod", "pipeline=nightly run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[string]string{}
+	for _, attr := range baggageAttributes(ctx) {
+		attrs[string(attr.Key)] = attr.Value.AsString()
+	}
+	expected := map[string]string{"team": "platform", "env": "prod", "pipeline": "nightly run"}
+	if len(attrs) != len(expected) {
+		t.Fatalf("expected attributes %v, got %v", expected, attrs)
+	}
+	for key, value := range expected {
+		if attrs[key] != value {
+			t.Errorf("expected attribute %s=%s, got %s", key, value, attrs[key])
+		}
+	}
+
+	for _, invalid := range []string{"novalue", "=value", "bad key=value"} {
+		if _, err := ContextWithBaggage(context.Background(), []string{invalid}); err == nil {
+			t.Errorf("expected an error for baggage '%s'", invalid)
+		}
+	}
+}
+
+func mustBaggage(t *testing.T, s string) baggage.Baggage {
+	t.Helper()
+	b, err := baggage.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
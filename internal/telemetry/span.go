@@ -0,0 +1,41 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/turbot/powerpipe"
+
+// StartSpan starts a span with the given name and attributes, as a child of any span in the context
+// any baggage in the context is also added to the span attributes (see ContextWithBaggage)
+// NOTE: if telemetry has not been initialised, the returned span does not record anything
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(append(baggageAttributes(ctx), attrs...)...))
+}
+
+// baggageAttributes returns a span attribute for each member of the context baggage
+func baggageAttributes(ctx context.Context) []attribute.KeyValue {
+	members := baggage.FromContext(ctx).Members()
+	attrs := make([]attribute.KeyValue, len(members))
+	for i, member := range members {
+		attrs[i] = attribute.String(member.Key(), member.Value())
+	}
+	return attrs
+}
+
+// EndSpan ends the span, recording the error (if any) and setting the span status accordingly
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
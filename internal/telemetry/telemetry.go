@@ -0,0 +1,172 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	sdktelemetry "github.com/turbot/steampipe-plugin-sdk/v5/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// shutdownTimeout is the time allowed to flush telemetry data on shutdown
+const shutdownTimeout = 2 * time.Second
+
+// Config is the configuration used to export telemetry to a custom OpenTelemetry collector
+type Config struct {
+	// the OTLP gRPC endpoint to export to, e.g. collector:4317
+	Endpoint string
+	// headers sent with each export request (e.g. for authentication)
+	Headers map[string]string
+	// the ratio of traces to sample, between 0 and 1
+	// if this is nil, all traces are sampled
+	SamplingRatio *float64
+	// disable transport security for the collector connection
+	Insecure bool
+
+	// if set, traces and/or metrics are not exported to the collector (see defaultConfig)
+	disableTraceExport  bool
+	disableMetricExport bool
+}
+
+// Init initialises telemetry for the given service.
+// If no config is provided, the default steampipe telemetry initialisation is used
+// (configured by the STEAMPIPE_OTEL_LEVEL and OTEL_EXPORTER_OTLP_ENDPOINT env vars) - or, if the metrics server has
+// been started, the equivalent config (see defaultConfig).
+// Otherwise, traces and metrics are exported to the configured endpoint.
+// If the metrics server has been started, its reader is added to the meter provider, alongside any OTLP exporter.
+// The returned function must be called to flush and shut down telemetry.
+func Init(serviceName string, cfg *Config) (func(), error) {
+	if cfg == nil {
+		if metricsReader == nil {
+			return sdktelemetry.Init(serviceName)
+		}
+		// the default initialisation creates its own meter provider, which the metrics reader cannot be added to -
+		// instead, use the equivalent config, so the metrics reader is added to the meter provider created below
+		cfg = defaultConfig()
+	}
+
+	ctx := context.Background()
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithProcess(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracerProvider *sdktrace.TracerProvider
+	if !cfg.disableTraceExport {
+		traceExporter, err := otlptracegrpc.New(ctx, cfg.traceOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		tracerProvider = sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(cfg.sampler()),
+			sdktrace.WithResource(res),
+			sdktrace.WithBatcher(traceExporter),
+		)
+	}
+	meterOpts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	if !cfg.disableMetricExport {
+		metricExporter, err := otlpmetricgrpc.New(ctx, cfg.metricOptions()...)
+		if err != nil {
+			if tracerProvider != nil {
+				_ = tracerProvider.Shutdown(ctx)
+			}
+			return nil, err
+		}
+		meterOpts = append(meterOpts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	}
+	// the metrics server reader is an additional reader of the same meter provider
+	if metricsReader != nil {
+		meterOpts = append(meterOpts, sdkmetric.WithReader(metricsReader))
+	}
+	meterProvider := sdkmetric.NewMeterProvider(meterOpts...)
+
+	if tracerProvider != nil {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+		otel.SetTracerProvider(tracerProvider)
+	}
+	otel.SetMeterProvider(meterProvider)
+
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		// shutting down the providers flushes any batched data and shuts down the exporters
+		if tracerProvider != nil {
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				slog.Warn("error shutting down tracer provider", "error", err)
+			}
+		}
+		if err := meterProvider.Shutdown(ctx); err != nil {
+			slog.Warn("error shutting down meter provider", "error", err)
+		}
+	}
+	return shutdown, nil
+}
+
+// defaultConfig returns the config equivalent to the default telemetry initialisation - this exports traces and/or
+// metrics (as enabled by the STEAMPIPE_OTEL_LEVEL env var) to the OTEL_EXPORTER_OTLP_ENDPOINT collector
+func defaultConfig() *Config {
+	level := strings.ToLower(os.Getenv(sdktelemetry.EnvOtelLevel))
+	endpoint, ok := os.LookupEnv(sdktelemetry.EnvOtelEndpoint)
+	if !ok {
+		endpoint = "localhost:4317"
+	}
+	_, insecure := os.LookupEnv(sdktelemetry.EnvOtelInsecure)
+	return &Config{
+		Endpoint:            endpoint,
+		Insecure:            insecure,
+		disableTraceExport:  level != sdktelemetry.OtelAll && level != sdktelemetry.OtelTrace,
+		disableMetricExport: level != sdktelemetry.OtelAll && level != sdktelemetry.OtelMetrics,
+	}
+}
+
+func (c *Config) traceOptions() []otlptracegrpc.Option {
+	var opts []otlptracegrpc.Option
+	if c.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(c.Endpoint))
+	}
+	if len(c.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(c.Headers))
+	}
+	if c.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	return opts
+}
+
+func (c *Config) metricOptions() []otlpmetricgrpc.Option {
+	var opts []otlpmetricgrpc.Option
+	if c.Endpoint != "" {
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(c.Endpoint))
+	}
+	if len(c.Headers) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(c.Headers))
+	}
+	if c.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	return opts
+}
+
+func (c *Config) sampler() sdktrace.Sampler {
+	if c.SamplingRatio == nil {
+		return sdktrace.AlwaysSample()
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*c.SamplingRatio))
+}
@@ -0,0 +1,72 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	sdktelemetry "github.com/turbot/steampipe-plugin-sdk/v5/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	tests := map[string]struct {
+		level          string
+		endpoint       string
+		expectTraces   bool
+		expectMetrics  bool
+		expectEndpoint string
+	}{
+		"none":    {level: "none", expectEndpoint: "localhost:4317"},
+		"all":     {level: "ALL", endpoint: "collector:4317", expectTraces: true, expectMetrics: true, expectEndpoint: "collector:4317"},
+		"trace":   {level: "trace", expectTraces: true, expectEndpoint: "localhost:4317"},
+		"metrics": {level: "metrics", expectMetrics: true, expectEndpoint: "localhost:4317"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(sdktelemetry.EnvOtelLevel, test.level)
+			if test.endpoint != "" {
+				t.Setenv(sdktelemetry.EnvOtelEndpoint, test.endpoint)
+			}
+			cfg := defaultConfig()
+			if cfg.disableTraceExport == test.expectTraces || cfg.disableMetricExport == test.expectMetrics {
+				t.Errorf("expected traces %v, metrics %v - got config %+v", test.expectTraces, test.expectMetrics, cfg)
+			}
+			if cfg.Endpoint != test.expectEndpoint {
+				t.Errorf("expected endpoint %s, got %s", test.expectEndpoint, cfg.Endpoint)
+			}
+		})
+	}
+}
+
+func TestInitAddsMetricsReader(t *testing.T) {
+	t.Setenv(sdktelemetry.EnvOtelLevel, "none")
+	metricsReader = sdkmetric.NewManualReader()
+	defer func() {
+		metricsReader = nil
+		otel.SetMeterProvider(noop.NewMeterProvider())
+	}()
+
+	shutdown, err := Init("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown()
+
+	// metrics recorded using the global meter provider are collected by the metrics reader
+	counter, err := otel.Meter("test").Int64Counter("test.count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(context.Background(), 1)
+
+	var rm metricdata.ResourceMetrics
+	if err := metricsReader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.ScopeMetrics) != 1 || len(rm.ScopeMetrics[0].Metrics) != 1 || rm.ScopeMetrics[0].Metrics[0].Name != "test.count" {
+		t.Errorf("expected the metrics reader to collect test.count, got %+v", rm.ScopeMetrics)
+	}
+}